@@ -0,0 +1,346 @@
+package ai
+
+import (
+	"math"
+
+	"github.com/Nathene/bitbase/anim"
+	"github.com/Nathene/bitbase/combat"
+	"github.com/Nathene/bitbase/entity"
+)
+
+func init() {
+	RegisterNode("findTarget", func(def *NodeDef, children []Node) (Node, error) {
+		radius := def.Float("radius", 200)
+		return &FindTarget{
+			Key:    def.String("key", "target"),
+			Team:   def.String("team", ""),
+			Radius: radius,
+			Keep:   def.Float("keep", radius*1.5),
+			Leash:  def.Float("leash", 0),
+		}, leaf(children)
+	})
+	RegisterNode("chase", func(def *NodeDef, children []Node) (Node, error) {
+		return &Chase{Target: def.String("target", "target"), Range: def.Float("range", 24), Speed: def.Float("speed", 1)}, leaf(children)
+	})
+	RegisterNode("follow", func(def *NodeDef, children []Node) (Node, error) {
+		return &Follow{Target: def.String("target", "target"), Distance: def.Float("distance", 48), Speed: def.Float("speed", 1)}, leaf(children)
+	})
+	RegisterNode("flee", func(def *NodeDef, children []Node) (Node, error) {
+		return &Flee{Target: def.String("target", "target"), Distance: def.Float("distance", 240), Speed: def.Float("speed", 1)}, leaf(children)
+	})
+	RegisterNode("wander", func(def *NodeDef, children []Node) (Node, error) {
+		return &Wander{Radius: def.Float("radius", 96), Speed: def.Float("speed", 0.5)}, leaf(children)
+	})
+	RegisterNode("patrol", func(def *NodeDef, children []Node) (Node, error) {
+		return &Patrol{Speed: def.Float("speed", 0.5)}, leaf(children)
+	})
+	RegisterNode("returnHome", func(def *NodeDef, children []Node) (Node, error) {
+		return &ReturnHome{Radius: def.Float("radius", 4), Speed: def.Float("speed", 1)}, leaf(children)
+	})
+	RegisterNode("wait", func(def *NodeDef, children []Node) (Node, error) {
+		return &Wait{Seconds: def.Float("seconds", 1), Random: def.Float("random", 0)}, leaf(children)
+	})
+	RegisterNode("attack", func(def *NodeDef, children []Node) (Node, error) {
+		return &Attack{Target: def.String("target", "target")}, leaf(children)
+	})
+	RegisterNode("shoot", func(def *NodeDef, children []Node) (Node, error) {
+		return &Shoot{Target: def.String("target", "target")}, leaf(children)
+	})
+}
+
+// FindTarget stores the nearest living enemy within Radius under Key. An enemy is
+// anything damageable on Team, or on any other team if Team is empty. A target
+// already found is kept until it is further than Keep away, and everything is
+// dropped once the agent strays more than Leash from home.
+type FindTarget struct {
+	Key    string
+	Team   string
+	Radius float64
+	Keep   float64
+	Leash  float64 // 0 means no limit
+}
+
+func (n *FindTarget) Tick(ctx *Context) Status {
+	x, y := ctx.Position()
+	if n.Leash > 0 {
+		hx, hy := ctx.Brain.Home()
+		if math.Hypot(hx-x, hy-y) > n.Leash {
+			delete(ctx.Blackboard, n.Key)
+			return Failure
+		}
+	}
+
+	if current := ctx.Blackboard.Object(n.Key); current != nil && n.isEnemy(ctx, current) && ctx.Distance(current) <= n.Keep {
+		return Success
+	}
+
+	var best *entity.Object
+	bestDist := math.Inf(1)
+	for _, o := range ctx.World.QueryRadius(x, y, n.Radius) {
+		if !n.isEnemy(ctx, o) {
+			continue
+		}
+		if d := ctx.Distance(o); d <= n.Radius && d < bestDist {
+			best, bestDist = o, d
+		}
+	}
+	if best == nil {
+		delete(ctx.Blackboard, n.Key)
+		return Failure
+	}
+	ctx.Blackboard[n.Key] = best
+	return Success
+}
+
+func (n *FindTarget) isEnemy(ctx *Context, o *entity.Object) bool {
+	d := combat.DamageableOf(o)
+	if o == ctx.Agent || d == nil || combat.IsDead(o) {
+		return false
+	}
+	if n.Team != "" {
+		return d.Team == n.Team
+	}
+	own := combat.DamageableOf(ctx.Agent)
+	return own == nil || own.Team == "" || d.Team != own.Team
+}
+
+func (n *FindTarget) Reset() {}
+
+// Chase runs at the object under Target until within Range of it
+type Chase struct {
+	Target string
+	Range  float64
+	Speed  float64
+}
+
+func (n *Chase) Tick(ctx *Context) Status {
+	target := ctx.Blackboard.Object(n.Target)
+	if target == nil {
+		return Failure
+	}
+	if ctx.Distance(target) <= n.Range {
+		return Success
+	}
+	tx, ty := target.Bounds().Center()
+	if ctx.NavigateTo(tx, ty, n.Speed) == Failure {
+		return Failure
+	}
+	return Running
+}
+
+func (n *Chase) Reset() {}
+
+// Follow keeps within Distance of the object under Target for as long as it exists
+type Follow struct {
+	Target   string
+	Distance float64
+	Speed    float64
+}
+
+func (n *Follow) Tick(ctx *Context) Status {
+	target := ctx.Blackboard.Object(n.Target)
+	if target == nil {
+		return Failure
+	}
+	if ctx.Distance(target) > n.Distance {
+		tx, ty := target.Bounds().Center()
+		ctx.NavigateTo(tx, ty, n.Speed)
+	}
+	return Running
+}
+
+func (n *Follow) Reset() {}
+
+// Flee runs directly away from the object under Target until Distance away,
+// failing if it is cornered
+type Flee struct {
+	Target   string
+	Distance float64
+	Speed    float64
+}
+
+func (n *Flee) Tick(ctx *Context) Status {
+	target := ctx.Blackboard.Object(n.Target)
+	if target == nil {
+		return Success
+	}
+	dist := ctx.Distance(target)
+	if dist >= n.Distance {
+		return Success
+	}
+
+	x, y := ctx.Position()
+	tx, ty := target.Bounds().Center()
+	awayX, awayY := x-tx, y-ty
+	if dist < 1 {
+		awayX, awayY, dist = 1, 0, 1
+	}
+	if ctx.MoveTowards(x+awayX/dist*n.Distance, y+awayY/dist*n.Distance, n.Speed) == Failure {
+		return Failure
+	}
+	return Running
+}
+
+func (n *Flee) Reset() {}
+
+// wanderTries is how many random points Wander tests before giving up for the tick
+const wanderTries = 8
+
+// Wander walks to a random walkable point within Radius of home, succeeding on
+// arrival and failing if the way is blocked
+type Wander struct {
+	Radius float64
+	Speed  float64
+
+	x, y   float64
+	picked bool
+}
+
+func (n *Wander) Tick(ctx *Context) Status {
+	if !n.picked {
+		hx, hy := ctx.Brain.Home()
+		for i := 0; i < wanderTries && !n.picked; i++ {
+			angle := ctx.Rand.Float64() * 2 * math.Pi
+			r := n.Radius * math.Sqrt(ctx.Rand.Float64())
+			n.x, n.y = hx+math.Cos(angle)*r, hy+math.Sin(angle)*r
+			n.picked = ctx.Host.Walkable(n.x, n.y)
+		}
+		if !n.picked {
+			return Failure
+		}
+	}
+
+	status := ctx.NavigateTo(n.x, n.y, n.Speed)
+	if status != Running {
+		n.picked = false
+	}
+	return status
+}
+
+func (n *Wander) Reset() {
+	n.picked = false
+}
+
+// Patrol walks to the next point of the brain's patrol route, succeeding on arrival.
+// Progress along the route is kept by the brain, so an interrupted patrol resumes
+// where it left off.
+type Patrol struct {
+	Speed float64
+}
+
+func (n *Patrol) Tick(ctx *Context) Status {
+	b := ctx.Brain
+	if len(b.Patrol) == 0 {
+		return Failure
+	}
+	b.patrolIndex %= len(b.Patrol)
+	hx, hy := b.Home()
+	point := b.Patrol[b.patrolIndex]
+
+	status := ctx.NavigateTo(hx+point[0], hy+point[1], n.Speed)
+	if status != Running {
+		// Skip a blocked point rather than walking into the wall forever
+		b.patrolIndex = (b.patrolIndex + 1) % len(b.Patrol)
+	}
+	return status
+}
+
+func (n *Patrol) Reset() {}
+
+// ReturnHome walks back until within Radius of home
+type ReturnHome struct {
+	Radius float64
+	Speed  float64
+}
+
+func (n *ReturnHome) Tick(ctx *Context) Status {
+	x, y := ctx.Position()
+	hx, hy := ctx.Brain.Home()
+	if math.Hypot(hx-x, hy-y) <= n.Radius {
+		return Success
+	}
+	return ctx.NavigateTo(hx, hy, n.Speed)
+}
+
+func (n *ReturnHome) Reset() {}
+
+// Wait runs for Seconds plus up to Random extra seconds, then succeeds
+type Wait struct {
+	Seconds float64
+	Random  float64
+
+	until   float64
+	waiting bool
+}
+
+func (n *Wait) Tick(ctx *Context) Status {
+	if !n.waiting {
+		n.waiting = true
+		n.until = ctx.Time + n.Seconds + n.Random*ctx.Rand.Float64()
+	}
+	if ctx.Time < n.until {
+		return Running
+	}
+	n.waiting = false
+	return Success
+}
+
+func (n *Wait) Reset() {
+	n.waiting = false
+}
+
+// Attack turns to face the object under Target and starts a melee attack,
+// failing while the attack is on cooldown
+type Attack struct {
+	Target string
+}
+
+func (n *Attack) Tick(ctx *Context) Status {
+	target := ctx.Blackboard.Object(n.Target)
+	if target == nil {
+		return Failure
+	}
+	dx, dy := aim(ctx, target)
+	if m := combat.MeleeOf(ctx.Agent); m != nil {
+		m.Aim(dx, dy)
+	}
+	if animator := anim.AnimatorOf(ctx.Agent); animator != nil {
+		animator.Face(dx, dy)
+	}
+	if !ctx.Host.Attack(ctx.Agent) {
+		return Failure
+	}
+	return Success
+}
+
+func (n *Attack) Reset() {}
+
+// Shoot fires a ranged attack at the object under Target, failing while it is
+// on cooldown
+type Shoot struct {
+	Target string
+}
+
+func (n *Shoot) Tick(ctx *Context) Status {
+	target := ctx.Blackboard.Object(n.Target)
+	if target == nil {
+		return Failure
+	}
+	dx, dy := aim(ctx, target)
+	if animator := anim.AnimatorOf(ctx.Agent); animator != nil {
+		animator.Face(dx, dy)
+	}
+	if !ctx.Host.Shoot(ctx.Agent, dx, dy) {
+		return Failure
+	}
+	return Success
+}
+
+func (n *Shoot) Reset() {}
+
+// aim returns the direction from the agent's centre to the target's
+func aim(ctx *Context, target *entity.Object) (float64, float64) {
+	x, y := ctx.Position()
+	tx, ty := target.Bounds().Center()
+	return tx - x, ty - y
+}
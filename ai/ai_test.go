@@ -0,0 +1,316 @@
+package ai
+
+import (
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Nathene/bitbase/combat"
+	"github.com/Nathene/bitbase/common"
+	"github.com/Nathene/bitbase/entity"
+	"github.com/Nathene/bitbase/pathfind"
+)
+
+const (
+	tileSize  = 32
+	agentSize = 16
+	tickRate  = 60
+)
+
+// testMap is a walled room with a short wall down the middle
+var testMap = []string{
+	"################",
+	"#..............#",
+	"#..............#",
+	"#......#.......#",
+	"#......#.......#",
+	"#......#.......#",
+	"#..............#",
+	"#..............#",
+	"#..............#",
+	"################",
+}
+
+// testHost is a Host over testMap that remembers the attacks asked of it
+type testHost struct {
+	rows    []string
+	paths   *pathfind.Finder
+	attacks int
+	shots   int
+}
+
+func newTestHost() *testHost {
+	h := &testHost{rows: testMap}
+	h.paths = pathfind.NewFinder(pathfind.Func(len(testMap[0]), len(testMap), func(x, y int) float64 {
+		if h.wall(x, y) {
+			return pathfind.Blocked
+		}
+		return 1
+	}))
+	return h
+}
+
+func (h *testHost) wall(x, y int) bool {
+	return y < 0 || y >= len(h.rows) || x < 0 || x >= len(h.rows[y]) || h.rows[y][x] == '#'
+}
+
+// blocked reports whether r overlaps a wall tile
+func (h *testHost) blocked(r common.Rect) bool {
+	for y := int(math.Floor(r.Y / tileSize)); y <= int(math.Floor((r.MaxY()-1e-9)/tileSize)); y++ {
+		for x := int(math.Floor(r.X / tileSize)); x <= int(math.Floor((r.MaxX()-1e-9)/tileSize)); x++ {
+			if h.wall(x, y) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Move slides along walls one axis at a time
+func (h *testHost) Move(o *entity.Object, dx, dy float64) (float64, float64) {
+	b := o.Bounds()
+	if h.blocked(common.Rect{X: b.X + dx, Y: b.Y, W: b.W, H: b.H}) {
+		dx = 0
+	}
+	if h.blocked(common.Rect{X: b.X + dx, Y: b.Y + dy, W: b.W, H: b.H}) {
+		dy = 0
+	}
+	o.SetPosition(o.GetX()+dx, o.GetY()+dy)
+	return dx, dy
+}
+
+func (h *testHost) Walkable(x, y float64) bool {
+	return !h.wall(int(math.Floor(x/tileSize)), int(math.Floor(y/tileSize)))
+}
+
+func (h *testHost) Attack(o *entity.Object) bool {
+	h.attacks++
+	return true
+}
+
+func (h *testHost) Shoot(o *entity.Object, dx, dy float64) bool {
+	h.shots++
+	return true
+}
+
+func (h *testHost) FindPath(fromX, fromY, toX, toY float64) ([][2]float64, bool) {
+	tile := func(x, y float64) pathfind.Point {
+		return pathfind.Point{X: int(math.Floor(x / tileSize)), Y: int(math.Floor(y / tileSize))}
+	}
+	path, err := h.paths.FindPath(tile(fromX, fromY), tile(toX, toY), pathfind.Options{Diagonal: true})
+	if err != nil {
+		return nil, false
+	}
+	waypoints := make([][2]float64, 0, len(path))
+	for _, p := range path[1:] {
+		waypoints = append(waypoints, [2]float64{(float64(p.X) + 0.5) * tileSize, (float64(p.Y) + 0.5) * tileSize})
+	}
+	if len(waypoints) == 0 {
+		return [][2]float64{{toX, toY}}, true
+	}
+	waypoints[len(waypoints)-1] = [2]float64{toX, toY}
+	return waypoints, true
+}
+
+// testWorld holds a System ticking agents around testMap
+type testWorld struct {
+	t      *testing.T
+	host   *testHost
+	world  *entity.World
+	system *System
+}
+
+func newTestWorld(t *testing.T, trees string) *testWorld {
+	t.Helper()
+	var defs []*TreeDef
+	if err := json.Unmarshal([]byte(trees), &defs); err != nil {
+		t.Fatal(err)
+	}
+	library := NewLibrary()
+	for _, d := range defs {
+		if err := library.Add(d); err != nil {
+			t.Fatal(err)
+		}
+	}
+	w := &testWorld{t: t, host: newTestHost(), world: entity.NewWorld()}
+	w.system = NewSystem(w.world, w.host, library)
+	return w
+}
+
+// add puts a character with its centre on tile (x, y)
+func (w *testWorld) add(name string, x, y int, components ...entity.Component) *entity.Object {
+	o := entity.NewObject(name)
+	o.AddComponent(&entity.Sprite{Width: agentSize, Height: agentSize})
+	o.AddComponent(&entity.Movement{Speed: 120})
+	for _, c := range components {
+		o.AddComponent(c)
+	}
+	o.SetPosition((float64(x)+0.5)*tileSize-agentSize/2, (float64(y)+0.5)*tileSize-agentSize/2)
+	w.world.Add(o)
+	w.system.Prepare(o)
+	return o
+}
+
+// run ticks the system for up to seconds, stopping early once done returns true,
+// and fails the test if any agent ends up inside a wall
+func (w *testWorld) run(seconds float64, done func() bool) bool {
+	for i := 0; i < int(seconds*tickRate); i++ {
+		w.system.Update(1.0 / tickRate)
+		for _, o := range w.world.All() {
+			if w.host.blocked(o.Bounds()) {
+				w.t.Fatalf("%s walked into a wall at %+v", o.Name, o.Bounds())
+			}
+		}
+		if done != nil && done() {
+			return true
+		}
+	}
+	return false
+}
+
+func near(o *entity.Object, x, y, within float64) bool {
+	cx, cy := o.Bounds().Center()
+	return math.Hypot(cx-x, cy-y) <= within
+}
+
+func TestPatrol(t *testing.T) {
+	w := newTestWorld(t, `[{"name": "patrol", "root": {"type": "patrol"}}]`)
+	// Round a loop whose third leg runs into the middle wall
+	route := [][2]float64{{256, 0}, {256, 96}, {0, 96}, {0, 0}}
+	guard := w.add("guard", 2, 2, &Brain{Tree: "patrol", Patrol: route})
+	hx, hy := BrainOf(guard).Home()
+
+	for lap := 0; lap < 2; lap++ {
+		for i, p := range route {
+			if !w.run(10, func() bool { return near(guard, hx+p[0], hy+p[1], 1) }) {
+				cx, cy := guard.Bounds().Center()
+				t.Fatalf("lap %d: never reached patrol point %d (%v), stopped at (%v, %v)", lap, i, p, cx, cy)
+			}
+		}
+	}
+}
+
+const chaseTrees = `[{
+	"name": "chaser",
+	"root": {"type": "sequence", "reactive": true, "children": [
+		{"type": "findTarget", "team": "player", "radius": 400},
+		{"type": "selector", "children": [
+			{"type": "sequence", "reactive": true, "children": [
+				{"type": "inRange", "radius": 30},
+				{"type": "attack"}
+			]},
+			{"type": "chase", "range": 26}
+		]}
+	]}
+}]`
+
+func TestChaseAroundWall(t *testing.T) {
+	w := newTestWorld(t, chaseTrees)
+	// The wall is straight between them, so walking directly gets stuck
+	goblin := w.add("goblin", 4, 4, &Brain{Tree: "chaser"}, &combat.Damageable{Team: "goblins"})
+	player := w.add("player", 10, 4, &combat.Damageable{Team: "player"})
+
+	px, py := player.Bounds().Center()
+	if !w.run(10, func() bool { return w.host.attacks > 0 }) {
+		cx, cy := goblin.Bounds().Center()
+		t.Fatalf("goblin never attacked; stopped at (%v, %v)", cx, cy)
+	}
+	if !near(goblin, px, py, 30) {
+		t.Errorf("goblin attacked from %+v, out of reach of the player at (%v, %v)", goblin.Bounds(), px, py)
+	}
+	if BrainOf(goblin).Board().Object("target") != player {
+		t.Error("goblin's target isn't the player")
+	}
+}
+
+func TestChaseIgnoresOwnTeam(t *testing.T) {
+	w := newTestWorld(t, chaseTrees)
+	goblin := w.add("goblin", 4, 4, &Brain{Tree: "chaser"}, &combat.Damageable{Team: "goblins"})
+	w.add("friend", 5, 2, &combat.Damageable{Team: "goblins"})
+
+	w.run(2, nil)
+	if w.host.attacks > 0 || BrainOf(goblin).Status() != Failure {
+		t.Errorf("goblin went after its own team: %d attacks, status %v", w.host.attacks, BrainOf(goblin).Status())
+	}
+	hx, hy := BrainOf(goblin).Home()
+	if !near(goblin, hx, hy, 0) {
+		t.Error("goblin moved with no one to chase")
+	}
+}
+
+const fleeTrees = `[{
+	"name": "coward",
+	"root": {"type": "sequence", "children": [
+		{"type": "findTarget", "team": "player", "radius": 300},
+		{"type": "flee", "distance": 200}
+	]}
+}]`
+
+func TestFlee(t *testing.T) {
+	w := newTestWorld(t, fleeTrees)
+	coward := w.add("coward", 4, 7, &Brain{Tree: "coward"}, &combat.Damageable{})
+	player := w.add("player", 2, 7, &combat.Damageable{Team: "player"})
+
+	w.run(5, func() bool { return BrainOf(coward).Status() != Running })
+	b := BrainOf(coward)
+	if b.Status() != Success {
+		t.Fatalf("flee ended with %v, want success", b.Status())
+	}
+	cx, cy := coward.Bounds().Center()
+	px, py := player.Bounds().Center()
+	if d := math.Hypot(cx-px, cy-py); d < 200 {
+		t.Errorf("coward only got %v away, want 200", d)
+	}
+	if cx <= px {
+		t.Errorf("coward ran towards the player: at x %v, player at %v", cx, px)
+	}
+}
+
+func TestFleeCornered(t *testing.T) {
+	w := newTestWorld(t, fleeTrees)
+	// Backed up against the east wall with the player to the west
+	coward := w.add("coward", 14, 7, &Brain{Tree: "coward"}, &combat.Damageable{})
+	w.add("player", 12, 7, &combat.Damageable{Team: "player"})
+
+	if !w.run(3, func() bool { return BrainOf(coward).Status() == Failure }) {
+		t.Fatalf("cornered flee never failed; status %v at %+v", BrainOf(coward).Status(), coward.Bounds())
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"single.json": "\n  {\"name\": \"idle\", \"root\": {\"type\": \"wait\", \"seconds\": 1}}",
+		"many.json":   " [{\"name\": \"a\", \"root\": {\"type\": \"wait\", \"seconds\": 1}}, {\"name\": \"b\", \"root\": {\"type\": \"wait\", \"seconds\": 2}}]",
+		"bad.json":    "[{\"name\": \"c\", \"root\": {\"type\": \"wait\", \"seconds\": 1}}, {\"name\": 5}]",
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	library := NewLibrary()
+	for _, name := range []string{"single.json", "many.json"} {
+		if err := library.LoadFile(filepath.Join(dir, name)); err != nil {
+			t.Errorf("loading %s: %v", name, err)
+		}
+	}
+	for _, name := range []string{"idle", "a", "b"} {
+		if library.Get(name) == nil {
+			t.Errorf("tree %q wasn't loaded", name)
+		}
+	}
+
+	// A broken array reports what is wrong inside it, not that it isn't a single tree
+	err := library.LoadFile(filepath.Join(dir, "bad.json"))
+	if err == nil || !strings.Contains(err.Error(), "cannot unmarshal number") {
+		t.Errorf("got error %v, want one about the name", err)
+	}
+	if library.Get("c") != nil {
+		t.Error("loaded part of a broken file")
+	}
+}
@@ -0,0 +1,37 @@
+package ai
+
+import "github.com/Nathene/bitbase/entity"
+
+// Blackboard is a character's memory, shared by every node in its tree
+type Blackboard map[string]any
+
+// Has reports whether a key is set
+func (b Blackboard) Has(key string) bool {
+	_, ok := b[key]
+	return ok
+}
+
+// Float returns a numeric value, or 0. Booleans read as 0 or 1.
+func (b Blackboard) Float(key string) float64 {
+	switch v := b[key].(type) {
+	case float64:
+		return v
+	case int:
+		return float64(v)
+	case bool:
+		if v {
+			return 1
+		}
+	}
+	return 0
+}
+
+// Object returns an entity stored under key, or nil if there is none or it has
+// left the world
+func (b Blackboard) Object(key string) *entity.Object {
+	o, _ := b[key].(*entity.Object)
+	if o == nil || o.World() == nil {
+		return nil
+	}
+	return o
+}
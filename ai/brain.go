@@ -0,0 +1,174 @@
+package ai
+
+import (
+	"log"
+	"math"
+	"math/rand"
+
+	"github.com/Nathene/bitbase/anim"
+	"github.com/Nathene/bitbase/combat"
+	"github.com/Nathene/bitbase/entity"
+)
+
+func init() {
+	entity.RegisterComponent("brain", func() entity.Component { return &Brain{} })
+}
+
+// Brain gives an object a behaviour tree to run
+type Brain struct {
+	Tree       string         `json:"tree"`       // Name of the tree in the library
+	Blackboard map[string]any `json:"blackboard"` // Initial blackboard values
+	Patrol     [][2]float64   `json:"patrol"`     // Patrol route as offsets from home, in pixels
+
+	root         Node
+	failed       bool // The tree couldn't be built, so don't try every tick
+	board        Blackboard
+	status       Status
+	homeX, homeY float64
+	homeSet      bool
+	patrolIndex  int
+	stuck        float64 // Seconds the current move has been blocked
+	wasDead      bool
+	path         [][2]float64 // Waypoints still to walk, ending at the goal
+	goalX, goalY float64      // Where path leads
+}
+
+func (b *Brain) ComponentName() string { return "brain" }
+
+// BrainOf returns the object's brain component, or nil
+func BrainOf(o *entity.Object) *Brain {
+	b, _ := o.Component("brain").(*Brain)
+	return b
+}
+
+// Home returns the point the character wanders around and returns to
+func (b *Brain) Home() (float64, float64) {
+	return b.homeX, b.homeY
+}
+
+// SetHome moves the character's home point
+func (b *Brain) SetHome(x, y float64) {
+	b.homeX, b.homeY = x, y
+	b.homeSet = true
+}
+
+// Board returns the character's blackboard, creating it from the initial values if needed
+func (b *Brain) Board() Blackboard {
+	if b.board == nil {
+		b.board = make(Blackboard, len(b.Blackboard))
+		for key, value := range b.Blackboard {
+			b.board[key] = value
+		}
+	}
+	return b.board
+}
+
+// Status returns what the tree reported on its last tick
+func (b *Brain) Status() Status {
+	return b.status
+}
+
+// Path returns the waypoints the character is still to walk, for debugging
+func (b *Brain) Path() [][2]float64 {
+	return b.path
+}
+
+// ClearPath forgets the planned path, so the next move plans a new one
+func (b *Brain) ClearPath() {
+	b.path = nil
+}
+
+// Reset abandons whatever the tree was doing
+func (b *Brain) Reset() {
+	if b.root != nil {
+		b.root.Reset()
+	}
+	b.stuck = 0
+	b.ClearPath()
+}
+
+// System ticks the brain of every living character in a world
+type System struct {
+	World   *entity.World
+	Host    Host
+	Library *Library
+	Rand    *rand.Rand // Wander targets and random waits; seed it for repeatable tests
+
+	time float64
+	ctx  Context
+}
+
+// NewSystem creates an AI system
+func NewSystem(world *entity.World, host Host, library *Library) *System {
+	return &System{
+		World:   world,
+		Host:    host,
+		Library: library,
+		Rand:    rand.New(rand.NewSource(1)),
+	}
+}
+
+// Prepare makes an object's current position its home. Call it once an object is
+// placed in the world.
+func (s *System) Prepare(o *entity.Object) {
+	if b := BrainOf(o); b != nil && !b.homeSet {
+		b.SetHome(o.Bounds().Center())
+	}
+}
+
+// Update ticks every brain by dt seconds
+func (s *System) Update(dt float64) {
+	s.time += dt
+	for _, o := range s.World.All() {
+		b := BrainOf(o)
+		if b == nil {
+			continue
+		}
+		if combat.IsDead(o) {
+			if !b.wasDead {
+				b.Reset()
+				b.wasDead = true
+			}
+			continue
+		}
+		b.wasDead = false
+		s.Tick(o, b, dt)
+	}
+}
+
+// Tick runs one object's tree once
+func (s *System) Tick(o *entity.Object, b *Brain, dt float64) Status {
+	if b.root == nil {
+		if b.failed || s.Library == nil {
+			return Failure
+		}
+		root, err := s.Library.Build(b.Tree)
+		if err != nil {
+			log.Printf("Failed to build behaviour tree for %s: %v", o.Name, err)
+			b.failed = true
+			return Failure
+		}
+		b.root = root
+	}
+	if !b.homeSet {
+		s.Prepare(o)
+	}
+
+	s.ctx = Context{
+		Agent:      o,
+		Brain:      b,
+		Blackboard: b.Board(),
+		Host:       s.Host,
+		World:      s.World,
+		Rand:       s.Rand,
+		DT:         dt,
+		Time:       s.time,
+	}
+	b.status = b.root.Tick(&s.ctx)
+
+	if animator := anim.AnimatorOf(o); animator != nil && dt > 0 {
+		animator.Face(s.ctx.movedX, s.ctx.movedY)
+		animator.SetFloat("speed", math.Hypot(s.ctx.movedX, s.ctx.movedY)/dt)
+	}
+	return b.status
+}
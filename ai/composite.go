@@ -0,0 +1,148 @@
+package ai
+
+import "fmt"
+
+func init() {
+	RegisterNode("sequence", func(def *NodeDef, children []Node) (Node, error) {
+		if len(children) == 0 {
+			return nil, fmt.Errorf("needs children")
+		}
+		return &Sequence{Children: children, Reactive: def.Params["reactive"] == true}, nil
+	})
+	RegisterNode("selector", func(def *NodeDef, children []Node) (Node, error) {
+		if len(children) == 0 {
+			return nil, fmt.Errorf("needs children")
+		}
+		return &Selector{Children: children}, nil
+	})
+	RegisterNode("parallel", func(def *NodeDef, children []Node) (Node, error) {
+		if len(children) == 0 {
+			return nil, fmt.Errorf("needs children")
+		}
+		return &Parallel{Children: children, RequireAll: def.String("policy", "all") == "all"}, nil
+	})
+}
+
+// Sequence runs its children in order until one fails. A running child is
+// resumed on the next tick without re-running the ones before it, unless the
+// sequence is Reactive, in which case every tick starts from the first child
+// so conditions guarding a long action are checked again.
+type Sequence struct {
+	Children []Node
+	Reactive bool
+	current  int
+}
+
+func (s *Sequence) Tick(ctx *Context) Status {
+	i := s.current
+	if s.Reactive {
+		i = 0
+	}
+	for ; i < len(s.Children); i++ {
+		switch s.Children[i].Tick(ctx) {
+		case Running:
+			if i < s.current {
+				s.Children[s.current].Reset()
+			}
+			s.current = i
+			return Running
+		case Failure:
+			s.Reset()
+			return Failure
+		}
+	}
+	s.Reset()
+	return Success
+}
+
+func (s *Sequence) Reset() {
+	for _, child := range s.Children {
+		child.Reset()
+	}
+	s.current = 0
+}
+
+// Selector tries its children in priority order until one doesn't fail. It
+// re-checks higher priority children every tick, abandoning a running
+// lower priority child when one of them takes over.
+type Selector struct {
+	Children []Node
+	running  int // Index of the child that returned Running last tick
+}
+
+func (s *Selector) Tick(ctx *Context) Status {
+	for i, child := range s.Children {
+		status := child.Tick(ctx)
+		if status == Failure {
+			continue
+		}
+		if s.running > i {
+			s.Children[s.running].Reset()
+		}
+		if status == Running {
+			s.running = i
+		} else {
+			s.running = 0
+		}
+		return status
+	}
+	s.running = 0
+	return Failure
+}
+
+func (s *Selector) Reset() {
+	for _, child := range s.Children {
+		child.Reset()
+	}
+	s.running = 0
+}
+
+// Parallel ticks every child each tick. With RequireAll it succeeds when all
+// children have succeeded and fails as soon as one fails; otherwise it
+// succeeds as soon as one succeeds and fails when all have failed.
+type Parallel struct {
+	Children   []Node
+	RequireAll bool
+	done       []Status
+}
+
+func (p *Parallel) Tick(ctx *Context) Status {
+	if p.done == nil {
+		p.done = make([]Status, len(p.Children))
+		for i := range p.done {
+			p.done[i] = Running
+		}
+	}
+
+	successes, failures := 0, 0
+	for i, child := range p.Children {
+		if p.done[i] == Running {
+			p.done[i] = child.Tick(ctx)
+		}
+		switch p.done[i] {
+		case Success:
+			successes++
+		case Failure:
+			failures++
+		}
+	}
+
+	var status Status
+	switch {
+	case p.RequireAll && failures > 0, !p.RequireAll && failures == len(p.Children):
+		status = Failure
+	case p.RequireAll && successes == len(p.Children), !p.RequireAll && successes > 0:
+		status = Success
+	default:
+		return Running
+	}
+	p.Reset()
+	return status
+}
+
+func (p *Parallel) Reset() {
+	for _, child := range p.Children {
+		child.Reset()
+	}
+	p.done = nil
+}
@@ -0,0 +1,169 @@
+package ai
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/Nathene/bitbase/combat"
+	"github.com/Nathene/bitbase/entity"
+)
+
+func init() {
+	RegisterNode("set", func(def *NodeDef, children []Node) (Node, error) {
+		key := def.String("key", "")
+		if key == "" {
+			return nil, fmt.Errorf("needs a key")
+		}
+		return &Set{Key: key, Value: def.Params["value"]}, leaf(children)
+	})
+	RegisterNode("clear", func(def *NodeDef, children []Node) (Node, error) {
+		key := def.String("key", "")
+		if key == "" {
+			return nil, fmt.Errorf("needs a key")
+		}
+		return &Clear{Key: key}, leaf(children)
+	})
+	RegisterNode("check", func(def *NodeDef, children []Node) (Node, error) {
+		key := def.String("key", "")
+		if key == "" {
+			return nil, fmt.Errorf("needs a key")
+		}
+		op := def.String("op", "set")
+		if _, ok := checkOps[op]; !ok {
+			return nil, fmt.Errorf("unknown op %q", op)
+		}
+		return &Check{Key: key, Op: op, Value: def.Params["value"]}, leaf(children)
+	})
+	RegisterNode("inRange", func(def *NodeDef, children []Node) (Node, error) {
+		return &InRange{Target: def.String("target", "target"), Radius: def.Float("radius", 32)}, leaf(children)
+	})
+	RegisterNode("healthBelow", func(def *NodeDef, children []Node) (Node, error) {
+		return &HealthBelow{Fraction: def.Float("fraction", 0.25)}, leaf(children)
+	})
+	RegisterNode("atHome", func(def *NodeDef, children []Node) (Node, error) {
+		return &AtHome{Radius: def.Float("radius", 8)}, leaf(children)
+	})
+}
+
+// Set stores a value on the blackboard
+type Set struct {
+	Key   string
+	Value any
+}
+
+func (n *Set) Tick(ctx *Context) Status {
+	ctx.Blackboard[n.Key] = n.Value
+	return Success
+}
+
+func (n *Set) Reset() {}
+
+// Clear removes a value from the blackboard
+type Clear struct {
+	Key string
+}
+
+func (n *Clear) Tick(ctx *Context) Status {
+	delete(ctx.Blackboard, n.Key)
+	return Success
+}
+
+func (n *Clear) Reset() {}
+
+var checkOps = map[string]func(have, want any) bool{
+	"set":   func(have, want any) bool { return have != nil },
+	"unset": func(have, want any) bool { return have == nil },
+	"==":    equal,
+	"!=":    func(have, want any) bool { return !equal(have, want) },
+	"<":     func(have, want any) bool { return number(have) < number(want) },
+	"<=":    func(have, want any) bool { return number(have) <= number(want) },
+	">":     func(have, want any) bool { return number(have) > number(want) },
+	">=":    func(have, want any) bool { return number(have) >= number(want) },
+}
+
+// Check compares a blackboard value, succeeding if the comparison holds. The "set"
+// and "unset" ops test whether the key has a value at all.
+type Check struct {
+	Key   string
+	Op    string
+	Value any
+}
+
+func (n *Check) Tick(ctx *Context) Status {
+	have := ctx.Blackboard[n.Key]
+	if _, isObject := have.(*entity.Object); isObject && ctx.Blackboard.Object(n.Key) == nil {
+		have = nil // Gone from the world
+	}
+	if checkOps[n.Op](have, n.Value) {
+		return Success
+	}
+	return Failure
+}
+
+func (n *Check) Reset() {}
+
+// number reads a blackboard or parameter value as a number; booleans are 0 or 1
+func number(v any) float64 {
+	return Blackboard{"v": v}.Float("v")
+}
+
+func equal(a, b any) bool {
+	switch a.(type) {
+	case float64, int, bool:
+		if _, ok := b.(string); !ok {
+			return number(a) == number(b)
+		}
+	case string:
+		s, ok := b.(string)
+		return ok && a == s
+	}
+	return a == nil && b == nil
+}
+
+// InRange succeeds if the object under Target is within Radius pixels
+type InRange struct {
+	Target string
+	Radius float64
+}
+
+func (n *InRange) Tick(ctx *Context) Status {
+	target := ctx.Blackboard.Object(n.Target)
+	if target == nil || ctx.Distance(target) > n.Radius {
+		return Failure
+	}
+	return Success
+}
+
+func (n *InRange) Reset() {}
+
+// HealthBelow succeeds if the agent's health is under Fraction of its maximum
+type HealthBelow struct {
+	Fraction float64
+}
+
+func (n *HealthBelow) Tick(ctx *Context) Status {
+	h := combat.HealthOf(ctx.Agent)
+	maxHealth := combat.MaxHealth(ctx.Agent)
+	if h == nil || maxHealth <= 0 || h.Current >= maxHealth*n.Fraction {
+		return Failure
+	}
+	return Success
+}
+
+func (n *HealthBelow) Reset() {}
+
+// AtHome succeeds if the agent is within Radius pixels of its home
+type AtHome struct {
+	Radius float64
+}
+
+func (n *AtHome) Tick(ctx *Context) Status {
+	x, y := ctx.Position()
+	hx, hy := ctx.Brain.Home()
+	if math.Hypot(hx-x, hy-y) > n.Radius {
+		return Failure
+	}
+	return Success
+}
+
+func (n *AtHome) Reset() {}
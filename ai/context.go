@@ -0,0 +1,132 @@
+package ai
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/Nathene/bitbase/entity"
+)
+
+const (
+	stuckTime      = 0.5 // Seconds of blocked movement before a move gives up
+	stuckFraction  = 0.1 // Moving less than this share of the requested distance counts as blocked
+	repathDistance = 16  // How far a goal can move, in pixels, before the path to it is planned again
+	waypointRadius = 4   // How close counts as reaching a waypoint
+)
+
+// Host is what the AI needs from the game around it. Keeping it an interface lets
+// trees run headlessly against a small map in tests.
+type Host interface {
+	// Move tries to move an object by (dx, dy) and returns how far it actually went
+	Move(o *entity.Object, dx, dy float64) (float64, float64)
+	// Walkable reports whether a character could stand with its centre at (x, y)
+	Walkable(x, y float64) bool
+	// Attack starts the object's melee attack, reporting whether it did
+	Attack(o *entity.Object) bool
+	// Shoot fires the object's ranged attack in direction (dx, dy), reporting whether it did
+	Shoot(o *entity.Object, dx, dy float64) bool
+	// FindPath plans a route between two points, returning waypoints after the start
+	// and ending at the destination, or false if there is no way there
+	FindPath(fromX, fromY, toX, toY float64) ([][2]float64, bool)
+}
+
+// Context is everything a node can see while it ticks
+type Context struct {
+	Agent      *entity.Object
+	Brain      *Brain
+	Blackboard Blackboard
+	Host       Host
+	World      *entity.World
+	Rand       *rand.Rand
+	DT         float64 // Seconds this tick covers
+	Time       float64 // Seconds since the system started
+
+	movedX, movedY float64 // Distance moved this tick, for facing and animation
+}
+
+// Position returns the centre of the agent
+func (ctx *Context) Position() (float64, float64) {
+	return ctx.Agent.Bounds().Center()
+}
+
+// Distance returns the distance between the centres of the agent and another object
+func (ctx *Context) Distance(o *entity.Object) float64 {
+	x, y := ctx.Position()
+	ox, oy := o.Bounds().Center()
+	return math.Hypot(ox-x, oy-y)
+}
+
+// MoveTowards walks the agent's centre towards (x, y) at its move speed times scale.
+// It succeeds once there, runs while on the way, and fails when it has been
+// blocked for a while.
+func (ctx *Context) MoveTowards(x, y, scale float64) Status {
+	cx, cy := ctx.Position()
+	dx, dy := x-cx, y-cy
+	dist := math.Hypot(dx, dy)
+	if dist < 1 {
+		ctx.Brain.stuck = 0
+		return Success
+	}
+
+	step := min(entity.MoveSpeed(ctx.Agent)*scale*ctx.DT, dist)
+	if step <= 0 {
+		return Failure
+	}
+	mx, my := ctx.Host.Move(ctx.Agent, dx/dist*step, dy/dist*step)
+	ctx.movedX += mx
+	ctx.movedY += my
+
+	if math.Hypot(mx, my) < step*stuckFraction {
+		ctx.Brain.stuck += ctx.DT
+		if ctx.Brain.stuck >= stuckTime {
+			ctx.Brain.stuck = 0
+			return Failure
+		}
+	} else {
+		ctx.Brain.stuck = 0
+	}
+	if math.Hypot(dx-mx, dy-my) < 1 {
+		return Success
+	}
+	return Running
+}
+
+// NavigateTo walks the agent's centre to (x, y) along a planned path, going around
+// walls. It plans again when the goal moves, and walks straight at the goal if
+// there is no path. It reports like MoveTowards.
+func (ctx *Context) NavigateTo(x, y, scale float64) Status {
+	b := ctx.Brain
+	if b.path == nil || math.Hypot(x-b.goalX, y-b.goalY) > repathDistance {
+		cx, cy := ctx.Position()
+		path, ok := ctx.Host.FindPath(cx, cy, x, y)
+		if !ok {
+			b.ClearPath()
+			return ctx.MoveTowards(x, y, scale)
+		}
+		b.path, b.goalX, b.goalY = path, x, y
+	}
+
+	for len(b.path) > 1 {
+		cx, cy := ctx.Position()
+		if math.Hypot(b.path[0][0]-cx, b.path[0][1]-cy) > waypointRadius {
+			break
+		}
+		b.path = b.path[1:]
+	}
+
+	// Keep heading for the live goal on the last leg, in case it has shifted a little
+	wx, wy := x, y
+	if len(b.path) > 1 {
+		wx, wy = b.path[0][0], b.path[0][1]
+	}
+	status := ctx.MoveTowards(wx, wy, scale)
+	switch {
+	case status == Failure:
+		b.ClearPath()
+	case status == Success && len(b.path) <= 1:
+		b.ClearPath()
+	default:
+		status = Running
+	}
+	return status
+}
@@ -0,0 +1,155 @@
+package ai
+
+func init() {
+	RegisterNode("inverter", func(def *NodeDef, children []Node) (Node, error) {
+		child, err := single(children)
+		return &Inverter{Child: child}, err
+	})
+	RegisterNode("succeeder", func(def *NodeDef, children []Node) (Node, error) {
+		child, err := single(children)
+		return &Succeeder{Child: child}, err
+	})
+	RegisterNode("repeat", func(def *NodeDef, children []Node) (Node, error) {
+		child, err := single(children)
+		return &Repeat{Child: child, Count: int(def.Float("count", 0))}, err
+	})
+	RegisterNode("untilFail", func(def *NodeDef, children []Node) (Node, error) {
+		child, err := single(children)
+		return &UntilFail{Child: child}, err
+	})
+	RegisterNode("cooldown", func(def *NodeDef, children []Node) (Node, error) {
+		child, err := single(children)
+		return &Cooldown{Child: child, Seconds: def.Float("seconds", 1)}, err
+	})
+	RegisterNode("timeout", func(def *NodeDef, children []Node) (Node, error) {
+		child, err := single(children)
+		return &Timeout{Child: child, Seconds: def.Float("seconds", 1)}, err
+	})
+}
+
+// Inverter turns success into failure and failure into success
+type Inverter struct {
+	Child Node
+}
+
+func (d *Inverter) Tick(ctx *Context) Status {
+	switch d.Child.Tick(ctx) {
+	case Success:
+		return Failure
+	case Failure:
+		return Success
+	}
+	return Running
+}
+
+func (d *Inverter) Reset() { d.Child.Reset() }
+
+// Succeeder reports success however its child finishes
+type Succeeder struct {
+	Child Node
+}
+
+func (d *Succeeder) Tick(ctx *Context) Status {
+	if d.Child.Tick(ctx) == Running {
+		return Running
+	}
+	return Success
+}
+
+func (d *Succeeder) Reset() { d.Child.Reset() }
+
+// Repeat runs its child Count times, or forever if Count is 0, failing if the child fails.
+// Each tick runs the child at most once.
+type Repeat struct {
+	Child Node
+	Count int
+	done  int
+}
+
+func (d *Repeat) Tick(ctx *Context) Status {
+	switch d.Child.Tick(ctx) {
+	case Running:
+		return Running
+	case Failure:
+		d.Reset()
+		return Failure
+	}
+	d.done++
+	d.Child.Reset()
+	if d.Count > 0 && d.done >= d.Count {
+		d.done = 0
+		return Success
+	}
+	return Running
+}
+
+func (d *Repeat) Reset() {
+	d.Child.Reset()
+	d.done = 0
+}
+
+// UntilFail runs its child until it fails, then succeeds
+type UntilFail struct {
+	Child Node
+}
+
+func (d *UntilFail) Tick(ctx *Context) Status {
+	if d.Child.Tick(ctx) == Failure {
+		d.Child.Reset()
+		return Success
+	}
+	return Running
+}
+
+func (d *UntilFail) Reset() { d.Child.Reset() }
+
+// Cooldown fails without running its child until Seconds have passed since the
+// child last finished
+type Cooldown struct {
+	Child   Node
+	Seconds float64
+	readyAt float64
+}
+
+func (d *Cooldown) Tick(ctx *Context) Status {
+	if ctx.Time < d.readyAt {
+		return Failure
+	}
+	status := d.Child.Tick(ctx)
+	if status != Running {
+		d.readyAt = ctx.Time + d.Seconds
+	}
+	return status
+}
+
+// Reset keeps the cooldown running; it's about time, not progress
+func (d *Cooldown) Reset() { d.Child.Reset() }
+
+// Timeout fails its child if it is still running after Seconds
+type Timeout struct {
+	Child     Node
+	Seconds   float64
+	startedAt float64
+	running   bool
+}
+
+func (d *Timeout) Tick(ctx *Context) Status {
+	if !d.running {
+		d.running = true
+		d.startedAt = ctx.Time
+	}
+	if ctx.Time-d.startedAt >= d.Seconds {
+		d.Reset()
+		return Failure
+	}
+	status := d.Child.Tick(ctx)
+	if status != Running {
+		d.running = false
+	}
+	return status
+}
+
+func (d *Timeout) Reset() {
+	d.Child.Reset()
+	d.running = false
+}
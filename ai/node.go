@@ -0,0 +1,171 @@
+// Package ai runs behaviour trees that drive non-player characters. Trees are
+// defined in JSON, built per character, and ticked by a System that needs no
+// rendering, so behaviours can run headlessly.
+package ai
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Status is the result of ticking a node
+type Status int
+
+const (
+	Success Status = iota
+	Failure
+	Running
+)
+
+var statusNames = [...]string{"success", "failure", "running"}
+
+func (s Status) String() string {
+	if s < 0 || int(s) >= len(statusNames) {
+		return fmt.Sprintf("Status(%d)", int(s))
+	}
+	return statusNames[s]
+}
+
+// Node is one node of a behaviour tree. Each character gets its own instances,
+// so nodes may keep per-character state in their fields.
+type Node interface {
+	// Tick runs the node for one simulation step
+	Tick(ctx *Context) Status
+	// Reset forgets any progress, e.g. when a parent abandons a running node
+	Reset()
+}
+
+// NodeDef is the data form of a node: its type, children and parameters
+type NodeDef struct {
+	Type     string
+	Children []*NodeDef
+	Params   map[string]any
+}
+
+// UnmarshalJSON reads {"type": ..., "children": [...], "child": {...}, ...params}
+func (d *NodeDef) UnmarshalJSON(data []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(raw["type"], &d.Type); err != nil || d.Type == "" {
+		return fmt.Errorf("node has no type")
+	}
+	if children, ok := raw["children"]; ok {
+		if err := json.Unmarshal(children, &d.Children); err != nil {
+			return fmt.Errorf("%s children: %w", d.Type, err)
+		}
+	}
+	if child, ok := raw["child"]; ok {
+		var c NodeDef
+		if err := json.Unmarshal(child, &c); err != nil {
+			return fmt.Errorf("%s child: %w", d.Type, err)
+		}
+		d.Children = append(d.Children, &c)
+	}
+
+	d.Params = make(map[string]any)
+	for key, value := range raw {
+		if key == "type" || key == "children" || key == "child" {
+			continue
+		}
+		var v any
+		if err := json.Unmarshal(value, &v); err != nil {
+			return err
+		}
+		d.Params[key] = v
+	}
+	return nil
+}
+
+// Float returns a numeric parameter, or fallback if it is missing
+func (d *NodeDef) Float(key string, fallback float64) float64 {
+	if v, ok := d.Params[key].(float64); ok {
+		return v
+	}
+	return fallback
+}
+
+// String returns a string parameter, or fallback if it is missing
+func (d *NodeDef) String(key string, fallback string) string {
+	if v, ok := d.Params[key].(string); ok {
+		return v
+	}
+	return fallback
+}
+
+// NodeFactory builds a node from its definition, with its children already built
+type NodeFactory func(def *NodeDef, children []Node) (Node, error)
+
+var (
+	nodeFactories = make(map[string]NodeFactory)
+	nodeMutex     sync.RWMutex
+)
+
+// RegisterNode makes a node type available to tree files
+func RegisterNode(typ string, factory NodeFactory) {
+	nodeMutex.Lock()
+	defer nodeMutex.Unlock()
+
+	if _, exists := nodeFactories[typ]; exists {
+		panic(fmt.Sprintf("ai: node %q registered twice", typ))
+	}
+	nodeFactories[typ] = factory
+}
+
+// NodeTypes returns every registered node type in sorted order
+func NodeTypes() []string {
+	nodeMutex.RLock()
+	defer nodeMutex.RUnlock()
+
+	types := make([]string, 0, len(nodeFactories))
+	for typ := range nodeFactories {
+		types = append(types, typ)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// Build creates a fresh node tree from a definition
+func Build(def *NodeDef) (Node, error) {
+	nodeMutex.RLock()
+	factory, ok := nodeFactories[def.Type]
+	nodeMutex.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown node type %q", def.Type)
+	}
+
+	children := make([]Node, 0, len(def.Children))
+	for i, childDef := range def.Children {
+		child, err := Build(childDef)
+		if err != nil {
+			return nil, fmt.Errorf("%s[%d]: %w", def.Type, i, err)
+		}
+		children = append(children, child)
+	}
+
+	node, err := factory(def, children)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", def.Type, err)
+	}
+	return node, nil
+}
+
+// leaf checks that a leaf node has no children
+func leaf(children []Node) error {
+	if len(children) > 0 {
+		return fmt.Errorf("takes no children")
+	}
+	return nil
+}
+
+// single checks that a decorator has exactly one child
+func single(children []Node) (Node, error) {
+	if len(children) != 1 {
+		return nil, fmt.Errorf("needs exactly one child, has %d", len(children))
+	}
+	return children[0], nil
+}
@@ -0,0 +1,108 @@
+package ai
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// TreeDef is a named behaviour tree as stored in data files
+type TreeDef struct {
+	Name string   `json:"name"`
+	Root *NodeDef `json:"root"`
+}
+
+// Library holds every known behaviour tree definition, keyed by name
+type Library struct {
+	trees map[string]*TreeDef
+}
+
+// NewLibrary creates an empty tree library
+func NewLibrary() *Library {
+	return &Library{trees: make(map[string]*TreeDef)}
+}
+
+// Add validates a tree by building it once, then registers it
+func (l *Library) Add(t *TreeDef) error {
+	if t.Name == "" {
+		return fmt.Errorf("tree has no name")
+	}
+	if _, exists := l.trees[t.Name]; exists {
+		return fmt.Errorf("tree %q defined twice", t.Name)
+	}
+	if t.Root == nil {
+		return fmt.Errorf("tree %q has no root", t.Name)
+	}
+	if _, err := Build(t.Root); err != nil {
+		return fmt.Errorf("tree %q: %w", t.Name, err)
+	}
+	l.trees[t.Name] = t
+	return nil
+}
+
+// Get returns the tree definition with the given name, or nil
+func (l *Library) Get(name string) *TreeDef {
+	return l.trees[name]
+}
+
+// Names returns every tree name in sorted order
+func (l *Library) Names() []string {
+	names := make([]string, 0, len(l.trees))
+	for name := range l.trees {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Build creates a fresh instance of a named tree for one character
+func (l *Library) Build(name string) (Node, error) {
+	t := l.trees[name]
+	if t == nil {
+		return nil, fmt.Errorf("unknown tree %q", name)
+	}
+	return Build(t.Root)
+}
+
+// LoadFile reads a JSON file holding one tree or an array of trees
+func (l *Library) LoadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var trees []*TreeDef
+	if strings.HasPrefix(strings.TrimSpace(string(data)), "[") {
+		err = json.Unmarshal(data, &trees)
+	} else {
+		t := &TreeDef{}
+		err = json.Unmarshal(data, t)
+		trees = append(trees, t)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	for _, t := range trees {
+		if err := l.Add(t); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// LoadDir loads every .json file in a directory
+func (l *Library) LoadDir(dir string) error {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return err
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		if err := l.LoadFile(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,335 @@
+package anim
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/Nathene/bitbase/entity"
+)
+
+// Event is fired when an animation reaches a frame tagged in its clip
+type Event struct {
+	Name  string
+	State string
+	Frame int
+}
+
+// Animator plays an animation set on an entity
+type Animator struct {
+	SetName string  `json:"set"`
+	Speed   float64 `json:"speed"` // Overall playback multiplier (default 1)
+
+	set      *Set
+	state    string
+	dir      Direction
+	frame    int
+	timer    float64
+	finished bool
+	params   map[string]float64
+	triggers map[string]bool
+	events   []Event
+}
+
+func init() {
+	entity.RegisterComponent("animator", func() entity.Component { return &Animator{} })
+}
+
+func (a *Animator) ComponentName() string { return "animator" }
+
+// AnimatorOf returns the object's animator component, or nil
+func AnimatorOf(o *entity.Object) *Animator {
+	a, _ := o.Component("animator").(*Animator)
+	return a
+}
+
+// Bind attaches the animator to an animation set and enters its initial state
+func (a *Animator) Bind(set *Set) {
+	a.set = set
+	a.SetName = set.Name
+	if a.params == nil {
+		a.params = make(map[string]float64)
+	}
+	if a.triggers == nil {
+		a.triggers = make(map[string]bool)
+	}
+	a.events = a.events[:0]
+	a.enter(set.Initial)
+}
+
+// BindFrom looks up the animator's set by name in a library
+func (a *Animator) BindFrom(lib *Library) error {
+	set := lib.Get(a.SetName)
+	if set == nil {
+		return fmt.Errorf("unknown animation set %q", a.SetName)
+	}
+	a.Bind(set)
+	return nil
+}
+
+// Set returns the bound animation set, or nil
+func (a *Animator) Set() *Set {
+	return a.set
+}
+
+// State returns the name of the current state
+func (a *Animator) State() string {
+	return a.state
+}
+
+// Direction returns the current facing
+func (a *Animator) Direction() Direction {
+	return a.dir
+}
+
+// FrameIndex returns the index of the current frame within its clip
+func (a *Animator) FrameIndex() int {
+	return a.frame
+}
+
+// Finished reports whether a non-looping clip has played to its end
+func (a *Animator) Finished() bool {
+	return a.finished
+}
+
+// SetFloat sets a numeric parameter used by transition conditions
+func (a *Animator) SetFloat(name string, value float64) {
+	if a.params == nil {
+		a.params = make(map[string]float64)
+	}
+	a.params[name] = value
+}
+
+// SetBool sets a boolean parameter, stored as 1 or 0
+func (a *Animator) SetBool(name string, value bool) {
+	if value {
+		a.SetFloat(name, 1)
+	} else {
+		a.SetFloat(name, 0)
+	}
+}
+
+// Float returns the value of a parameter
+func (a *Animator) Float(name string) float64 {
+	return a.params[name]
+}
+
+// SetTrigger sets a one-shot parameter that is cleared by the transition that uses it
+func (a *Animator) SetTrigger(name string) {
+	if a.triggers == nil {
+		a.triggers = make(map[string]bool)
+	}
+	a.triggers[name] = true
+}
+
+// SetDirection changes the facing without restarting the clip
+func (a *Animator) SetDirection(dir Direction) {
+	if a.set != nil && a.set.Directions == 4 && dir%2 == 1 {
+		// Snap diagonals to the horizontal side on 4-way sets
+		if dir.IsLeft() {
+			dir = Left
+		} else {
+			dir = Right
+		}
+	}
+	a.dir = dir
+}
+
+// Face turns the animator towards a movement vector, keeping the old facing for a zero vector
+func (a *Animator) Face(dx, dy float64) {
+	count := 4
+	if a.set != nil {
+		count = a.set.Directions
+	}
+	if dir, ok := DirectionFromVector(dx, dy, count); ok {
+		a.SetDirection(dir)
+	}
+}
+
+// Play jumps straight to a state, restarting it even if it is already playing
+func (a *Animator) Play(state string) {
+	if a.set == nil {
+		return
+	}
+	if _, ok := a.set.States[state]; ok {
+		a.enter(state)
+	}
+}
+
+// Update evaluates transitions and advances playback by dt seconds.
+// It returns the frame events fired during this update.
+func (a *Animator) Update(dt float64) []Event {
+	a.events = a.events[:0]
+	if a.set == nil {
+		return a.events
+	}
+
+	a.evaluateTransitions()
+	a.advance(dt)
+	return a.events
+}
+
+// Frame returns the source rectangle to draw and whether it must be mirrored horizontally
+func (a *Animator) Frame() (Frame, bool) {
+	if a.set == nil {
+		return Frame{}, false
+	}
+	frames, flip := a.set.framesFor(a.clip(), a.dir)
+	if len(frames) == 0 {
+		return Frame{}, false
+	}
+	return frames[min(a.frame, len(frames)-1)], flip
+}
+
+func (a *Animator) clip() *Clip {
+	return a.set.Clips[a.set.States[a.state].Clip]
+}
+
+func (a *Animator) frameCount() int {
+	frames, _ := a.set.framesFor(a.clip(), a.dir)
+	return len(frames)
+}
+
+func (a *Animator) enter(state string) {
+	a.state = state
+	a.frame = 0
+	a.timer = 0
+	a.finished = false
+	a.fireFrameEvents()
+}
+
+func (a *Animator) evaluateTransitions() {
+	current := a.set.States[a.state]
+
+	for _, t := range a.set.Transitions {
+		// Re-entering the current state only makes sense for triggered restarts
+		if t.To == a.state && !usesTrigger(t.Conditions) {
+			continue
+		}
+		if !matchesState(t.From, a.state) {
+			continue
+		}
+		if t.ExitTime && !a.finished {
+			continue
+		}
+		if current.Uninterruptible && !a.finished && !t.Force {
+			continue
+		}
+		if !a.conditionsHold(t.Conditions) {
+			continue
+		}
+
+		for _, c := range t.Conditions {
+			if c.Op == "trigger" {
+				delete(a.triggers, c.Param)
+			}
+		}
+		a.enter(t.To)
+		return
+	}
+
+	// One-shot clips hand over to their follow-up state once they finish
+	if a.finished && current.Next != "" {
+		a.enter(current.Next)
+	}
+}
+
+func (a *Animator) conditionsHold(conditions []Condition) bool {
+	for _, c := range conditions {
+		v := a.params[c.Param]
+		var ok bool
+		switch c.Op {
+		case ">":
+			ok = v > c.Value
+		case ">=":
+			ok = v >= c.Value
+		case "<":
+			ok = v < c.Value
+		case "<=":
+			ok = v <= c.Value
+		case "==":
+			ok = v == c.Value
+		case "!=":
+			ok = v != c.Value
+		case "trigger":
+			ok = a.triggers[c.Param]
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// playbackRate combines the global speed with the state's speed parameter
+func (a *Animator) playbackRate() float64 {
+	rate := a.Speed
+	if rate == 0 {
+		rate = 1
+	}
+
+	state := a.set.States[a.state]
+	if state.SpeedParam != "" && state.BaseSpeed > 0 {
+		scale := a.params[state.SpeedParam] / state.BaseSpeed
+		if state.MinRate > 0 {
+			scale = math.Max(scale, state.MinRate)
+		}
+		if state.MaxRate > 0 {
+			scale = math.Min(scale, state.MaxRate)
+		}
+		rate *= scale
+	}
+	return rate
+}
+
+func (a *Animator) advance(dt float64) {
+	if a.finished {
+		return
+	}
+
+	clip := a.clip()
+	count := a.frameCount()
+	if count == 0 {
+		return
+	}
+
+	a.timer += dt * a.playbackRate()
+	for a.timer >= clip.duration(a.frame) {
+		a.timer -= clip.duration(a.frame)
+		a.frame++
+
+		if a.frame >= count {
+			if !clip.Loop {
+				a.frame = count - 1
+				a.finished = true
+				a.timer = 0
+				return
+			}
+			a.frame = 0
+		}
+		a.fireFrameEvents()
+	}
+}
+
+func (a *Animator) fireFrameEvents() {
+	for _, name := range a.clip().Events[a.frame] {
+		a.events = append(a.events, Event{Name: name, State: a.state, Frame: a.frame})
+	}
+}
+
+func matchesState(from []string, state string) bool {
+	for _, f := range from {
+		if f == "*" || f == state {
+			return true
+		}
+	}
+	return false
+}
+
+func usesTrigger(conditions []Condition) bool {
+	for _, c := range conditions {
+		if c.Op == "trigger" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,152 @@
+package anim
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+// testSet is a small fighter: idle and walk loops, an attack that can't be
+// interrupted, a forced hurt flinch and a death. Every frame lasts 0.25s.
+func testSet(t *testing.T) *Set {
+	t.Helper()
+	strip := func(y, count int) FrameList {
+		return FrameList{Strip: &Strip{Y: y, W: 16, H: 16, Count: count}}
+	}
+	s := &Set{
+		Name:       "fighter",
+		Directions: 4,
+		Facing:     Right,
+		Initial:    "idle",
+		Clips: map[string]*Clip{
+			"idle":   {FrameList: strip(0, 2), FrameDuration: 0.25, Loop: true},
+			"walk":   {FrameList: strip(16, 4), FrameDuration: 0.25, Loop: true, Events: map[int][]string{1: {"step"}, 3: {"step"}}},
+			"attack": {FrameList: strip(32, 3), FrameDuration: 0.25, Events: map[int][]string{0: {"swing"}, 2: {"hit"}}},
+			"hurt":   {FrameList: strip(48, 2), FrameDuration: 0.25},
+			"death":  {FrameList: strip(64, 3), FrameDuration: 0.25},
+		},
+		States: map[string]*State{
+			"idle":   {Clip: "idle"},
+			"walk":   {Clip: "walk", SpeedParam: "speed", BaseSpeed: 50, MinRate: 0.5, MaxRate: 2},
+			"attack": {Clip: "attack", Next: "idle", Uninterruptible: true},
+			"hurt":   {Clip: "hurt", Next: "idle"},
+			"dead":   {Clip: "death"},
+		},
+		Transitions: []Transition{
+			{From: []string{"*"}, To: "hurt", Conditions: []Condition{{Param: "hurt", Op: "trigger"}}, Force: true},
+			{From: []string{"*"}, To: "attack", Conditions: []Condition{{Param: "attack", Op: "trigger"}}},
+			{From: []string{"idle"}, To: "walk", Conditions: []Condition{{Param: "speed", Op: ">", Value: 0}}},
+			{From: []string{"walk"}, To: "idle", Conditions: []Condition{{Param: "speed", Op: "<=", Value: 0}}},
+			{From: []string{"*"}, To: "dead", Conditions: []Condition{{Param: "dead", Op: "==", Value: 1}}},
+		},
+	}
+	if err := s.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestUpdate(t *testing.T) {
+	a := &Animator{}
+	a.Bind(testSet(t))
+
+	steps := []struct {
+		name     string
+		do       func()
+		dt       float64
+		state    string
+		frame    int
+		finished bool
+		events   []string
+	}{
+		{name: "idling", dt: 0.125, state: "idle"},
+		{name: "starting to walk", do: func() { a.SetFloat("speed", 50) }, dt: 0.125, state: "walk"},
+		{name: "first step", dt: 0.125, state: "walk", frame: 1, events: []string{"step walk 1"}},
+		{name: "twice as fast", do: func() { a.SetFloat("speed", 100) }, dt: 0.125, state: "walk", frame: 2},
+		{name: "capped at the max rate", do: func() { a.SetFloat("speed", 400) }, dt: 0.25, state: "walk", frame: 0, events: []string{"step walk 3"}},
+		{name: "held at the min rate", do: func() { a.SetFloat("speed", 10) }, dt: 0.5, state: "walk", frame: 1, events: []string{"step walk 1"}},
+		{name: "attacking", do: func() { a.SetTrigger("attack") }, dt: 0.125, state: "attack", events: []string{"swing attack 0"}},
+		{name: "dying mid-attack waits", do: func() { a.SetBool("dead", true) }, dt: 0.125, state: "attack", frame: 1},
+		{name: "landing the hit", do: func() { a.SetBool("dead", false) }, dt: 0.25, state: "attack", frame: 2, events: []string{"hit attack 2"}},
+		{name: "holding the last frame", dt: 0.25, state: "attack", frame: 2, finished: true},
+		{name: "back to idle when done", dt: 0, state: "idle"},
+		{name: "still moving", dt: 0, state: "walk"},
+		{name: "attacking again", do: func() { a.SetTrigger("attack") }, dt: 0.125, state: "attack", events: []string{"swing attack 0"}},
+		{name: "hurt cuts the attack short", do: func() { a.SetTrigger("hurt") }, dt: 0.125, state: "hurt"},
+		{name: "flinch done", dt: 0.5, state: "hurt", frame: 1, finished: true},
+		{name: "back to idle after flinching", dt: 0.125, state: "idle"},
+		{name: "dying", do: func() { a.SetFloat("speed", 0); a.SetBool("dead", true) }, dt: 1, state: "dead", frame: 2, finished: true},
+		{name: "staying dead", dt: 1, state: "dead", frame: 2, finished: true},
+	}
+	for _, step := range steps {
+		if step.do != nil {
+			step.do()
+		}
+		var events []string
+		for _, e := range a.Update(step.dt) {
+			events = append(events, fmt.Sprintf("%s %s %d", e.Name, e.State, e.Frame))
+		}
+		if a.State() != step.state || a.FrameIndex() != step.frame || a.Finished() != step.finished {
+			t.Errorf("%s: %s frame %d finished %v, want %s frame %d finished %v",
+				step.name, a.State(), a.FrameIndex(), a.Finished(), step.state, step.frame, step.finished)
+		}
+		if !slices.Equal(events, step.events) {
+			t.Errorf("%s: events %v, want %v", step.name, events, step.events)
+		}
+	}
+}
+
+func TestUpdateEventsEveryFrame(t *testing.T) {
+	// One long update fires every frame passed through, in order
+	a := &Animator{}
+	a.Bind(testSet(t))
+	a.SetFloat("speed", 50)
+	a.Update(0)
+	var events []string
+	for _, e := range a.Update(2) {
+		events = append(events, fmt.Sprintf("%s %d", e.Name, e.Frame))
+	}
+	want := []string{"step 1", "step 3", "step 1", "step 3"}
+	if !slices.Equal(events, want) {
+		t.Errorf("events %v, want %v", events, want)
+	}
+	if a.FrameIndex() != 0 {
+		t.Errorf("frame %d after two full loops, want 0", a.FrameIndex())
+	}
+}
+
+func TestPlaybackRate(t *testing.T) {
+	tests := []struct {
+		name  string
+		speed float64 // Animator.Speed
+		param float64 // The walk state's speed parameter
+		want  float64
+	}{
+		{name: "at base speed", param: 50, want: 1},
+		{name: "scaled by the parameter", param: 75, want: 1.5},
+		{name: "clamped to the max rate", param: 500, want: 2},
+		{name: "clamped to the min rate", param: 5, want: 0.5},
+		{name: "stopped still plays at the min rate", param: 0, want: 0.5},
+		{name: "times the overall speed", speed: 2, param: 75, want: 3},
+		{name: "overall speed past the clamp", speed: 0.5, param: 500, want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &Animator{Speed: tt.speed}
+			a.Bind(testSet(t))
+			a.Play("walk")
+			a.SetFloat("speed", tt.param)
+			if got := a.playbackRate(); got != tt.want {
+				t.Errorf("rate %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// States without a speed parameter only follow the overall speed
+	a := &Animator{Speed: 3}
+	a.Bind(testSet(t))
+	a.SetFloat("speed", 75)
+	if got := a.playbackRate(); got != 3 {
+		t.Errorf("idle rate %v, want 3", got)
+	}
+}
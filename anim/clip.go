@@ -0,0 +1,229 @@
+// Package anim drives sprite sheet animations through a parameterised state machine
+package anim
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// Frame is a source rectangle on a sprite sheet
+type Frame struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+	W int `json:"w"`
+	H int `json:"h"`
+}
+
+// Strip describes frames laid out in a row on a sprite sheet
+type Strip struct {
+	X     int `json:"x"`
+	Y     int `json:"y"`
+	W     int `json:"w"`
+	H     int `json:"h"`
+	StepX int `json:"stepX"` // Horizontal distance between frame starts
+	Count int `json:"count"`
+}
+
+// Frames expands the strip into individual frames
+func (s Strip) Frames() []Frame {
+	step := s.StepX
+	if step == 0 {
+		step = s.W
+	}
+	frames := make([]Frame, s.Count)
+	for i := range frames {
+		frames[i] = Frame{X: s.X + i*step, Y: s.Y, W: s.W, H: s.H}
+	}
+	return frames
+}
+
+// FrameList is the artwork of a clip for one facing direction
+type FrameList struct {
+	Strip  *Strip  `json:"strip,omitempty"`
+	Frames []Frame `json:"frames,omitempty"`
+	FlipX  bool    `json:"flipX,omitempty"`
+}
+
+func (fl FrameList) frames() []Frame {
+	if len(fl.Frames) > 0 {
+		return fl.Frames
+	}
+	if fl.Strip != nil {
+		return fl.Strip.Frames()
+	}
+	return nil
+}
+
+// Clip is a sequence of frames played back at a fixed rate
+type Clip struct {
+	FrameList
+
+	FrameDuration float64   `json:"frameDuration"`       // Seconds per frame
+	Durations     []float64 `json:"durations,omitempty"` // Optional per-frame overrides
+	Loop          bool      `json:"loop"`
+
+	// Events maps a frame index to the events fired when that frame starts
+	Events map[int][]string `json:"events,omitempty"`
+
+	// Directions overrides the artwork for specific facings
+	Directions map[Direction]FrameList `json:"directions,omitempty"`
+}
+
+// duration returns how long frame i stays on screen
+func (c *Clip) duration(i int) float64 {
+	if i < len(c.Durations) && c.Durations[i] > 0 {
+		return c.Durations[i]
+	}
+	if c.FrameDuration > 0 {
+		return c.FrameDuration
+	}
+	return 0.1
+}
+
+// State is a node in an animation state machine
+type State struct {
+	Clip string `json:"clip"`
+	// Next is the state entered when a non-looping clip finishes
+	Next string `json:"next,omitempty"`
+	// Uninterruptible states can only be left by forced transitions until their clip finishes
+	Uninterruptible bool `json:"uninterruptible,omitempty"`
+
+	// SpeedParam scales playback by param/BaseSpeed, so walk cycles keep pace with movement
+	SpeedParam string  `json:"speedParam,omitempty"`
+	BaseSpeed  float64 `json:"baseSpeed,omitempty"`
+	MinRate    float64 `json:"minRate,omitempty"`
+	MaxRate    float64 `json:"maxRate,omitempty"`
+}
+
+// Condition compares a parameter against a value
+type Condition struct {
+	Param string  `json:"param"`
+	Op    string  `json:"op"` // One of >, >=, <, <=, ==, != or "trigger"
+	Value float64 `json:"value"`
+}
+
+// Transition moves the state machine between states when all its conditions hold
+type Transition struct {
+	From       []string    `json:"from"` // Source states; "*" matches any state
+	To         string      `json:"to"`
+	Conditions []Condition `json:"conditions"`
+	ExitTime   bool        `json:"exitTime,omitempty"` // Only fire once the current clip has finished
+	Force      bool        `json:"force,omitempty"`    // Fire even from uninterruptible states
+}
+
+// Set is a sprite sheet together with its clips and state machine
+type Set struct {
+	Name        string            `json:"name"`
+	Image       string            `json:"image"`      // Asset ID of the sprite sheet
+	Directions  int               `json:"directions"` // 4 or 8
+	Facing      Direction         `json:"facing"`     // Direction the default artwork faces
+	Initial     string            `json:"initial"`
+	Clips       map[string]*Clip  `json:"clips"`
+	States      map[string]*State `json:"states"`
+	Transitions []Transition      `json:"transitions"`
+}
+
+// Validate checks that every state and transition refers to something that exists
+func (s *Set) Validate() error {
+	if _, ok := s.States[s.Initial]; !ok {
+		return fmt.Errorf("animation set %q: initial state %q not defined", s.Name, s.Initial)
+	}
+	for name, state := range s.States {
+		if _, ok := s.Clips[state.Clip]; !ok {
+			return fmt.Errorf("animation set %q: state %q uses unknown clip %q", s.Name, name, state.Clip)
+		}
+		if state.Next != "" {
+			if _, ok := s.States[state.Next]; !ok {
+				return fmt.Errorf("animation set %q: state %q has unknown next state %q", s.Name, name, state.Next)
+			}
+		}
+	}
+	for i, t := range s.Transitions {
+		if _, ok := s.States[t.To]; !ok {
+			return fmt.Errorf("animation set %q: transition %d targets unknown state %q", s.Name, i, t.To)
+		}
+		for _, from := range t.From {
+			if _, ok := s.States[from]; !ok && from != "*" {
+				return fmt.Errorf("animation set %q: transition %d from unknown state %q", s.Name, i, from)
+			}
+		}
+		for _, c := range t.Conditions {
+			switch c.Op {
+			case ">", ">=", "<", "<=", "==", "!=", "trigger":
+			default:
+				return fmt.Errorf("animation set %q: transition %d has unknown operator %q", s.Name, i, c.Op)
+			}
+		}
+	}
+	return nil
+}
+
+// framesFor returns the frames of a clip for a facing, and whether to mirror them
+func (s *Set) framesFor(c *Clip, dir Direction) ([]Frame, bool) {
+	if override, ok := c.Directions[dir]; ok {
+		return override.frames(), override.FlipX
+	}
+	// Mirror the opposite side's artwork if only one side was drawn
+	if override, ok := c.Directions[dir.Mirror()]; ok && dir.Mirror() != dir {
+		return override.frames(), !override.FlipX
+	}
+	return c.frames(), c.FlipX != (dir.IsLeft() != s.Facing.IsLeft())
+}
+
+// Library holds every loaded animation set
+type Library struct {
+	sets map[string]*Set
+}
+
+// NewLibrary creates an empty animation library
+func NewLibrary() *Library {
+	return &Library{sets: make(map[string]*Set)}
+}
+
+// Add validates and registers an animation set
+func (l *Library) Add(s *Set) error {
+	if err := s.Validate(); err != nil {
+		return err
+	}
+	l.sets[s.Name] = s
+	return nil
+}
+
+// Get returns the named animation set, or nil
+func (l *Library) Get(name string) *Set {
+	return l.sets[name]
+}
+
+// LoadFile reads a single animation set from a JSON file
+func (l *Library) LoadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	s := &Set{}
+	if err := json.Unmarshal(data, s); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	if err := l.Add(s); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// LoadDir loads every .json file in a directory
+func (l *Library) LoadDir(dir string) error {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return err
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		if err := l.LoadFile(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package anim
+
+import (
+	"fmt"
+	"math"
+)
+
+// Direction is the way a character is facing
+type Direction int
+
+const (
+	Down Direction = iota
+	DownRight
+	Right
+	UpRight
+	Up
+	UpLeft
+	Left
+	DownLeft
+)
+
+var directionNames = [...]string{"down", "down_right", "right", "up_right", "up", "up_left", "left", "down_left"}
+
+func (d Direction) String() string {
+	if d < 0 || int(d) >= len(directionNames) {
+		return fmt.Sprintf("Direction(%d)", int(d))
+	}
+	return directionNames[d]
+}
+
+// ParseDirection converts a direction name such as "up_left" to a Direction
+func ParseDirection(name string) (Direction, error) {
+	for i, n := range directionNames {
+		if n == name {
+			return Direction(i), nil
+		}
+	}
+	return Down, fmt.Errorf("unknown direction %q", name)
+}
+
+// UnmarshalText reads a direction from its name, so directions can be JSON values and map keys
+func (d *Direction) UnmarshalText(text []byte) error {
+	parsed, err := ParseDirection(string(text))
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+// MarshalText writes a direction as its name
+func (d Direction) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// Vector returns the unit vector the direction points along (screen space, +Y is down)
+func (d Direction) Vector() (float64, float64) {
+	angle := float64(d) * math.Pi / 4
+	return math.Sin(angle), math.Cos(angle)
+}
+
+// IsLeft reports whether the direction has a leftward component
+func (d Direction) IsLeft() bool {
+	return d == UpLeft || d == Left || d == DownLeft
+}
+
+// Mirror returns the direction reflected horizontally
+func (d Direction) Mirror() Direction {
+	return Direction((8 - int(d)) % 8)
+}
+
+// DirectionFromVector returns the facing for a movement vector, snapped to
+// 4 or 8 directions. It returns ok=false for a zero vector.
+func DirectionFromVector(dx, dy float64, count int) (Direction, bool) {
+	if dx == 0 && dy == 0 {
+		return Down, false
+	}
+
+	// Angle measured from straight down, increasing counter-clockwise on screen
+	angle := math.Atan2(dx, dy)
+	if angle < 0 {
+		angle += 2 * math.Pi
+	}
+
+	if count == 8 {
+		return Direction(int(math.Round(angle/(math.Pi/4))) % 8), true
+	}
+	return Direction((int(math.Round(angle/(math.Pi/2))) % 4) * 2), true
+}
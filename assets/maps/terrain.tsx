@@ -0,0 +1,224 @@
+<?xml version="1.0" encoding="UTF-8"?>
+<tileset version="1.10" tiledversion="1.10.2" name="terrain" tilewidth="32" tileheight="32" tilecount="48" columns="8">
+ <image source="terrain.png" width="256" height="192"/>
+ <tile id="0" type="grass">
+  <properties>
+   <property name="footstep" value="grass"/>
+  </properties>
+ </tile>
+ <tile id="1" type="grass">
+  <properties>
+   <property name="footstep" value="grass"/>
+  </properties>
+ </tile>
+ <tile id="2" type="dirt">
+  <properties>
+   <property name="footstep" value="dirt"/>
+  </properties>
+ </tile>
+ <tile id="3" type="mud">
+  <properties>
+   <property name="cost" type="float" value="2"/>
+   <property name="footstep" value="mud"/>
+   <property name="speed" type="float" value="0.5"/>
+  </properties>
+ </tile>
+ <tile id="4" type="stone">
+  <properties>
+   <property name="footstep" value="stone"/>
+  </properties>
+ </tile>
+ <tile id="5" type="wall">
+  <properties>
+   <property name="collision" type="bool" value="true"/>
+  </properties>
+ </tile>
+ <tile id="6" type="water">
+  <properties>
+   <property name="cost" type="float" value="3"/>
+   <property name="footstep" value="splash"/>
+   <property name="speed" type="float" value="0.6"/>
+   <property name="water" type="bool" value="true"/>
+  </properties>
+  <animation>
+   <frame tileid="6" duration="400"/>
+   <frame tileid="14" duration="400"/>
+   <frame tileid="22" duration="400"/>
+   <frame tileid="30" duration="400"/>
+  </animation>
+ </tile>
+ <tile id="7" type="sand">
+  <properties>
+   <property name="footstep" value="sand"/>
+  </properties>
+ </tile>
+ <tile id="8" type="tree_trunk"/>
+ <tile id="9" type="tree_canopy"/>
+ <tile id="10" type="house_wall"/>
+ <tile id="11" type="roof"/>
+ <tile id="12" type="bush">
+  <properties>
+   <property name="break_sound" value="leaves"/>
+   <property name="hits" type="int" value="1"/>
+  </properties>
+ </tile>
+ <tile id="13" type="house_wall">
+  <properties>
+   <property name="sort_offset" type="float" value="32"/>
+  </properties>
+ </tile>
+ <tile id="15" type="deep_water">
+  <properties>
+   <property name="deep_water" type="bool" value="true"/>
+  </properties>
+  <animation>
+   <frame tileid="15" duration="600"/>
+   <frame tileid="23" duration="600"/>
+   <frame tileid="31" duration="600"/>
+  </animation>
+ </tile>
+ <tile id="16" type="ice">
+  <properties>
+   <property name="footstep" value="ice"/>
+   <property name="slippery" type="bool" value="true"/>
+  </properties>
+ </tile>
+ <tile id="17" type="embers">
+  <properties>
+   <property name="cost" type="float" value="10"/>
+   <property name="damage_per_second" type="float" value="8"/>
+   <property name="damage_type" value="fire"/>
+   <property name="footstep" value="stone"/>
+  </properties>
+  <animation>
+   <frame tileid="17" duration="250"/>
+   <frame tileid="25" duration="250"/>
+  </animation>
+ </tile>
+ <tile id="18" type="ladder">
+  <properties>
+   <property name="footstep" value="wood"/>
+   <property name="ladder" type="bool" value="true"/>
+   <property name="speed" type="float" value="0.6"/>
+  </properties>
+ </tile>
+ <tile id="19" type="rock">
+  <properties>
+   <property name="break_sound" value="rock_break"/>
+   <property name="drop" value="stone"/>
+   <property name="hits" type="int" value="3"/>
+  </properties>
+ </tile>
+ <tile id="20" type="stone_block">
+  <properties>
+   <property name="break_sound" value="rock_break"/>
+   <property name="drop" value="stone"/>
+   <property name="hits" type="int" value="2"/>
+  </properties>
+ </tile>
+ <tile id="32" type="dirt_edge">
+  <properties>
+   <property name="footstep" value="grass"/>
+  </properties>
+ </tile>
+ <tile id="33" type="dirt_edge">
+  <properties>
+   <property name="footstep" value="grass"/>
+  </properties>
+ </tile>
+ <tile id="34" type="dirt_edge">
+  <properties>
+   <property name="footstep" value="grass"/>
+  </properties>
+ </tile>
+ <tile id="35" type="dirt_edge">
+  <properties>
+   <property name="footstep" value="dirt"/>
+  </properties>
+ </tile>
+ <tile id="36" type="dirt_edge">
+  <properties>
+   <property name="footstep" value="grass"/>
+  </properties>
+ </tile>
+ <tile id="37" type="dirt_edge">
+  <properties>
+   <property name="footstep" value="dirt"/>
+  </properties>
+ </tile>
+ <tile id="38" type="dirt_edge">
+  <properties>
+   <property name="footstep" value="dirt"/>
+  </properties>
+ </tile>
+ <tile id="39" type="dirt_edge">
+  <properties>
+   <property name="footstep" value="dirt"/>
+  </properties>
+ </tile>
+ <tile id="40" type="dirt_edge">
+  <properties>
+   <property name="footstep" value="grass"/>
+  </properties>
+ </tile>
+ <tile id="41" type="dirt_edge">
+  <properties>
+   <property name="footstep" value="dirt"/>
+  </properties>
+ </tile>
+ <tile id="42" type="dirt_edge">
+  <properties>
+   <property name="footstep" value="dirt"/>
+  </properties>
+ </tile>
+ <tile id="43" type="dirt_edge">
+  <properties>
+   <property name="footstep" value="dirt"/>
+  </properties>
+ </tile>
+ <tile id="44" type="dirt_edge">
+  <properties>
+   <property name="footstep" value="dirt"/>
+  </properties>
+ </tile>
+ <tile id="45" type="dirt_edge">
+  <properties>
+   <property name="footstep" value="dirt"/>
+  </properties>
+ </tile>
+ <tile id="46" type="dirt_edge">
+  <properties>
+   <property name="footstep" value="dirt"/>
+  </properties>
+ </tile>
+ <tile id="47" type="dirt_edge">
+  <properties>
+   <property name="footstep" value="dirt"/>
+  </properties>
+ </tile>
+ <wangsets>
+  <wangset name="paths" type="corner" tile="47">
+   <wangcolor name="dirt" color="#785f3c" tile="47" probability="1"/>
+   <wangcolor name="grass" color="#466e3c" tile="32" probability="1"/>
+   <wangtile tileid="0" wangid="0,2,0,2,0,2,0,2"/>
+   <wangtile tileid="1" wangid="0,2,0,2,0,2,0,2"/>
+   <wangtile tileid="2" wangid="0,1,0,1,0,1,0,1"/>
+   <wangtile tileid="32" wangid="0,2,0,2,0,2,0,2"/>
+   <wangtile tileid="33" wangid="0,1,0,2,0,2,0,2"/>
+   <wangtile tileid="34" wangid="0,2,0,1,0,2,0,2"/>
+   <wangtile tileid="35" wangid="0,1,0,1,0,2,0,2"/>
+   <wangtile tileid="36" wangid="0,2,0,2,0,1,0,2"/>
+   <wangtile tileid="37" wangid="0,1,0,2,0,1,0,2"/>
+   <wangtile tileid="38" wangid="0,2,0,1,0,1,0,2"/>
+   <wangtile tileid="39" wangid="0,1,0,1,0,1,0,2"/>
+   <wangtile tileid="40" wangid="0,2,0,2,0,2,0,1"/>
+   <wangtile tileid="41" wangid="0,1,0,2,0,2,0,1"/>
+   <wangtile tileid="42" wangid="0,2,0,1,0,2,0,1"/>
+   <wangtile tileid="43" wangid="0,1,0,1,0,2,0,1"/>
+   <wangtile tileid="44" wangid="0,2,0,2,0,1,0,1"/>
+   <wangtile tileid="45" wangid="0,1,0,2,0,1,0,1"/>
+   <wangtile tileid="46" wangid="0,2,0,1,0,1,0,1"/>
+   <wangtile tileid="47" wangid="0,1,0,1,0,1,0,1"/>
+  </wangset>
+ </wangsets>
+</tileset>
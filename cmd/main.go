@@ -1,6 +1,7 @@
 package main
 
 import (
+	"flag"
 	"log"
 
 	"github.com/Nathene/bitbase/game"
@@ -29,6 +30,9 @@ func (g *GameApplication) Layout(outsideWidth, outsideHeight int) (int, int) {
 }
 
 func main() {
+	flag.Int64Var(&game.WorldSeed, "seed", 0, "generate the world from this seed instead of loading the map")
+	flag.Parse()
+
 	// Create asset manager
 	assetManager := game.NewAssetManager()
 
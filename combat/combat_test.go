@@ -0,0 +1,176 @@
+package combat
+
+import (
+	"math"
+	"testing"
+
+	"github.com/Nathene/bitbase/entity"
+	"github.com/Nathene/bitbase/stats"
+)
+
+// newFighter makes a 16x16 object with health and the given team, plus stats when base is set
+func newFighter(name, team string, health float64, base map[stats.Stat]float64) *entity.Object {
+	o := entity.NewObject(name)
+	o.AddComponent(&entity.Sprite{Width: 16, Height: 16})
+	o.AddComponent(&Health{Max: health})
+	o.AddComponent(&Damageable{Team: team})
+	if base != nil {
+		o.AddComponent(stats.New(base))
+	}
+	return o
+}
+
+func newTestSystem(objects ...*entity.Object) *System {
+	w := entity.NewWorld()
+	s := NewSystem(w, nil, nil)
+	for _, o := range objects {
+		w.Add(o)
+		s.Prepare(o)
+	}
+	return s
+}
+
+func TestDamageLog(t *testing.T) {
+	tests := []struct {
+		name        string
+		dmg         Damage
+		defense     float64
+		resistances map[DamageType]float64
+		kind        EventKind
+		amount      float64
+	}{
+		{name: "physical", dmg: Damage{Amount: 10}, kind: EventHit, amount: 10},
+		{name: "defense", dmg: Damage{Amount: 10}, defense: 4, kind: EventHit, amount: 6},
+		{name: "defense then resistance", dmg: Damage{Amount: 10}, defense: 4, resistances: map[DamageType]float64{Physical: 0.5}, kind: EventHit, amount: 3},
+		{name: "defense ignores fire", dmg: Damage{Amount: 10, Type: Fire}, defense: 4, kind: EventHit, amount: 10},
+		{name: "resisted", dmg: Damage{Amount: 10, Type: Fire}, resistances: map[DamageType]float64{Fire: 0.75}, kind: EventHit, amount: 2.5},
+		{name: "weakness", dmg: Damage{Amount: 10, Type: Ice}, resistances: map[DamageType]float64{Ice: -0.5}, kind: EventHit, amount: 15},
+		{name: "immune", dmg: Damage{Amount: 10, Type: Poison}, resistances: map[DamageType]float64{Poison: 1}, kind: EventImmune},
+		{name: "true ignores resistance", dmg: Damage{Amount: 10, Type: True}, defense: 4, resistances: map[DamageType]float64{True: 1}, kind: EventHit, amount: 10},
+		{name: "armour floor", dmg: Damage{Amount: 3}, defense: 10, kind: EventHit, amount: 1},
+		{name: "small hit floored", dmg: Damage{Amount: 0.2, Type: Poison}, kind: EventHit, amount: 1},
+		{name: "tick not floored", dmg: Damage{Amount: 0.2, Type: Poison, OverTime: true}, resistances: map[DamageType]float64{Poison: 0.5}, kind: EventHit, amount: 0.1},
+		{name: "tick through defense", dmg: Damage{Amount: 3, OverTime: true}, defense: 10, kind: EventImmune},
+		{name: "overkill", dmg: Damage{Amount: 500}, kind: EventHit, amount: 100},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target := newFighter("target", "", 100, map[stats.Stat]float64{stats.MaxHealth: 100, stats.Defense: tt.defense})
+			DamageableOf(target).Resistances = tt.resistances
+			s := newTestSystem(target)
+
+			got := s.Damage(target, tt.dmg)
+			events := s.Log.Events()
+			if len(events) == 0 || events[0] != got {
+				t.Fatalf("Damage returned %+v, log has %+v", got, events)
+			}
+			if got.Kind != tt.kind || math.Abs(got.Amount-tt.amount) > 1e-9 {
+				t.Errorf("got %v for %v, want %v for %v", got.Kind, got.Amount, tt.kind, tt.amount)
+			}
+			if got.Raw != tt.dmg.Amount {
+				t.Errorf("logged raw %v, want %v", got.Raw, tt.dmg.Amount)
+			}
+			if want := 100 - tt.amount; math.Abs(HealthOf(target).Current-want) > 1e-9 || got.Kind == EventHit && got.Health != HealthOf(target).Current {
+				t.Errorf("health %v, logged %v, want %v", HealthOf(target).Current, got.Health, want)
+			}
+		})
+	}
+}
+
+func TestDamageTicksAccumulate(t *testing.T) {
+	target := newFighter("target", "", 100, nil)
+	DamageableOf(target).Resistances = map[DamageType]float64{Poison: 0.5}
+	s := newTestSystem(target)
+
+	// Ten resisted poison ticks of 0.2 should take 1 health, not 10
+	for range 10 {
+		s.Damage(target, Damage{Amount: 0.2, Type: Poison, IgnoreInvulnerability: true, OverTime: true})
+	}
+	if got := HealthOf(target).Current; math.Abs(got-99) > 1e-9 {
+		t.Errorf("health %v after ten ticks, want 99", got)
+	}
+	if n := len(s.Log.Events()); n != 10 {
+		t.Errorf("logged %d events, want 10", n)
+	}
+}
+
+func TestDamageImmunity(t *testing.T) {
+	attacker := newFighter("goblin", "goblins", 10, nil)
+	friend := newFighter("shaman", "goblins", 10, nil)
+	target := newFighter("player", "player", 100, nil)
+	DamageableOf(target).Invulnerability = 0.5
+	s := newTestSystem(attacker, friend, target)
+
+	s.Damage(friend, Damage{Amount: 5, Source: attacker})
+	s.Damage(target, Damage{Amount: 5, Source: attacker})
+	s.Damage(target, Damage{Amount: 5, Source: attacker}) // Inside the invulnerability window
+	s.Damage(target, Damage{Amount: 1, Type: Poison, IgnoreInvulnerability: true, OverTime: true})
+	s.Update(0.5)
+	s.Damage(target, Damage{Amount: 5, Source: attacker})
+
+	want := []struct {
+		kind   EventKind
+		target *entity.Object
+		health float64
+	}{
+		{EventImmune, friend, 10},
+		{EventHit, target, 95},
+		{EventImmune, target, 95},
+		{EventHit, target, 94},
+		{EventHit, target, 89},
+	}
+	events := s.Log.Events()
+	if len(events) != len(want) {
+		t.Fatalf("logged %d events, want %d: %v", len(events), len(want), events)
+	}
+	for i, w := range want {
+		if e := events[i]; e.Kind != w.kind || e.Target != w.target || e.Health != w.health {
+			t.Errorf("event %d: %v", i, e)
+		}
+	}
+}
+
+func TestMeleeLog(t *testing.T) {
+	attacker := newFighter("player", "player", 100, map[stats.Stat]float64{stats.Attack: 2})
+	attacker.AddComponent(&Melee{Damage: 5, Type: Fire, Range: 24, Width: 24})
+	target := newFighter("goblin", "goblins", 50, nil)
+	DamageableOf(target).Resistances = map[DamageType]float64{Fire: 0.5}
+	target.SetPosition(0, 16) // Below the attacker, which faces down by default
+	behind := newFighter("bat", "goblins", 50, nil)
+	behind.SetPosition(0, -40)
+	s := newTestSystem(attacker, target, behind)
+
+	events := s.Melee(attacker)
+	if len(events) != 1 {
+		t.Fatalf("melee hit %d objects, want 1: %v", len(events), events)
+	}
+	if e := events[0]; e.Kind != EventHit || e.Target != target || e.Attacker != attacker || e.Type != Fire || e.Raw != 7 || e.Amount != 3.5 {
+		t.Errorf("logged %+v", e)
+	}
+}
+
+func TestKillLog(t *testing.T) {
+	attacker := newFighter("player", "player", 100, nil)
+	target := newFighter("goblin", "goblins", 10, nil)
+	s := newTestSystem(attacker, target)
+
+	s.Damage(target, Damage{Amount: 6, Source: attacker})
+	s.Damage(target, Damage{Amount: 6, Source: attacker})
+	s.Damage(target, Damage{Amount: 6, Source: attacker})
+
+	events := s.Log.Drain()
+	kinds := make([]EventKind, len(events))
+	for i, e := range events {
+		kinds[i] = e.Kind
+	}
+	want := []EventKind{EventHit, EventHit, EventDeath}
+	if len(kinds) != len(want) || kinds[0] != want[0] || kinds[1] != want[1] || kinds[2] != want[2] {
+		t.Fatalf("logged %v, want %v", kinds, want)
+	}
+	if events[1].Amount != 4 || events[2].Attacker != attacker || !IsDead(target) {
+		t.Errorf("killing blow logged as %v then %v", events[1], events[2])
+	}
+	if len(s.Log.Events()) != 0 {
+		t.Error("Drain left events behind")
+	}
+}
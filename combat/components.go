@@ -0,0 +1,129 @@
+package combat
+
+import (
+	"github.com/Nathene/bitbase/entity"
+	"github.com/Nathene/bitbase/stats"
+)
+
+func init() {
+	entity.RegisterComponent("health", func() entity.Component { return &Health{} })
+	entity.RegisterComponent("damageable", func() entity.Component { return &Damageable{} })
+	entity.RegisterComponent("melee", func() entity.Component { return &Melee{} })
+}
+
+// Health tracks how much damage an object can still take
+type Health struct {
+	Max     float64 `json:"max"`     // Ignored when the object has a maxHealth stat
+	Current float64 `json:"current"` // Zero when spawned means full health
+	Regen   float64 `json:"regen"`   // Health restored per second while alive
+}
+
+func (h *Health) ComponentName() string { return "health" }
+
+// HealthOf returns the object's health component, or nil
+func HealthOf(o *entity.Object) *Health {
+	h, _ := o.Component("health").(*Health)
+	return h
+}
+
+// MaxHealth returns an object's maximum health, preferring its derived stats
+func MaxHealth(o *entity.Object) float64 {
+	if s := entity.StatsOf(o); s != nil {
+		if _, ok := s.Base[stats.MaxHealth]; ok {
+			return s.Get(stats.MaxHealth)
+		}
+	}
+	if h := HealthOf(o); h != nil {
+		return h.Max
+	}
+	return 0
+}
+
+// Damageable lets an object be hurt, and says how it reacts
+type Damageable struct {
+	Team            string                 `json:"team"`            // Attacks never hurt their own team
+	Resistances     map[DamageType]float64 `json:"resistances"`     // Fraction of each type ignored: 1 is immune, negative is a weakness
+	Invulnerability float64                `json:"invulnerability"` // Seconds of immunity after each hit
+	KnockbackResist float64                `json:"knockbackResist"` // 0 takes full knockback, 1 none
+	Drops           []Drop                 `json:"drops"`
+	Respawn         float64                `json:"respawn"` // Seconds before coming back at the spawn point; 0 never respawns
+	Corpse          float64                `json:"corpse"`  // Seconds a body that won't respawn stays before being removed
+
+	invulnerable   float64 // Immunity time left
+	knockX, knockY float64 // Knockback velocity
+	dead           bool
+	deadFor        float64
+	homeX, homeY   float64
+}
+
+func (d *Damageable) ComponentName() string { return "damageable" }
+
+// DamageableOf returns the object's damageable component, or nil
+func DamageableOf(o *entity.Object) *Damageable {
+	d, _ := o.Component("damageable").(*Damageable)
+	return d
+}
+
+// Resistance returns the fraction of a damage type the object ignores
+func (d *Damageable) Resistance(t DamageType) float64 {
+	if t == True {
+		return 0
+	}
+	return min(d.Resistances[t], 1)
+}
+
+// Drop is an item that may be left behind on death
+type Drop struct {
+	Item   string  `json:"item"`
+	Count  int     `json:"count"`  // Default 1
+	Chance float64 `json:"chance"` // 0..1, default 1
+	Prefab string  `json:"prefab"` // Pickup prefab to spawn, default "item_drop"
+}
+
+// Melee describes an object's close-range attack. The hit lands on the
+// animation event named Event, or immediately for objects without an animator.
+type Melee struct {
+	Damage    float64    `json:"damage"` // Added to the attacker's attack stat
+	Type      DamageType `json:"type"`
+	Range     float64    `json:"range"`     // How far the hitbox reaches out from the attacker's centre
+	Width     float64    `json:"width"`     // Size of the hitbox across the swing
+	Knockback float64    `json:"knockback"` // Pixels per second
+	Cooldown  float64    `json:"cooldown"`  // Seconds between attacks
+	Event     string     `json:"event"`     // Animation event that lands the hit, default "hit"
+	Effects   []string   `json:"effects"`   // Status effects applied to whatever it hits
+
+	cooldown   float64
+	aimX, aimY float64
+}
+
+func (m *Melee) ComponentName() string { return "melee" }
+
+// MeleeOf returns the object's melee component, or nil
+func MeleeOf(o *entity.Object) *Melee {
+	m, _ := o.Component("melee").(*Melee)
+	return m
+}
+
+// Aim sets which way attacks go for objects without an animator to face for them
+func (m *Melee) Aim(dx, dy float64) {
+	if dx != 0 || dy != 0 {
+		m.aimX, m.aimY = dx, dy
+	}
+}
+
+// Ready reports whether the attack is off cooldown
+func (m *Melee) Ready() bool {
+	return m.cooldown <= 0
+}
+
+// IsDead reports whether an object has been killed and not yet respawned
+func IsDead(o *entity.Object) bool {
+	d := DamageableOf(o)
+	return d != nil && d.dead
+}
+
+// IsInvulnerable reports whether an object is ignoring hits right now
+func IsInvulnerable(o *entity.Object) bool {
+	d := DamageableOf(o)
+	return d != nil && d.invulnerable > 0
+}
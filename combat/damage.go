@@ -0,0 +1,128 @@
+// Package combat handles health, damage, melee attacks, death and respawning
+package combat
+
+import (
+	"fmt"
+
+	"github.com/Nathene/bitbase/entity"
+)
+
+// DamageType says what kind of harm a hit does, so targets can resist some kinds more than others
+type DamageType string
+
+const (
+	Physical  DamageType = "physical" // Reduced by the defense stat as well as resistance
+	Fire      DamageType = "fire"
+	Ice       DamageType = "ice"
+	Poison    DamageType = "poison"
+	Lightning DamageType = "lightning"
+	True      DamageType = "true" // Ignores defense and resistances
+)
+
+// Damage describes one hit
+type Damage struct {
+	Amount    float64
+	Type      DamageType
+	Source    *entity.Object // Who dealt it, or nil for the environment
+	Knockback float64        // Initial knockback speed in pixels per second, away from Source
+	Effects   []string       // Status effects applied if the hit lands
+
+	// IgnoreInvulnerability lets damage over time keep ticking through hit immunity
+	IgnoreInvulnerability bool
+	// OverTime marks one tick of damage over time. Armour can reduce a tick below
+	// one point, where a direct hit always does at least one.
+	OverTime bool
+}
+
+// EventKind says what happened in a combat log event
+type EventKind int
+
+const (
+	EventHit     EventKind = iota // Health was lost
+	EventImmune                   // A hit was ignored: invulnerable, same team or fully resisted
+	EventHeal                     // Health was restored
+	EventDeath                    // Health reached zero
+	EventRespawn                  // A dead object came back
+)
+
+var eventKindNames = [...]string{"hit", "immune", "heal", "death", "respawn"}
+
+func (k EventKind) String() string {
+	if k < 0 || int(k) >= len(eventKindNames) {
+		return fmt.Sprintf("EventKind(%d)", int(k))
+	}
+	return eventKindNames[k]
+}
+
+// Event is one entry in the combat log
+type Event struct {
+	Kind     EventKind
+	Time     float64 // Simulation time in seconds
+	Attacker *entity.Object
+	Target   *entity.Object
+	Type     DamageType
+	Raw      float64 // Damage or healing before defense and resistance
+	Amount   float64 // Health actually lost or gained
+	Health   float64 // Target's health afterwards
+}
+
+func (e Event) String() string {
+	attacker := "world"
+	if e.Attacker != nil {
+		attacker = e.Attacker.Name
+	}
+	target := e.Target.Name
+
+	switch e.Kind {
+	case EventHit:
+		return fmt.Sprintf("%7.2fs %s hit %s for %.1f %s (raw %.1f), %.1f left", e.Time, attacker, target, e.Amount, e.Type, e.Raw, e.Health)
+	case EventImmune:
+		return fmt.Sprintf("%7.2fs %s hit %s, no effect", e.Time, attacker, target)
+	case EventHeal:
+		return fmt.Sprintf("%7.2fs %s healed %.1f, %.1f now", e.Time, target, e.Amount, e.Health)
+	case EventDeath:
+		return fmt.Sprintf("%7.2fs %s was killed by %s", e.Time, target, attacker)
+	default:
+		return fmt.Sprintf("%7.2fs %s %s", e.Time, target, e.Kind)
+	}
+}
+
+// Log records combat events so balance can be checked without rendering anything
+type Log struct {
+	Max int // Oldest events are dropped past this many; 0 keeps everything
+
+	events    []Event
+	listeners []func(Event)
+}
+
+// NewLog creates a log that keeps the most recent max events
+func NewLog(max int) *Log {
+	return &Log{Max: max}
+}
+
+// OnEvent registers a function called for every event as it happens
+func (l *Log) OnEvent(fn func(Event)) {
+	l.listeners = append(l.listeners, fn)
+}
+
+// Events returns the recorded events, oldest first
+func (l *Log) Events() []Event {
+	return l.events
+}
+
+// Drain returns the recorded events and clears the log
+func (l *Log) Drain() []Event {
+	events := l.events
+	l.events = nil
+	return events
+}
+
+func (l *Log) record(e Event) {
+	l.events = append(l.events, e)
+	if l.Max > 0 && len(l.events) > l.Max {
+		l.events = append(l.events[:0], l.events[len(l.events)-l.Max:]...)
+	}
+	for _, fn := range l.listeners {
+		fn(e)
+	}
+}
@@ -0,0 +1,395 @@
+package combat
+
+import (
+	"log"
+	"math"
+	"math/rand"
+
+	"github.com/Nathene/bitbase/anim"
+	"github.com/Nathene/bitbase/common"
+	"github.com/Nathene/bitbase/entity"
+	"github.com/Nathene/bitbase/physics"
+	"github.com/Nathene/bitbase/stats"
+)
+
+const (
+	knockbackFriction = 10.0 // Knockback slows by this factor per second
+	knockbackStop     = 5.0  // Speed in px/s below which knockback ends
+	defaultDropPrefab = "item_drop"
+	defaultHitEvent   = "hit"
+)
+
+// System applies damage and runs the parts of combat that play out over time:
+// invulnerability, knockback, regeneration, corpses and respawning
+type System struct {
+	World   *entity.World
+	Space   *physics.Space  // Used to move knocked-back objects; may be nil
+	Spawner *entity.Spawner // Used to spawn drops; may be nil
+	Log     *Log
+	Rand    *rand.Rand // Drop rolls; seed it for repeatable tests
+
+	time    float64
+	onHit   []func(target *entity.Object, dmg Damage)
+	onSwing []func(attacker *entity.Object, hitbox common.Rect)
+}
+
+// NewSystem creates a combat system for a world
+func NewSystem(world *entity.World, space *physics.Space, spawner *entity.Spawner) *System {
+	return &System{
+		World:   world,
+		Space:   space,
+		Spawner: spawner,
+		Log:     NewLog(1000),
+		Rand:    rand.New(rand.NewSource(1)),
+	}
+}
+
+// Time returns how many seconds of simulation the system has run
+func (s *System) Time() float64 {
+	return s.time
+}
+
+// OnHit registers a function called whenever damage lands, before a killing
+// blow is resolved. Status effects use it to apply a hit's Effects.
+func (s *System) OnHit(fn func(target *entity.Object, dmg Damage)) {
+	s.onHit = append(s.onHit, fn)
+}
+
+// OnSwing registers a function called whenever a melee attack lands, hit or
+// miss, with the area it covered. The game uses it to break tiles.
+func (s *System) OnSwing(fn func(attacker *entity.Object, hitbox common.Rect)) {
+	s.onSwing = append(s.onSwing, fn)
+}
+
+// Prepare fills an object's health and remembers where it respawns.
+// Call it once an object is placed in the world.
+func (s *System) Prepare(o *entity.Object) {
+	if h := HealthOf(o); h != nil && h.Current <= 0 {
+		h.Current = MaxHealth(o)
+	}
+	if d := DamageableOf(o); d != nil {
+		d.homeX, d.homeY = o.GetX(), o.GetY()
+	}
+}
+
+// Damage hurts target and returns what happened. Physical damage is reduced by the
+// target's defense stat first, then every type except true damage by its resistance.
+func (s *System) Damage(target *entity.Object, dmg Damage) Event {
+	event := Event{Kind: EventImmune, Time: s.time, Attacker: dmg.Source, Target: target, Type: dmg.Type, Raw: dmg.Amount}
+	if dmg.Type == "" {
+		event.Type = Physical
+	}
+
+	h, d := HealthOf(target), DamageableOf(target)
+	if h == nil || d == nil || d.dead {
+		return event
+	}
+	event.Health = h.Current
+
+	if d.invulnerable > 0 && !dmg.IgnoreInvulnerability {
+		s.Log.record(event)
+		return event
+	}
+	if dmg.Source != nil && dmg.Source != target {
+		if sd := DamageableOf(dmg.Source); sd != nil && sd.Team != "" && sd.Team == d.Team {
+			s.Log.record(event)
+			return event
+		}
+	}
+
+	amount := dmg.Amount
+	if event.Type == Physical {
+		if st := entity.StatsOf(target); st != nil {
+			amount -= st.Get(stats.Defense)
+		}
+	}
+	amount *= 1 - d.Resistance(event.Type)
+	if dmg.Amount > 0 && !dmg.OverTime && d.Resistance(event.Type) < 1 {
+		amount = max(amount, 1) // Armour never makes a hit do nothing at all
+	}
+	if amount <= 0 {
+		s.Log.record(event)
+		return event
+	}
+
+	amount = min(amount, h.Current)
+	h.Current -= amount
+	event.Kind = EventHit
+	event.Amount = amount
+	event.Health = h.Current
+	s.Log.record(event)
+
+	if !dmg.IgnoreInvulnerability {
+		d.invulnerable = d.Invulnerability
+	}
+	if dmg.Knockback > 0 && dmg.Source != nil {
+		s.knockBack(target, d, dmg.Source, dmg.Knockback)
+	}
+	for _, fn := range s.onHit {
+		fn(target, dmg)
+	}
+
+	if h.Current <= 0 {
+		s.kill(target, d, dmg.Source)
+	} else if animator := anim.AnimatorOf(target); animator != nil {
+		animator.SetTrigger("hurt")
+	}
+	return event
+}
+
+// Heal restores up to amount health, never past the maximum
+func (s *System) Heal(target *entity.Object, amount float64) Event {
+	event := Event{Kind: EventHeal, Time: s.time, Target: target, Raw: amount}
+	h := HealthOf(target)
+	if h == nil || IsDead(target) || amount <= 0 {
+		return event
+	}
+
+	healed := min(amount, MaxHealth(target)-h.Current)
+	if healed <= 0 {
+		event.Health = h.Current
+		return event
+	}
+	h.Current += healed
+	event.Amount = healed
+	event.Health = h.Current
+	s.Log.record(event)
+	return event
+}
+
+// Attack starts an object's melee attack if it is off cooldown. With an animator the
+// hit lands on the attack animation's hit event; without one it lands immediately.
+func (s *System) Attack(o *entity.Object) bool {
+	m := MeleeOf(o)
+	if m == nil || !m.Ready() || IsDead(o) {
+		return false
+	}
+	m.cooldown = m.Cooldown
+
+	if animator := anim.AnimatorOf(o); animator != nil && animator.Set() != nil {
+		animator.SetTrigger("attack")
+		return true
+	}
+	s.Melee(o)
+	return true
+}
+
+// OnAnimationEvent lands melee hits on their animation event. Register it with the game's
+// animation event dispatch.
+func (s *System) OnAnimationEvent(o *entity.Object, e anim.Event) {
+	m := MeleeOf(o)
+	if m == nil {
+		return
+	}
+	event := m.Event
+	if event == "" {
+		event = defaultHitEvent
+	}
+	if e.Name == event {
+		s.Melee(o)
+	}
+}
+
+// Melee damages everything in the attacker's hitbox and returns the log events
+func (s *System) Melee(attacker *entity.Object) []Event {
+	m := MeleeOf(attacker)
+	if m == nil || IsDead(attacker) || s.World == nil {
+		return nil
+	}
+
+	amount := m.Damage
+	if st := entity.StatsOf(attacker); st != nil {
+		amount += st.Get(stats.Attack)
+	}
+
+	box := s.Hitbox(attacker)
+	for _, fn := range s.onSwing {
+		fn(attacker, box)
+	}
+
+	var events []Event
+	for _, target := range s.World.QueryRect(box) {
+		if target == attacker || DamageableOf(target) == nil {
+			continue
+		}
+		events = append(events, s.Damage(target, Damage{
+			Amount:    amount,
+			Type:      m.Type,
+			Source:    attacker,
+			Knockback: m.Knockback,
+			Effects:   m.Effects,
+		}))
+	}
+	return events
+}
+
+// Hitbox returns the area an object's melee attack covers, reaching out from its
+// centre the way it is facing
+func (s *System) Hitbox(o *entity.Object) common.Rect {
+	m := MeleeOf(o)
+	if m == nil {
+		return common.Rect{}
+	}
+
+	fx, fy := m.aimX, m.aimY
+	if animator := anim.AnimatorOf(o); animator != nil {
+		fx, fy = animator.Direction().Vector()
+	}
+	if fx == 0 && fy == 0 {
+		fy = 1 // Face down by default, like the animator
+	}
+
+	cx, cy := o.Bounds().Center()
+	w, h := m.Width, m.Range
+	if math.Abs(fx) > math.Abs(fy) {
+		w, h = m.Range, m.Width
+	}
+	length := math.Hypot(fx, fy)
+	cx += fx / length * m.Range / 2
+	cy += fy / length * m.Range / 2
+	return common.NewRectAround(cx, cy, w, h)
+}
+
+// Update advances timers, knockback, regeneration and respawns by dt seconds
+func (s *System) Update(dt float64) {
+	s.time += dt
+	if s.World == nil {
+		return
+	}
+
+	for _, o := range s.World.All() {
+		if m := MeleeOf(o); m != nil && m.cooldown > 0 {
+			m.cooldown -= dt
+		}
+
+		d := DamageableOf(o)
+		if d == nil {
+			if h := HealthOf(o); h != nil && h.Regen > 0 {
+				s.regen(o, h, dt)
+			}
+			continue
+		}
+		if d.dead {
+			s.updateDead(o, d, dt)
+			continue
+		}
+
+		d.invulnerable = max(d.invulnerable-dt, 0)
+		if h := HealthOf(o); h != nil && h.Regen > 0 {
+			s.regen(o, h, dt)
+		}
+
+		if d.knockX != 0 || d.knockY != 0 {
+			dx, dy := d.knockX*dt, d.knockY*dt
+			if s.Space != nil {
+				s.Space.Move(o, dx, dy)
+			} else {
+				o.SetPosition(o.GetX()+dx, o.GetY()+dy)
+			}
+			decay := math.Exp(-knockbackFriction * dt)
+			d.knockX *= decay
+			d.knockY *= decay
+			if math.Hypot(d.knockX, d.knockY) < knockbackStop {
+				d.knockX, d.knockY = 0, 0
+			}
+		}
+	}
+}
+
+func (s *System) regen(o *entity.Object, h *Health, dt float64) {
+	h.Current = min(h.Current+h.Regen*dt, MaxHealth(o))
+}
+
+// knockBack pushes target directly away from source
+func (s *System) knockBack(target *entity.Object, d *Damageable, source *entity.Object, speed float64) {
+	speed *= 1 - min(max(d.KnockbackResist, 0), 1)
+	tx, ty := target.Bounds().Center()
+	sx, sy := source.Bounds().Center()
+	dx, dy := tx-sx, ty-sy
+	length := math.Hypot(dx, dy)
+	if length == 0 || speed == 0 {
+		return
+	}
+	d.knockX, d.knockY = dx/length*speed, dy/length*speed
+}
+
+// kill marks an object dead, stops it colliding and drops its loot
+func (s *System) kill(o *entity.Object, d *Damageable, killer *entity.Object) {
+	d.dead = true
+	d.deadFor = 0
+	d.knockX, d.knockY = 0, 0
+	if c := physics.ColliderOf(o); c != nil {
+		c.Disabled = true
+	}
+	if animator := anim.AnimatorOf(o); animator != nil {
+		animator.SetBool("dead", true)
+	}
+
+	s.Log.record(Event{Kind: EventDeath, Time: s.time, Attacker: killer, Target: o})
+	s.dropLoot(o, d)
+}
+
+func (s *System) dropLoot(o *entity.Object, d *Damageable) {
+	if s.Spawner == nil {
+		return
+	}
+	x, y := o.Bounds().Center()
+	for _, drop := range d.Drops {
+		chance := drop.Chance
+		if chance == 0 {
+			chance = 1
+		}
+		if s.Rand.Float64() >= chance {
+			continue
+		}
+
+		count := max(drop.Count, 1)
+		items := make([]any, count)
+		for i := range items {
+			items[i] = drop.Item
+		}
+		prefab := drop.Prefab
+		if prefab == "" {
+			prefab = defaultDropPrefab
+		}
+
+		// Scatter drops a little so they don't all stack on one spot
+		ox, oy := (s.Rand.Float64()-0.5)*24, (s.Rand.Float64()-0.5)*24
+		if _, err := s.Spawner.Spawn(prefab, x+ox, y+oy, map[string]map[string]any{
+			"interactable": {"items": items},
+		}); err != nil {
+			log.Printf("Failed to drop %s from %s: %v", drop.Item, o.Name, err)
+		}
+	}
+}
+
+// updateDead removes corpses and brings back objects that respawn
+func (s *System) updateDead(o *entity.Object, d *Damageable, dt float64) {
+	d.deadFor += dt
+
+	if d.Respawn <= 0 {
+		if d.deadFor >= d.Corpse {
+			s.World.Remove(o.ID)
+		}
+		return
+	}
+	if d.deadFor < d.Respawn {
+		return
+	}
+
+	d.dead = false
+	d.invulnerable = d.Invulnerability // A moment of safety after coming back
+	if h := HealthOf(o); h != nil {
+		h.Current = MaxHealth(o)
+	}
+	if c := physics.ColliderOf(o); c != nil {
+		c.Disabled = false
+	}
+	if animator := anim.AnimatorOf(o); animator != nil {
+		animator.SetBool("dead", false)
+	}
+	o.SetPosition(d.homeX, d.homeY)
+	o.SnapshotPosition() // Don't interpolate across the map
+
+	s.Log.record(Event{Kind: EventRespawn, Time: s.time, Target: o, Health: MaxHealth(o)})
+}
@@ -0,0 +1,48 @@
+package common
+
+// FixedClock turns variable frame times into a whole number of fixed-size
+// simulation steps, so the simulation behaves the same at any frame rate
+type FixedClock struct {
+	Step     float64 // Seconds per simulation step
+	MaxSteps int     // Cap on steps per frame so a long stall can't snowball
+
+	accumulator float64
+}
+
+// NewFixedClock creates a clock that steps the simulation rate times per second
+func NewFixedClock(rate float64) *FixedClock {
+	return &FixedClock{
+		Step:     1 / rate,
+		MaxSteps: 8,
+	}
+}
+
+// Advance adds elapsed real time and returns how many simulation steps to run
+func (c *FixedClock) Advance(elapsed float64) int {
+	if elapsed > 0 {
+		c.accumulator += elapsed
+	}
+
+	// The small bias stops rounding error (e.g. 1/30 / 1/60 = 1.9999...) from
+	// dropping a step that should have run this frame
+	steps := int(c.accumulator/c.Step + 1e-9)
+	if c.MaxSteps > 0 && steps > c.MaxSteps {
+		// Drop the time we can't catch up on rather than falling further behind
+		steps = c.MaxSteps
+		c.accumulator = 0
+		return steps
+	}
+	c.accumulator -= float64(steps) * c.Step
+	return steps
+}
+
+// Alpha returns how far between the last two simulation steps the current
+// frame is, from 0 to 1, for interpolating rendered positions
+func (c *FixedClock) Alpha() float64 {
+	return c.accumulator / c.Step
+}
+
+// Reset discards any accumulated time
+func (c *FixedClock) Reset() {
+	c.accumulator = 0
+}
@@ -0,0 +1,72 @@
+package common
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFixedClockSteps(t *testing.T) {
+	tests := []struct {
+		fps       float64
+		perFrame  []int // Steps expected on each of the first few frames
+		perSecond int
+	}{
+		{fps: 30, perFrame: []int{2, 2, 2, 2}, perSecond: 60},
+		{fps: 60, perFrame: []int{1, 1, 1, 1}, perSecond: 60},
+		{fps: 144, perFrame: []int{0, 0, 1, 0, 1, 0}, perSecond: 60},
+	}
+	for _, tt := range tests {
+		c := NewFixedClock(60)
+		for i, want := range tt.perFrame {
+			if got := c.Advance(1 / tt.fps); got != want {
+				t.Errorf("%v fps frame %d: got %d steps, want %d", tt.fps, i, got, want)
+			}
+		}
+
+		c.Reset()
+		total := 0
+		for i := 0; i < int(tt.fps)*10; i++ {
+			total += c.Advance(1 / tt.fps)
+		}
+		if total != tt.perSecond*10 {
+			t.Errorf("%v fps: got %d steps in 10 seconds, want %d", tt.fps, total, tt.perSecond*10)
+		}
+	}
+}
+
+func TestFixedClockAlpha(t *testing.T) {
+	tests := []struct {
+		fps   float64
+		alpha []float64 // Alpha after each of the first few frames
+	}{
+		{fps: 30, alpha: []float64{0, 0, 0}},
+		{fps: 60, alpha: []float64{0, 0, 0}},
+		{fps: 144, alpha: []float64{60.0 / 144, 120.0 / 144, 36.0 / 144, 96.0 / 144, 12.0 / 144}},
+	}
+	for _, tt := range tests {
+		c := NewFixedClock(60)
+		for i, want := range tt.alpha {
+			c.Advance(1 / tt.fps)
+			got := c.Alpha()
+			if math.Abs(got-want) > 1e-6 {
+				t.Errorf("%v fps frame %d: got alpha %v, want %v", tt.fps, i, got, want)
+			}
+			if got < -1e-6 || got >= 1 {
+				t.Errorf("%v fps frame %d: alpha %v is outside [0, 1)", tt.fps, i, got)
+			}
+		}
+	}
+}
+
+func TestFixedClockStall(t *testing.T) {
+	c := NewFixedClock(60)
+	if got := c.Advance(2); got != c.MaxSteps {
+		t.Errorf("got %d steps after a stall, want the cap of %d", got, c.MaxSteps)
+	}
+	if got := c.Alpha(); got != 0 {
+		t.Errorf("got alpha %v after a stall, want the lost time dropped", got)
+	}
+	if got := c.Advance(-1); got != 0 {
+		t.Errorf("got %d steps for negative time, want 0", got)
+	}
+}
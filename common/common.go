@@ -1,5 +1,51 @@
 package common
 
+// Camera is the part of the world in view. X and Y are the world position of
+// the view's top-left corner, and Zoom is how many screen pixels one world
+// pixel covers, where 0 means 1.
+//
+// Draw code works in view space, which is world space shifted by the camera;
+// the game scales the finished view to the screen by Zoom.
 type Camera struct {
 	X, Y float64
+	Zoom float64
+}
+
+// Scale returns the zoom factor, treating zero as 1
+func (c Camera) Scale() float64 {
+	if c.Zoom <= 0 {
+		return 1
+	}
+	return c.Zoom
+}
+
+// WorldToScreen converts a world position to screen pixels
+func (c Camera) WorldToScreen(x, y float64) (float64, float64) {
+	scale := c.Scale()
+	return (x - c.X) * scale, (y - c.Y) * scale
+}
+
+// ScreenToWorld converts screen pixels to a world position
+func (c Camera) ScreenToWorld(x, y float64) (float64, float64) {
+	scale := c.Scale()
+	return x/scale + c.X, y/scale + c.Y
+}
+
+// ViewSize returns how much of the world a screen of the given size shows
+func (c Camera) ViewSize(screenW, screenH float64) (float64, float64) {
+	scale := c.Scale()
+	return screenW / scale, screenH / scale
+}
+
+// View returns the part of the world a screen of the given size shows
+func (c Camera) View(screenW, screenH float64) Rect {
+	w, h := c.ViewSize(screenW, screenH)
+	return Rect{X: c.X, Y: c.Y, W: w, H: h}
+}
+
+// CenterOn moves the camera so (x, y) is in the middle of a screen of the given size
+func (c *Camera) CenterOn(x, y, screenW, screenH float64) {
+	w, h := c.ViewSize(screenW, screenH)
+	c.X = x - w/2
+	c.Y = y - h/2
 }
@@ -0,0 +1,63 @@
+package common
+
+import (
+	"math"
+	"testing"
+)
+
+const (
+	screenW, screenH = 640, 480
+	epsilon          = 1e-9
+)
+
+func near(a, b float64) bool {
+	return math.Abs(a-b) <= epsilon*max(1, math.Abs(a), math.Abs(b))
+}
+
+func TestCameraRoundTrip(t *testing.T) {
+	offsets := [][2]float64{{0, 0}, {100, 50}, {-320.5, -240.25}, {12345.75, 9876.125}, {0.3, -0.7}}
+	zooms := []float64{0, 0.5, 0.8, 1, 1.25, 1.5625, 2, 2.7, 3}
+	points := [][2]float64{{0, 0}, {screenW, screenH}, {screenW / 2, screenH / 2}, {17.5, 433.3}, {-5, 700}}
+
+	for _, off := range offsets {
+		for _, zoom := range zooms {
+			c := Camera{X: off[0], Y: off[1], Zoom: zoom}
+			for _, p := range points {
+				wx, wy := c.ScreenToWorld(p[0], p[1])
+				sx, sy := c.WorldToScreen(wx, wy)
+				if !near(sx, p[0]) || !near(sy, p[1]) {
+					t.Errorf("%+v: screen %v -> world (%v, %v) -> screen (%v, %v)", c, p, wx, wy, sx, sy)
+				}
+
+				// The same numbers as a world position
+				sx, sy = c.WorldToScreen(p[0], p[1])
+				wx, wy = c.ScreenToWorld(sx, sy)
+				if !near(wx, p[0]) || !near(wy, p[1]) {
+					t.Errorf("%+v: world %v -> screen (%v, %v) -> world (%v, %v)", c, p, sx, sy, wx, wy)
+				}
+			}
+
+			// The camera's corner is the screen's top-left, and the view covers the screen
+			if sx, sy := c.WorldToScreen(c.X, c.Y); !near(sx, 0) || !near(sy, 0) {
+				t.Errorf("%+v: camera corner is at screen (%v, %v), want (0, 0)", c, sx, sy)
+			}
+			view := c.View(screenW, screenH)
+			if sx, sy := c.WorldToScreen(view.MaxX(), view.MaxY()); !near(sx, screenW) || !near(sy, screenH) {
+				t.Errorf("%+v: view %+v ends at screen (%v, %v), want (%v, %v)", c, view, sx, sy, screenW, screenH)
+			}
+		}
+	}
+}
+
+func TestCameraCenterOn(t *testing.T) {
+	for _, zoom := range []float64{0, 0.5, 0.8, 1, 1.5625, 3} {
+		c := Camera{Zoom: zoom}
+		c.CenterOn(1000.5, -250.25, screenW, screenH)
+		if sx, sy := c.WorldToScreen(1000.5, -250.25); !near(sx, screenW/2) || !near(sy, screenH/2) {
+			t.Errorf("zoom %v: centred point is at screen (%v, %v), want the middle", zoom, sx, sy)
+		}
+		if cx, cy := c.View(screenW, screenH).Center(); !near(cx, 1000.5) || !near(cy, -250.25) {
+			t.Errorf("zoom %v: view is centred on (%v, %v)", zoom, cx, cy)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package common
+
+import "math"
+
+// Rect is an axis-aligned rectangle in world space
+type Rect struct {
+	X, Y, W, H float64
+}
+
+// NewRectAround creates a rect of the given size centered on (cx, cy)
+func NewRectAround(cx, cy, w, h float64) Rect {
+	return Rect{X: cx - w/2, Y: cy - h/2, W: w, H: h}
+}
+
+// MaxX returns the right edge of the rect
+func (r Rect) MaxX() float64 { return r.X + r.W }
+
+// MaxY returns the bottom edge of the rect
+func (r Rect) MaxY() float64 { return r.Y + r.H }
+
+// Center returns the center point of the rect
+func (r Rect) Center() (float64, float64) {
+	return r.X + r.W/2, r.Y + r.H/2
+}
+
+// Intersects reports whether two rects overlap. Touching edges count as overlap
+// so that zero-sized rects (points) can still be found by queries.
+func (r Rect) Intersects(o Rect) bool {
+	return r.X <= o.MaxX() && o.X <= r.MaxX() && r.Y <= o.MaxY() && o.Y <= r.MaxY()
+}
+
+// Contains reports whether the point lies inside the rect
+func (r Rect) Contains(x, y float64) bool {
+	return x >= r.X && x <= r.MaxX() && y >= r.Y && y <= r.MaxY()
+}
+
+// Union returns the smallest rect containing both rects
+func (r Rect) Union(o Rect) Rect {
+	minX := math.Min(r.X, o.X)
+	minY := math.Min(r.Y, o.Y)
+	maxX := math.Max(r.MaxX(), o.MaxX())
+	maxY := math.Max(r.MaxY(), o.MaxY())
+	return Rect{X: minX, Y: minY, W: maxX - minX, H: maxY - minY}
+}
+
+// DistanceTo returns the distance from a point to the closest point of the rect,
+// or 0 if the point is inside it
+func (r Rect) DistanceTo(x, y float64) float64 {
+	dx := math.Max(math.Max(r.X-x, 0), x-r.MaxX())
+	dy := math.Max(math.Max(r.Y-y, 0), y-r.MaxY())
+	return math.Hypot(dx, dy)
+}
@@ -0,0 +1,77 @@
+package effect
+
+import (
+	"slices"
+
+	"github.com/Nathene/bitbase/entity"
+)
+
+func init() {
+	entity.RegisterComponent("effects", func() entity.Component { return &Effects{} })
+}
+
+// Effects lets an object carry status effects. It marshals to JSON with its
+// active effects, so they survive a save and load.
+type Effects struct {
+	Immune []string  `json:"immune"` // Effect IDs or tags that never take hold
+	Active []*Active `json:"active,omitempty"`
+}
+
+func (e *Effects) ComponentName() string { return "effects" }
+
+// EffectsOf returns the object's effects component, or nil
+func EffectsOf(o *entity.Object) *Effects {
+	e, _ := o.Component("effects").(*Effects)
+	return e
+}
+
+// Get returns the active effect with the given ID, or nil
+func (e *Effects) Get(id string) *Active {
+	for _, a := range e.Active {
+		if a.ID == id {
+			return a
+		}
+	}
+	return nil
+}
+
+// Has reports whether an effect is active
+func (e *Effects) Has(id string) bool {
+	return e.Get(id) != nil
+}
+
+// IsImmune reports whether the object shrugs off an effect
+func (e *Effects) IsImmune(s *Spec) bool {
+	return slices.ContainsFunc(e.Immune, s.Matches)
+}
+
+// Active is one effect currently on a character
+type Active struct {
+	ID        string  `json:"id"`
+	Remaining float64 `json:"remaining"` // Seconds left
+	Stacks    int     `json:"stacks"`
+	NextTick  float64 `json:"nextTick"` // Seconds until the next damage or healing tick
+
+	Source *entity.Object `json:"-"` // Who applied it; credited with its damage
+	spec   *Spec
+}
+
+// Spec returns the effect's definition, or nil if it hasn't been resolved yet,
+// as happens just after loading a save
+func (a *Active) Spec() *Spec {
+	return a.spec
+}
+
+// Blocked reports whether an active effect, such as a stun, stops the object doing something
+func Blocked(o *entity.Object, action Action) bool {
+	e := EffectsOf(o)
+	if e == nil {
+		return false
+	}
+	for _, a := range e.Active {
+		if a.spec != nil && slices.Contains(a.spec.Blocks, action) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,130 @@
+package effect
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/Nathene/bitbase/combat"
+	"github.com/Nathene/bitbase/entity"
+	"github.com/Nathene/bitbase/stats"
+)
+
+// testWorld is one object under the effects in assets/effects, with combat for the ticks
+type testWorld struct {
+	effects *System
+	o       *entity.Object
+}
+
+func newTestWorld(t *testing.T, library *Library, e *Effects) *testWorld {
+	t.Helper()
+	o := entity.NewObject("player")
+	o.AddComponent(&entity.Sprite{Width: 16, Height: 16})
+	o.AddComponent(&combat.Health{Max: 100})
+	o.AddComponent(&combat.Damageable{Team: "player"})
+	o.AddComponent(stats.New(map[stats.Stat]float64{stats.MoveSpeed: 100, stats.MaxHealth: 100, stats.Attack: 10}))
+	o.AddComponent(e)
+
+	w := entity.NewWorld()
+	w.Add(o)
+	c := combat.NewSystem(w, nil, nil)
+	c.Prepare(o)
+	return &testWorld{effects: NewSystem(library, w, c), o: o}
+}
+
+func loadLibrary(t *testing.T) *Library {
+	t.Helper()
+	library := NewLibrary()
+	if err := library.LoadFile("../assets/effects/effects.json"); err != nil {
+		t.Fatal(err)
+	}
+	return library
+}
+
+// TestSaveRoundTrip saves an object's effects partway through, loads them onto
+// a fresh object, and checks both carry on exactly alike
+func TestSaveRoundTrip(t *testing.T) {
+	library := loadLibrary(t)
+	saved := newTestWorld(t, library, &Effects{Immune: []string{"fire"}})
+	for _, id := range []string{"poison", "poison", "slow", "weakness", "stun"} {
+		if _, err := saved.effects.Apply(saved.o, id, nil); err != nil {
+			t.Fatalf("applying %s: %v", id, err)
+		}
+	}
+	saved.effects.Update(0.7)
+
+	data, err := json.Marshal(EffectsOf(saved.o))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var e Effects
+	if err := json.Unmarshal(data, &e); err != nil {
+		t.Fatal(err)
+	}
+	loaded := newTestWorld(t, library, &e)
+	combat.HealthOf(loaded.o).Current = combat.HealthOf(saved.o).Current
+
+	if len(e.Active) != 4 || len(e.Immune) != 1 {
+		t.Fatalf("loaded %+v from %s", e, data)
+	}
+	for i, a := range e.Active {
+		want := EffectsOf(saved.o).Active[i]
+		if a.ID != want.ID || a.Remaining != want.Remaining || a.Stacks != want.Stacks || a.NextTick != want.NextTick {
+			t.Errorf("loaded %+v, saved %+v", *a, *want)
+		}
+		if a.Spec() != nil {
+			t.Errorf("%s has a spec before the first update", a.ID)
+		}
+	}
+
+	for step := 1; step <= 24; step++ {
+		saved.effects.Update(0.25)
+		loaded.effects.Update(0.25)
+
+		got, want := EffectsOf(loaded.o).Active, EffectsOf(saved.o).Active
+		if len(got) != len(want) {
+			t.Fatalf("step %d: %d effects loaded, %d saved", step, len(got), len(want))
+		}
+		for i, a := range got {
+			if a.Spec() != library.Get(a.ID) {
+				t.Errorf("step %d: %s didn't get its spec back", step, a.ID)
+			}
+			if a.ID != want[i].ID || math.Abs(a.Remaining-want[i].Remaining) > 1e-9 || a.Stacks != want[i].Stacks {
+				t.Errorf("step %d: loaded %+v, saved %+v", step, *a, *want[i])
+			}
+		}
+		for _, stat := range []stats.Stat{stats.MoveSpeed, stats.Attack} {
+			if g, w := entity.StatsOf(loaded.o).Get(stat), entity.StatsOf(saved.o).Get(stat); g != w {
+				t.Errorf("step %d: loaded %s %v, saved %v", step, stat, g, w)
+			}
+		}
+		if g, w := combat.HealthOf(loaded.o).Current, combat.HealthOf(saved.o).Current; g != w {
+			t.Errorf("step %d: loaded health %v, saved %v", step, g, w)
+		}
+		if g, w := Blocked(loaded.o, Move), Blocked(saved.o, Move); g != w {
+			t.Errorf("step %d: loaded blocked %v, saved %v", step, g, w)
+		}
+	}
+	if n := len(EffectsOf(loaded.o).Active); n != 0 {
+		t.Errorf("%d effects left after they all ran out", n)
+	}
+	if got := combat.HealthOf(loaded.o).Current; got != 100-4*2*2 {
+		t.Errorf("health %v after two stacks of poison, want %v", got, 100-4*2*2)
+	}
+}
+
+func TestLoadUnknownEffect(t *testing.T) {
+	var e Effects
+	if err := json.Unmarshal([]byte(`{"active": [{"id": "cursed", "remaining": 3, "stacks": 1}, {"id": "haste", "remaining": 3, "stacks": 1}]}`), &e); err != nil {
+		t.Fatal(err)
+	}
+	w := newTestWorld(t, loadLibrary(t), &e)
+	w.effects.Update(0.1)
+
+	if len(e.Active) != 1 || e.Active[0].ID != "haste" || e.Active[0].Spec() == nil {
+		t.Fatalf("active after loading: %+v", e.Active)
+	}
+	if got := entity.StatsOf(w.o).Get(stats.MoveSpeed); got <= 100 {
+		t.Errorf("haste didn't come back: move speed %v", got)
+	}
+}
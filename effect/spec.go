@@ -0,0 +1,159 @@
+// Package effect runs timed status effects such as poison, slows, haste and
+// stuns. Effects change characters through the stats pipeline, hurt or heal on
+// a tick, and can stop a character moving or attacking.
+package effect
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+
+	"github.com/Nathene/bitbase/combat"
+	"github.com/Nathene/bitbase/stats"
+)
+
+// Stacking says what happens when an effect is applied to a character that already has it
+type Stacking string
+
+const (
+	Refresh Stacking = "refresh" // Restart the duration
+	Extend  Stacking = "extend"  // Add the duration to what is left
+	Stack   Stacking = "stack"   // Add a stack, up to MaxStacks, and restart the duration
+	Ignore  Stacking = "ignore"  // Keep the existing effect unchanged
+)
+
+// Action is something an effect can stop a character from doing
+type Action string
+
+const (
+	Move   Action = "move"
+	Attack Action = "attack"
+	Shoot  Action = "shoot"
+)
+
+// Spec is the static definition of a status effect
+type Spec struct {
+	ID        string   `json:"id"`
+	Name      string   `json:"name"`
+	Icon      string   `json:"icon,omitempty"` // Asset ID for the HUD; empty draws a square in Color
+	Color     string   `json:"color,omitempty"`
+	Debuff    bool     `json:"debuff"`
+	Tags      []string `json:"tags"` // Immunities can name the ID or any tag, such as "poison" or "magic"
+	Duration  float64  `json:"duration"`
+	Stacking  Stacking `json:"stacking"`  // Default "refresh"
+	MaxStacks int      `json:"maxStacks"` // Default 1
+
+	Modifiers []stats.Modifier `json:"modifiers"` // Applied once per stack
+	Blocks    []Action         `json:"blocks"`    // What the character can't do while affected
+
+	// Damage or healing every Tick seconds, per stack
+	Tick       float64           `json:"tick"`
+	Damage     float64           `json:"damage"`
+	DamageType combat.DamageType `json:"damageType"`
+	Heal       float64           `json:"heal"`
+}
+
+// Matches reports whether an immunity entry names this effect or one of its tags
+func (s *Spec) Matches(name string) bool {
+	return s.ID == name || slices.Contains(s.Tags, name)
+}
+
+// Library holds every known effect spec, keyed by ID
+type Library struct {
+	specs map[string]*Spec
+}
+
+// NewLibrary creates an empty effect library
+func NewLibrary() *Library {
+	return &Library{specs: make(map[string]*Spec)}
+}
+
+// Add validates and registers a spec
+func (l *Library) Add(s *Spec) error {
+	if s.ID == "" {
+		return fmt.Errorf("effect has no id")
+	}
+	if _, exists := l.specs[s.ID]; exists {
+		return fmt.Errorf("effect %q defined twice", s.ID)
+	}
+	if s.Duration <= 0 {
+		return fmt.Errorf("effect %q: duration must be positive", s.ID)
+	}
+	switch s.Stacking {
+	case "":
+		s.Stacking = Refresh
+	case Refresh, Extend, Stack, Ignore:
+	default:
+		return fmt.Errorf("effect %q: unknown stacking %q", s.ID, s.Stacking)
+	}
+	for _, a := range s.Blocks {
+		if a != Move && a != Attack && a != Shoot {
+			return fmt.Errorf("effect %q: unknown action %q", s.ID, a)
+		}
+	}
+	if (s.Damage != 0 || s.Heal != 0) && s.Tick <= 0 {
+		return fmt.Errorf("effect %q: damage and healing need a tick", s.ID)
+	}
+	if s.MaxStacks <= 0 {
+		s.MaxStacks = 1
+	}
+	if s.DamageType == "" {
+		s.DamageType = combat.True
+	}
+	if s.Name == "" {
+		s.Name = s.ID
+	}
+	l.specs[s.ID] = s
+	return nil
+}
+
+// Get returns the spec with the given ID, or nil
+func (l *Library) Get(id string) *Spec {
+	return l.specs[id]
+}
+
+// IDs returns every spec ID in sorted order
+func (l *Library) IDs() []string {
+	ids := make([]string, 0, len(l.specs))
+	for id := range l.specs {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// LoadFile reads a JSON array of effect specs
+func (l *Library) LoadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var specs []*Spec
+	if err := json.Unmarshal(data, &specs); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	for _, s := range specs {
+		if err := l.Add(s); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// LoadDir loads every .json file in a directory
+func (l *Library) LoadDir(dir string) error {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return err
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		if err := l.LoadFile(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
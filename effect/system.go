@@ -0,0 +1,250 @@
+package effect
+
+import (
+	"errors"
+	"log"
+	"math"
+	"strings"
+
+	"github.com/Nathene/bitbase/combat"
+	"github.com/Nathene/bitbase/entity"
+	"github.com/Nathene/bitbase/stats"
+)
+
+const (
+	sourcePrefix = "effect:" // Marks the stat modifier sources owned by effects
+	tickEpsilon  = 1e-9      // Lets a tick landing exactly as an effect ends still count
+)
+
+var (
+	ErrUnknownEffect = errors.New("unknown effect")
+	ErrNoEffects     = errors.New("object can't have effects")
+	ErrImmune        = errors.New("immune")
+	ErrDead          = errors.New("object is dead")
+)
+
+// System applies status effects and runs them over time
+type System struct {
+	Library *Library
+	World   *entity.World
+	Combat  *combat.System // Deals tick damage and healing; may be nil
+}
+
+// NewSystem creates an effect system. Hits that carry effects apply them.
+func NewSystem(library *Library, world *entity.World, combatSystem *combat.System) *System {
+	s := &System{
+		Library: library,
+		World:   world,
+		Combat:  combatSystem,
+	}
+	if combatSystem != nil {
+		combatSystem.OnHit(s.onHit)
+	}
+	return s
+}
+
+// Apply puts an effect on an object, following the effect's stacking rule if
+// the object already has it
+func (s *System) Apply(o *entity.Object, id string, source *entity.Object) (*Active, error) {
+	spec := s.Library.Get(id)
+	if spec == nil {
+		return nil, ErrUnknownEffect
+	}
+	e := EffectsOf(o)
+	if e == nil {
+		return nil, ErrNoEffects
+	}
+	if combat.IsDead(o) {
+		return nil, ErrDead
+	}
+	if e.IsImmune(spec) {
+		return nil, ErrImmune
+	}
+
+	a := e.Get(id)
+	if a == nil {
+		a = &Active{ID: id, Stacks: 1, Remaining: spec.Duration, NextTick: spec.Tick, Source: source, spec: spec}
+		e.Active = append(e.Active, a)
+		s.sync(o, e)
+		return a, nil
+	}
+
+	a.spec = spec
+	switch spec.Stacking {
+	case Ignore:
+		return a, nil
+	case Refresh:
+		a.Remaining = spec.Duration
+	case Extend:
+		a.Remaining += spec.Duration
+	case Stack:
+		a.Stacks = min(a.Stacks+1, spec.MaxStacks)
+		a.Remaining = spec.Duration
+	}
+	a.Source = source
+	s.sync(o, e)
+	return a, nil
+}
+
+// Remove ends an effect early, reporting whether it was active
+func (s *System) Remove(o *entity.Object, id string) bool {
+	e := EffectsOf(o)
+	if e == nil || !e.Has(id) {
+		return false
+	}
+	e.Active = removeActive(e.Active, func(a *Active) bool { return a.ID == id })
+	s.sync(o, e)
+	return true
+}
+
+// Clear ends every effect on an object
+func (s *System) Clear(o *entity.Object) {
+	if e := EffectsOf(o); e != nil && len(e.Active) > 0 {
+		e.Active = e.Active[:0]
+		s.sync(o, e)
+	}
+}
+
+// Update runs every effect for dt seconds, ticking damage and healing and
+// removing effects that have run out
+func (s *System) Update(dt float64) {
+	for _, o := range s.World.All() {
+		e := EffectsOf(o)
+		if e == nil || len(e.Active) == 0 {
+			continue
+		}
+		if combat.IsDead(o) {
+			s.Clear(o) // Death ends everything
+			continue
+		}
+
+		changed := false
+		for _, a := range e.Active {
+			if a.spec == nil {
+				// Just loaded from a save: find the definition and put its modifiers back
+				if a.spec = s.Library.Get(a.ID); a.spec == nil {
+					log.Printf("Dropping unknown effect %q on %s", a.ID, o.Name)
+					a.Remaining = 0
+				}
+				changed = true
+			}
+			if a.spec == nil {
+				continue
+			}
+			s.tick(o, a, dt)
+			a.Remaining -= dt
+		}
+
+		before := len(e.Active)
+		e.Active = removeActive(e.Active, func(a *Active) bool { return a.Remaining <= 0 })
+		if changed || len(e.Active) != before {
+			s.sync(o, e)
+		}
+	}
+}
+
+// tick deals an effect's damage or healing for every tick that falls within dt
+func (s *System) tick(o *entity.Object, a *Active, dt float64) {
+	if a.spec.Tick <= 0 {
+		return
+	}
+	a.NextTick -= dt
+	for a.NextTick <= 0 && a.Remaining-dt-a.NextTick >= -tickEpsilon {
+		a.NextTick += a.spec.Tick
+		if s.Combat == nil || combat.IsDead(o) {
+			continue
+		}
+		stacks := float64(a.Stacks)
+		if a.spec.Damage > 0 {
+			s.Combat.Damage(o, combat.Damage{
+				Amount:                a.spec.Damage * stacks,
+				Type:                  a.spec.DamageType,
+				Source:                a.Source,
+				IgnoreInvulnerability: true,
+				OverTime:              true,
+			})
+		}
+		if a.spec.Heal > 0 {
+			s.Combat.Heal(o, a.spec.Heal*stacks)
+		}
+	}
+}
+
+// sync makes the object's stat modifiers match its active effects
+func (s *System) sync(o *entity.Object, e *Effects) {
+	st := entity.StatsOf(o)
+	if st == nil {
+		if !hasModifiers(e) {
+			return
+		}
+		st = newStats(o)
+	}
+
+	active := make(map[string]bool, len(e.Active))
+	for _, a := range e.Active {
+		if a.spec == nil || len(a.spec.Modifiers) == 0 {
+			continue
+		}
+		active[sourcePrefix+a.ID] = true
+		mods := make([]stats.Modifier, len(a.spec.Modifiers))
+		for i, m := range a.spec.Modifiers {
+			mods[i] = stack(m, a.Stacks)
+		}
+		st.SetModifiers(sourcePrefix+a.ID, mods...)
+	}
+	for _, name := range st.Sources() {
+		if strings.HasPrefix(name, sourcePrefix) && !active[name] {
+			st.RemoveSource(name)
+		}
+	}
+}
+
+// stack scales a modifier by its effect's stack count
+func stack(m stats.Modifier, stacks int) stats.Modifier {
+	if m.Op == stats.Multiply {
+		m.Value = math.Pow(m.Value, float64(stacks))
+	} else {
+		m.Value *= float64(stacks)
+	}
+	return m
+}
+
+func hasModifiers(e *Effects) bool {
+	for _, a := range e.Active {
+		if a.spec != nil && len(a.spec.Modifiers) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// newStats gives an object without stats a stats component, so effects can
+// slow or speed up characters that only have a flat movement speed
+func newStats(o *entity.Object) *stats.Stats {
+	base := map[stats.Stat]float64{}
+	if m := entity.MovementOf(o); m != nil {
+		base[stats.MoveSpeed] = m.Speed
+	}
+	st := stats.New(base)
+	o.AddComponent(st)
+	return st
+}
+
+func (s *System) onHit(target *entity.Object, dmg combat.Damage) {
+	for _, id := range dmg.Effects {
+		if _, err := s.Apply(target, id, dmg.Source); err != nil && !errors.Is(err, ErrImmune) && !errors.Is(err, ErrNoEffects) {
+			log.Printf("Failed to apply %s to %s: %v", id, target.Name, err)
+		}
+	}
+}
+
+func removeActive(list []*Active, drop func(*Active) bool) []*Active {
+	kept := list[:0]
+	for _, a := range list {
+		if !drop(a) {
+			kept = append(kept, a)
+		}
+	}
+	clear(list[len(kept):])
+	return kept
+}
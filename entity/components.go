@@ -0,0 +1,149 @@
+package entity
+
+import (
+	"fmt"
+	"image/color"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/Nathene/bitbase/common"
+	"github.com/Nathene/bitbase/stats"
+)
+
+// ComponentFactory creates a zero-valued component ready to be filled from prefab data
+type ComponentFactory func() Component
+
+var (
+	componentFactories = make(map[string]ComponentFactory)
+	componentMutex     sync.RWMutex
+)
+
+// RegisterComponent makes a component type available to prefabs under the given name.
+// Packages that define components call this from init.
+func RegisterComponent(name string, factory ComponentFactory) {
+	componentMutex.Lock()
+	defer componentMutex.Unlock()
+
+	if _, exists := componentFactories[name]; exists {
+		panic(fmt.Sprintf("entity: component %q registered twice", name))
+	}
+	componentFactories[name] = factory
+}
+
+// NewComponent creates a new instance of a registered component type
+func NewComponent(name string) (Component, error) {
+	componentMutex.RLock()
+	factory, ok := componentFactories[name]
+	componentMutex.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unknown component %q", name)
+	}
+	return factory(), nil
+}
+
+// RegisteredComponents returns the names of all registered component types
+func RegisteredComponents() []string {
+	componentMutex.RLock()
+	defer componentMutex.RUnlock()
+
+	names := make([]string, 0, len(componentFactories))
+	for name := range componentFactories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func init() {
+	RegisterComponent("sprite", func() Component { return &Sprite{} })
+	RegisterComponent("movement", func() Component { return &Movement{} })
+	RegisterComponent("stats", func() Component { return stats.New(nil) })
+}
+
+// Sprite describes how an object is drawn
+type Sprite struct {
+	Image     string  `json:"image"` // Asset ID of the sprite sheet; empty draws a coloured box
+	SrcX      int     `json:"srcX"`
+	SrcY      int     `json:"srcY"`
+	SrcWidth  int     `json:"srcWidth"`
+	SrcHeight int     `json:"srcHeight"`
+	Scale     float64 `json:"scale"`
+
+	// Used for the placeholder box when no image is available
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+	Color  string  `json:"color"` // Hex colour such as "#3c8c3c"
+}
+
+func (s *Sprite) ComponentName() string { return "sprite" }
+
+// Bounds returns the area covered by the sprite when drawn at (x, y)
+func (s *Sprite) Bounds(x, y float64) common.Rect {
+	w, h := s.Width, s.Height
+	if s.SrcWidth > 0 && s.SrcHeight > 0 {
+		scale := s.Scale
+		if scale == 0 {
+			scale = 1
+		}
+		w, h = float64(s.SrcWidth)*scale, float64(s.SrcHeight)*scale
+	}
+	return common.Rect{X: x, Y: y, W: w, H: h}
+}
+
+// fillColor parses the sprite's hex colour, falling back to magenta
+func (s *Sprite) fillColor() color.RGBA {
+	hex := strings.TrimPrefix(s.Color, "#")
+	if len(hex) != 6 && len(hex) != 8 {
+		return fallbackColor
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return fallbackColor
+	}
+	if len(hex) == 6 {
+		return color.RGBA{uint8(v >> 16), uint8(v >> 8), uint8(v), 255}
+	}
+	return color.RGBA{uint8(v >> 24), uint8(v >> 16), uint8(v >> 8), uint8(v)}
+}
+
+// SpriteOf returns the object's sprite component, or nil
+func SpriteOf(o *Object) *Sprite {
+	s, _ := o.Component("sprite").(*Sprite)
+	return s
+}
+
+// Movement holds how fast an object can move
+type Movement struct {
+	Speed float64 `json:"speed"` // Pixels per second
+}
+
+func (m *Movement) ComponentName() string { return "movement" }
+
+// MovementOf returns the object's movement component, or nil
+func MovementOf(o *Object) *Movement {
+	m, _ := o.Component("movement").(*Movement)
+	return m
+}
+
+// StatsOf returns the object's stats component, or nil
+func StatsOf(o *Object) *stats.Stats {
+	s, _ := o.Component("stats").(*stats.Stats)
+	return s
+}
+
+// MoveSpeed returns how fast an object moves in pixels per second, preferring
+// its derived stats over a flat movement component
+func MoveSpeed(o *Object) float64 {
+	if s := StatsOf(o); s != nil {
+		if _, ok := s.Base[stats.MoveSpeed]; ok {
+			return s.Get(stats.MoveSpeed)
+		}
+	}
+	if m := MovementOf(o); m != nil {
+		return m.Speed
+	}
+	return 0
+}
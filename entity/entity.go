@@ -2,17 +2,14 @@ package entity
 
 import (
 	"github.com/Nathene/bitbase/common"
+	"github.com/Nathene/bitbase/item"
 	"github.com/hajimehoshi/ebiten/v2"
 )
 
 type Entity interface {
-	GetInventory() Inventory
-	SetInventory(Inventory)
+	GetInventory() *item.Inventory
+	SetInventory(*item.Inventory)
 	GetX() float64
 	GetY() float64
 	Draw(screen *ebiten.Image, camera common.Camera)
 }
-
-type Inventory struct {
-	Items []string
-}
@@ -0,0 +1,255 @@
+package entity
+
+import (
+	"image"
+	"image/color"
+	"sort"
+
+	"github.com/Nathene/bitbase/common"
+	"github.com/Nathene/bitbase/item"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// Component is a named piece of data attached to an Object
+type Component interface {
+	// ComponentName returns the key the component is stored under
+	ComponentName() string
+}
+
+// Bounded is implemented by components that occupy space in the world
+type Bounded interface {
+	// Bounds returns the component's world-space bounds for an object at (x, y)
+	Bounds(x, y float64) common.Rect
+}
+
+// Layered is implemented by components that draw extra images over an object's
+// sprite, such as worn equipment
+type Layered interface {
+	// SpriteLayers returns asset IDs drawn over the base sprite, bottom first
+	SpriteLayers() []string
+}
+
+// Object is a generic world entity (NPC, chest, enemy, pickup, ...) built from components
+type Object struct {
+	ID     int
+	Name   string
+	Prefab string // Name of the prefab the object was created from, if any
+
+	x, y       float64
+	prevX      float64 // Position at the start of the current simulation step,
+	prevY      float64 // used to interpolate rendering between steps
+	inventory  *item.Inventory
+	components map[string]Component
+	world      *World
+}
+
+// NewObject creates a new object with no components
+func NewObject(name string) *Object {
+	return &Object{
+		Name:       name,
+		components: make(map[string]Component),
+	}
+}
+
+// GetInventory returns the object's inventory, or nil if it can't carry anything
+func (o *Object) GetInventory() *item.Inventory {
+	return o.inventory
+}
+
+func (o *Object) SetInventory(inventory *item.Inventory) {
+	o.inventory = inventory
+}
+
+func (o *Object) GetX() float64 {
+	return o.x
+}
+
+func (o *Object) SetX(x float64) {
+	o.x = x
+	o.moved()
+}
+
+func (o *Object) GetY() float64 {
+	return o.y
+}
+
+func (o *Object) SetY(y float64) {
+	o.y = y
+	o.moved()
+}
+
+// SetPosition moves the object to the given world position
+func (o *Object) SetPosition(x, y float64) {
+	o.x = x
+	o.y = y
+	o.moved()
+}
+
+// SnapshotPosition records the current position as the start of a simulation step
+func (o *Object) SnapshotPosition() {
+	o.prevX, o.prevY = o.x, o.y
+}
+
+// LerpPosition returns the position interpolated between the last snapshot
+// and the current position, with alpha running from 0 to 1
+func (o *Object) LerpPosition(alpha float64) (float64, float64) {
+	return o.prevX + (o.x-o.prevX)*alpha, o.prevY + (o.y-o.prevY)*alpha
+}
+
+// Bounds returns the union of the bounds of every Bounded component,
+// or a zero-sized rect at the object's position if it has none. It runs on
+// every move, so it walks the components directly rather than in name order;
+// a union comes out the same either way.
+func (o *Object) Bounds() common.Rect {
+	bounds := common.Rect{X: o.x, Y: o.y}
+	first := true
+	for _, c := range o.components {
+		b, ok := c.(Bounded)
+		if !ok {
+			continue
+		}
+		if first {
+			bounds = b.Bounds(o.x, o.y)
+			first = false
+		} else {
+			bounds = bounds.Union(b.Bounds(o.x, o.y))
+		}
+	}
+	return bounds
+}
+
+// Feet returns the point an object stands on: the middle of the bottom edge
+// of its bounds, nudged inside them
+func (o *Object) Feet() (float64, float64) {
+	b := o.Bounds()
+	x, _ := b.Center()
+	return x, b.MaxY() - 0.5
+}
+
+// moved keeps the world's spatial index in sync with the object
+func (o *Object) moved() {
+	if o.world != nil {
+		o.world.reindex(o)
+	}
+}
+
+// World returns the world the object has been added to, or nil
+func (o *Object) World() *World {
+	return o.world
+}
+
+// AddComponent attaches a component, replacing any existing one with the same name
+func (o *Object) AddComponent(c Component) {
+	if o.components == nil {
+		o.components = make(map[string]Component)
+	}
+	o.components[c.ComponentName()] = c
+	o.moved()
+}
+
+// Component returns the component stored under name, or nil
+func (o *Object) Component(name string) Component {
+	return o.components[name]
+}
+
+// HasComponent reports whether a component with the given name is attached
+func (o *Object) HasComponent(name string) bool {
+	_, ok := o.components[name]
+	return ok
+}
+
+// RemoveComponent detaches the component stored under name
+func (o *Object) RemoveComponent(name string) {
+	delete(o.components, name)
+	o.moved()
+}
+
+// ComponentNames returns the names of all attached components in sorted order
+func (o *Object) ComponentNames() []string {
+	names := make([]string, 0, len(o.components))
+	for name := range o.components {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Draw draws the object's sprite, or a coloured box if it has no image
+func (o *Object) Draw(screen *ebiten.Image, camera common.Camera) {
+	o.drawAt(screen, o.x-camera.X, o.y-camera.Y)
+}
+
+// DrawLerped draws the object's sprite, interpolated between simulation steps
+func (o *Object) DrawLerped(screen *ebiten.Image, camera common.Camera, alpha float64) {
+	x, y := o.LerpPosition(alpha)
+	o.drawAt(screen, x-camera.X, y-camera.Y)
+}
+
+// Depth returns the world Y of the object's feet, the bottom of its bounds,
+// interpolated between simulation steps. Things further down the screen are
+// in front.
+func (o *Object) Depth(alpha float64) float64 {
+	_, y := o.LerpPosition(alpha)
+	return o.Bounds().MaxY() - o.y + y
+}
+
+// drawAt draws the object's sprite with its top-left corner at a screen position
+func (o *Object) drawAt(screen *ebiten.Image, screenX, screenY float64) {
+	sprite := SpriteOf(o)
+	if sprite == nil {
+		return
+	}
+
+	var img *ebiten.Image
+	if sprite.Image != "" && o.world != nil && o.world.Images != nil {
+		img = o.world.Images(sprite.Image)
+	}
+
+	if img == nil {
+		vector.DrawFilledRect(screen, float32(screenX), float32(screenY),
+			float32(sprite.Width), float32(sprite.Height), sprite.fillColor(), false)
+		return
+	}
+
+	if sprite.SrcWidth > 0 && sprite.SrcHeight > 0 {
+		img = img.SubImage(image.Rect(sprite.SrcX, sprite.SrcY,
+			sprite.SrcX+sprite.SrcWidth, sprite.SrcY+sprite.SrcHeight)).(*ebiten.Image)
+	}
+
+	opts := &ebiten.DrawImageOptions{}
+	scale := sprite.Scale
+	if scale == 0 {
+		scale = 1
+	}
+	opts.GeoM.Scale(scale, scale)
+	opts.GeoM.Translate(screenX, screenY)
+	screen.DrawImage(img, opts)
+
+	// Layers share the base sheet's layout, so draw the same region of each
+	for _, id := range o.SpriteLayers() {
+		layer := o.world.Images(id)
+		if layer == nil {
+			continue
+		}
+		if sprite.SrcWidth > 0 && sprite.SrcHeight > 0 {
+			layer = layer.SubImage(image.Rect(sprite.SrcX, sprite.SrcY,
+				sprite.SrcX+sprite.SrcWidth, sprite.SrcY+sprite.SrcHeight)).(*ebiten.Image)
+		}
+		screen.DrawImage(layer, opts)
+	}
+}
+
+// SpriteLayers returns the asset IDs of every layer drawn over the object's sprite
+func (o *Object) SpriteLayers() []string {
+	var layers []string
+	for _, name := range o.ComponentNames() {
+		if l, ok := o.components[name].(Layered); ok {
+			layers = append(layers, l.SpriteLayers()...)
+		}
+	}
+	return layers
+}
+
+// fallbackColor is used when a sprite has neither an image nor a valid colour
+var fallbackColor = color.RGBA{255, 0, 255, 255}
@@ -3,48 +3,22 @@ package player
 import (
 	"github.com/Nathene/bitbase/common"
 	"github.com/Nathene/bitbase/entity"
+	"github.com/Nathene/bitbase/item"
 	"github.com/hajimehoshi/ebiten/v2"
 )
 
 type Player struct {
-	x, y          float64
-	Speed         float64
-	inventory     entity.Inventory
-	ShowInventory bool
-
-	AnimTimer float64
-	AnimFrame int
-}
-
-// NewInventory creates a new empty inventory
-func NewInventory() entity.Inventory {
-	return entity.Inventory{
-		Items: []string{},
-	}
-}
-
-func (p *Player) GetInventory() entity.Inventory {
-	return p.inventory
-}
-
-func (p *Player) SetInventory(inventory entity.Inventory) {
-	p.inventory = inventory
+	entity.Object
 }
 
-func (p *Player) GetX() float64 {
-	return p.x
-}
-
-func (p *Player) SetX(x float64) {
-	p.x = x
-}
-
-func (p *Player) GetY() float64 {
-	return p.y
-}
+const (
+	inventorySlots     = 24
+	inventoryMaxWeight = 60
+)
 
-func (p *Player) SetY(y float64) {
-	p.y = y
+// NewInventory creates a new empty inventory
+func NewInventory(defs *item.Registry) *item.Inventory {
+	return item.NewInventory(defs, inventorySlots, inventoryMaxWeight)
 }
 
 // Draw draws the player on the screen
@@ -0,0 +1,221 @@
+package entity
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Prefab is a named template listing the components of an entity and their default values
+type Prefab struct {
+	Name       string                    `json:"name"`
+	Extends    string                    `json:"extends,omitempty"`
+	Components map[string]map[string]any `json:"components"`
+}
+
+// PrefabLibrary holds every known prefab, keyed by name
+type PrefabLibrary struct {
+	prefabs map[string]*Prefab
+}
+
+// NewPrefabLibrary creates an empty prefab library
+func NewPrefabLibrary() *PrefabLibrary {
+	return &PrefabLibrary{
+		prefabs: make(map[string]*Prefab),
+	}
+}
+
+// Register adds a prefab to the library
+func (pl *PrefabLibrary) Register(p *Prefab) error {
+	if p.Name == "" {
+		return fmt.Errorf("prefab has no name")
+	}
+	if _, exists := pl.prefabs[p.Name]; exists {
+		return fmt.Errorf("prefab %q defined twice", p.Name)
+	}
+	pl.prefabs[p.Name] = p
+	return nil
+}
+
+// Get returns the prefab with the given name, or nil
+func (pl *PrefabLibrary) Get(name string) *Prefab {
+	return pl.prefabs[name]
+}
+
+// Names returns the names of all prefabs in sorted order
+func (pl *PrefabLibrary) Names() []string {
+	names := make([]string, 0, len(pl.prefabs))
+	for name := range pl.prefabs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LoadFile reads a JSON file containing either a single prefab or an array of prefabs
+func (pl *PrefabLibrary) LoadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var prefabs []*Prefab
+	if strings.HasPrefix(strings.TrimSpace(string(data)), "[") {
+		err = json.Unmarshal(data, &prefabs)
+	} else {
+		p := &Prefab{}
+		err = json.Unmarshal(data, p)
+		prefabs = append(prefabs, p)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	for _, p := range prefabs {
+		if err := pl.Register(p); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// LoadDir loads every .json file in a directory
+func (pl *PrefabLibrary) LoadDir(dir string) error {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return err
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		if err := pl.LoadFile(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Resolve flattens a prefab's inheritance chain into its final component values
+func (pl *PrefabLibrary) Resolve(name string) (map[string]map[string]any, error) {
+	if cycle := pl.cycle(name); cycle != nil {
+		return nil, fmt.Errorf("prefab %q: extends cycle %s", name, strings.Join(cycle, " -> "))
+	}
+	return pl.resolve(name)
+}
+
+// cycle returns the loop in a prefab's inheritance chain, such as a -> b -> a,
+// or nil if the chain ends
+func (pl *PrefabLibrary) cycle(name string) []string {
+	var chain []string
+	for n := name; pl.prefabs[n] != nil; n = pl.prefabs[n].Extends {
+		if i := slices.Index(chain, n); i >= 0 {
+			return append(chain[i:], n)
+		}
+		chain = append(chain, n)
+	}
+	return nil
+}
+
+func (pl *PrefabLibrary) resolve(name string) (map[string]map[string]any, error) {
+	p, ok := pl.prefabs[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown prefab %q", name)
+	}
+
+	resolved := make(map[string]map[string]any)
+	if p.Extends != "" {
+		parent, err := pl.resolve(p.Extends)
+		if err != nil {
+			return nil, fmt.Errorf("prefab %q: %w", name, err)
+		}
+		resolved = parent
+	}
+
+	return mergeComponents(resolved, p.Components), nil
+}
+
+// Instantiate creates a new object from a prefab, applying per-instance overrides on top
+func (pl *PrefabLibrary) Instantiate(name string, overrides map[string]map[string]any) (*Object, error) {
+	o := NewObject(name)
+	if err := pl.Apply(o, name, overrides); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// Apply attaches the components of a prefab to an existing object
+func (pl *PrefabLibrary) Apply(o *Object, name string, overrides map[string]map[string]any) error {
+	values, err := pl.Resolve(name)
+	if err != nil {
+		return err
+	}
+	values = mergeComponents(values, overrides)
+
+	componentNames := make([]string, 0, len(values))
+	for componentName := range values {
+		componentNames = append(componentNames, componentName)
+	}
+	sort.Strings(componentNames)
+
+	for _, componentName := range componentNames {
+		c, err := decodeComponent(componentName, values[componentName])
+		if err != nil {
+			return fmt.Errorf("prefab %q: %w", name, err)
+		}
+		o.AddComponent(c)
+	}
+	o.Prefab = name
+	return nil
+}
+
+// decodeComponent builds a registered component from its raw field values
+func decodeComponent(name string, fields map[string]any) (Component, error) {
+	c, err := NewComponent(name)
+	if err != nil {
+		return nil, err
+	}
+
+	// Round-trip through JSON so components only need struct tags to be prefab-aware
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return nil, fmt.Errorf("component %q: %w", name, err)
+	}
+	if err := json.Unmarshal(data, c); err != nil {
+		return nil, fmt.Errorf("component %q: %w", name, err)
+	}
+	return c, nil
+}
+
+// mergeComponents returns base with every field in top layered over it
+func mergeComponents(base, top map[string]map[string]any) map[string]map[string]any {
+	merged := make(map[string]map[string]any, len(base)+len(top))
+	for name, fields := range base {
+		merged[name] = mergeValues(nil, fields)
+	}
+	for name, fields := range top {
+		merged[name] = mergeValues(merged[name], fields)
+	}
+	return merged
+}
+
+// mergeValues deep-merges nested JSON objects; any other value in top replaces the base value
+func mergeValues(base, top map[string]any) map[string]any {
+	merged := make(map[string]any, len(base)+len(top))
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range top {
+		topMap, topIsMap := v.(map[string]any)
+		baseMap, baseIsMap := merged[k].(map[string]any)
+		if topIsMap && baseIsMap {
+			merged[k] = mergeValues(baseMap, topMap)
+		} else {
+			merged[k] = v
+		}
+	}
+	return merged
+}
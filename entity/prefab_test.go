@@ -0,0 +1,75 @@
+package entity
+
+import (
+	"reflect"
+	"testing"
+)
+
+func testLibrary(t *testing.T, extends map[string]string) *PrefabLibrary {
+	t.Helper()
+	pl := NewPrefabLibrary()
+	for name, parent := range extends {
+		if err := pl.Register(&Prefab{Name: name, Extends: parent}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return pl
+}
+
+func TestResolveCycle(t *testing.T) {
+	tests := []struct {
+		name    string
+		extends map[string]string
+		resolve string
+		want    string
+	}{
+		{name: "self", extends: map[string]string{"a": "a"}, resolve: "a", want: `prefab "a": extends cycle a -> a`},
+		{name: "pair", extends: map[string]string{"a": "b", "b": "a"}, resolve: "a", want: `prefab "a": extends cycle a -> b -> a`},
+		{name: "from the other end", extends: map[string]string{"a": "b", "b": "a"}, resolve: "b", want: `prefab "b": extends cycle b -> a -> b`},
+		{name: "leading into", extends: map[string]string{"c": "a", "a": "b", "b": "a"}, resolve: "c", want: `prefab "c": extends cycle a -> b -> a`},
+		{name: "unknown parent", extends: map[string]string{"a": "b", "b": "ghost"}, resolve: "a", want: `prefab "a": prefab "b": unknown prefab "ghost"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := testLibrary(t, tt.extends).Resolve(tt.resolve)
+			if err == nil || err.Error() != tt.want {
+				t.Errorf("got error %v, want %s", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveMerges(t *testing.T) {
+	pl := NewPrefabLibrary()
+	for _, p := range []*Prefab{
+		{Name: "character", Components: map[string]map[string]any{
+			"sprite":   {"width": 16.0, "height": 16.0},
+			"movement": {"speed": 100.0, "limits": map[string]any{"min": 0.0, "max": 200.0}},
+		}},
+		{Name: "goblin", Extends: "character", Components: map[string]map[string]any{
+			"movement": {"limits": map[string]any{"max": 150.0}},
+		}},
+		{Name: "goblin_chief", Extends: "goblin", Components: map[string]map[string]any{
+			"sprite": {"width": 24.0},
+		}},
+	} {
+		if err := pl.Register(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := pl.Resolve("goblin_chief")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]map[string]any{
+		"sprite":   {"width": 24.0, "height": 16.0},
+		"movement": {"speed": 100.0, "limits": map[string]any{"min": 0.0, "max": 150.0}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if base, _ := pl.Resolve("character"); base["movement"]["limits"].(map[string]any)["max"] != 200.0 {
+		t.Error("resolving a child changed its parent")
+	}
+}
@@ -0,0 +1,39 @@
+package entity
+
+// Spawner instantiates prefabs into a world
+type Spawner struct {
+	Library *PrefabLibrary
+	World   *World
+
+	onSpawn []func(*Object)
+}
+
+// NewSpawner creates a spawner for the given library and world
+func NewSpawner(library *PrefabLibrary, world *World) *Spawner {
+	return &Spawner{
+		Library: library,
+		World:   world,
+	}
+}
+
+// Spawn creates an instance of the named prefab at (x, y) and adds it to the world.
+// overrides maps component names to field values that replace the prefab defaults.
+func (s *Spawner) Spawn(name string, x, y float64, overrides map[string]map[string]any) (*Object, error) {
+	o, err := s.Library.Instantiate(name, overrides)
+	if err != nil {
+		return nil, err
+	}
+	o.SetPosition(x, y)
+	s.World.Add(o)
+
+	for _, fn := range s.onSpawn {
+		fn(o)
+	}
+	return o, nil
+}
+
+// OnSpawn registers a function called for every object after it is spawned,
+// so systems can finish setting up the components they own
+func (s *Spawner) OnSpawn(fn func(*Object)) {
+	s.onSpawn = append(s.onSpawn, fn)
+}
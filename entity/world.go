@@ -0,0 +1,162 @@
+package entity
+
+import (
+	"slices"
+	"sort"
+
+	"github.com/Nathene/bitbase/common"
+	"github.com/Nathene/bitbase/spatial"
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// spatialCellSize is the size of a spatial hash cell, about two tiles
+const spatialCellSize = 64
+
+// World owns every object that exists in the game world
+type World struct {
+	objects map[int]*Object
+	nextID  int
+	index   *spatial.Grid
+	scratch []int // Reused by AppendQueryRect
+
+	// Images resolves sprite image IDs to loaded images
+	Images func(id string) *ebiten.Image
+}
+
+// NewWorld creates an empty world
+func NewWorld() *World {
+	return &World{
+		objects: make(map[int]*Object),
+		nextID:  1,
+		index:   spatial.NewGrid(spatialCellSize),
+	}
+}
+
+// Add inserts an object into the world and assigns it an ID
+func (w *World) Add(o *Object) int {
+	o.ID = w.nextID
+	w.nextID++
+	o.world = w
+	o.SnapshotPosition()
+	w.objects[o.ID] = o
+	w.index.Insert(o.ID, o.Bounds())
+	return o.ID
+}
+
+// Remove deletes an object from the world
+func (w *World) Remove(id int) {
+	if o, ok := w.objects[id]; ok {
+		o.world = nil
+		delete(w.objects, id)
+		w.index.Remove(id)
+	}
+}
+
+// Get returns the object with the given ID, or nil
+func (w *World) Get(id int) *Object {
+	return w.objects[id]
+}
+
+// Len returns the number of objects in the world
+func (w *World) Len() int {
+	return len(w.objects)
+}
+
+// All returns every object ordered by ID
+func (w *World) All() []*Object {
+	all := make([]*Object, 0, len(w.objects))
+	for _, o := range w.objects {
+		all = append(all, o)
+	}
+	sortByID(all)
+	return all
+}
+
+// QueryRect returns every object whose bounds overlap r, ordered by ID
+func (w *World) QueryRect(r common.Rect) []*Object {
+	return w.lookup(w.index.QueryRect(r, nil))
+}
+
+// AppendQueryRect appends every object whose bounds overlap r to out, ordered by ID.
+// Passing the previous result back in avoids allocating in hot loops.
+func (w *World) AppendQueryRect(out []*Object, r common.Rect) []*Object {
+	w.scratch = w.index.QueryRect(r, w.scratch[:0])
+	start := len(out)
+	for _, id := range w.scratch {
+		out = append(out, w.objects[id])
+	}
+	slices.SortFunc(out[start:], func(a, b *Object) int { return a.ID - b.ID })
+	return out
+}
+
+// QueryRadius returns every object within radius of (x, y), ordered by ID
+func (w *World) QueryRadius(x, y, radius float64) []*Object {
+	return w.lookup(w.index.QueryRadius(x, y, radius, nil))
+}
+
+// Nearest returns up to n objects ordered by distance from (x, y).
+// filter may be nil; otherwise only objects it accepts are returned.
+func (w *World) Nearest(x, y float64, n int, filter func(*Object) bool) []*Object {
+	ids := w.index.Nearest(x, y, n, w.idFilter(filter))
+	objects := make([]*Object, 0, len(ids))
+	for _, id := range ids {
+		objects = append(objects, w.objects[id])
+	}
+	return objects
+}
+
+// Raycast returns the first object hit by a ray from (x, y) in direction (dirX, dirY)
+// within maxDist, along with the hit. filter may be nil.
+func (w *World) Raycast(x, y, dirX, dirY, maxDist float64, filter func(*Object) bool) (*Object, spatial.Hit, bool) {
+	hit, ok := w.index.Raycast(x, y, dirX, dirY, maxDist, w.idFilter(filter))
+	if !ok {
+		return nil, hit, false
+	}
+	return w.objects[hit.ID], hit, true
+}
+
+// SnapshotPositions marks the start of a simulation step for every object
+func (w *World) SnapshotPositions() {
+	for _, o := range w.objects {
+		o.SnapshotPosition()
+	}
+}
+
+// InView returns every object close enough to a view of the given size to be
+// drawn, ordered by ID
+func (w *World) InView(camera common.Camera, width, height float64) []*Object {
+	// Widen the view a little so objects moving in from the edge don't pop in late
+	return w.QueryRect(common.Rect{
+		X: camera.X - spatialCellSize,
+		Y: camera.Y - spatialCellSize,
+		W: width + spatialCellSize*2,
+		H: height + spatialCellSize*2,
+	})
+}
+
+// reindex updates an object's entry in the spatial index after it moves or changes shape
+func (w *World) reindex(o *Object) {
+	w.index.Update(o.ID, o.Bounds())
+}
+
+func (w *World) lookup(ids []int) []*Object {
+	objects := make([]*Object, 0, len(ids))
+	for _, id := range ids {
+		objects = append(objects, w.objects[id])
+	}
+	sortByID(objects)
+	return objects
+}
+
+func (w *World) idFilter(filter func(*Object) bool) func(int) bool {
+	if filter == nil {
+		return nil
+	}
+	return func(id int) bool {
+		return filter(w.objects[id])
+	}
+}
+
+func sortByID(objects []*Object) {
+	sort.Slice(objects, func(i, j int) bool { return objects[i].ID < objects[j].ID })
+}
@@ -0,0 +1,207 @@
+// Package equipment lets characters wear and wield items from their inventory
+package equipment
+
+import (
+	"errors"
+
+	"github.com/Nathene/bitbase/entity"
+	"github.com/Nathene/bitbase/item"
+)
+
+// Slot names a place an item can be worn
+type Slot string
+
+const (
+	Head      Slot = "head"
+	Body      Slot = "body"
+	Weapon    Slot = "weapon"
+	Offhand   Slot = "offhand"
+	Accessory Slot = "accessory"
+)
+
+// Slots lists every equipment slot in the order their sprite layers are drawn
+var Slots = []Slot{Body, Head, Offhand, Weapon, Accessory}
+
+// allowedCategories says which kinds of item each slot accepts
+var allowedCategories = map[Slot][]item.Category{
+	Head:      {item.CategoryArmor},
+	Body:      {item.CategoryArmor},
+	Weapon:    {item.CategoryWeapon},
+	Offhand:   {item.CategoryWeapon, item.CategoryArmor},
+	Accessory: {item.CategoryAccessory},
+}
+
+// Reasons equipping can fail, wrapped in an *item.OpError
+var (
+	ErrUnknownSlot   = errors.New("unknown equipment slot")
+	ErrNotEquippable = errors.New("item can't be equipped")
+	ErrWrongSlot     = errors.New("item doesn't go in that slot")
+	ErrNothingWorn   = errors.New("nothing equipped in that slot")
+	ErrNoEquipment   = errors.New("object can't equip items")
+)
+
+// Equipment holds the items an object is wearing, keyed by slot
+type Equipment struct {
+	Items map[Slot]string `json:"items"`
+
+	layers []string
+}
+
+func init() {
+	entity.RegisterComponent("equipment", func() entity.Component { return &Equipment{} })
+}
+
+func (e *Equipment) ComponentName() string { return "equipment" }
+
+// EquipmentOf returns the object's equipment component, or nil
+func EquipmentOf(o *entity.Object) *Equipment {
+	e, _ := o.Component("equipment").(*Equipment)
+	return e
+}
+
+// Item returns the ID of the item in a slot, or ""
+func (e *Equipment) Item(slot Slot) string {
+	return e.Items[slot]
+}
+
+// SpriteLayers returns the layers of every equipped item that has one, bottom first
+func (e *Equipment) SpriteLayers() []string {
+	return e.layers
+}
+
+// Valid reports whether a slot name is known
+func Valid(slot Slot) bool {
+	_, ok := allowedCategories[slot]
+	return ok
+}
+
+// CanEquip checks whether an item may go in a slot. Items go in the slot their
+// definition names, except that weapons may also be held in the offhand.
+func CanEquip(def *item.Def, slot Slot) error {
+	if !Valid(slot) {
+		return &item.OpError{Op: "equip", ItemID: def.ID, Err: ErrUnknownSlot}
+	}
+	if def.Equip == nil {
+		return &item.OpError{Op: "equip", ItemID: def.ID, Err: ErrNotEquippable}
+	}
+
+	home := Slot(def.Equip.Slot)
+	if home != slot && !(slot == Offhand && home == Weapon) {
+		return &item.OpError{Op: "equip", ItemID: def.ID, Err: ErrWrongSlot}
+	}
+	for _, c := range allowedCategories[slot] {
+		if def.Category == c {
+			return nil
+		}
+	}
+	return &item.OpError{Op: "equip", ItemID: def.ID, Err: ErrWrongSlot}
+}
+
+// Equip moves one item from an inventory slot into an equipment slot, putting
+// whatever was there back in the inventory. An empty slot uses the item's own slot.
+func Equip(o *entity.Object, invSlot int, slot Slot) error {
+	e, inv := EquipmentOf(o), o.GetInventory()
+	if e == nil || inv == nil {
+		return &item.OpError{Op: "equip", Err: ErrNoEquipment}
+	}
+	stack := inv.Slot(invSlot)
+	if stack.Empty() {
+		return &item.OpError{Op: "equip", Err: item.ErrEmptySlot}
+	}
+	def := inv.Defs().Get(stack.ItemID)
+	if def == nil {
+		return &item.OpError{Op: "equip", ItemID: stack.ItemID, Err: item.ErrUnknownItem}
+	}
+	if slot == "" && def.Equip != nil {
+		slot = Slot(def.Equip.Slot)
+	}
+	if err := CanEquip(def, slot); err != nil {
+		return err
+	}
+
+	previous := e.Items[slot]
+	err := inv.Transact(func(tx *item.Inventory) error {
+		if _, err := tx.RemoveAt(invSlot, 1); err != nil {
+			return err
+		}
+		if previous == "" {
+			return nil
+		}
+		// Prefer the slot the new item came from so swapping feels like a swap
+		if tx.AddToSlot(invSlot, previous, 1) == nil {
+			return nil
+		}
+		return tx.Add(previous, 1)
+	})
+	if err != nil {
+		return err
+	}
+
+	if e.Items == nil {
+		e.Items = make(map[Slot]string)
+	}
+	e.Items[slot] = stack.ItemID
+	Refresh(o, inv.Defs())
+	return nil
+}
+
+// Unequip puts the item in a slot back into the inventory, at invSlot if it is
+// not negative or wherever it fits otherwise
+func Unequip(o *entity.Object, slot Slot, invSlot int) error {
+	e, inv := EquipmentOf(o), o.GetInventory()
+	if e == nil || inv == nil {
+		return &item.OpError{Op: "unequip", Err: ErrNoEquipment}
+	}
+	id := e.Items[slot]
+	if id == "" {
+		return &item.OpError{Op: "unequip", Err: ErrNothingWorn}
+	}
+
+	var err error
+	if invSlot >= 0 {
+		err = inv.AddToSlot(invSlot, id, 1)
+	} else {
+		err = inv.Add(id, 1)
+	}
+	if err != nil {
+		return err
+	}
+
+	delete(e.Items, slot)
+	Refresh(o, inv.Defs())
+	return nil
+}
+
+// Refresh reapplies the stat modifiers and sprite layers of everything an object
+// has equipped. Call it after changing Items directly, e.g. after spawning from a prefab.
+func Refresh(o *entity.Object, defs *item.Registry) {
+	e := EquipmentOf(o)
+	if e == nil {
+		return
+	}
+	st := entity.StatsOf(o)
+
+	e.layers = e.layers[:0]
+	for _, slot := range Slots {
+		var def *item.Def
+		if id := e.Items[slot]; id != "" {
+			def = defs.Get(id)
+		}
+
+		if st != nil {
+			if def != nil && def.Equip != nil {
+				st.SetModifiers(source(slot), def.Equip.Stats...)
+			} else {
+				st.RemoveSource(source(slot))
+			}
+		}
+		if def != nil && def.Equip != nil && def.Equip.Layer != "" {
+			e.layers = append(e.layers, def.Equip.Layer)
+		}
+	}
+}
+
+// source is the stat modifier source name for a slot
+func source(slot Slot) string {
+	return "equipment:" + string(slot)
+}
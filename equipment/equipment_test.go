@@ -0,0 +1,232 @@
+package equipment
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/Nathene/bitbase/entity"
+	"github.com/Nathene/bitbase/item"
+	"github.com/Nathene/bitbase/stats"
+)
+
+// testDefs returns a registry with something for every slot and a few things
+// that can't be worn
+func testDefs(t *testing.T) *item.Registry {
+	t.Helper()
+	r := item.NewRegistry()
+	for _, d := range []*item.Def{
+		{ID: "sword", Weight: 3, Category: item.CategoryWeapon, Equip: &item.EquipSpec{Slot: "weapon", Layer: "sword", Stats: []stats.Modifier{{Stat: stats.Attack, Op: stats.Add, Value: 5}}}},
+		{ID: "axe", Weight: 4, Category: item.CategoryWeapon, Equip: &item.EquipSpec{Slot: "weapon", Layer: "axe", Stats: []stats.Modifier{{Stat: stats.Attack, Op: stats.Add, Value: 8}}}},
+		{ID: "helm", Weight: 2, Category: item.CategoryArmor, Equip: &item.EquipSpec{Slot: "head", Layer: "helm", Stats: []stats.Modifier{{Stat: stats.Defense, Op: stats.Add, Value: 2}}}},
+		{ID: "shield", Weight: 3, Category: item.CategoryArmor, Equip: &item.EquipSpec{Slot: "offhand", Stats: []stats.Modifier{{Stat: stats.Defense, Op: stats.Multiply, Value: 2}}}},
+		{ID: "plate", Weight: 20, Category: item.CategoryArmor, Equip: &item.EquipSpec{Slot: "body", Layer: "plate"}},
+		{ID: "cloak", Weight: 1, Category: item.CategoryArmor, Equip: &item.EquipSpec{Slot: "body", Layer: "cloak"}},
+		{ID: "ring", MaxStack: 5, Category: item.CategoryAccessory, Equip: &item.EquipSpec{Slot: "accessory", Stats: []stats.Modifier{{Stat: stats.MoveSpeed, Op: stats.Percent, Value: 0.5}}}},
+		{ID: "amulet", Category: item.CategoryAccessory, Equip: &item.EquipSpec{Slot: "accessory", Stats: []stats.Modifier{{Stat: stats.MaxHealth, Op: stats.Add, Value: 10}}}},
+		{ID: "potion", MaxStack: 5, Category: item.CategoryConsumable},
+		{ID: "wand", Category: item.CategoryMisc, Equip: &item.EquipSpec{Slot: "weapon"}},
+	} {
+		if err := r.Add(d); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return r
+}
+
+// newWearer makes an object with equipment, stats and an inventory holding
+// the given slots, then puts on everything in worn
+func newWearer(t *testing.T, size int, maxWeight float64, worn map[Slot]string, slots ...item.Stack) *entity.Object {
+	t.Helper()
+	o := entity.NewObject("hero")
+	o.AddComponent(&Equipment{Items: worn})
+	o.AddComponent(stats.New(map[stats.Stat]float64{stats.Attack: 10, stats.Defense: 1, stats.MoveSpeed: 60, stats.MaxHealth: 20}))
+	inv := item.NewInventory(testDefs(t), size, maxWeight)
+	for i, s := range slots {
+		if s.Empty() {
+			continue
+		}
+		if err := inv.AddToSlot(i, s.ItemID, s.Count); err != nil {
+			t.Fatal(err)
+		}
+	}
+	o.SetInventory(inv)
+	Refresh(o, inv.Defs())
+	return o
+}
+
+// wantWorn fails the test unless the object is wearing exactly want, and its
+// inventory holds exactly slots
+func wantWorn(t *testing.T, o *entity.Object, want map[Slot]string, slots ...item.Stack) {
+	t.Helper()
+	for _, slot := range Slots {
+		if got := EquipmentOf(o).Item(slot); got != want[slot] {
+			t.Errorf("%s holds %q, want %q", slot, got, want[slot])
+		}
+	}
+	padded := make([]item.Stack, o.GetInventory().Size())
+	copy(padded, slots)
+	if got := o.GetInventory().Slots(); !slices.Equal(got, padded) {
+		t.Errorf("inventory %v, want %v", got, padded)
+	}
+}
+
+func wantStat(t *testing.T, o *entity.Object, stat stats.Stat, want float64) {
+	t.Helper()
+	if got := entity.StatsOf(o).Get(stat); got != want {
+		t.Errorf("%s is %v, want %v", stat, got, want)
+	}
+}
+
+func TestCanEquip(t *testing.T) {
+	defs := testDefs(t)
+	tests := []struct {
+		item string
+		slot Slot
+		want error
+	}{
+		{"sword", Weapon, nil},
+		{"sword", Offhand, nil},
+		{"shield", Offhand, nil},
+		{"ring", Accessory, nil},
+		{"helm", Head, nil},
+		{"helm", Offhand, ErrWrongSlot},
+		{"shield", Weapon, ErrWrongSlot},
+		{"plate", Head, ErrWrongSlot},
+		{"wand", Weapon, ErrWrongSlot}, // Not a weapon, whatever its equip slot says
+		{"potion", Weapon, ErrNotEquippable},
+		{"sword", "belt", ErrUnknownSlot},
+	}
+	for _, tt := range tests {
+		err := CanEquip(defs.Get(tt.item), tt.slot)
+		if !errors.Is(err, tt.want) {
+			t.Errorf("%s in %s: got %v, want %v", tt.item, tt.slot, err, tt.want)
+		}
+	}
+}
+
+func TestEquip(t *testing.T) {
+	o := newWearer(t, 5, 0, nil,
+		item.Stack{ItemID: "sword", Count: 1},
+		item.Stack{ItemID: "axe", Count: 1},
+		item.Stack{ItemID: "helm", Count: 1},
+		item.Stack{ItemID: "potion", Count: 2},
+	)
+	e := EquipmentOf(o)
+
+	if err := Equip(o, 0, ""); err != nil {
+		t.Fatal(err)
+	}
+	wantWorn(t, o, map[Slot]string{Weapon: "sword"}, item.Stack{}, item.Stack{ItemID: "axe", Count: 1}, item.Stack{ItemID: "helm", Count: 1}, item.Stack{ItemID: "potion", Count: 2})
+	wantStat(t, o, stats.Attack, 15)
+
+	// Swapping puts the old weapon where the new one came from
+	if err := Equip(o, 1, ""); err != nil {
+		t.Fatal(err)
+	}
+	wantWorn(t, o, map[Slot]string{Weapon: "axe"}, item.Stack{}, item.Stack{ItemID: "sword", Count: 1}, item.Stack{ItemID: "helm", Count: 1}, item.Stack{ItemID: "potion", Count: 2})
+	wantStat(t, o, stats.Attack, 18)
+
+	if err := Equip(o, 1, Offhand); err != nil {
+		t.Fatal(err)
+	}
+	if err := Equip(o, 2, ""); err != nil {
+		t.Fatal(err)
+	}
+	wantWorn(t, o, map[Slot]string{Weapon: "axe", Offhand: "sword", Head: "helm"}, item.Stack{}, item.Stack{}, item.Stack{}, item.Stack{ItemID: "potion", Count: 2})
+	wantStat(t, o, stats.Attack, 23)
+	wantStat(t, o, stats.Defense, 3)
+	if got, want := e.SpriteLayers(), []string{"helm", "sword", "axe"}; !slices.Equal(got, want) {
+		t.Errorf("layers %v, want %v", got, want)
+	}
+
+	// Failures change nothing
+	for _, tt := range []struct {
+		slot int
+		to   Slot
+		want error
+	}{
+		{slot: 3, to: Weapon, want: ErrNotEquippable},
+		{slot: 0, want: item.ErrEmptySlot},
+		{slot: 9, want: item.ErrEmptySlot},
+	} {
+		err := Equip(o, tt.slot, tt.to)
+		var opErr *item.OpError
+		if !errors.As(err, &opErr) || !errors.Is(err, tt.want) {
+			t.Errorf("equipping slot %d: got %v, want %v", tt.slot, err, tt.want)
+		}
+	}
+	wantWorn(t, o, map[Slot]string{Weapon: "axe", Offhand: "sword", Head: "helm"}, item.Stack{}, item.Stack{}, item.Stack{}, item.Stack{ItemID: "potion", Count: 2})
+
+	// Unequipping goes to the first free slot, or to the one asked for
+	if err := Unequip(o, Offhand, -1); err != nil {
+		t.Fatal(err)
+	}
+	if err := Unequip(o, Head, 2); err != nil {
+		t.Fatal(err)
+	}
+	wantWorn(t, o, map[Slot]string{Weapon: "axe"}, item.Stack{ItemID: "sword", Count: 1}, item.Stack{}, item.Stack{ItemID: "helm", Count: 1}, item.Stack{ItemID: "potion", Count: 2})
+	wantStat(t, o, stats.Attack, 18)
+	wantStat(t, o, stats.Defense, 1)
+	if got, want := e.SpriteLayers(), []string{"axe"}; !slices.Equal(got, want) {
+		t.Errorf("layers %v, want %v", got, want)
+	}
+	if got, want := entity.StatsOf(o).Sources(), []string{"equipment:weapon"}; !slices.Equal(got, want) {
+		t.Errorf("stat sources %v, want %v", got, want)
+	}
+
+	if err := Unequip(o, Offhand, -1); !errors.Is(err, ErrNothingWorn) {
+		t.Errorf("unequipping an empty slot: got %v", err)
+	}
+	if err := Unequip(o, Weapon, 3); !errors.Is(err, item.ErrItemMismatch) {
+		t.Errorf("unequipping onto the potions: got %v", err)
+	}
+	wantWorn(t, o, map[Slot]string{Weapon: "axe"}, item.Stack{ItemID: "sword", Count: 1}, item.Stack{}, item.Stack{ItemID: "helm", Count: 1}, item.Stack{ItemID: "potion", Count: 2})
+	wantStat(t, o, stats.Attack, 18)
+
+	bare := entity.NewObject("rock")
+	if err := Equip(bare, 0, ""); !errors.Is(err, ErrNoEquipment) {
+		t.Errorf("equipping something without equipment: got %v", err)
+	}
+}
+
+func TestEquipTransaction(t *testing.T) {
+	rings := item.Stack{ItemID: "ring", Count: 3}
+	potions := item.Stack{ItemID: "potion", Count: 5}
+
+	// One ring off a stack of three, with nowhere to put the amulet it replaces
+	o := newWearer(t, 2, 0, map[Slot]string{Accessory: "amulet"}, rings, potions)
+	if err := Equip(o, 0, ""); !errors.Is(err, item.ErrNoSpace) {
+		t.Errorf("swapping into a full inventory: got %v", err)
+	}
+	wantWorn(t, o, map[Slot]string{Accessory: "amulet"}, rings, potions)
+	wantStat(t, o, stats.MaxHealth, 30)
+	wantStat(t, o, stats.MoveSpeed, 60)
+
+	// With room the amulet goes in the free slot instead
+	o = newWearer(t, 3, 0, map[Slot]string{Accessory: "amulet"}, rings, potions)
+	if err := Equip(o, 0, ""); err != nil {
+		t.Fatal(err)
+	}
+	wantWorn(t, o, map[Slot]string{Accessory: "ring"}, item.Stack{ItemID: "ring", Count: 2}, potions, item.Stack{ItemID: "amulet", Count: 1})
+	wantStat(t, o, stats.MaxHealth, 20)
+	wantStat(t, o, stats.MoveSpeed, 90)
+
+	// Taking off heavy armour for a cloak would overload the bag
+	o = newWearer(t, 3, 10, map[Slot]string{Body: "plate"}, item.Stack{ItemID: "cloak", Count: 1})
+	if err := Equip(o, 0, ""); !errors.Is(err, item.ErrOverweight) {
+		t.Errorf("swapping for something too heavy to carry: got %v", err)
+	}
+	wantWorn(t, o, map[Slot]string{Body: "plate"}, item.Stack{ItemID: "cloak", Count: 1})
+	if got, want := EquipmentOf(o).SpriteLayers(), []string{"plate"}; !slices.Equal(got, want) {
+		t.Errorf("layers %v, want %v", got, want)
+	}
+
+	// Unequipping into a full inventory keeps the item on
+	o = newWearer(t, 1, 0, map[Slot]string{Accessory: "amulet"}, potions)
+	if err := Unequip(o, Accessory, -1); !errors.Is(err, item.ErrNoSpace) {
+		t.Errorf("unequipping into a full inventory: got %v", err)
+	}
+	wantWorn(t, o, map[Slot]string{Accessory: "amulet"}, potions)
+	wantStat(t, o, stats.MaxHealth, 30)
+}
@@ -0,0 +1,42 @@
+package game
+
+import (
+	"github.com/Nathene/bitbase/effect"
+	"github.com/Nathene/bitbase/entity"
+)
+
+// aiHost lets behaviour trees act through the game's physics, combat and projectiles
+type aiHost struct {
+	g *Game
+}
+
+func (h aiHost) Move(o *entity.Object, dx, dy float64) (float64, float64) {
+	if effect.Blocked(o, effect.Move) {
+		return 0, 0
+	}
+	speed := h.g.TerrainUnder(o).Speed
+	return h.g.Physics.Move(o, dx*speed, dy*speed)
+}
+
+func (h aiHost) Walkable(x, y float64) bool {
+	p := h.g.tileAt(x, y)
+	return !h.g.isSolidTile(p.X, p.Y)
+}
+
+func (h aiHost) Attack(o *entity.Object) bool {
+	if effect.Blocked(o, effect.Attack) {
+		return false
+	}
+	return h.g.Combat.Attack(o)
+}
+
+func (h aiHost) Shoot(o *entity.Object, dx, dy float64) bool {
+	if effect.Blocked(o, effect.Shoot) {
+		return false
+	}
+	return h.g.Projectiles.Shoot(o, dx, dy)
+}
+
+func (h aiHost) FindPath(fromX, fromY, toX, toY float64) ([][2]float64, bool) {
+	return h.g.FindPath(fromX, fromY, toX, toY)
+}
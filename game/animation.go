@@ -0,0 +1,40 @@
+package game
+
+import (
+	"log"
+
+	"github.com/Nathene/bitbase/anim"
+	"github.com/Nathene/bitbase/entity"
+)
+
+// OnAnimationEvent registers a function called for every animation event, such as
+// footsteps or the hit frame of an attack
+func (g *Game) OnAnimationEvent(fn func(o *entity.Object, e anim.Event)) {
+	g.animationEvents = append(g.animationEvents, fn)
+}
+
+// bindAnimator connects an object's animator to its animation set
+func (g *Game) bindAnimator(o *entity.Object) {
+	animator := anim.AnimatorOf(o)
+	if animator == nil {
+		return
+	}
+	if err := animator.BindFrom(g.Animations); err != nil {
+		log.Printf("Failed to bind animator for %s: %v", o.Name, err)
+	}
+}
+
+// updateAnimations advances every animator in the world and dispatches their events
+func (g *Game) updateAnimations(dt float64) {
+	for _, o := range g.Entities.All() {
+		animator := anim.AnimatorOf(o)
+		if animator == nil {
+			continue
+		}
+		for _, e := range animator.Update(dt) {
+			for _, fn := range g.animationEvents {
+				fn(o, e)
+			}
+		}
+	}
+}
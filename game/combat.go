@@ -0,0 +1,93 @@
+package game
+
+import (
+	"fmt"
+	"image/color"
+
+	"github.com/Nathene/bitbase/anim"
+	"github.com/Nathene/bitbase/combat"
+	"github.com/Nathene/bitbase/common"
+	"github.com/Nathene/bitbase/input"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+const (
+	healthBarWidth  = 32
+	healthBarHeight = 4
+)
+
+// onCombatEvent tells the player about things that happen to them
+func (g *Game) onCombatEvent(e combat.Event) {
+	if e.Target != &g.Player.Object {
+		return
+	}
+	switch e.Kind {
+	case combat.EventDeath:
+		g.ShowMessage("You have fallen...")
+	case combat.EventRespawn:
+		g.ShowMessage("You wake up back where you started.")
+	}
+}
+
+// shoot fires the player's ranged attack at the mouse pointer, or the way they're facing
+func (g *Game) shoot(in input.State) {
+	p := &g.Player.Object
+	aimX, aimY := 0.0, 1.0
+	if animator := anim.AnimatorOf(p); animator != nil {
+		aimX, aimY = animator.Direction().Vector()
+	}
+	if in.AimAtPointer {
+		cx, cy := p.Bounds().Center()
+		x, y := g.pointerToWorld(in)
+		aimX, aimY = x-cx, y-cy
+	}
+	g.Projectiles.Shoot(p, aimX, aimY)
+}
+
+// drawHealthBars draws a bar over every hurt object in view
+func (g *Game) drawHealthBars(screen *ebiten.Image, camera common.Camera, alpha float64) {
+	view := camera.View(ScreenWidth, ScreenHeight)
+	for _, o := range g.Entities.QueryRect(view) {
+		h := combat.HealthOf(o)
+		if h == nil || o == &g.Player.Object || combat.IsDead(o) {
+			continue
+		}
+		maxHealth := combat.MaxHealth(o)
+		if maxHealth <= 0 || h.Current >= maxHealth {
+			continue
+		}
+
+		x, y := o.LerpPosition(alpha)
+		b := o.Bounds()
+		sx := x + (b.X - o.GetX()) + b.W/2 - healthBarWidth/2 - camera.X
+		sy := y + (b.Y - o.GetY()) - healthBarHeight - 4 - camera.Y
+		drawBar(screen, sx, sy, healthBarWidth, healthBarHeight, h.Current/maxHealth)
+	}
+}
+
+// drawPlayerHealth draws the player's health in the top-left corner
+func (g *Game) drawPlayerHealth(screen *ebiten.Image) {
+	h := combat.HealthOf(&g.Player.Object)
+	if h == nil {
+		return
+	}
+	maxHealth := combat.MaxHealth(&g.Player.Object)
+	if maxHealth <= 0 {
+		return
+	}
+	drawBar(screen, 8, 24, 200, 12, h.Current/maxHealth)
+	ebitenutil.DebugPrintAt(screen, fmt.Sprintf("%.0f / %.0f", h.Current, maxHealth), 216, 22)
+}
+
+func drawBar(screen *ebiten.Image, x, y, w, h, fraction float64) {
+	fraction = min(max(fraction, 0), 1)
+	vector.DrawFilledRect(screen, float32(x), float32(y), float32(w), float32(h), color.RGBA{40, 0, 0, 200}, false)
+	vector.DrawFilledRect(screen, float32(x), float32(y), float32(w*fraction), float32(h), color.RGBA{200, 40, 40, 255}, false)
+}
+
+// playerVisible is false on alternate flashes while the player is invulnerable after a hit
+func (g *Game) playerVisible() bool {
+	return !combat.IsInvulnerable(&g.Player.Object) || int(g.Combat.Time()*15)%2 == 0
+}
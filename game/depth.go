@@ -0,0 +1,56 @@
+package game
+
+import (
+	"slices"
+
+	"github.com/Nathene/bitbase/common"
+	"github.com/Nathene/bitbase/entity"
+	"github.com/Nathene/bitbase/tilemap/render"
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// depthItem is one thing drawn in depth order: an entity or a decoration tile
+type depthItem struct {
+	depth  float64
+	object *entity.Object // nil for a tile
+	tile   render.SortedTile
+}
+
+// drawSorted draws the entities and decoration tiles back to front by where
+// their feet are, so the player can walk behind a tree trunk or a wall and in
+// front of it. Tiles come first when level with an entity.
+func (g *Game) drawSorted(dst *ebiten.Image, camera common.Camera, alpha, playerX, playerY float64) {
+	g.sortedTiles = g.Tiles.AppendSorted(g.sortedTiles[:0], dst, camera)
+	items := g.depthItems[:0]
+	for _, t := range g.sortedTiles {
+		items = append(items, depthItem{depth: t.Depth, tile: t})
+	}
+	for _, o := range g.Entities.InView(camera, float64(dst.Bounds().Dx()), float64(dst.Bounds().Dy())) {
+		items = append(items, depthItem{depth: o.Depth(alpha), object: o})
+	}
+	slices.SortStableFunc(items, func(a, b depthItem) int {
+		switch {
+		case a.depth < b.depth:
+			return -1
+		case a.depth > b.depth:
+			return 1
+		case a.object == nil && b.object != nil:
+			return -1
+		case a.object != nil && b.object == nil:
+			return 1
+		}
+		return 0
+	})
+
+	for _, item := range items {
+		switch {
+		case item.object == nil:
+			g.Tiles.DrawSorted(dst, item.tile, camera)
+		case item.object == &g.Player.Object:
+			g.drawPlayer(dst, camera, playerX, playerY)
+		default:
+			item.object.DrawLerped(dst, camera, alpha)
+		}
+	}
+	g.depthItems = items
+}
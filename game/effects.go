@@ -0,0 +1,73 @@
+package game
+
+import (
+	"fmt"
+	"image/color"
+	"strings"
+
+	"github.com/Nathene/bitbase/effect"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+const (
+	effectIconSize = 24
+	effectIconGap  = 4
+)
+
+// canAct reports whether the player is free to do something, not dead or stunned
+func (g *Game) canAct(action effect.Action) bool {
+	return !effect.Blocked(&g.Player.Object, action)
+}
+
+// drawEffectIcons draws the player's active effects under their health bar, with
+// stack counts and a bar showing how much time is left
+func (g *Game) drawEffectIcons(screen *ebiten.Image) {
+	e := effect.EffectsOf(&g.Player.Object)
+	if e == nil {
+		return
+	}
+
+	x, y := 8.0, 44.0
+	for _, a := range e.Active {
+		spec := a.Spec()
+		if spec == nil {
+			continue
+		}
+
+		var icon *ebiten.Image
+		if spec.Icon != "" && g.Entities.Images != nil {
+			icon = g.Entities.Images(spec.Icon)
+		}
+		if icon != nil {
+			opts := &ebiten.DrawImageOptions{}
+			b := icon.Bounds()
+			opts.GeoM.Scale(effectIconSize/float64(b.Dx()), effectIconSize/float64(b.Dy()))
+			opts.GeoM.Translate(x, y)
+			screen.DrawImage(icon, opts)
+		} else {
+			vector.DrawFilledRect(screen, float32(x), float32(y), effectIconSize, effectIconSize, effectColor(spec), false)
+		}
+
+		border := color.RGBA{80, 200, 80, 255}
+		if spec.Debuff {
+			border = color.RGBA{220, 60, 60, 255}
+		}
+		vector.StrokeRect(screen, float32(x), float32(y), effectIconSize, effectIconSize, 2, border, false)
+		if a.Stacks > 1 {
+			ebitenutil.DebugPrintAt(screen, fmt.Sprint(a.Stacks), int(x)+effectIconSize-8, int(y)+effectIconSize-16)
+		}
+		drawBar(screen, x, y+effectIconSize+2, effectIconSize, 3, a.Remaining/spec.Duration)
+
+		x += effectIconSize + effectIconGap
+	}
+}
+
+func effectColor(spec *effect.Spec) color.RGBA {
+	var r, g, b uint8
+	if _, err := fmt.Sscanf(strings.TrimPrefix(spec.Color, "#"), "%02x%02x%02x", &r, &g, &b); err != nil {
+		return color.RGBA{160, 160, 160, 255}
+	}
+	return color.RGBA{r, g, b, 255}
+}
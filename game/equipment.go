@@ -0,0 +1,27 @@
+package game
+
+import (
+	"image"
+
+	"github.com/Nathene/bitbase/entity"
+	"github.com/Nathene/bitbase/equipment"
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// refreshEquipment applies the stats and sprite layers of anything an object spawned wearing
+func (g *Game) refreshEquipment(o *entity.Object) {
+	equipment.Refresh(o, g.Items)
+}
+
+// drawSpriteLayers draws an object's layers (worn equipment and so on) over a frame
+// already drawn with opts. Layer sheets share the layout of the base sheet.
+func (g *Game) drawSpriteLayers(screen *ebiten.Image, o *entity.Object, src image.Rectangle, opts *ebiten.DrawImageOptions) {
+	if g.Entities.Images == nil {
+		return
+	}
+	for _, id := range o.SpriteLayers() {
+		if layer := g.Entities.Images(id); layer != nil {
+			screen.DrawImage(layer.SubImage(src).(*ebiten.Image), opts)
+		}
+	}
+}
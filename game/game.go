@@ -5,9 +5,25 @@ import (
 	"image"
 	"image/color"
 	"log"
+	"math"
+	"time"
 
+	"github.com/Nathene/bitbase/ai"
+	"github.com/Nathene/bitbase/anim"
+	"github.com/Nathene/bitbase/combat"
 	"github.com/Nathene/bitbase/common"
+	"github.com/Nathene/bitbase/effect"
+	"github.com/Nathene/bitbase/entity"
 	"github.com/Nathene/bitbase/entity/player"
+	"github.com/Nathene/bitbase/input"
+	"github.com/Nathene/bitbase/item"
+	"github.com/Nathene/bitbase/pathfind"
+	"github.com/Nathene/bitbase/physics"
+	"github.com/Nathene/bitbase/projectile"
+	"github.com/Nathene/bitbase/stats"
+	"github.com/Nathene/bitbase/tilemap"
+	"github.com/Nathene/bitbase/tilemap/render"
+	"github.com/Nathene/bitbase/trigger"
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
 	"github.com/hajimehoshi/ebiten/v2/vector"
@@ -18,238 +34,400 @@ const (
 	ScreenWidth  = 1920
 	ScreenHeight = 1080
 
-	tileSize = 32
-	tilesX   = 100
-	tilesY   = 100
+	// SimulationRate is how many fixed simulation steps run per second
+	SimulationRate = 60
+
+	tileSize = 32 // Tile size of the fallback map used when the world map won't load
 
-	playerWidth    = tileSize
-	playerHeight   = tileSize
 	maxTrailLength = 20
 
-	playerSheetStartX = 43  // Note 2: Assumed 0, adjust if first frame has left padding
-	playerSheetStartY = 23  // Note 2: Assumed 0, adjust if first frame has top padding
-	playerFrameWidth  = 11  // Your measurement: Width of a single frame
-	playerFrameHeight = 16  // Your measurement: Height of a single frame
-	playerFrameStepX  = 96  // Your measurement: Horizontal distance between frame starts
-	playerFrameCount  = 9   // Note 3: Make sure this matches the actual number of frames!
-	playerAnimSpeed   = 0.1 // Adjust for desired animation speed (lower = faster)
-	playerDrawScale   = 3.0
-)
+	playerDrawScale = 3.0
+	runMultiplier   = 1.75 // Speed multiplier while Shift is held
 
-type Tile struct {
-	X, Y  int
-	Color color.RGBA
-}
+	defaultPlayerSpeed = 240 // Pixels per second, if the player prefab has no stats
 
-type TileProperty struct {
-	IsWall bool
-}
+	prefabDir     = "assets/prefabs"
+	itemDir       = "assets/items"
+	animationDir  = "assets/animations"
+	projectileDir = "assets/projectiles"
+	aiDir         = "assets/ai"
+	effectDir     = "assets/effects"
+	worldMapPath  = "assets/maps/world.tmx"
+	worldGenPath  = "assets/maps/generated.json"
+)
 
-type Game struct {
-	Tiles    []Tile
-	Player   player.Player
-	Camera   common.Camera
-	WorldMap [][]TileProperty
+// WorldSeed, if not zero, makes NewGame generate the world from this seed
+// instead of loading the hand-made map
+var WorldSeed int64
 
-	PlayerSheet     *ebiten.Image
-	BackgroundImage *ebiten.Image
+type Game struct {
+	Player player.Player
+	Camera common.Camera
+	// What Draw last put on screen: Camera centred on the interpolated player.
+	// Pointer positions are converted through it so clicks land where they look.
+	shownCamera *common.Camera
+	Map         *tilemap.Map
+	World       *tilemap.World // Map's tile layers, streamed in chunks around the camera
+	// Terrains kept joined up with their neighbours as World changes, by name
+	Autotiles map[string]*tilemap.Autotile
+	mapPath   string               // Current map's file, which keys maps
+	maps      map[string]*mapState // Maps visited and left, by file
+	trip      *trip                // Set by Travel until FinishTravel
+	inPortal  *tilemap.Object      // Portal the player's feet were in last step
+	// Changed tiles of maps not visited yet, restored from a save
+	pendingTiles map[string][]tilemap.TileChange
+	tileHits     map[tileCell]int // Hits taken by breakable tiles that haven't broken yet
+
+	Prefabs  *entity.PrefabLibrary
+	Entities *entity.World
+	Spawner  *entity.Spawner
+	Physics  *physics.Space
+	Paths    *pathfind.Cache // Invalidated whenever World changes
+	pathGrid *worldGrid      // The window of World that Paths searches
+	Combat   *combat.System
+	Effects  *effect.System
+
+	Projectiles *projectile.System
+	AI          *ai.System
+	Triggers    *trigger.System // Zones from the current map
+
+	Animations      *anim.Library
+	Items           *item.Registry
+	animationEvents []func(o *entity.Object, e anim.Event)
+	footsteps       []func(o *entity.Object, sound string)
+	sounds          []func(sound string, x, y float64)
+	cutscenes       []func(name string)
+	quests          map[string]int // Stage of each quest that has started
+
+	Clock          *common.FixedClock
+	lastUpdate     time.Time
+	pendingPresses input.State
+	InputBlocked   bool // Set while an overlay like the inventory has focus
+	DebugPaths     bool // Draw the paths NPCs are following
+
+	clickMove      clickMove
+	slide          [2]float64 // Player velocity carried over on slippery ground, pixels per second
+	hazardTimer    float64
+	interactTarget *entity.Object // What pressing E would use right now
+	message        string
+	messageTimer   float64
+	dialog         []string // Lines waiting to be shown after the current message
+
+	Tiles       *render.Renderer // Draws the tile map
+	sortedTiles []render.SortedTile
+	depthItems  []depthItem
+	PlayerSheet *ebiten.Image
+	view        *ebiten.Image // The world before it is zoomed onto the screen
 }
 
 // NewGame creates a new game instance with initialized components
-func NewGame(playerSheet, backgroundImage *ebiten.Image) *Game {
-	tiles := make([]Tile, 0)
-
-	for x := 0; x < tilesX; x++ {
-		for y := 0; y < tilesY; y++ {
-			tileColor := color.RGBA{50, 50, 50, 255}
-			if (x+y)%2 == 0 {
-				tileColor = color.RGBA{70, 70, 70, 255}
-			}
-			tiles = append(tiles, Tile{X: x, Y: y, Color: tileColor})
-		}
-	}
+func NewGame(playerSheet *ebiten.Image) *Game {
+	worldMap := loadWorld()
 
-	worldMap := make([][]TileProperty, tilesY)
+	prefabs := entity.NewPrefabLibrary()
+	if err := prefabs.LoadDir(prefabDir); err != nil {
+		log.Printf("Failed to load prefabs: %v", err)
+	}
+	entities := entity.NewWorld()
 
-	for row := range worldMap {
-		worldMap[row] = make([]TileProperty, tilesX)
-		for col := range worldMap[row] {
-			if row == 0 || row == tilesY-1 || col == 0 || col == tilesX-1 {
-				worldMap[row][col] = TileProperty{IsWall: true}
-			}
-		}
+	items := item.NewRegistry()
+	if err := items.LoadDir(itemDir); err != nil {
+		log.Printf("Failed to load items: %v", err)
 	}
 
-	p := player.Player{}
-	p.SetX(1000)
-	p.SetY(1000)
-	p.Speed = 4
-	p.SetInventory(player.NewInventory())
-
-	return &Game{
-		Tiles:           tiles,
-		Player:          p,
-		Camera:          common.Camera{},
-		WorldMap:        worldMap,
-		PlayerSheet:     playerSheet,
-		BackgroundImage: backgroundImage,
+	projectiles := projectile.NewLibrary()
+	if err := projectiles.LoadDir(projectileDir); err != nil {
+		log.Printf("Failed to load projectiles: %v", err)
 	}
-}
 
-func (g *Game) Update() error {
-	var dx, dy float64
-	if ebiten.IsKeyPressed(ebiten.KeyW) || ebiten.IsKeyPressed(ebiten.KeyUp) {
-		dy -= g.Player.Speed
+	effects := effect.NewLibrary()
+	if err := effects.LoadDir(effectDir); err != nil {
+		log.Printf("Failed to load effects: %v", err)
 	}
-	if ebiten.IsKeyPressed(ebiten.KeyS) || ebiten.IsKeyPressed(ebiten.KeyDown) {
-		dy += g.Player.Speed
+
+	trees := ai.NewLibrary()
+	if err := trees.LoadDir(aiDir); err != nil {
+		log.Printf("Failed to load behaviour trees: %v", err)
 	}
-	if ebiten.IsKeyPressed(ebiten.KeyA) || ebiten.IsKeyPressed(ebiten.KeyLeft) {
-		dx -= g.Player.Speed
+
+	animations := anim.NewLibrary()
+	if err := animations.LoadDir(animationDir); err != nil {
+		log.Printf("Failed to load animations: %v", err)
 	}
-	if ebiten.IsKeyPressed(ebiten.KeyD) || ebiten.IsKeyPressed(ebiten.KeyRight) {
-		dx += g.Player.Speed
+
+	g := &Game{
+		Camera:       common.Camera{},
+		Map:          worldMap,
+		mapPath:      worldMap.Path,
+		maps:         make(map[string]*mapState),
+		pendingTiles: make(map[string][]tilemap.TileChange),
+		tileHits:     make(map[tileCell]int),
+		quests:       make(map[string]int),
+		World:        tilemap.NewWorld(worldMap, nil),
+		Prefabs:      prefabs,
+		Entities:     entities,
+		Spawner:      entity.NewSpawner(prefabs, entities),
+		Animations:   animations,
+		Items:        items,
+		Clock:        common.NewFixedClock(SimulationRate),
+		PlayerSheet:  playerSheet,
 	}
 
-	playerMoved := dx != 0 || dy != 0
+	g.Tiles = render.New(g.World)
+	g.watchAutotiles()
+	g.Physics = physics.NewSpace(entities, float64(g.Map.TileWidth), g.isSolidTile)
+	g.pathGrid = &worldGrid{g: g}
+	g.Paths = pathfind.NewCache(g.pathGrid, pathfind.Options{Diagonal: true})
+	g.watchTiles()
+	g.Spawner.OnSpawn(g.bindAnimator)
+	g.Spawner.OnSpawn(g.refreshEquipment)
+	g.Combat = combat.NewSystem(entities, g.Physics, g.Spawner)
+	g.Combat.Log.OnEvent(g.onCombatEvent)
+	g.Combat.OnSwing(g.hitTiles)
+	g.Spawner.OnSpawn(g.Combat.Prepare)
+	g.Effects = effect.NewSystem(effects, entities, g.Combat)
+	g.OnAnimationEvent(g.Combat.OnAnimationEvent)
+	g.OnAnimationEvent(g.onAnimationFootstep)
+	g.Projectiles = projectile.NewSystem(projectiles, entities, g.Physics, g.Combat)
+	g.AI = ai.NewSystem(entities, aiHost{g}, trees)
+	g.Spawner.OnSpawn(g.AI.Prepare)
+	g.Triggers = trigger.NewSystem(entities, g)
+
+	g.Player.Name = "player"
+	g.Player.SetPosition(g.playerStartPosition())
+	if err := prefabs.Apply(&g.Player.Object, "player", nil); err != nil {
+		log.Printf("Failed to apply player prefab: %v", err)
+	}
+	if entity.StatsOf(&g.Player.Object) == nil {
+		g.Player.AddComponent(stats.New(map[stats.Stat]float64{stats.MoveSpeed: defaultPlayerSpeed}))
+	}
+	g.Player.SetInventory(player.NewInventory(items))
+	entities.Add(&g.Player.Object)
+	g.bindAnimator(&g.Player.Object)
+	g.refreshEquipment(&g.Player.Object)
+	g.Combat.Prepare(&g.Player.Object)
 
-	playerX, playerY := g.Player.GetX(), g.Player.GetY()
+	g.spawnMapObjects()
+	g.loadTriggers()
 
-	// --- COLLISION DETECTION ---
-	nextX := playerX + dx
-	nextY := playerY + dy
+	return g
+}
 
-	if dx != 0 {
-		if !g.collidesWithWorld(nextX, playerY) {
-			g.Player.SetX(nextX)
-		}
+func (g *Game) Update() error {
+	// Measure real time since the last frame and let the clock turn it into fixed steps
+	now := time.Now()
+	elapsed := 1.0 / SimulationRate
+	if !g.lastUpdate.IsZero() {
+		elapsed = now.Sub(g.lastUpdate).Seconds()
 	}
+	g.lastUpdate = now
 
-	if dy != 0 {
-		if !g.collidesWithWorld(playerX, nextY) {
-			g.Player.SetY(nextY)
-		}
+	in := input.Poll()
+	if g.InputBlocked {
+		in = input.State{} // A UI overlay owns the keyboard
 	}
-	if playerMoved {
-		g.Player.AnimFrame = 0 // Reset frame when moving (adjust if needed)
-		g.Player.AnimTimer = 0
-	} else { // When IDLE (not moving)
-		// --- Animate the idle state using stable ActualTPS ---
-
-		// Calculate time elapsed since last Update tick using ActualTPS
-		var deltaT float64
-		actualTps := ebiten.ActualTPS()
-		if actualTps > 0 { // Avoid division by zero if TPS is somehow zero
-			deltaT = 1.0 / actualTps
-		} else {
-			// Fallback or assume target TPS if ActualTPS is zero (shouldn't happen often)
-			deltaT = 1.0 / 60.0 // Assuming target is 60 if unavailable
-		}
+	g.Advance(elapsed, in)
 
-		g.Player.AnimTimer += deltaT // Increment timer by calculated delta time
+	return nil
+}
 
-		if g.Player.AnimTimer >= playerAnimSpeed {
-			g.Player.AnimTimer -= playerAnimSpeed // Reset timer partially
-			g.Player.AnimFrame++
-			if g.Player.AnimFrame >= playerFrameCount { // Frame count is 9
-				g.Player.AnimFrame = 0 // Loop the idle animation
-			}
-		}
+// Advance runs as many fixed simulation steps as fit in elapsed seconds of real time
+func (g *Game) Advance(elapsed float64, in input.State) {
+	in = in.WithPresses(g.pendingPresses)
+	steps := g.Clock.Advance(elapsed)
+	if steps == 0 {
+		// Hold on to presses until a step actually runs
+		g.pendingPresses = in
+		return
 	}
-	g.Camera.X = g.Player.GetX() - ScreenWidth/2
-	g.Camera.Y = g.Player.GetY() - ScreenHeight/2
+	g.pendingPresses = input.State{}
 
-	if ebiten.IsKeyPressed(ebiten.KeyTab) {
-		g.Player.ShowInventory = !g.Player.ShowInventory
+	for i := 0; i < steps; i++ {
+		g.Step(in)
+		in = in.Held()
 	}
+}
 
-	return nil
+// Simulate runs the game for the given number of seconds as if frames were
+// arriving at frameRate, holding the same input throughout. Because the
+// simulation uses a fixed step, the outcome doesn't depend on frameRate.
+func (g *Game) Simulate(seconds, frameRate float64, in input.State) {
+	frames := int(math.Round(seconds * frameRate))
+	for i := 0; i < frames; i++ {
+		g.Advance(1/frameRate, in)
+	}
 }
 
-func (g *Game) collidesWithWorld(checkX, checkY float64) bool {
-	minX := checkX
-	maxX := checkX + playerWidth
-	minY := checkY
-	maxY := checkY + playerHeight
+// Step advances the simulation by exactly one fixed time step
+func (g *Game) Step(in input.State) {
+	dt := g.Clock.Step
+	g.Entities.SnapshotPositions()
 
-	minTileX := int(minX / tileSize)
-	maxTileX := int((maxX - 1) / tileSize)
-	minTileY := int(minY / tileSize)
-	maxTileY := int((maxY - 1) / tileSize)
+	// Speeds are in pixels per second
+	speed := entity.MoveSpeed(&g.Player.Object)
+	if in.Run {
+		speed *= runMultiplier
+	}
+	dirX, dirY := in.Direction()
+	dx, dy := dirX*speed*dt, dirY*speed*dt
+	switch {
+	case in.Moving():
+		g.CancelMove() // Manual movement always wins
+	case in.MoveTo:
+		g.MoveTo(g.pointerToWorld(in))
+	}
+	if !in.Moving() {
+		dx, dy = g.clickMoveStep(speed * dt)
+	}
+	dx, dy = g.terrainMove(dx, dy, dt)
+	if combat.IsDead(&g.Player.Object) || !g.canAct(effect.Move) {
+		dx, dy = 0, 0
+		g.CancelMove()
+	}
+	if !combat.IsDead(&g.Player.Object) {
+		if in.Attack && g.canAct(effect.Attack) {
+			g.Combat.Attack(&g.Player.Object)
+		}
+		if in.Shoot && g.canAct(effect.Shoot) {
+			g.shoot(in)
+		}
+		if in.Place && g.canAct(effect.Attack) {
+			g.placeBlock()
+		}
+	}
 
-	if g.WorldMap == nil {
-		return true
+	playerMoved := dx != 0 || dy != 0
+
+	// --- COLLISION DETECTION ---
+	// Slides along walls and solid entities, pushing anything movable
+	var movedX, movedY float64
+	if playerMoved {
+		movedX, movedY = g.Physics.Move(&g.Player.Object, dx, dy)
 	}
+	g.stopSlide(dx, dy, movedX, movedY, dt)
+	g.checkClickMove(dx, dy, movedX, movedY, dt)
+	g.AI.Update(dt)
+	g.updateHazards(dt)
+	g.Effects.Update(dt)
+	g.Combat.Update(dt)
+	g.Projectiles.Update(dt)
+	g.Physics.Update()
+
+	// --- ANIMATION ---
+	// Face the way the player is trying to go, but drive the walk cycle by how fast they actually moved
+	if animator := anim.AnimatorOf(&g.Player.Object); animator != nil {
+		animator.Face(dx, dy)
+		animator.SetFloat("speed", math.Hypot(movedX, movedY)/dt)
+	}
+	g.updateAnimations(dt)
+	g.Tiles.Update(dt)
 
-	for ty := minTileY; ty <= maxTileY; ty++ {
-		for tx := minTileX; tx <= maxTileX; tx++ {
-			if ty < 0 || ty >= len(g.WorldMap) || tx < 0 || tx >= len(g.WorldMap[0]) {
-				return true
-			}
-			if g.WorldMap[ty][tx].IsWall {
-				return true
-			}
-		}
+	// --- INTERACTION ---
+	g.updateInteraction(in, dt)
+	g.updatePortals()
+	g.Triggers.Update(dt)
+
+	g.Camera.CenterOn(g.Player.GetX(), g.Player.GetY(), ScreenWidth, ScreenHeight)
+	view := g.Camera.View(ScreenWidth, ScreenHeight)
+	g.World.Update(view.X, view.Y, view.W, view.H)
+}
+
+// isSolidTile reports whether a tile blocks movement. Everything outside the map is solid.
+func (g *Game) isSolidTile(tx, ty int) bool {
+	if g.World == nil {
+		return true
 	}
-	return false
+	return g.World.Solid(tx, ty)
 }
 
 func (g *Game) Draw(screen *ebiten.Image) {
 	screen.Fill(color.RGBA{30, 30, 30, 255})
 
-	// --- Draw the World Background Image ---
-	if g.BackgroundImage != nil {
+	// Render between the last two simulation steps so motion stays smooth
+	// whatever the display refresh rate
+	alpha := g.Clock.Alpha()
+	playerX, playerY := g.Player.LerpPosition(alpha)
+	camera := g.Camera
+	camera.CenterOn(playerX, playerY, ScreenWidth, ScreenHeight)
+	g.shownCamera = &camera
+
+	// The world is drawn unscaled, then zoomed onto the screen in one go
+	view := screen
+	if camera.Scale() != 1 {
+		view = g.viewBuffer(camera)
+		view.Fill(color.RGBA{30, 30, 30, 255})
+	}
+	g.drawWorld(view, camera, alpha, playerX, playerY)
+	if view != screen {
 		opts := &ebiten.DrawImageOptions{}
+		opts.GeoM.Scale(camera.Scale(), camera.Scale())
+		screen.DrawImage(view, opts)
+	}
+	g.drawMoveMarker(screen, camera)
 
-		// Translate the background based on the camera's position
-		// Move the background opposite to the camera's view
-		opts.GeoM.Translate(-g.Camera.X, -g.Camera.Y)
-
-		screen.DrawImage(g.BackgroundImage, opts)
-	} else {
-		// Fallback if background failed to load
-		screen.Fill(color.RGBA{50, 50, 50, 255})
+	// In Game.Draw() near the end
+	animator := anim.AnimatorOf(&g.Player.Object)
+	debugText := fmt.Sprintf("X: %.1f, Y: %.1f", g.Player.GetX(), g.Player.GetY())
+	if animator != nil {
+		debugText += fmt.Sprintf(" | Anim: %s %s #%d", animator.State(), animator.Direction(), animator.FrameIndex())
+	}
+	if g.DebugPaths {
+		st := g.Tiles.Stats
+		debugText += fmt.Sprintf("\nChunks: %d drawn, %d rebuilt, %d cached | Tiles: %d", st.Chunks, st.Rebuilt, st.Cached, st.Tiles)
 	}
+	ebitenutil.DebugPrint(screen, debugText)
+	g.drawPlayerHealth(screen)
+	g.drawEffectIcons(screen)
+	g.drawMessage(screen)
+}
 
-	if g.PlayerSheet != nil {
-		frameStartX := playerSheetStartX + (g.Player.AnimFrame * playerFrameStepX)
-		frameStartY := playerSheetStartY
+// drawWorld draws everything that lives in the world, in view space
+func (g *Game) drawWorld(dst *ebiten.Image, camera common.Camera, alpha, playerX, playerY float64) {
+	// --- Draw the tile map, with entities between its layers ---
+	g.Tiles.DrawBelow(dst, camera)
+	g.drawSorted(dst, camera, alpha, playerX, playerY)
+	g.Projectiles.Draw(dst, camera, alpha)
+	g.Tiles.DrawAbove(dst, camera)
+
+	// --- Overlays ---
+	g.drawInteractionPrompt(dst, camera, alpha)
+	g.drawPaths(dst, camera)
+	g.drawHealthBars(dst, camera, alpha)
+}
 
-		sourceRect := image.Rect(
-			frameStartX,
-			frameStartY,
-			frameStartX+playerFrameWidth,
-			frameStartY+playerFrameHeight,
-		)
+// drawPlayer draws the player's animation frame with its top-left corner at (playerX, playerY)
+func (g *Game) drawPlayer(dst *ebiten.Image, camera common.Camera, playerX, playerY float64) {
+	animator := anim.AnimatorOf(&g.Player.Object)
+	switch {
+	case !g.playerVisible():
+		// Flashing after a hit
+	case g.PlayerSheet != nil && animator != nil:
+		frame, flip := animator.Frame()
+		sourceRect := image.Rect(frame.X, frame.Y, frame.X+frame.W, frame.Y+frame.H)
 
 		frameToDraw := g.PlayerSheet.SubImage(sourceRect).(*ebiten.Image)
 
 		opts := &ebiten.DrawImageOptions{}
 
-		opts.GeoM.Scale(playerDrawScale, playerDrawScale)
+		if flip {
+			// Mirror around the frame's left edge, then shift it back into place
+			opts.GeoM.Scale(-playerDrawScale, playerDrawScale)
+			opts.GeoM.Translate(float64(frame.W)*playerDrawScale, 0)
+		} else {
+			opts.GeoM.Scale(playerDrawScale, playerDrawScale)
+		}
 
-		playerScreenX := g.Player.GetX() - g.Camera.X
-		playerScreenY := g.Player.GetY() - g.Camera.Y
+		playerScreenX := playerX - camera.X
+		playerScreenY := playerY - camera.Y
 		opts.GeoM.Translate(playerScreenX, playerScreenY)
 
-		screen.DrawImage(frameToDraw, opts)
-	} else {
+		dst.DrawImage(frameToDraw, opts)
+		g.drawSpriteLayers(dst, &g.Player.Object, sourceRect, opts)
+	default:
 		log.Println("Player sheet is nil")
-		px := g.Player.GetX() - g.Camera.X
-		py := g.Player.GetY() - g.Camera.Y
-		vector.DrawFilledRect(screen, float32(px), float32(py), float32(tileSize), float32(tileSize), color.RGBA{255, 0, 0, 255}, false)
-	}
-
-	// In Game.Draw() near the end
-	debugText := fmt.Sprintf("X: %.1f, Y: %.1f | Frame: %d", g.Player.GetX(), g.Player.GetY(), g.Player.AnimFrame)
-	ebitenutil.DebugPrint(screen, debugText)
-
-	if g.Player.ShowInventory {
-		inventoryText := "Inventory:\n"
-		for i, item := range g.Player.GetInventory().Items {
-			inventoryText += fmt.Sprintf("%d: %s\n", i+1, item)
-		}
-		ebitenutil.DebugPrint(screen, inventoryText)
+		px := playerX - camera.X
+		py := playerY - camera.Y
+		vector.DrawFilledRect(dst, float32(px), float32(py), float32(tileSize), float32(tileSize), color.RGBA{255, 0, 0, 255}, false)
 	}
 }
 
@@ -258,9 +436,6 @@ func (g *Game) Layout(outsideWidth, outsideHeight int) (int, int) {
 }
 
 func (g *Game) Run() {
-	g.Player.AnimFrame = 0
-	g.Player.AnimTimer = 0
-
 	ebiten.SetWindowSize(ScreenWidth, ScreenHeight)
 	ebiten.SetWindowTitle("Phase 1: Movement + Camera")
 	if err := ebiten.RunGame(g); err != nil {
@@ -0,0 +1,201 @@
+package game
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/Nathene/bitbase/input"
+	"github.com/Nathene/bitbase/tilemap"
+)
+
+// TestMain runs the tests from the repository root, where the assets are
+func TestMain(m *testing.M) {
+	if err := os.Chdir(".."); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+// positions returns where every entity is, by ID
+func positions(g *Game) map[int][2]float64 {
+	out := make(map[int][2]float64)
+	for _, o := range g.Entities.All() {
+		out[o.ID] = [2]float64{o.GetX(), o.GetY()}
+	}
+	return out
+}
+
+func TestSimulateFrameRateIndependent(t *testing.T) {
+	inputs := []struct {
+		name string
+		in   input.State
+	}{
+		{"idle", input.State{}},
+		{"walk", input.State{MoveX: 1}},
+		{"diagonal", input.State{MoveX: 1, MoveY: 1}},
+		{"run", input.State{MoveX: -1, Run: true}},
+	}
+	for _, tt := range inputs {
+		t.Run(tt.name, func(t *testing.T) {
+			var want map[int][2]float64
+			for _, fps := range []float64{30, 60, 75, 144, 240} {
+				g := NewGame(nil)
+				g.Simulate(3, fps, tt.in)
+				got := positions(g)
+				if want == nil {
+					want = got
+					continue
+				}
+				for id, p := range want {
+					if got[id] != p {
+						t.Errorf("%v fps: entity %d ended at %v, want %v as at 30 fps", fps, id, got[id], p)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestSimulateSpeed(t *testing.T) {
+	// Out on open grass, well away from anything in the way
+	const x0, y0 = 1600, 400
+	straight := NewGame(nil)
+	straight.placePlayer(x0, y0)
+	straight.Simulate(1, 60, input.State{MoveX: 1})
+	dx := straight.Player.GetX() - x0
+
+	diagonal := NewGame(nil)
+	diagonal.placePlayer(x0, y0)
+	diagonal.Simulate(1, 60, input.State{MoveX: 1, MoveY: 1})
+	ddx, ddy := diagonal.Player.GetX()-x0, diagonal.Player.GetY()-y0
+
+	if dx <= 0 {
+		t.Fatalf("player moved %v pixels right in a second, want some", dx)
+	}
+	// Diagonals are normalised, so they cover the same distance as straight lines
+	if d := math.Hypot(ddx, ddy); math.Abs(d-dx) > 1e-6 {
+		t.Errorf("diagonal covered %v pixels in a second, straight %v", d, dx)
+	}
+}
+
+// TestTravelStopsLoaders goes back and forth between two maps and checks the
+// map left behind stops loading chunks in the background
+func TestTravelStopsLoaders(t *testing.T) {
+	g := NewGame(nil)
+	g.Simulate(0.5, 60, input.State{})
+	before := runtime.NumGoroutine()
+
+	for range 5 {
+		for _, path := range []string{"house.tmx", "world.tmx"} {
+			g.Travel(path, "")
+			if err := g.FinishTravel(); err != nil {
+				t.Fatal(err)
+			}
+			g.Simulate(0.5, 60, input.State{})
+		}
+	}
+	if len(g.maps) != 1 {
+		t.Fatalf("%d maps stowed, want 1", len(g.maps))
+	}
+	for deadline := time.Now().Add(5 * time.Second); runtime.NumGoroutine() > before; time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("%d goroutines after travelling, %d before", runtime.NumGoroutine(), before)
+		}
+	}
+}
+
+func TestRestoreTileChanges(t *testing.T) {
+	g := NewGame(nil)
+	world, house := g.mapPath, filepath.Join(filepath.Dir(g.mapPath), "house.tmx")
+	g.World.Set(g.World.Layer("walls"), 10, 12, 999)
+
+	// The house hasn't been visited, so its changes wait for the player to get there
+	g.RestoreTileChanges(map[string][]tilemap.TileChange{
+		house: {{Layer: "floor", X: 2, Y: 3, GID: 999}, {Layer: "walls", X: 4, Y: 1, GID: 998}},
+	})
+	want := map[string][]tilemap.TileChange{
+		world: {{Layer: "walls", X: 10, Y: 12, GID: 999}},
+		house: {{Layer: "floor", X: 2, Y: 3, GID: 999}, {Layer: "walls", X: 4, Y: 1, GID: 998}},
+	}
+	if got := g.TileChanges(); !reflect.DeepEqual(got, want) {
+		t.Errorf("changes before visiting %v, want %v", got, want)
+	}
+
+	g.Travel("house.tmx", "")
+	if err := g.FinishTravel(); err != nil {
+		t.Fatal(err)
+	}
+	if len(g.pendingTiles) != 0 {
+		t.Errorf("changes still pending after visiting: %v", g.pendingTiles)
+	}
+	if got := g.World.At(g.World.Layer("floor"), 2, 3); got != 999 {
+		t.Errorf("house floor 2,3 is %d on the first visit, want 999", got)
+	}
+	if got := g.TileChanges(); !reflect.DeepEqual(got, want) {
+		t.Errorf("changes after visiting %v, want %v", got, want)
+	}
+
+	// Going back doesn't apply them a second time over later changes
+	g.World.Set(g.World.Layer("floor"), 2, 3, 0)
+	g.Travel("world.tmx", "")
+	if err := g.FinishTravel(); err != nil {
+		t.Fatal(err)
+	}
+	g.Travel("house.tmx", "")
+	if err := g.FinishTravel(); err != nil {
+		t.Fatal(err)
+	}
+	if got := g.World.At(g.World.Layer("floor"), 2, 3); got != 0 {
+		t.Errorf("house floor 2,3 is %d after coming back, want the later 0", got)
+	}
+}
+
+func TestFindPathWindow(t *testing.T) {
+	g := NewGame(nil)
+	g.World.Close()
+	big := tilemap.New(1024, 1024, g.Map.TileWidth, g.Map.TileHeight)
+	big.AddTileLayer("ground")
+	g.World = tilemap.NewWorld(big, nil)
+	defer g.World.Close()
+	tw, th := float64(g.Map.TileWidth), float64(g.Map.TileHeight)
+	reach := float64((pathRadius+1)*g.World.ChunkSize) - 1 // Tiles from the player's chunk's start to the window's far edge
+
+	// Start on the first tile of a chunk, so the window reaches further right than left
+	x0, y0 := float64(10*g.World.ChunkSize)*tw, float64(10*g.World.ChunkSize)*th
+	g.placePlayer(x0, y0)
+	if _, ok := g.FindPath(x0, y0, x0+reach*tw, y0); !ok {
+		t.Error("no path to the far edge of the window")
+	}
+	if _, ok := g.FindPath(x0, y0, x0+(reach+1)*tw, y0); ok {
+		t.Error("found a path out of the window")
+	}
+	if w, h := g.pathGrid.Size(); w != (2*pathRadius+1)*g.World.ChunkSize || h != w {
+		t.Errorf("pathfinder covers %dx%d tiles of a %dx%d world", w, h, g.World.Width, g.World.Height)
+	}
+	if n := g.World.LoadedCount(); n > g.World.CacheSize {
+		t.Errorf("%d chunks loaded by searches, cache holds %d", n, g.World.CacheSize)
+	}
+
+	// The window follows the player, and drops paths found in the old one
+	g.FindPath(x0, y0, x0+tw, y0+th)
+	g.placePlayer(x0+reach*tw, y0)
+	path, ok := g.FindPath(x0+reach*tw, y0, x0+(reach+1)*tw, y0+th)
+	if !ok || len(path) != 1 || path[0] != [2]float64{x0 + (reach+1)*tw, y0 + th} {
+		t.Errorf("path %v after the window moved, want a single diagonal step", path)
+	}
+
+	// Near the world's corner it is clipped rather than hanging off the edge
+	g.placePlayer(tw, th)
+	g.FindPath(tw, th, 2*tw, th)
+	if o := g.pathGrid.origin; o.X != 0 || o.Y != 0 {
+		t.Errorf("window starts at %v by the corner", o)
+	}
+	if w, h := g.pathGrid.Size(); w != (pathRadius+1)*g.World.ChunkSize || h != w {
+		t.Errorf("window is %dx%d by the corner", w, h)
+	}
+}
@@ -0,0 +1,97 @@
+package game
+
+import (
+	"log"
+
+	"github.com/Nathene/bitbase/anim"
+	"github.com/Nathene/bitbase/common"
+	"github.com/Nathene/bitbase/entity"
+	"github.com/Nathene/bitbase/input"
+	"github.com/Nathene/bitbase/interaction"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+)
+
+const (
+	messageDuration = 4.0 // Seconds a message stays on screen
+	debugCharWidth  = 6   // Width of a glyph in the debug font
+	debugLineHeight = 16
+)
+
+// updateInteraction picks what the player is looking at and uses it when E is pressed
+func (g *Game) updateInteraction(in input.State, dt float64) {
+	if g.messageTimer > 0 {
+		if g.messageTimer -= dt; g.messageTimer <= 0 {
+			g.nextDialogLine()
+		}
+	}
+
+	faceX, faceY := 0.0, 1.0
+	if animator := anim.AnimatorOf(&g.Player.Object); animator != nil {
+		faceX, faceY = animator.Direction().Vector()
+	}
+	g.interactTarget = interaction.FindTarget(g.Entities, &g.Player.Object, faceX, faceY)
+
+	if in.Interact && g.interactTarget != nil {
+		if err := interaction.Interact(g, &g.Player.Object, g.interactTarget); err != nil {
+			log.Printf("Interaction failed: %v", err)
+		}
+		// The target may have been picked up or changed; pick again next step
+		g.interactTarget = nil
+	}
+}
+
+// ShowMessage displays text at the bottom of the screen for a few seconds
+func (g *Game) ShowMessage(text string) {
+	g.message = text
+	g.messageTimer = messageDuration
+}
+
+// GiveItem adds an item to an entity's inventory
+func (g *Game) GiveItem(to *entity.Object, itemID string) bool {
+	inv := to.GetInventory()
+	if inv == nil {
+		return false
+	}
+	if err := inv.Add(itemID, 1); err != nil {
+		log.Printf("Could not give %s to %s: %v", itemID, to.Name, err)
+		return false
+	}
+	return true
+}
+
+// RemoveEntity takes an object out of the world
+func (g *Game) RemoveEntity(o *entity.Object) {
+	g.Entities.Remove(o.ID)
+}
+
+// drawInteractionPrompt shows "[E] <prompt>" above the current target
+func (g *Game) drawInteractionPrompt(screen *ebiten.Image, camera common.Camera, alpha float64) {
+	target := g.interactTarget
+	if target == nil || target.World() == nil {
+		return
+	}
+	i := interaction.InteractableOf(target)
+	if i == nil || i.Prompt == "" {
+		return
+	}
+
+	text := "[E] " + i.Prompt
+	x, y := target.LerpPosition(alpha)
+	bounds := target.Bounds()
+	centerX := x + (bounds.X - target.GetX()) + bounds.W/2
+	top := y + (bounds.Y - target.GetY())
+
+	screenX := int(centerX-camera.X) - len(text)*debugCharWidth/2
+	screenY := int(top-camera.Y) - debugLineHeight - 4
+	ebitenutil.DebugPrintAt(screen, text, screenX, screenY)
+}
+
+// drawMessage shows the most recent interaction message
+func (g *Game) drawMessage(screen *ebiten.Image) {
+	if g.messageTimer <= 0 || g.message == "" {
+		return
+	}
+	x := ScreenWidth/2 - len(g.message)*debugCharWidth/2
+	ebitenutil.DebugPrintAt(screen, g.message, x, ScreenHeight-80)
+}
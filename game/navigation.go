@@ -0,0 +1,142 @@
+package game
+
+import (
+	"image/color"
+	"math"
+
+	"github.com/Nathene/bitbase/common"
+	"github.com/Nathene/bitbase/input"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+const (
+	minZoom  = 0.5
+	maxZoom  = 3.0
+	zoomStep = 1.25 // Zoom factor per wheel notch or key press
+
+	moveArriveRadius = 2   // How close the player must get to a click-to-move waypoint
+	moveStuckTime    = 0.5 // Seconds of being blocked before a click-to-move gives up
+	markerRadius     = 8
+)
+
+// clickMove is a walk the player was sent on by clicking the world
+type clickMove struct {
+	active           bool
+	targetX, targetY float64
+	path             [][2]float64 // Waypoints still to reach, ending at the target
+	stuck            float64
+}
+
+// MoveTo sends the player walking to a world position along a path around walls,
+// reporting whether there is a way there
+func (g *Game) MoveTo(x, y float64) bool {
+	cx, cy := g.Player.Bounds().Center()
+	path, ok := g.FindPath(cx, cy, x, y)
+	if !ok {
+		return false
+	}
+	g.clickMove = clickMove{active: true, targetX: x, targetY: y, path: path}
+	return true
+}
+
+// CancelMove stops any click-to-move walk
+func (g *Game) CancelMove() {
+	g.clickMove = clickMove{}
+}
+
+// MoveTarget returns where the player is walking to, if they were sent somewhere by a click
+func (g *Game) MoveTarget() (float64, float64, bool) {
+	return g.clickMove.targetX, g.clickMove.targetY, g.clickMove.active
+}
+
+// clickMoveStep returns how far the player should move this step to follow the
+// click-to-move path, going no further than maxStep
+func (g *Game) clickMoveStep(maxStep float64) (float64, float64) {
+	m := &g.clickMove
+	if !m.active {
+		return 0, 0
+	}
+
+	cx, cy := g.Player.Bounds().Center()
+	for len(m.path) > 0 && math.Hypot(m.path[0][0]-cx, m.path[0][1]-cy) <= moveArriveRadius {
+		m.path = m.path[1:]
+	}
+	if len(m.path) == 0 {
+		g.CancelMove()
+		return 0, 0
+	}
+
+	dx, dy := m.path[0][0]-cx, m.path[0][1]-cy
+	dist := math.Hypot(dx, dy)
+	step := min(maxStep, dist)
+	return dx / dist * step, dy / dist * step
+}
+
+// checkClickMove gives up on a click-to-move walk that has been blocked for a while
+func (g *Game) checkClickMove(dx, dy, movedX, movedY, dt float64) {
+	m := &g.clickMove
+	if !m.active {
+		return
+	}
+	if math.Hypot(movedX, movedY) < math.Hypot(dx, dy)*0.1 {
+		m.stuck += dt
+		if m.stuck >= moveStuckTime {
+			g.CancelMove()
+		}
+	} else {
+		m.stuck = 0
+	}
+}
+
+// drawMoveMarker marks where the player is walking to
+func (g *Game) drawMoveMarker(screen *ebiten.Image, camera common.Camera) {
+	x, y, ok := g.MoveTarget()
+	if !ok {
+		return
+	}
+	sx, sy := camera.WorldToScreen(x, y)
+	markerColor := color.RGBA{120, 220, 255, 220}
+	vector.StrokeCircle(screen, float32(sx), float32(sy), markerRadius, 2, markerColor, true)
+	vector.DrawFilledCircle(screen, float32(sx), float32(sy), 2, markerColor, true)
+}
+
+// pointerToWorld converts the pointer position to the world through the
+// camera the screen was last drawn with, or Camera before anything is drawn
+func (g *Game) pointerToWorld(in input.State) (float64, float64) {
+	camera := g.Camera
+	if g.shownCamera != nil {
+		camera = *g.shownCamera
+	}
+	return camera.ScreenToWorld(in.PointerX, in.PointerY)
+}
+
+// SetZoom sets the camera zoom, within limits, keeping the player centred
+func (g *Game) SetZoom(zoom float64) {
+	g.Camera.Zoom = min(max(zoom, minZoom), maxZoom)
+	g.Camera.CenterOn(g.Player.GetX(), g.Player.GetY(), ScreenWidth, ScreenHeight)
+}
+
+// ZoomIn zooms the camera in one step
+func (g *Game) ZoomIn() {
+	g.SetZoom(g.Camera.Scale() * zoomStep)
+}
+
+// ZoomOut zooms the camera out one step
+func (g *Game) ZoomOut() {
+	g.SetZoom(g.Camera.Scale() / zoomStep)
+}
+
+// viewBuffer returns an offscreen image the size of the camera's view, for
+// drawing the world before it is zoomed onto the screen
+func (g *Game) viewBuffer(camera common.Camera) *ebiten.Image {
+	w, h := camera.ViewSize(ScreenWidth, ScreenHeight)
+	width, height := int(math.Ceil(w)), int(math.Ceil(h))
+	if g.view == nil || g.view.Bounds().Dx() != width || g.view.Bounds().Dy() != height {
+		if g.view != nil {
+			g.view.Deallocate()
+		}
+		g.view = ebiten.NewImage(width, height)
+	}
+	return g.view
+}
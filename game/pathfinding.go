@@ -0,0 +1,114 @@
+package game
+
+import (
+	"image/color"
+	"math"
+
+	"github.com/Nathene/bitbase/ai"
+	"github.com/Nathene/bitbase/common"
+	"github.com/Nathene/bitbase/pathfind"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// pathRadius is how many chunks the pathfinder reaches on each side of the
+// player's chunk. Searches stay inside that window, so the pathfinder's memory
+// and the chunks its costs load stay the same however big the world is; 5x5
+// chunks fit in the world's default cache.
+const pathRadius = 2
+
+// worldGrid exposes a window of the streamed tile world to the pathfinder.
+// Its points are relative to origin.
+type worldGrid struct {
+	g             *Game
+	origin        pathfind.Point
+	width, height int
+}
+
+func (w *worldGrid) Size() (int, int) {
+	return w.width, w.height
+}
+
+func (w *worldGrid) Cost(x, y int) float64 {
+	x, y = x+w.origin.X, y+w.origin.Y
+	if w.g.World.Solid(x, y) {
+		return pathfind.Blocked
+	}
+	return 1 + w.g.World.Cost(x, y)
+}
+
+// follow moves the window to the chunks around the player, clipped to the
+// world, and reports whether it moved
+func (w *worldGrid) follow() bool {
+	world := w.g.World
+	p := w.g.tileAt(w.g.Player.Bounds().Center())
+	c := world.ChunkAt(p.X, p.Y)
+	x0, y0 := max((c.X-pathRadius)*world.ChunkSize, 0), max((c.Y-pathRadius)*world.ChunkSize, 0)
+	x1, y1 := min((c.X+pathRadius+1)*world.ChunkSize, world.Width), min((c.Y+pathRadius+1)*world.ChunkSize, world.Height)
+	origin, width, height := pathfind.Point{X: x0, Y: y0}, max(x1-x0, 0), max(y1-y0, 0)
+	if origin == w.origin && width == w.width && height == w.height {
+		return false
+	}
+	w.origin, w.width, w.height = origin, width, height
+	return true
+}
+
+// tileAt returns the tile containing a world position
+func (g *Game) tileAt(x, y float64) pathfind.Point {
+	return pathfind.Point{
+		X: int(math.Floor(x / float64(g.Map.TileWidth))),
+		Y: int(math.Floor(y / float64(g.Map.TileHeight))),
+	}
+}
+
+// tileCenter returns the world position of a tile's centre
+func (g *Game) tileCenter(p pathfind.Point) (float64, float64) {
+	return (float64(p.X) + 0.5) * float64(g.Map.TileWidth), (float64(p.Y) + 0.5) * float64(g.Map.TileHeight)
+}
+
+// FindPath plans a route between two world positions around walls. The waypoints
+// are tile centres after the start, ending exactly at the destination. Both
+// ends must be within pathRadius chunks of the player's chunk.
+func (g *Game) FindPath(fromX, fromY, toX, toY float64) ([][2]float64, bool) {
+	if g.pathGrid.follow() {
+		g.Paths.Invalidate()
+	}
+	o := g.pathGrid.origin
+	from, to := g.tileAt(fromX, fromY), g.tileAt(toX, toY)
+	from.X, from.Y, to.X, to.Y = from.X-o.X, from.Y-o.Y, to.X-o.X, to.Y-o.Y
+	path, err := g.Paths.Path(from, to)
+	if err != nil {
+		return nil, false
+	}
+	waypoints := make([][2]float64, 0, len(path))
+	for _, p := range path[1:] {
+		x, y := g.tileCenter(pathfind.Point{X: p.X + o.X, Y: p.Y + o.Y})
+		waypoints = append(waypoints, [2]float64{x, y})
+	}
+	if len(waypoints) == 0 {
+		waypoints = append(waypoints, [2]float64{toX, toY})
+	} else {
+		waypoints[len(waypoints)-1] = [2]float64{toX, toY}
+	}
+	return waypoints, true
+}
+
+// drawPaths draws the route every NPC is following, when path debugging is on
+func (g *Game) drawPaths(screen *ebiten.Image, camera common.Camera) {
+	if !g.DebugPaths {
+		return
+	}
+	pathColor := color.RGBA{255, 220, 0, 200}
+	for _, o := range g.Entities.All() {
+		b := ai.BrainOf(o)
+		if b == nil || len(b.Path()) == 0 {
+			continue
+		}
+		x, y := o.Bounds().Center()
+		for _, p := range b.Path() {
+			vector.StrokeLine(screen, float32(x-camera.X), float32(y-camera.Y), float32(p[0]-camera.X), float32(p[1]-camera.Y), 2, pathColor, false)
+			vector.DrawFilledCircle(screen, float32(p[0]-camera.X), float32(p[1]-camera.Y), 3, pathColor, false)
+			x, y = p[0], p[1]
+		}
+	}
+}
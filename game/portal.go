@@ -0,0 +1,175 @@
+package game
+
+import (
+	"log"
+	"path/filepath"
+
+	"github.com/Nathene/bitbase/combat"
+	"github.com/Nathene/bitbase/entity"
+	"github.com/Nathene/bitbase/tilemap"
+	"github.com/Nathene/bitbase/tilemap/render"
+	"github.com/Nathene/bitbase/trigger"
+)
+
+// Map object types for getting between maps
+const (
+	portalType = "portal" // An area that sends the player on when they walk into it
+	spawnType  = "spawn"  // A named place portals and doors lead to
+)
+
+// mapState is a map the player has left, kept as it was so it is the same
+// when they come back: changed tiles, opened chests, enemies where they were
+type mapState struct {
+	Map       *tilemap.Map
+	World     *tilemap.World
+	Autotiles map[string]*tilemap.Autotile
+	entities  []*entity.Object
+	zones     []*trigger.Zone
+}
+
+// trip is where the player is waiting to be taken
+type trip struct {
+	mapPath string
+	spawn   string
+}
+
+// Travel sends the player to a named spawn point on another map, or on this
+// one if mapPath is empty. mapPath is relative to the current map's file. The
+// trip waits for FinishTravel so the screen can fade out first.
+func (g *Game) Travel(mapPath, spawn string) {
+	if g.trip != nil {
+		return
+	}
+	path := g.mapPath
+	if mapPath != "" {
+		path = filepath.Join(filepath.Dir(g.Map.Path), mapPath)
+	}
+	g.trip = &trip{mapPath: path, spawn: spawn}
+	g.CancelMove()
+}
+
+// Travelling reports whether a trip is waiting for FinishTravel
+func (g *Game) Travelling() bool {
+	return g.trip != nil
+}
+
+// FinishTravel makes the waiting trip: the current map is put away, the
+// destination is loaded or brought back as the player left it, and the player
+// is moved to the spawn point. If the destination can't be loaded the player
+// stays where they are.
+func (g *Game) FinishTravel() error {
+	t := g.trip
+	g.trip = nil
+	if t == nil {
+		return nil
+	}
+	if t.mapPath != g.mapPath {
+		if err := g.switchMap(t.mapPath); err != nil {
+			return err
+		}
+	}
+	g.placePlayer(g.spawnPoint(t.spawn))
+	return nil
+}
+
+// switchMap swaps the current map for another, keeping the old one's state
+// but freeing its images and chunks and stopping its chunk loaders
+func (g *Game) switchMap(path string) error {
+	next, visited := g.maps[path]
+	if !visited {
+		m, err := tilemap.Load(path)
+		if err != nil {
+			return err
+		}
+		next = &mapState{Map: m, World: tilemap.NewWorld(m, nil)}
+	}
+
+	g.maps[g.mapPath] = g.stowMap()
+	g.Projectiles.Clear()
+	g.Tiles.Release()
+	g.World.Unload()
+
+	delete(g.maps, path)
+	g.Map, g.World, g.Autotiles, g.mapPath = next.Map, next.World, next.Autotiles, path
+	g.Tiles = render.New(g.World)
+	g.Physics.TileSize = float64(g.Map.TileWidth)
+	g.Paths.Invalidate()
+	clear(g.tileHits)
+	g.interactTarget = nil
+	if visited {
+		for _, o := range next.entities {
+			g.Entities.Add(o)
+		}
+		g.Triggers.SetZones(next.zones)
+	} else {
+		g.watchAutotiles()
+		g.watchTiles()
+		if changes, ok := g.pendingTiles[path]; ok {
+			delete(g.pendingTiles, path)
+			if err := g.World.ApplyChanges(changes); err != nil {
+				log.Printf("Failed to restore tiles of %s: %v", path, err)
+			}
+		}
+		g.spawnMapObjects()
+		g.loadTriggers()
+	}
+	return nil
+}
+
+// stowMap takes everything but the player out of the world and returns it
+// with the current map
+func (g *Game) stowMap() *mapState {
+	s := &mapState{Map: g.Map, World: g.World, Autotiles: g.Autotiles, zones: g.Triggers.Zones}
+	for _, o := range g.Entities.All() {
+		if o == &g.Player.Object {
+			continue
+		}
+		s.entities = append(s.entities, o)
+		g.Entities.Remove(o.ID)
+	}
+	return s
+}
+
+// spawnPoint returns where the object with the given name is on the current
+// map, or the player start if there isn't one
+func (g *Game) spawnPoint(name string) (float64, float64) {
+	if name != "" {
+		for _, o := range g.Map.Objects("") {
+			if o.Name == name {
+				return o.X, o.Y
+			}
+		}
+		log.Printf("Map %s has no spawn point %q", g.mapPath, name)
+	}
+	return g.playerStartPosition()
+}
+
+// placePlayer moves the player without sliding or interpolating there
+func (g *Game) placePlayer(x, y float64) {
+	g.Player.SetPosition(x, y)
+	g.Player.SnapshotPosition()
+	g.CancelMove()
+	g.slide = [2]float64{}
+	g.Camera.CenterOn(x, y, ScreenWidth, ScreenHeight)
+	// A portal the player arrives in doesn't fire until they step out and back in
+	g.inPortal = g.portalAt(g.Player.Feet())
+}
+
+// updatePortals starts a trip when the player's feet step into a portal
+func (g *Game) updatePortals() {
+	p := g.portalAt(g.Player.Feet())
+	if p != nil && p != g.inPortal && !combat.IsDead(&g.Player.Object) {
+		g.Travel(p.Properties.String("map", ""), p.Properties.String("spawn", ""))
+	}
+	g.inPortal = p
+}
+
+// portalAt returns the portal whose bounding box holds a world position, or nil
+func (g *Game) portalAt(x, y float64) *tilemap.Object {
+	for _, p := range g.Map.Objects(portalType) {
+		if x >= p.X && y >= p.Y && x < p.X+p.Width && y < p.Y+p.Height {
+			return p
+		}
+	}
+	return nil
+}
@@ -28,12 +28,13 @@ func NewGameplayState(assetManager *game.AssetManager, stateManager *StateManage
 
 // Initialize sets up the gameplay state
 func (gs *GameplayState) Initialize() error {
-	// Load the player sprite sheet and background
+	// Load the player sprite sheet
 	playerSheet := gs.assetManager.GetImage("playerSheet")
-	backgroundImage := gs.assetManager.GetImage("worldBackground")
 
 	// Create the game instance
-	gs.game = game.NewGame(playerSheet, backgroundImage)
+	gs.game = game.NewGame(playerSheet)
+	gs.game.Entities.Images = gs.assetManager.GetImage
+	gs.game.Projectiles.Images = gs.assetManager.GetImage
 
 	return nil
 }
@@ -55,6 +56,13 @@ func (gs *GameplayState) Update() error {
 		return nil
 	}
 
+	// Keep simulating under overlays like the inventory, but without player input
+	active := gs.stateManager.GetActiveState() == gs
+	gs.game.InputBlocked = !active
+	if !active {
+		return gs.game.Update()
+	}
+
 	// Check for pause action
 	if inpututil.IsKeyJustPressed(ebiten.KeyEscape) {
 		gs.isPaused = true
@@ -66,8 +74,34 @@ func (gs *GameplayState) Update() error {
 		return nil
 	}
 
+	// Open the inventory
+	if inpututil.IsKeyJustPressed(ebiten.KeyTab) || inpututil.IsKeyJustPressed(ebiten.KeyI) {
+		inventoryState := NewInventoryState(gs.assetManager, gs.stateManager, &gs.game.Player.Object)
+		gs.stateManager.PushState(inventoryState)
+	}
+
+	// Zoom with the mouse wheel or +/-
+	if _, wheel := ebiten.Wheel(); wheel > 0 || inpututil.IsKeyJustPressed(ebiten.KeyEqual) || inpututil.IsKeyJustPressed(ebiten.KeyKPAdd) {
+		gs.game.ZoomIn()
+	} else if wheel < 0 || inpututil.IsKeyJustPressed(ebiten.KeyMinus) || inpututil.IsKeyJustPressed(ebiten.KeyKPSubtract) {
+		gs.game.ZoomOut()
+	}
+
+	// Toggle the path debug overlay
+	if inpututil.IsKeyJustPressed(ebiten.KeyF3) {
+		gs.game.DebugPaths = !gs.game.DebugPaths
+	}
+
 	// Update the game
-	return gs.game.Update()
+	if err := gs.game.Update(); err != nil {
+		return err
+	}
+
+	// Fade out to the map the player is travelling to
+	if gs.game.Travelling() {
+		gs.stateManager.PushState(NewTransitionState(gs.game, gs.stateManager))
+	}
+	return nil
 }
 
 // HandleInput processes all input for this state
@@ -0,0 +1,632 @@
+package states
+
+import (
+	"errors"
+	"fmt"
+	"image/color"
+	"strings"
+
+	"github.com/Nathene/bitbase/entity"
+	"github.com/Nathene/bitbase/equipment"
+	"github.com/Nathene/bitbase/game"
+	"github.com/Nathene/bitbase/game/ui"
+	"github.com/Nathene/bitbase/item"
+	"github.com/Nathene/bitbase/stats"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+const (
+	inventoryColumns  = 6
+	inventorySlotSize = 64.0
+	inventorySpacing  = 8.0
+	inventoryPadding  = 24.0
+	iconInset         = 8.0
+	statsWidth        = 200.0
+)
+
+// equipColumn is the order equipment slots are shown in, top to bottom
+var equipColumn = []equipment.Slot{equipment.Head, equipment.Body, equipment.Weapon, equipment.Offhand, equipment.Accessory}
+
+// statNames are the labels shown in the stats readout
+var statNames = map[stats.Stat]string{
+	stats.MoveSpeed:   "Move speed",
+	stats.MaxHealth:   "Max health",
+	stats.Attack:      "Attack",
+	stats.Defense:     "Defense",
+	stats.AttackSpeed: "Attack speed",
+}
+
+// slotRef addresses either an inventory slot or an equipment slot
+type slotRef struct {
+	equip bool
+	index int // Inventory slot, or index into equipColumn
+}
+
+var noSlot = slotRef{index: -1}
+
+func (r slotRef) valid() bool { return r.index >= 0 }
+
+// heldStack is a stack picked up with the mouse or the cursor, waiting to be dropped
+type heldStack struct {
+	from    slotRef
+	count   int
+	byMouse bool
+}
+
+// InventoryState is a non-blocking overlay showing the player's inventory as a slot grid,
+// with their equipment alongside. Items can be dragged with the mouse or moved with the
+// keyboard/gamepad cursor.
+type InventoryState struct {
+	assetManager *game.AssetManager
+	stateManager *StateManager
+	owner        *entity.Object
+	inventory    *item.Inventory
+	equipment    *equipment.Equipment // nil if the owner can't equip anything
+
+	grid       *ui.SlotGrid
+	equipGrid  *ui.SlotGrid
+	sortButton *ui.Button
+	cursor     slotRef
+	hover      slotRef // Slot under the mouse
+	usingMouse bool
+	held       *heldStack
+	message    string
+}
+
+// NewInventoryState creates an inventory overlay for an object's inventory and equipment
+func NewInventoryState(assetManager *game.AssetManager, stateManager *StateManager, owner *entity.Object) *InventoryState {
+	return &InventoryState{
+		assetManager: assetManager,
+		stateManager: stateManager,
+		owner:        owner,
+		inventory:    owner.GetInventory(),
+		equipment:    equipment.EquipmentOf(owner),
+		hover:        noSlot,
+	}
+}
+
+// Initialize sets up the slot grids and buttons
+func (is *InventoryState) Initialize() error {
+	rows := (is.inventory.Size() + inventoryColumns - 1) / inventoryColumns
+	is.grid = ui.NewSlotGrid(game.ScreenWidth/2, game.ScreenHeight/2, inventoryColumns, rows, inventorySlotSize, inventorySpacing)
+
+	bottom := is.grid.Y + is.grid.Height()
+	if is.equipment != nil {
+		// Equipment sits in a column to the left of the inventory
+		is.equipGrid = ui.NewSlotGrid(0, game.ScreenHeight/2, 1, len(equipColumn), inventorySlotSize, inventorySpacing)
+		is.equipGrid.X = is.grid.X - inventorySlotSize - inventoryPadding*2
+		bottom = max(bottom, is.equipGrid.Y+is.equipGrid.Height())
+	}
+
+	buttonWidth := 100.0
+	is.sortButton = ui.NewButton(is.grid.X+is.grid.Width()-buttonWidth, bottom+inventoryPadding/2, buttonWidth, 30, "Sort", nil)
+	is.sortButton.OnClick = is.sort
+
+	return nil
+}
+
+// Enter is called when this state becomes active
+func (is *InventoryState) Enter() error {
+	is.held = nil
+	is.message = ""
+	return nil
+}
+
+// Exit is called when this state is no longer active
+func (is *InventoryState) Exit() error {
+	return nil
+}
+
+// BlocksUpdate lets the game keep running underneath the inventory
+func (is *InventoryState) BlocksUpdate() bool {
+	return false
+}
+
+// Update handles mouse, keyboard and gamepad input for the inventory
+func (is *InventoryState) Update() error {
+	if is.closePressed() {
+		if is.held != nil {
+			is.held = nil // First press just drops what we're holding back where it was
+		} else {
+			is.stateManager.PopState()
+		}
+		return nil
+	}
+
+	is.updateMouse()
+	is.updateCursor()
+
+	if is.held == nil {
+		is.sortButton.Update()
+		if inpututil.IsKeyJustPressed(ebiten.KeyR) || gamepadJustPressed(ebiten.StandardGamepadButtonRightTop) {
+			is.sort()
+		}
+	}
+
+	return nil
+}
+
+// HandleInput processes all input for this state
+func (is *InventoryState) HandleInput() error {
+	// Input handling is done in Update
+	return nil
+}
+
+func (is *InventoryState) closePressed() bool {
+	return inpututil.IsKeyJustPressed(ebiten.KeyEscape) ||
+		inpututil.IsKeyJustPressed(ebiten.KeyTab) ||
+		inpututil.IsKeyJustPressed(ebiten.KeyI) ||
+		gamepadJustPressed(ebiten.StandardGamepadButtonRightRight)
+}
+
+// slotAt returns the inventory or equipment slot under a screen position
+func (is *InventoryState) slotAt(x, y float64) slotRef {
+	if i := is.grid.SlotAt(x, y); i >= 0 && i < is.inventory.Size() {
+		return slotRef{index: i}
+	}
+	if is.equipGrid != nil {
+		if i := is.equipGrid.SlotAt(x, y); i >= 0 {
+			return slotRef{equip: true, index: i}
+		}
+	}
+	return noSlot
+}
+
+// slotRect returns the top-left corner of a slot on screen
+func (is *InventoryState) slotRect(ref slotRef) (float64, float64) {
+	if ref.equip {
+		return is.equipGrid.SlotRect(ref.index)
+	}
+	return is.grid.SlotRect(ref.index)
+}
+
+// stackAt returns what a slot holds; equipment slots hold at most one item
+func (is *InventoryState) stackAt(ref slotRef) item.Stack {
+	if !ref.valid() {
+		return item.Stack{}
+	}
+	if ref.equip {
+		if id := is.equipment.Item(equipColumn[ref.index]); id != "" {
+			return item.Stack{ItemID: id, Count: 1}
+		}
+		return item.Stack{}
+	}
+	return is.inventory.Slot(ref.index)
+}
+
+// updateMouse handles drag-and-drop; right-click equips or unequips
+func (is *InventoryState) updateMouse() {
+	mx, my := ebiten.CursorPosition()
+	hover := is.slotAt(float64(mx), float64(my))
+	if hover != is.hover {
+		is.hover = hover
+		if hover.valid() {
+			is.usingMouse = true
+		}
+	}
+
+	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) && is.hover.valid() && is.held == nil {
+		is.pickUp(is.hover, true, splitModifierHeld())
+	}
+
+	if inpututil.IsMouseButtonJustReleased(ebiten.MouseButtonLeft) && is.held != nil && is.held.byMouse {
+		if is.hover.valid() {
+			is.drop(is.hover)
+		} else {
+			is.held = nil // Dropped outside the grid: cancel
+		}
+	}
+
+	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonRight) && is.hover.valid() && is.held == nil {
+		is.toggleEquipped(is.hover)
+	}
+}
+
+// updateCursor handles keyboard and gamepad navigation
+func (is *InventoryState) updateCursor() {
+	dx, dy := 0, 0
+	switch {
+	case inpututil.IsKeyJustPressed(ebiten.KeyLeft) || inpututil.IsKeyJustPressed(ebiten.KeyA) || gamepadJustPressed(ebiten.StandardGamepadButtonLeftLeft):
+		dx = -1
+	case inpututil.IsKeyJustPressed(ebiten.KeyRight) || inpututil.IsKeyJustPressed(ebiten.KeyD) || gamepadJustPressed(ebiten.StandardGamepadButtonLeftRight):
+		dx = 1
+	case inpututil.IsKeyJustPressed(ebiten.KeyUp) || inpututil.IsKeyJustPressed(ebiten.KeyW) || gamepadJustPressed(ebiten.StandardGamepadButtonLeftTop):
+		dy = -1
+	case inpututil.IsKeyJustPressed(ebiten.KeyDown) || inpututil.IsKeyJustPressed(ebiten.KeyS) || gamepadJustPressed(ebiten.StandardGamepadButtonLeftBottom):
+		dy = 1
+	}
+	if dx != 0 || dy != 0 {
+		is.moveCursor(dx, dy)
+		is.usingMouse = false
+	}
+
+	if (inpututil.IsKeyJustPressed(ebiten.KeyE) || gamepadJustPressed(ebiten.StandardGamepadButtonFrontTopRight)) && is.held == nil {
+		is.usingMouse = false
+		is.toggleEquipped(is.cursor)
+		return
+	}
+
+	selectPressed := inpututil.IsKeyJustPressed(ebiten.KeyEnter) || inpututil.IsKeyJustPressed(ebiten.KeySpace) ||
+		gamepadJustPressed(ebiten.StandardGamepadButtonRightBottom)
+	splitPressed := gamepadJustPressed(ebiten.StandardGamepadButtonRightLeft)
+	if !selectPressed && !splitPressed {
+		return
+	}
+
+	is.usingMouse = false
+	if is.held == nil {
+		is.pickUp(is.cursor, false, splitPressed || splitModifierHeld())
+	} else if !is.held.byMouse {
+		is.drop(is.cursor)
+	}
+}
+
+// moveCursor steps the cursor around the inventory grid, crossing over to the
+// equipment column off its left edge
+func (is *InventoryState) moveCursor(dx, dy int) {
+	cols := is.grid.Columns
+	if !is.cursor.equip {
+		row := is.cursor.index / cols
+		if dx < 0 && is.cursor.index%cols == 0 && is.equipGrid != nil {
+			is.cursor = slotRef{equip: true, index: min(row, len(equipColumn)-1)}
+			return
+		}
+		is.cursor.index = is.grid.Step(is.cursor.index, dx, dy)
+		return
+	}
+
+	row := min(is.cursor.index, is.grid.Rows-1)
+	switch {
+	case dx > 0:
+		is.cursor = slotRef{index: row * cols}
+	case dx < 0:
+		is.cursor = slotRef{index: row*cols + cols - 1}
+	default:
+		is.cursor.index = is.equipGrid.Step(is.cursor.index, 0, dy)
+	}
+}
+
+// pickUp starts holding a slot's stack, or half of it when splitting
+func (is *InventoryState) pickUp(ref slotRef, byMouse, half bool) {
+	stack := is.stackAt(ref)
+	if stack.Empty() {
+		return
+	}
+
+	count := stack.Count
+	if half && stack.Count > 1 {
+		count = stack.Count / 2
+	}
+	is.held = &heldStack{from: ref, count: count, byMouse: byMouse}
+	is.message = ""
+}
+
+// drop puts the held stack down on a slot. Between inventory slots it tops the
+// target up as far as it can when the whole stack won't fit; to or from an
+// equipment slot it equips or unequips.
+func (is *InventoryState) drop(target slotRef) {
+	held := is.held
+	is.held = nil
+	if target == held.from {
+		return
+	}
+
+	var err error
+	switch {
+	case !held.from.equip && target.equip:
+		err = equipment.Equip(is.owner, held.from.index, equipColumn[target.index])
+
+	case held.from.equip && !target.equip:
+		err = equipment.Unequip(is.owner, equipColumn[held.from.index], target.index)
+
+	case held.from.equip && target.equip:
+		return // Gear doesn't move between equipment slots
+
+	default:
+		err = is.inventory.Move(held.from.index, target.index, held.count)
+		var opErr *item.OpError
+		if errors.As(err, &opErr) && errors.Is(err, item.ErrNoSpace) && opErr.Missing < held.count {
+			err = is.inventory.Move(held.from.index, target.index, held.count-opErr.Missing)
+		}
+	}
+	if err != nil {
+		is.message = describeInventoryError(err)
+	}
+}
+
+// toggleEquipped equips an inventory item in its own slot, or takes off an equipped one
+func (is *InventoryState) toggleEquipped(ref slotRef) {
+	if is.equipment == nil || is.stackAt(ref).Empty() {
+		return
+	}
+
+	var err error
+	if ref.equip {
+		err = equipment.Unequip(is.owner, equipColumn[ref.index], -1)
+	} else {
+		err = equipment.Equip(is.owner, ref.index, "")
+	}
+	if err != nil {
+		is.message = describeInventoryError(err)
+	} else {
+		is.message = ""
+	}
+}
+
+func (is *InventoryState) sort() {
+	is.inventory.Sort()
+	is.message = ""
+}
+
+// Draw renders the inventory panel; the state manager draws the game underneath
+func (is *InventoryState) Draw(screen *ebiten.Image) {
+	g := is.grid
+
+	// Panel
+	left, top, bottom := g.X, g.Y, g.Y+g.Height()
+	if is.equipGrid != nil {
+		left = is.equipGrid.X
+		top = min(top, is.equipGrid.Y)
+		bottom = max(bottom, is.equipGrid.Y+is.equipGrid.Height())
+	}
+	panelX := left - inventoryPadding
+	panelY := top - inventoryPadding*2
+	panelW := g.X + g.Width() + inventoryPadding + statsWidth - left + inventoryPadding*2
+	panelH := bottom - top + inventoryPadding*4
+	vector.DrawFilledRect(screen, float32(panelX), float32(panelY), float32(panelW), float32(panelH), color.RGBA{20, 20, 30, 220}, false)
+	vector.StrokeRect(screen, float32(panelX), float32(panelY), float32(panelW), float32(panelH), 3, color.RGBA{200, 200, 200, 255}, false)
+
+	title := fmt.Sprintf("Inventory   Weight: %.1f / %.0f", is.inventory.Weight(), is.inventory.MaxWeight)
+	ebitenutil.DebugPrintAt(screen, title, int(g.X), int(panelY+6))
+
+	// Slots
+	for i := 0; i < is.inventory.Size(); i++ {
+		ref := slotRef{index: i}
+		count := is.stackAt(ref).Count
+		if is.held != nil && is.held.from == ref {
+			count -= is.held.count // Show what's left behind while dragging
+		}
+		is.drawSlot(screen, ref, count)
+	}
+	if is.equipGrid != nil {
+		ebitenutil.DebugPrintAt(screen, "Equipped", int(is.equipGrid.X), int(panelY+6))
+		for i, slot := range equipColumn {
+			ref := slotRef{equip: true, index: i}
+			count := is.stackAt(ref).Count
+			if is.held != nil && is.held.from == ref {
+				count = 0
+			}
+			is.drawSlot(screen, ref, count)
+			if count == 0 {
+				x, y := is.slotRect(ref)
+				ebitenutil.DebugPrintAt(screen, string(slot), int(x+6), int(y+g.SlotSize/2-8))
+			}
+		}
+	}
+
+	is.drawStats(screen, g.X+g.Width()+inventoryPadding, g.Y)
+
+	is.sortButton.Draw(screen)
+	ebitenutil.DebugPrintAt(screen, "Sort [R]", int(is.sortButton.X+20), int(is.sortButton.Y+8))
+	ebitenutil.DebugPrintAt(screen, "Drag or Enter to move, Shift to split, E/right-click to equip, Tab to close", int(g.X), int(is.sortButton.Y+is.sortButton.Height+6))
+
+	// Held stack follows the mouse, or sits offset over the cursor slot
+	if is.held != nil {
+		held := is.stackAt(is.held.from)
+		var x, y float64
+		if is.held.byMouse {
+			mx, my := ebiten.CursorPosition()
+			x, y = float64(mx)-g.SlotSize/2, float64(my)-g.SlotSize/2
+		} else {
+			x, y = is.slotRect(is.cursor)
+			x, y = x+g.SlotSize/3, y-g.SlotSize/3
+		}
+		is.drawStack(screen, held.ItemID, is.held.count, x, y)
+	} else {
+		is.drawTooltip(screen)
+	}
+
+	if is.message != "" {
+		ebitenutil.DebugPrintAt(screen, is.message, int(g.X), int(panelY+panelH+8))
+	}
+}
+
+// drawSlot draws a slot's background, highlight and the first count items in it
+func (is *InventoryState) drawSlot(screen *ebiten.Image, ref slotRef, count int) {
+	size := is.grid.SlotSize
+	x, y := is.slotRect(ref)
+
+	border := color.RGBA{110, 110, 120, 255}
+	if ref == is.cursor && !is.usingMouse {
+		border = color.RGBA{255, 255, 100, 255}
+	} else if ref == is.hover {
+		border = color.RGBA{200, 200, 255, 255}
+	}
+	vector.DrawFilledRect(screen, float32(x), float32(y), float32(size), float32(size), color.RGBA{45, 45, 55, 255}, false)
+	vector.StrokeRect(screen, float32(x), float32(y), float32(size), float32(size), 2, border, false)
+
+	if count > 0 {
+		is.drawStack(screen, is.stackAt(ref).ItemID, count, x, y)
+	}
+}
+
+// drawStack draws an item's icon (or a coloured placeholder) and its count
+func (is *InventoryState) drawStack(screen *ebiten.Image, itemID string, count int, x, y float64) {
+	size := is.grid.SlotSize - iconInset*2
+	def := is.inventory.Defs().Get(itemID)
+
+	var icon *ebiten.Image
+	if def != nil && def.Icon != "" {
+		icon = is.assetManager.GetImage(def.Icon)
+	}
+	if icon != nil {
+		op := &ebiten.DrawImageOptions{}
+		op.GeoM.Scale(size/float64(icon.Bounds().Dx()), size/float64(icon.Bounds().Dy()))
+		op.GeoM.Translate(x+iconInset, y+iconInset)
+		screen.DrawImage(icon, op)
+	} else {
+		vector.DrawFilledRect(screen, float32(x+iconInset), float32(y+iconInset), float32(size), float32(size), placeholderColor(def), false)
+	}
+
+	if count > 1 {
+		text := fmt.Sprintf("%d", count)
+		ebitenutil.DebugPrintAt(screen, text, int(x+is.grid.SlotSize)-len(text)*6-4, int(y+is.grid.SlotSize)-18)
+	}
+}
+
+// drawStats lists the owner's derived stats, with the base value when gear or buffs change it
+func (is *InventoryState) drawStats(screen *ebiten.Image, x, y float64) {
+	st := entity.StatsOf(is.owner)
+	if st == nil {
+		return
+	}
+
+	lines := []string{"Stats"}
+	for _, stat := range st.Stats() {
+		name := statNames[stat]
+		if name == "" {
+			name = string(stat)
+		}
+		line := fmt.Sprintf("%s: %s", name, formatStat(st.Get(stat)))
+		if base := st.BaseValue(stat); base != st.Get(stat) {
+			line += fmt.Sprintf(" (%s)", formatStat(base))
+		}
+		lines = append(lines, line)
+	}
+	ebitenutil.DebugPrintAt(screen, strings.Join(lines, "\n"), int(x), int(y))
+}
+
+// drawTooltip describes the item under the mouse or cursor
+func (is *InventoryState) drawTooltip(screen *ebiten.Image) {
+	ref := is.cursor
+	if is.usingMouse {
+		ref = is.hover
+	}
+	stack := is.stackAt(ref)
+	if stack.Empty() {
+		return
+	}
+	def := is.inventory.Defs().Get(stack.ItemID)
+	if def == nil {
+		return
+	}
+
+	lines := []string{
+		def.Name,
+		fmt.Sprintf("%s %s", capitalize(def.Rarity.String()), def.Category),
+		fmt.Sprintf("Weight: %.2f (x%d = %.2f)", def.Weight, stack.Count, def.Weight*float64(stack.Count)),
+	}
+	if def.Equip != nil {
+		lines = append(lines, "Slot: "+def.Equip.Slot)
+		for _, m := range def.Equip.Stats {
+			lines = append(lines, describeModifier(m))
+		}
+	}
+	if def.Description != "" {
+		lines = append(lines, def.Description)
+	}
+
+	width := 0
+	for _, line := range lines {
+		width = max(width, len(line)*6)
+	}
+	x, y := is.slotRect(ref)
+	x += is.grid.SlotSize + 8
+	w, h := float64(width+16), float64(len(lines)*16+12)
+
+	vector.DrawFilledRect(screen, float32(x), float32(y), float32(w), float32(h), color.RGBA{10, 10, 15, 240}, false)
+	vector.StrokeRect(screen, float32(x), float32(y), float32(w), float32(h), 1, rarityColor(def.Rarity), false)
+	ebitenutil.DebugPrintAt(screen, strings.Join(lines, "\n"), int(x+8), int(y+6))
+}
+
+// GetStateID returns a unique identifier for this state
+func (is *InventoryState) GetStateID() string {
+	return "Inventory"
+}
+
+func splitModifierHeld() bool {
+	return ebiten.IsKeyPressed(ebiten.KeyShift)
+}
+
+// gamepadJustPressed reports whether any connected standard gamepad pressed the button
+func gamepadJustPressed(button ebiten.StandardGamepadButton) bool {
+	for _, id := range ebiten.AppendGamepadIDs(nil) {
+		if ebiten.IsStandardGamepadLayoutAvailable(id) && inpututil.IsStandardGamepadButtonJustPressed(id, button) {
+			return true
+		}
+	}
+	return false
+}
+
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+func formatStat(v float64) string {
+	return strings.TrimSuffix(fmt.Sprintf("%.1f", v), ".0")
+}
+
+// describeModifier turns a modifier into text like "+4 attack" or "-5% moveSpeed"
+func describeModifier(m stats.Modifier) string {
+	name := strings.ToLower(statNames[m.Stat])
+	if name == "" {
+		name = string(m.Stat)
+	}
+	switch m.Op {
+	case stats.Percent:
+		return fmt.Sprintf("%+g%% %s", m.Value*100, name)
+	case stats.Multiply:
+		return fmt.Sprintf("x%g %s", m.Value, name)
+	default:
+		return fmt.Sprintf("%+g %s", m.Value, name)
+	}
+}
+
+func describeInventoryError(err error) string {
+	switch {
+	case errors.Is(err, item.ErrNoSpace):
+		return "There isn't room for that."
+	case errors.Is(err, item.ErrItemMismatch):
+		return "Those items don't stack."
+	case errors.Is(err, item.ErrOverweight):
+		return "That would be too heavy to carry."
+	case errors.Is(err, equipment.ErrNotEquippable):
+		return "That can't be equipped."
+	case errors.Is(err, equipment.ErrWrongSlot):
+		return "That doesn't go there."
+	default:
+		return err.Error()
+	}
+}
+
+func placeholderColor(def *item.Def) color.RGBA {
+	if def == nil {
+		return color.RGBA{255, 0, 255, 255}
+	}
+	var r, g, b uint8
+	if _, err := fmt.Sscanf(strings.TrimPrefix(def.Color, "#"), "%02x%02x%02x", &r, &g, &b); err != nil {
+		return color.RGBA{160, 160, 160, 255}
+	}
+	return color.RGBA{r, g, b, 255}
+}
+
+func rarityColor(r item.Rarity) color.RGBA {
+	switch r {
+	case item.RarityUncommon:
+		return color.RGBA{80, 200, 80, 255}
+	case item.RarityRare:
+		return color.RGBA{80, 140, 255, 255}
+	case item.RarityEpic:
+		return color.RGBA{180, 80, 255, 255}
+	case item.RarityLegendary:
+		return color.RGBA{255, 160, 40, 255}
+	default:
+		return color.RGBA{200, 200, 200, 255}
+	}
+}
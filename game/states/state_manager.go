@@ -28,6 +28,15 @@ type GameState interface {
 	GetStateID() string
 }
 
+// Overlay is a state drawn on top of the states beneath it instead of replacing them.
+// States below a non-blocking overlay keep updating.
+type Overlay interface {
+	GameState
+
+	// BlocksUpdate reports whether states underneath should stop updating
+	BlocksUpdate() bool
+}
+
 // StateManager controls the flow between different game states
 type StateManager struct {
 	states       []GameState   // Stack of game states
@@ -126,14 +135,43 @@ func (sm *StateManager) Update() error {
 		sm.isProcessing = false
 	}
 
-	// Update active state
-	if len(sm.states) > 0 {
-		return sm.states[len(sm.states)-1].Update()
+	// Update the active state and anything a non-blocking overlay lets through,
+	// bottom first so overlays see the latest state of the world
+	states := sm.states
+	for i := sm.updateBase(); i < len(states); i++ {
+		if err := states[i].Update(); err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
+// updateBase returns the lowest state that should still be updated
+func (sm *StateManager) updateBase() int {
+	i := len(sm.states) - 1
+	for i > 0 {
+		overlay, ok := sm.states[i].(Overlay)
+		if !ok || overlay.BlocksUpdate() {
+			break
+		}
+		i--
+	}
+	return max(i, 0)
+}
+
+// drawBase returns the lowest state that is visible beneath any overlays
+func (sm *StateManager) drawBase() int {
+	i := len(sm.states) - 1
+	for i > 0 {
+		if _, ok := sm.states[i].(Overlay); !ok {
+			break
+		}
+		i--
+	}
+	return max(i, 0)
+}
+
 // RequestStateChange queues a state change
 func (sm *StateManager) RequestStateChange(change StateChange) {
 	sm.stateChanges = append(sm.stateChanges, change)
@@ -169,10 +207,13 @@ func (sm *StateManager) ClearStates() {
 	})
 }
 
-// Draw draws the active state
+// Draw draws the active state, along with the states beneath it if it's an overlay
 func (sm *StateManager) Draw(screen *ebiten.Image) {
-	if len(sm.states) > 0 {
-		sm.states[len(sm.states)-1].Draw(screen)
+	if len(sm.states) == 0 {
+		return
+	}
+	for i := sm.drawBase(); i < len(sm.states); i++ {
+		sm.states[i].Draw(screen)
 	}
 }
 
@@ -0,0 +1,100 @@
+package states
+
+import (
+	"image/color"
+	"log"
+
+	"github.com/Nathene/bitbase/game"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// fadeFrames is how many frames the screen takes to fade out, and again to fade in
+const fadeFrames = 20
+
+// transitionPhase is how far through a transition we are
+type transitionPhase int
+
+const (
+	fadingOut transitionPhase = iota
+	loadingMap
+	fadingIn
+)
+
+// TransitionState is a blocking overlay that fades the screen to black, makes
+// the game's waiting trip to another map, and fades back in
+type TransitionState struct {
+	game         *game.Game
+	stateManager *StateManager
+	phase        transitionPhase
+	alpha        float64 // 0 clear, 1 black
+}
+
+// NewTransitionState creates a transition for a game that is travelling
+func NewTransitionState(g *game.Game, stateManager *StateManager) *TransitionState {
+	return &TransitionState{
+		game:         g,
+		stateManager: stateManager,
+	}
+}
+
+// Initialize sets up the transition state
+func (ts *TransitionState) Initialize() error {
+	return nil
+}
+
+// Enter is called when this state becomes active
+func (ts *TransitionState) Enter() error {
+	return nil
+}
+
+// Exit is called when this state is no longer active
+func (ts *TransitionState) Exit() error {
+	return nil
+}
+
+// BlocksUpdate stops the game while the screen fades
+func (ts *TransitionState) BlocksUpdate() bool {
+	return true
+}
+
+// Update moves the fade along and loads the map once the screen is black
+func (ts *TransitionState) Update() error {
+	switch ts.phase {
+	case fadingOut:
+		if ts.alpha = min(ts.alpha+1.0/fadeFrames, 1); ts.alpha == 1 {
+			ts.phase = loadingMap
+		}
+	case loadingMap:
+		// One black frame with the loading text is drawn before this runs
+		if err := ts.game.FinishTravel(); err != nil {
+			log.Printf("Failed to travel: %v", err)
+			ts.game.ShowMessage("The way is blocked.")
+		}
+		ts.phase = fadingIn
+	case fadingIn:
+		if ts.alpha = max(ts.alpha-1.0/fadeFrames, 0); ts.alpha == 0 {
+			ts.stateManager.PopState()
+		}
+	}
+	return nil
+}
+
+// HandleInput processes all input for this state
+func (ts *TransitionState) HandleInput() error {
+	return nil
+}
+
+// Draw covers the game with black at the current fade
+func (ts *TransitionState) Draw(screen *ebiten.Image) {
+	vector.DrawFilledRect(screen, 0, 0, game.ScreenWidth, game.ScreenHeight, color.RGBA{0, 0, 0, uint8(ts.alpha * 255)}, false)
+	if ts.phase == loadingMap {
+		ebitenutil.DebugPrintAt(screen, "Loading...", game.ScreenWidth/2-30, game.ScreenHeight/2-8)
+	}
+}
+
+// GetStateID returns a unique identifier for this state
+func (ts *TransitionState) GetStateID() string {
+	return "Transition"
+}
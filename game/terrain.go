@@ -0,0 +1,100 @@
+package game
+
+import (
+	"math"
+
+	"github.com/Nathene/bitbase/anim"
+	"github.com/Nathene/bitbase/combat"
+	"github.com/Nathene/bitbase/entity"
+	"github.com/Nathene/bitbase/tilemap"
+)
+
+const (
+	hazardInterval = 0.5 // Seconds between ticks of damage from the ground
+	iceGrip        = 2.5 // How quickly the player's speed follows their input on slippery ground, per second
+)
+
+// TerrainAt returns what the ground is like at a world position
+func (g *Game) TerrainAt(x, y float64) tilemap.Terrain {
+	p := g.tileAt(x, y)
+	return g.World.Terrain(p.X, p.Y)
+}
+
+// TerrainUnder returns what the ground is like under an object's feet
+func (g *Game) TerrainUnder(o *entity.Object) tilemap.Terrain {
+	return g.TerrainAt(o.Feet())
+}
+
+// OnFootstep registers a function to call when a walking object's foot lands.
+// sound is the footstep property of the ground it landed on, which may be empty.
+func (g *Game) OnFootstep(fn func(o *entity.Object, sound string)) {
+	g.footsteps = append(g.footsteps, fn)
+}
+
+// onAnimationFootstep turns "footstep" animation events into footsteps on the ground underfoot
+func (g *Game) onAnimationFootstep(o *entity.Object, e anim.Event) {
+	if e.Name != "footstep" {
+		return
+	}
+	sound := g.TerrainUnder(o).Footstep
+	for _, fn := range g.footsteps {
+		fn(o, sound)
+	}
+}
+
+// terrainMove adjusts the player's movement for this step by the ground under
+// them: slower in mud and water, only up and down on ladders, and carrying
+// momentum on ice
+func (g *Game) terrainMove(dx, dy, dt float64) (float64, float64) {
+	t := g.TerrainUnder(&g.Player.Object)
+	dx, dy = dx*t.Speed, dy*t.Speed
+	if t.Ladder {
+		dx = 0
+	}
+
+	if !t.Slippery {
+		g.slide = [2]float64{}
+		return dx, dy
+	}
+	// On ice the velocity only drifts towards what the player is asking for
+	grip := math.Min(iceGrip*dt, 1)
+	g.slide[0] += (dx/dt - g.slide[0]) * grip
+	g.slide[1] += (dy/dt - g.slide[1]) * grip
+	return g.slide[0] * dt, g.slide[1] * dt
+}
+
+// stopSlide keeps momentum from pushing into whatever stopped the player
+func (g *Game) stopSlide(wantX, wantY, movedX, movedY, dt float64) {
+	if math.Abs(movedX-wantX) > 1e-9 {
+		g.slide[0] = movedX / dt
+	}
+	if math.Abs(movedY-wantY) > 1e-9 {
+		g.slide[1] = movedY / dt
+	}
+}
+
+// updateHazards hurts everything standing on damaging ground, in ticks so the
+// combat log isn't flooded
+func (g *Game) updateHazards(dt float64) {
+	g.hazardTimer += dt
+	if g.hazardTimer < hazardInterval {
+		return
+	}
+	g.hazardTimer -= hazardInterval
+
+	for _, o := range g.Entities.All() {
+		if combat.DamageableOf(o) == nil || combat.IsDead(o) {
+			continue
+		}
+		t := g.TerrainUnder(o)
+		if t.DamagePerSecond <= 0 {
+			continue
+		}
+		g.Combat.Damage(o, combat.Damage{
+			Amount:                t.DamagePerSecond * hazardInterval,
+			Type:                  combat.DamageType(t.DamageType),
+			IgnoreInvulnerability: true,
+			OverTime:              true,
+		})
+	}
+}
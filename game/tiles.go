@@ -0,0 +1,244 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+
+	"github.com/Nathene/bitbase/anim"
+	"github.com/Nathene/bitbase/common"
+	"github.com/Nathene/bitbase/entity"
+	"github.com/Nathene/bitbase/pathfind"
+	"github.com/Nathene/bitbase/physics"
+	"github.com/Nathene/bitbase/tilemap"
+)
+
+const (
+	dropPrefab        = "item_drop" // Spawned for what a broken tile drops
+	defaultPlaceLayer = "walls"     // Layer blocks go on if their item doesn't say
+)
+
+var (
+	ErrCellTaken    = errors.New("something is already there")
+	ErrCellOccupied = errors.New("something is standing there")
+)
+
+// tileCell is one cell of one of World's layers
+type tileCell struct {
+	layer, x, y int
+}
+
+// breakable is what a tile's "hits", "breaks_into", "drop" and "break_sound"
+// properties say about breaking it. Tiles without "hits" can't be broken.
+type breakable struct {
+	hits  int         // Swings it takes
+	into  tilemap.GID // Left behind, 0 for nothing; "breaks_into" names a tile type
+	drop  string      // Item dropped
+	sound string
+}
+
+// breakableTile returns how a tile breaks, and false if it doesn't
+func (g *Game) breakableTile(gid tilemap.GID) (breakable, bool) {
+	t := g.Map.Tile(gid)
+	if t == nil || t.Properties.Int("hits", 0) <= 0 {
+		return breakable{}, false
+	}
+	b := breakable{
+		hits:  t.Properties.Int("hits", 0),
+		drop:  t.Properties.String("drop", ""),
+		sound: t.Properties.String("break_sound", ""),
+	}
+	if into := t.Properties.String("breaks_into", ""); into != "" {
+		if b.into = g.Map.TileOfType(into); b.into == 0 {
+			log.Printf("Tile %d breaks into unknown tile type %q", gid.ID(), into)
+		}
+	}
+	return b, true
+}
+
+// watchTiles keeps what is built from World up to date as its tiles change
+func (g *Game) watchTiles() {
+	g.World.OnSet(g.onTileSet)
+}
+
+// onTileSet runs after any cell of World changes. Collision and drawing read
+// the world directly and autotiles watch it themselves.
+func (g *Game) onTileSet(layer, x, y int, old, gid tilemap.GID) {
+	g.Paths.Invalidate()
+	delete(g.tileHits, tileCell{layer, x, y})
+}
+
+// hitTiles lands the player's melee swings on breakable tiles in the hitbox.
+// Only the top-most breakable tile of each cell takes the hit.
+func (g *Game) hitTiles(attacker *entity.Object, box common.Rect) {
+	if attacker != &g.Player.Object {
+		return // Enemies don't wreck the map
+	}
+	tw, th := float64(g.Map.TileWidth), float64(g.Map.TileHeight)
+	x0, y0 := int(math.Floor(box.X/tw)), int(math.Floor(box.Y/th))
+	x1, y1 := int(math.Ceil(box.MaxX()/tw))-1, int(math.Ceil(box.MaxY()/th))-1
+	for y := y0; y <= y1; y++ {
+		for x := x0; x <= x1; x++ {
+			for layer := len(g.World.Layers) - 1; layer >= 0; layer-- {
+				gid := g.World.At(layer, x, y)
+				if b, ok := g.breakableTile(gid); ok {
+					g.hitTile(tileCell{layer, x, y}, b)
+					break
+				}
+			}
+		}
+	}
+}
+
+// hitTile counts a hit on a tile and breaks it once it has taken enough
+func (g *Game) hitTile(cell tileCell, b breakable) {
+	g.tileHits[cell]++
+	if g.tileHits[cell] < b.hits {
+		return
+	}
+	g.World.Set(cell.layer, cell.x, cell.y, b.into)
+
+	x, y := g.tileCenter(pathfind.Point{X: cell.x, Y: cell.y})
+	if b.sound != "" {
+		g.PlaySound(b.sound, x, y)
+	}
+	if b.drop != "" {
+		if _, err := g.Spawner.Spawn(dropPrefab, x, y, map[string]map[string]any{
+			"interactable": {"items": []any{b.drop}},
+		}); err != nil {
+			log.Printf("Failed to drop %s from a broken tile: %v", b.drop, err)
+		}
+	}
+}
+
+// PlaceTile puts a tile in an empty cell of a named layer, as long as nothing
+// solid is standing there
+func (g *Game) PlaceTile(layer string, x, y int, gid tilemap.GID) error {
+	i := g.World.Layer(layer)
+	switch {
+	case i < 0:
+		return fmt.Errorf("no tile layer %q", layer)
+	case !g.World.InBounds(x, y):
+		return fmt.Errorf("tile %d,%d is outside the map", x, y)
+	case g.World.At(i, x, y) != 0:
+		return ErrCellTaken
+	}
+	tw, th := float64(g.Map.TileWidth), float64(g.Map.TileHeight)
+	cell := common.Rect{X: float64(x) * tw, Y: float64(y) * th, W: tw, H: th}
+	for _, o := range g.Entities.QueryRect(cell) {
+		if c := physics.ColliderOf(o); c != nil && c.Solid() && c.Bounds(o.GetX(), o.GetY()).Intersects(cell) {
+			return ErrCellOccupied
+		}
+	}
+	g.World.Set(i, x, y, gid)
+	return nil
+}
+
+// placeBlock puts down the first item in the player's inventory that has a
+// "places" property, naming a tile type, in the cell in front of them.
+// A "place_layer" property picks the layer, "walls" by default.
+func (g *Game) placeBlock() {
+	p := &g.Player.Object
+	inv := p.GetInventory()
+	if inv == nil {
+		return
+	}
+	var itemID, tileType, layer string
+	for _, st := range inv.Slots() {
+		if st.Empty() {
+			continue
+		}
+		if def := g.Items.Get(st.ItemID); def != nil && def.StringProperty("places", "") != "" {
+			itemID = st.ItemID
+			tileType = def.StringProperty("places", "")
+			layer = def.StringProperty("place_layer", defaultPlaceLayer)
+			break
+		}
+	}
+	if itemID == "" {
+		g.ShowMessage("You have nothing to build with.")
+		return
+	}
+	gid := g.Map.TileOfType(tileType)
+	if gid == 0 {
+		log.Printf("Item %s places unknown tile type %q", itemID, tileType)
+		return
+	}
+
+	x, y := g.cellInFront(p)
+	if err := g.PlaceTile(layer, x, y, gid); err != nil {
+		g.ShowMessage("Can't build there.")
+		return
+	}
+	if err := inv.Remove(itemID, 1); err != nil {
+		log.Printf("Failed to use up %s: %v", itemID, err)
+	}
+}
+
+// cellInFront returns the nearest cell the way an object is facing that its
+// collider doesn't reach into, in line with its feet
+func (g *Game) cellInFront(o *entity.Object) (int, int) {
+	faceX, faceY := 0.0, 1.0
+	if animator := anim.AnimatorOf(o); animator != nil {
+		faceX, faceY = animator.Direction().Vector()
+	}
+	b := o.Bounds()
+	if c := physics.ColliderOf(o); c != nil {
+		b = c.Bounds(o.GetX(), o.GetY())
+	}
+	tw, th := float64(g.Map.TileWidth), float64(g.Map.TileHeight)
+	fx, fy := o.Feet()
+	x, y := int(math.Floor(fx/tw)), int(math.Floor(fy/th))
+	switch {
+	case faceX > 0.1:
+		x = int(math.Ceil(b.MaxX() / tw))
+	case faceX < -0.1:
+		x = int(math.Floor(b.X/tw)) - 1
+	}
+	switch {
+	case faceY > 0.1:
+		y = int(math.Ceil(b.MaxY() / th))
+	case faceY < -0.1:
+		y = int(math.Floor(b.Y/th)) - 1
+	}
+	return x, y
+}
+
+// TileChanges returns the changed cells of every map visited, by map file.
+// Saves store these rather than whole maps.
+func (g *Game) TileChanges() map[string][]tilemap.TileChange {
+	saved := make(map[string][]tilemap.TileChange)
+	if changes := g.World.Changes(); len(changes) > 0 {
+		saved[g.mapPath] = changes
+	}
+	for path, s := range g.maps {
+		if changes := s.World.Changes(); len(changes) > 0 {
+			saved[path] = changes
+		}
+	}
+	for path, changes := range g.pendingTiles {
+		saved[path] = changes
+	}
+	return saved
+}
+
+// RestoreTileChanges puts back changes from TileChanges, such as when loading
+// a save. Changes to maps not visited yet are applied when the player gets there.
+func (g *Game) RestoreTileChanges(saved map[string][]tilemap.TileChange) {
+	for path, changes := range saved {
+		var w *tilemap.World
+		switch s, ok := g.maps[path]; {
+		case path == g.mapPath:
+			w = g.World
+		case ok:
+			w = s.World
+		default:
+			g.pendingTiles[path] = changes
+			continue
+		}
+		if err := w.ApplyChanges(changes); err != nil {
+			log.Printf("Failed to restore tiles of %s: %v", path, err)
+		}
+	}
+}
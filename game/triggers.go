@@ -0,0 +1,107 @@
+package game
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/Nathene/bitbase/entity"
+	"github.com/Nathene/bitbase/tilemap"
+	"github.com/Nathene/bitbase/trigger"
+)
+
+// loadTriggers makes a zone of every trigger object on the current map
+func (g *Game) loadTriggers() {
+	var zones []*trigger.Zone
+	for _, o := range g.Map.Objects(trigger.ObjectType) {
+		z, err := trigger.FromObject(o)
+		if err != nil {
+			log.Printf("Failed to load trigger zone: %v", err)
+			continue
+		}
+		zones = append(zones, z)
+	}
+	g.Triggers.SetZones(zones)
+}
+
+// OnSound registers a function to call when a trigger plays a sound
+func (g *Game) OnSound(fn func(sound string, x, y float64)) {
+	g.sounds = append(g.sounds, fn)
+}
+
+// PlaySound passes a sound coming from a point in the world to the OnSound functions
+func (g *Game) PlaySound(sound string, x, y float64) {
+	for _, fn := range g.sounds {
+		fn(sound, x, y)
+	}
+}
+
+// OnCutscene registers a function to call when a trigger starts a cutscene
+func (g *Game) OnCutscene(fn func(name string)) {
+	g.cutscenes = append(g.cutscenes, fn)
+}
+
+// StartCutscene passes a cutscene to the OnCutscene functions to play
+func (g *Game) StartCutscene(name string) {
+	if len(g.cutscenes) == 0 {
+		log.Printf("Nothing plays cutscene %q", name)
+	}
+	for _, fn := range g.cutscenes {
+		fn(name)
+	}
+}
+
+// ShowDialog shows lines one after another as messages, with the speaker's name
+func (g *Game) ShowDialog(speaker string, lines []string) {
+	g.dialog = g.dialog[:0]
+	for _, line := range lines {
+		if speaker != "" {
+			line = speaker + ": " + line
+		}
+		g.dialog = append(g.dialog, line)
+	}
+	g.nextDialogLine()
+}
+
+// nextDialogLine shows the next waiting line of dialogue, if there is one
+func (g *Game) nextDialogLine() {
+	if len(g.dialog) == 0 {
+		return
+	}
+	g.ShowMessage(g.dialog[0])
+	g.dialog = g.dialog[1:]
+}
+
+// SpawnEntity creates an entity from a prefab at a world position
+func (g *Game) SpawnEntity(prefab string, x, y float64) error {
+	_, err := g.Spawner.Spawn(prefab, x, y, nil)
+	return err
+}
+
+// SetTile changes one cell of a named tile layer
+func (g *Game) SetTile(layer string, x, y int, gid tilemap.GID) error {
+	i := g.World.Layer(layer)
+	if i < 0 {
+		return fmt.Errorf("no tile layer %q", layer)
+	}
+	if !g.World.InBounds(x, y) {
+		return fmt.Errorf("tile %d,%d is outside the map", x, y)
+	}
+	g.World.Set(i, x, y, gid)
+	return nil
+}
+
+// HasItem reports whether an entity carries at least one of an item
+func (g *Game) HasItem(o *entity.Object, itemID string) bool {
+	inv := o.GetInventory()
+	return inv != nil && inv.Count(itemID) > 0
+}
+
+// QuestStage returns how far a quest has got, 0 if it hasn't started
+func (g *Game) QuestStage(quest string) int {
+	return g.quests[quest]
+}
+
+// SetQuestStage moves a quest to a stage
+func (g *Game) SetQuestStage(quest string, stage int) {
+	g.quests[quest] = stage
+}
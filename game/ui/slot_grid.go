@@ -0,0 +1,71 @@
+package ui
+
+// SlotGrid lays out a grid of equally sized slots and maps screen positions to them
+type SlotGrid struct {
+	X, Y     float64 // Top-left corner of the first slot
+	Columns  int
+	Rows     int
+	SlotSize float64
+	Spacing  float64
+}
+
+// NewSlotGrid creates a grid centered on (centerX, centerY)
+func NewSlotGrid(centerX, centerY float64, columns, rows int, slotSize, spacing float64) *SlotGrid {
+	g := &SlotGrid{
+		Columns:  columns,
+		Rows:     rows,
+		SlotSize: slotSize,
+		Spacing:  spacing,
+	}
+	g.X = centerX - g.Width()/2
+	g.Y = centerY - g.Height()/2
+	return g
+}
+
+// Count returns the number of slots in the grid
+func (g *SlotGrid) Count() int {
+	return g.Columns * g.Rows
+}
+
+// Width returns the total width of the grid
+func (g *SlotGrid) Width() float64 {
+	return float64(g.Columns)*g.SlotSize + float64(g.Columns-1)*g.Spacing
+}
+
+// Height returns the total height of the grid
+func (g *SlotGrid) Height() float64 {
+	return float64(g.Rows)*g.SlotSize + float64(g.Rows-1)*g.Spacing
+}
+
+// SlotRect returns the top-left corner of slot i
+func (g *SlotGrid) SlotRect(i int) (float64, float64) {
+	col := i % g.Columns
+	row := i / g.Columns
+	return g.X + float64(col)*(g.SlotSize+g.Spacing), g.Y + float64(row)*(g.SlotSize+g.Spacing)
+}
+
+// SlotAt returns the slot under a screen position, or -1 if there is none
+func (g *SlotGrid) SlotAt(x, y float64) int {
+	if x < g.X || y < g.Y {
+		return -1
+	}
+	step := g.SlotSize + g.Spacing
+	col := int((x - g.X) / step)
+	row := int((y - g.Y) / step)
+	if col >= g.Columns || row >= g.Rows {
+		return -1
+	}
+
+	// Ignore the gaps between slots
+	if x-g.X-float64(col)*step > g.SlotSize || y-g.Y-float64(row)*step > g.SlotSize {
+		return -1
+	}
+	return row*g.Columns + col
+}
+
+// Step moves a slot index by (dx, dy) cells, wrapping around the edges
+func (g *SlotGrid) Step(i, dx, dy int) int {
+	col := (i%g.Columns + dx + g.Columns) % g.Columns
+	row := (i/g.Columns + dy + g.Rows) % g.Rows
+	return row*g.Columns + col
+}
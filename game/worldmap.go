@@ -0,0 +1,132 @@
+package game
+
+import (
+	"encoding/json"
+	"log"
+	"strings"
+
+	"github.com/Nathene/bitbase/tilemap"
+	"github.com/Nathene/bitbase/tilemap/gen"
+	"github.com/Nathene/bitbase/trigger"
+)
+
+// playerStart is the object type that marks where the player begins
+const playerStart = gen.PlayerStart
+
+// markerTypes are map object types that mark places rather than spawn prefabs
+var markerTypes = map[string]bool{
+	playerStart:        true,
+	portalType:         true,
+	spawnType:          true,
+	trigger.ObjectType: true,
+}
+
+// Where the player starts if the map doesn't say
+const (
+	defaultPlayerX = 1000
+	defaultPlayerY = 1000
+)
+
+// loadWorld generates the world if WorldSeed is set, and otherwise loads the
+// hand-made map
+func loadWorld() *tilemap.Map {
+	if WorldSeed == 0 {
+		return loadMap(worldMapPath)
+	}
+	m, err := generateMap(worldGenPath, WorldSeed)
+	if err != nil {
+		log.Printf("Failed to generate world from seed %d: %v", WorldSeed, err)
+		return loadMap(worldMapPath)
+	}
+	return m
+}
+
+// generateMap builds a map from a generator config
+func generateMap(path string, seed int64) (*tilemap.Map, error) {
+	c, err := gen.LoadConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	m, err := gen.Generate(c, seed)
+	if err != nil {
+		return nil, err
+	}
+	m.Path = path // So portals on it can find maps beside the config
+	return m, nil
+}
+
+// loadMap reads the world map, falling back to an empty walled field so the
+// game stays playable if the file is missing or broken
+func loadMap(path string) *tilemap.Map {
+	m, err := tilemap.Load(path)
+	if err == nil {
+		return m
+	}
+	log.Printf("Failed to load map: %v", err)
+	return fallbackMap()
+}
+
+// fallbackMap is a 100x100 open field with a wall around the edge
+func fallbackMap() *tilemap.Map {
+	m := tilemap.New(100, 100, tileSize, tileSize)
+	walls := m.AddTileLayer("walls")
+	walls.Properties["collision"] = true
+	for y := 0; y < m.Height; y++ {
+		for x := 0; x < m.Width; x++ {
+			if x == 0 || y == 0 || x == m.Width-1 || y == m.Height-1 {
+				walls.Set(x, y, 1)
+			}
+		}
+	}
+	return m
+}
+
+// playerStartPosition returns where the map puts the player
+func (g *Game) playerStartPosition() (float64, float64) {
+	if starts := g.Map.Objects(playerStart); len(starts) > 0 {
+		return starts[0].X, starts[0].Y
+	}
+	return defaultPlayerX, defaultPlayerY
+}
+
+// spawnMapObjects spawns a prefab for every typed object on the map, using the
+// type as the prefab name. An "overrides" string property holds JSON component
+// overrides for that one object.
+func (g *Game) spawnMapObjects() {
+	for _, o := range g.Map.Objects("") {
+		if o.Type == "" || markerTypes[o.Type] {
+			continue
+		}
+
+		var overrides map[string]map[string]any
+		if raw := o.Properties.String("overrides", ""); raw != "" {
+			if err := json.Unmarshal([]byte(raw), &overrides); err != nil {
+				log.Printf("Failed to read overrides for map object %d (%s): %v", o.ID, o.Type, err)
+				continue
+			}
+		}
+		if _, err := g.Spawner.Spawn(o.Type, o.X, o.Y, overrides); err != nil {
+			log.Printf("Failed to spawn %s: %v", o.Type, err)
+		}
+	}
+}
+
+// watchAutotiles keeps the terrains named in each tile layer's "autotile"
+// property (comma-separated) joined up when the world's tiles change
+func (g *Game) watchAutotiles() {
+	g.Autotiles = make(map[string]*tilemap.Autotile)
+	for i, l := range g.World.Layers {
+		for _, name := range strings.Split(l.Properties.String("autotile", ""), ",") {
+			if name = strings.TrimSpace(name); name == "" {
+				continue
+			}
+			a := g.Map.Autotile(name)
+			if a == nil {
+				log.Printf("Layer %s autotiles unknown terrain %q", l.Name, name)
+				continue
+			}
+			a.Watch(g.World, i)
+			g.Autotiles[name] = a
+		}
+	}
+}
@@ -0,0 +1,104 @@
+// Package input turns raw keyboard state into the actions the simulation understands
+package input
+
+import (
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+// State is a snapshot of the player's intent for one frame.
+// The simulation only ever reads State, so it can be driven headlessly.
+type State struct {
+	MoveX, MoveY float64 // Desired movement, each axis in -1..1
+	Run          bool
+
+	// Mouse position in screen pixels, for aiming and click-to-move
+	PointerX, PointerY float64
+
+	// One-shot presses, true only for the frame the key went down
+	Interact bool
+	Attack   bool
+	Shoot    bool
+	// AimAtPointer is set when Shoot came from the mouse, so the shot goes towards
+	// the pointer rather than the way the player is facing
+	AimAtPointer bool
+	// MoveTo is a left click on the world: walk to the pointer
+	MoveTo bool
+	// Place puts a block from the inventory down in front of the player
+	Place bool
+}
+
+// Poll reads the current keyboard and mouse state
+func Poll() State {
+	var s State
+	if ebiten.IsKeyPressed(ebiten.KeyW) || ebiten.IsKeyPressed(ebiten.KeyUp) {
+		s.MoveY--
+	}
+	if ebiten.IsKeyPressed(ebiten.KeyS) || ebiten.IsKeyPressed(ebiten.KeyDown) {
+		s.MoveY++
+	}
+	if ebiten.IsKeyPressed(ebiten.KeyA) || ebiten.IsKeyPressed(ebiten.KeyLeft) {
+		s.MoveX--
+	}
+	if ebiten.IsKeyPressed(ebiten.KeyD) || ebiten.IsKeyPressed(ebiten.KeyRight) {
+		s.MoveX++
+	}
+	s.Run = ebiten.IsKeyPressed(ebiten.KeyShift)
+	s.Interact = inpututil.IsKeyJustPressed(ebiten.KeyE)
+	s.Attack = inpututil.IsKeyJustPressed(ebiten.KeySpace) || inpututil.IsKeyJustPressed(ebiten.KeyJ)
+
+	mx, my := ebiten.CursorPosition()
+	s.PointerX, s.PointerY = float64(mx), float64(my)
+	s.AimAtPointer = inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonRight)
+	s.Shoot = s.AimAtPointer || inpututil.IsKeyJustPressed(ebiten.KeyF)
+	s.MoveTo = inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft)
+	s.Place = inpututil.IsKeyJustPressed(ebiten.KeyB)
+	return s
+}
+
+// Held returns the state with one-shot presses cleared, so a press is only
+// acted on by the first simulation step of a frame
+func (s State) Held() State {
+	s.Interact = false
+	s.Attack = false
+	s.Shoot = false
+	s.AimAtPointer = false
+	s.MoveTo = false
+	s.Place = false
+	return s
+}
+
+// WithPresses returns s with any one-shot presses from other added, so presses
+// made during frames that ran no simulation step aren't lost
+func (s State) WithPresses(other State) State {
+	s.Interact = s.Interact || other.Interact
+	s.Attack = s.Attack || other.Attack
+	if other.Shoot && !s.Shoot {
+		s.Shoot = true
+		s.AimAtPointer = other.AimAtPointer
+	}
+	s.MoveTo = s.MoveTo || other.MoveTo
+	s.Place = s.Place || other.Place
+	return s
+}
+
+// Direction returns the movement direction as a unit vector (or zero),
+// so diagonal movement is no faster than straight movement
+func (s State) Direction() (float64, float64) {
+	length := math.Hypot(s.MoveX, s.MoveY)
+	if length == 0 {
+		return 0, 0
+	}
+	if length < 1 {
+		// Analog input below full tilt keeps its magnitude
+		return s.MoveX, s.MoveY
+	}
+	return s.MoveX / length, s.MoveY / length
+}
+
+// Moving reports whether any movement is requested
+func (s State) Moving() bool {
+	return s.MoveX != 0 || s.MoveY != 0
+}
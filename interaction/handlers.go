@@ -0,0 +1,108 @@
+package interaction
+
+import (
+	"fmt"
+	"strings"
+)
+
+func init() {
+	RegisterHandler("chest", openChest)
+	RegisterHandler("sign", readSign)
+	RegisterHandler("talk", talk)
+	RegisterHandler("lever", toggleLever)
+	RegisterHandler("pickup", pickUp)
+	RegisterHandler("door", openDoor)
+}
+
+// openChest hands the chest's contents to the actor. Items that don't fit stay inside.
+func openChest(ctx *Context) error {
+	i := ctx.Interactable
+	if len(i.Items) == 0 {
+		ctx.Host.ShowMessage("The chest is empty.")
+		i.On = true
+		i.Prompt = "Empty chest"
+		return nil
+	}
+
+	var taken, left []string
+	for _, item := range i.Items {
+		if ctx.Host.GiveItem(ctx.Actor, item) {
+			taken = append(taken, item)
+		} else {
+			left = append(left, item)
+		}
+	}
+	i.Items = left
+	i.On = true
+
+	if len(left) > 0 {
+		i.Prompt = "Search chest"
+		ctx.Host.ShowMessage(fmt.Sprintf("Found %s, but you can't carry the rest.", strings.Join(taken, ", ")))
+	} else {
+		i.Prompt = "Empty chest"
+		ctx.Host.ShowMessage(fmt.Sprintf("Found %s.", strings.Join(taken, ", ")))
+	}
+	return nil
+}
+
+// readSign shows the sign's text
+func readSign(ctx *Context) error {
+	ctx.Host.ShowMessage(ctx.Interactable.Text)
+	return nil
+}
+
+// talk shows the next line of dialogue, staying on the last line once it's reached
+func talk(ctx *Context) error {
+	i := ctx.Interactable
+	if len(i.Lines) == 0 {
+		return nil
+	}
+
+	line := i.Lines[i.line]
+	if i.line < len(i.Lines)-1 {
+		i.line++
+	}
+
+	if i.Speaker != "" {
+		line = i.Speaker + ": " + line
+	}
+	ctx.Host.ShowMessage(line)
+	return nil
+}
+
+// toggleLever flips the lever between on and off
+func toggleLever(ctx *Context) error {
+	i := ctx.Interactable
+	i.On = !i.On
+	if i.On {
+		ctx.Host.ShowMessage("The lever clicks into place.")
+	} else {
+		ctx.Host.ShowMessage("The lever swings back.")
+	}
+	return nil
+}
+
+// pickUp gives the items to the actor and removes the pickup once it's empty
+func pickUp(ctx *Context) error {
+	i := ctx.Interactable
+	for len(i.Items) > 0 {
+		if !ctx.Host.GiveItem(ctx.Actor, i.Items[0]) {
+			ctx.Host.ShowMessage("You can't carry any more.")
+			return nil
+		}
+		i.Items = i.Items[1:]
+	}
+	ctx.Host.RemoveEntity(ctx.Target)
+	return nil
+}
+
+// openDoor takes the actor through to wherever the door leads
+func openDoor(ctx *Context) error {
+	i := ctx.Interactable
+	if i.Map == "" && i.Spawn == "" {
+		ctx.Host.ShowMessage("It's locked.")
+		return nil
+	}
+	ctx.Host.Travel(i.Map, i.Spawn)
+	return nil
+}
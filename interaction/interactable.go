@@ -0,0 +1,117 @@
+// Package interaction lets the player use objects in the world with the interact key
+package interaction
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/Nathene/bitbase/entity"
+)
+
+// Interactable marks an object the player can use
+type Interactable struct {
+	Kind     string  `json:"kind"`     // Handler to run: chest, sign, talk, lever, pickup, door, ...
+	Prompt   string  `json:"prompt"`   // Text shown above the object, e.g. "Open chest"
+	Radius   float64 `json:"radius"`   // How close the player must be, in pixels
+	Priority int     `json:"priority"` // Higher priority targets win over closer ones
+	Disabled bool    `json:"disabled"`
+
+	// Handler-specific data
+	Text    string   `json:"text,omitempty"`    // Sign text
+	Speaker string   `json:"speaker,omitempty"` // Name shown when talking
+	Lines   []string `json:"lines,omitempty"`   // Dialogue lines, shown one per interaction
+	Items   []string `json:"items,omitempty"`   // Chest contents or the item a pickup grants
+	On      bool     `json:"on,omitempty"`      // Lever state, or whether a chest has been opened
+	Map     string   `json:"map,omitempty"`     // Where a door leads, relative to the current map; empty for the same map
+	Spawn   string   `json:"spawn,omitempty"`   // Name of the spawn point a door leads to
+
+	line int // Next dialogue line
+}
+
+func init() {
+	entity.RegisterComponent("interactable", func() entity.Component { return &Interactable{} })
+}
+
+func (i *Interactable) ComponentName() string { return "interactable" }
+
+// InteractableOf returns the object's interactable component, or nil
+func InteractableOf(o *entity.Object) *Interactable {
+	i, _ := o.Component("interactable").(*Interactable)
+	return i
+}
+
+// Host is implemented by the game to let handlers affect the world
+type Host interface {
+	// ShowMessage displays text to the player
+	ShowMessage(text string)
+	// GiveItem adds an item to an entity's inventory and reports whether it fitted
+	GiveItem(to *entity.Object, item string) bool
+	// RemoveEntity takes an object out of the world
+	RemoveEntity(o *entity.Object)
+	// Travel moves the player to a spawn point on another map, or on this
+	// one if mapPath is empty
+	Travel(mapPath, spawn string)
+}
+
+// Context is passed to a handler when an interaction happens
+type Context struct {
+	Host         Host
+	Actor        *entity.Object // Who is interacting, usually the player
+	Target       *entity.Object
+	Interactable *Interactable
+}
+
+// Handler performs an interaction
+type Handler func(ctx *Context) error
+
+var (
+	handlers     = make(map[string]Handler)
+	handlerMutex sync.RWMutex
+)
+
+// RegisterHandler makes a handler available to interactables of the given kind
+func RegisterHandler(kind string, handler Handler) {
+	handlerMutex.Lock()
+	defer handlerMutex.Unlock()
+
+	if _, exists := handlers[kind]; exists {
+		panic(fmt.Sprintf("interaction: handler %q registered twice", kind))
+	}
+	handlers[kind] = handler
+}
+
+// Kinds returns every registered handler kind in sorted order
+func Kinds() []string {
+	handlerMutex.RLock()
+	defer handlerMutex.RUnlock()
+
+	kinds := make([]string, 0, len(handlers))
+	for kind := range handlers {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+// Interact runs the handler for a target's interactable
+func Interact(host Host, actor, target *entity.Object) error {
+	i := InteractableOf(target)
+	if i == nil || i.Disabled {
+		return fmt.Errorf("%s cannot be interacted with", target.Name)
+	}
+
+	handlerMutex.RLock()
+	handler, ok := handlers[i.Kind]
+	handlerMutex.RUnlock()
+	if !ok {
+		return fmt.Errorf("no interaction handler for kind %q", i.Kind)
+	}
+
+	return handler(&Context{
+		Host:         host,
+		Actor:        actor,
+		Target:       target,
+		Interactable: i,
+	})
+}
@@ -0,0 +1,67 @@
+package interaction
+
+import (
+	"math"
+
+	"github.com/Nathene/bitbase/entity"
+)
+
+const (
+	// maxSearchRadius bounds the spatial query; no interactable should reach further
+	maxSearchRadius = 128
+	// closeRange is how near a target must be to be usable even when behind the actor
+	closeRange = 12
+	// minFacingDot rejects targets more than about 100 degrees off the facing direction
+	minFacingDot = -0.2
+)
+
+// FindTarget picks the best interactable for an actor facing along (faceX, faceY).
+// Higher priority wins; among equal priorities, targets that are closer and more
+// directly in front score better. It returns nil when nothing is in range.
+func FindTarget(world *entity.World, actor *entity.Object, faceX, faceY float64) *entity.Object {
+	ax, ay := actor.Bounds().Center()
+
+	faceLen := math.Hypot(faceX, faceY)
+	if faceLen > 0 {
+		faceX, faceY = faceX/faceLen, faceY/faceLen
+	}
+
+	var best *entity.Object
+	var bestPriority int
+	bestScore := math.Inf(1)
+
+	for _, o := range world.QueryRadius(ax, ay, maxSearchRadius) {
+		if o == actor {
+			continue
+		}
+		i := InteractableOf(o)
+		if i == nil || i.Disabled {
+			continue
+		}
+
+		dist := o.Bounds().DistanceTo(ax, ay)
+		if dist > i.Radius {
+			continue
+		}
+
+		// How directly the target sits in front of the actor, from -1 (behind) to 1
+		dot := 1.0
+		tx, ty := o.Bounds().Center()
+		if toLen := math.Hypot(tx-ax, ty-ay); toLen > 0 && faceLen > 0 {
+			dot = ((tx-ax)*faceX + (ty-ay)*faceY) / toLen
+		}
+		if dot < minFacingDot && dist > closeRange {
+			continue
+		}
+
+		// Distance stretched by up to 3x for targets off to the side
+		score := dist * (2 - dot)
+		if best == nil || i.Priority > bestPriority || (i.Priority == bestPriority && score < bestScore) {
+			best = o
+			bestPriority = i.Priority
+			bestScore = score
+		}
+	}
+
+	return best
+}
@@ -0,0 +1,88 @@
+package interaction
+
+import (
+	"testing"
+
+	"github.com/Nathene/bitbase/entity"
+)
+
+// thing is a 16x16 interactable with its top-left corner at (x, y)
+type thing struct {
+	name     string
+	x, y     float64
+	radius   float64
+	priority int
+	disabled bool
+}
+
+func TestFindTarget(t *testing.T) {
+	tests := []struct {
+		name         string
+		faceX, faceY float64
+		things       []thing
+		want         string
+	}{
+		{name: "nothing around", faceX: 1},
+		{name: "out of its radius", faceX: 1, things: []thing{{name: "chest", x: 100, radius: 24}}},
+		{name: "each target has its own radius", faceX: 1, things: []thing{
+			{name: "near", x: 20, radius: 8},
+			{name: "far", x: 40, radius: 48},
+		}, want: "far"},
+		{name: "past the search radius", faceX: 1, things: []thing{{name: "tower", x: 200, radius: 500}}},
+		{name: "closer of two in front", faceX: 1, things: []thing{
+			{name: "far", x: 40, radius: 48},
+			{name: "near", x: 20, radius: 48},
+		}, want: "near"},
+		{name: "straight ahead over nearer to the side", faceX: 1, things: []thing{
+			{name: "side", x: 8, y: -30, radius: 48},
+			{name: "ahead", x: 40, radius: 48},
+		}, want: "ahead"},
+		{name: "behind", faceX: 1, things: []thing{{name: "sign", x: -40, radius: 48}}},
+		{name: "behind but close enough", faceX: 1, things: []thing{{name: "sign", x: -20, radius: 48}}, want: "sign"},
+		{name: "in front beats close behind", faceX: 1, things: []thing{
+			{name: "behind", x: -20, radius: 48},
+			{name: "ahead", x: 36, radius: 48},
+		}, want: "ahead"},
+		{name: "facing is normalised", faceX: 0, faceY: -5, things: []thing{
+			{name: "below", y: 20, radius: 48},
+			{name: "above", y: -40, radius: 48},
+		}, want: "above"},
+		{name: "no facing takes the nearest", things: []thing{
+			{name: "ahead", x: 40, radius: 48},
+			{name: "behind", x: -20, radius: 48},
+		}, want: "behind"},
+		{name: "priority over distance and facing", faceX: 1, things: []thing{
+			{name: "near", x: 20, radius: 48},
+			{name: "lever", x: -20, radius: 48, priority: 1},
+		}, want: "lever"},
+		{name: "disabled", faceX: 1, things: []thing{
+			{name: "near", x: 20, radius: 48, disabled: true},
+			{name: "far", x: 40, radius: 48},
+		}, want: "far"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := entity.NewWorld()
+			// The actor is interactable too, and never picks itself
+			actor := entity.NewObject("player")
+			actor.AddComponent(&entity.Sprite{Width: 16, Height: 16})
+			actor.AddComponent(&Interactable{Kind: "talk", Radius: 48, Priority: 10})
+			w.Add(actor)
+			for _, th := range tt.things {
+				o := entity.NewObject(th.name)
+				o.AddComponent(&entity.Sprite{Width: 16, Height: 16})
+				o.AddComponent(&Interactable{Kind: "sign", Radius: th.radius, Priority: th.priority, Disabled: th.disabled})
+				o.SetPosition(th.x, th.y)
+				w.Add(o)
+			}
+
+			got := FindTarget(w, actor, tt.faceX, tt.faceY)
+			switch {
+			case got == nil && tt.want != "":
+				t.Errorf("found nothing, want %s", tt.want)
+			case got != nil && got.Name != tt.want:
+				t.Errorf("found %s, want %q", got.Name, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,194 @@
+// Package item defines item types and the slot-based inventories that hold them.
+// It has no dependency on ebiten so all of its rules can be unit tested.
+package item
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Nathene/bitbase/stats"
+)
+
+// Category groups items by what they are used for
+type Category string
+
+const (
+	CategoryWeapon     Category = "weapon"
+	CategoryArmor      Category = "armor"
+	CategoryAccessory  Category = "accessory"
+	CategoryConsumable Category = "consumable"
+	CategoryMaterial   Category = "material"
+	CategoryCurrency   Category = "currency"
+	CategoryQuest      Category = "quest"
+	CategoryMisc       Category = "misc"
+)
+
+// Rarity ranks how hard an item is to find
+type Rarity int
+
+const (
+	RarityCommon Rarity = iota
+	RarityUncommon
+	RarityRare
+	RarityEpic
+	RarityLegendary
+)
+
+var rarityNames = [...]string{"common", "uncommon", "rare", "epic", "legendary"}
+
+func (r Rarity) String() string {
+	if r < 0 || int(r) >= len(rarityNames) {
+		return fmt.Sprintf("Rarity(%d)", int(r))
+	}
+	return rarityNames[r]
+}
+
+// UnmarshalText reads a rarity from its name
+func (r *Rarity) UnmarshalText(text []byte) error {
+	for i, name := range rarityNames {
+		if name == strings.ToLower(string(text)) {
+			*r = Rarity(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown rarity %q", text)
+}
+
+// MarshalText writes a rarity as its name
+func (r Rarity) MarshalText() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+// Def is the static definition of an item type
+type Def struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Icon        string   `json:"icon,omitempty"`  // Asset ID of the icon image
+	Color       string   `json:"color,omitempty"` // Placeholder colour when there is no icon
+	MaxStack    int      `json:"maxStack"`
+	Weight      float64  `json:"weight"`
+	Category    Category `json:"category"`
+	Rarity      Rarity   `json:"rarity"`
+
+	// Equip is set for items that can be worn or wielded
+	Equip *EquipSpec `json:"equip,omitempty"`
+
+	// Properties holds anything item-specific, such as how much a potion heals
+	Properties map[string]any `json:"properties,omitempty"`
+}
+
+// EquipSpec describes how an item behaves when equipped
+type EquipSpec struct {
+	Slot  string           `json:"slot"`            // Equipment slot the item goes in, such as "head" or "weapon"
+	Stats []stats.Modifier `json:"stats,omitempty"` // Applied to the wearer while equipped
+	Layer string           `json:"layer,omitempty"` // Asset ID drawn over the wearer's sprite
+}
+
+// FloatProperty returns a numeric property, or fallback if it is missing or not a number
+func (d *Def) FloatProperty(key string, fallback float64) float64 {
+	if v, ok := d.Properties[key].(float64); ok {
+		return v
+	}
+	return fallback
+}
+
+// StringProperty returns a string property, or fallback if it is missing or not a string
+func (d *Def) StringProperty(key string, fallback string) string {
+	if v, ok := d.Properties[key].(string); ok {
+		return v
+	}
+	return fallback
+}
+
+// Registry holds every known item definition, keyed by ID
+type Registry struct {
+	defs map[string]*Def
+}
+
+// NewRegistry creates an empty item registry
+func NewRegistry() *Registry {
+	return &Registry{defs: make(map[string]*Def)}
+}
+
+// Add validates and registers an item definition
+func (r *Registry) Add(d *Def) error {
+	if d.ID == "" {
+		return fmt.Errorf("item has no id")
+	}
+	if _, exists := r.defs[d.ID]; exists {
+		return fmt.Errorf("item %q defined twice", d.ID)
+	}
+	if d.MaxStack == 0 {
+		d.MaxStack = 1
+	}
+	if d.MaxStack < 0 {
+		return fmt.Errorf("item %q: maxStack must be positive", d.ID)
+	}
+	if d.Weight < 0 {
+		return fmt.Errorf("item %q: weight must not be negative", d.ID)
+	}
+	if d.Name == "" {
+		d.Name = d.ID
+	}
+	if d.Category == "" {
+		d.Category = CategoryMisc
+	}
+	if d.Equip != nil && d.Equip.Slot == "" {
+		return fmt.Errorf("item %q: equip needs a slot", d.ID)
+	}
+	r.defs[d.ID] = d
+	return nil
+}
+
+// Get returns the definition with the given ID, or nil
+func (r *Registry) Get(id string) *Def {
+	return r.defs[id]
+}
+
+// IDs returns every item ID in sorted order
+func (r *Registry) IDs() []string {
+	ids := make([]string, 0, len(r.defs))
+	for id := range r.defs {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// LoadFile reads a JSON array of item definitions
+func (r *Registry) LoadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var defs []*Def
+	if err := json.Unmarshal(data, &defs); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	for _, d := range defs {
+		if err := r.Add(d); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// LoadDir loads every .json file in a directory
+func (r *Registry) LoadDir(dir string) error {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return err
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		if err := r.LoadFile(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
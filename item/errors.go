@@ -0,0 +1,42 @@
+package item
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Reasons an inventory operation can fail
+var (
+	ErrUnknownItem  = errors.New("unknown item")
+	ErrInvalidCount = errors.New("count must be positive")
+	ErrInvalidSlot  = errors.New("invalid slot")
+	ErrEmptySlot    = errors.New("slot is empty")
+	ErrNoSpace      = errors.New("not enough free slots")
+	ErrOverweight   = errors.New("too heavy to carry")
+	ErrNotEnough    = errors.New("not enough items")
+	ErrItemMismatch = errors.New("slots hold different items")
+)
+
+// OpError describes a failed inventory operation. The inventory is left
+// unchanged whenever an OpError is returned.
+type OpError struct {
+	Op        string // "add", "remove", "move", ...
+	ItemID    string
+	Requested int // How many items the operation asked for
+	Missing   int // How many of them could not be handled
+	Err       error
+}
+
+func (e *OpError) Error() string {
+	if e.Missing > 0 {
+		return fmt.Sprintf("%s %d x %s: %v (%d short)", e.Op, e.Requested, e.ItemID, e.Err, e.Missing)
+	}
+	if e.ItemID != "" {
+		return fmt.Sprintf("%s %s: %v", e.Op, e.ItemID, e.Err)
+	}
+	return fmt.Sprintf("%s: %v", e.Op, e.Err)
+}
+
+func (e *OpError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,440 @@
+package item
+
+import (
+	"math"
+	"sort"
+)
+
+// weightEpsilon absorbs floating point error when comparing carried weight to the limit
+const weightEpsilon = 1e-9
+
+// Stack is a number of identical items held in one slot
+type Stack struct {
+	ItemID string `json:"item"`
+	Count  int    `json:"count"`
+}
+
+// Empty reports whether the stack holds nothing
+func (s Stack) Empty() bool {
+	return s.Count <= 0
+}
+
+// Inventory is a fixed number of slots, each holding one stack of items.
+// Every operation either succeeds completely or leaves the inventory unchanged
+// and returns an *OpError explaining why.
+type Inventory struct {
+	defs      *Registry
+	slots     []Stack
+	MaxWeight float64 // Heaviest load the inventory can carry; 0 means no limit
+}
+
+// NewInventory creates an empty inventory with the given number of slots
+func NewInventory(defs *Registry, size int, maxWeight float64) *Inventory {
+	return &Inventory{
+		defs:      defs,
+		slots:     make([]Stack, size),
+		MaxWeight: maxWeight,
+	}
+}
+
+// Defs returns the registry item IDs are resolved against
+func (inv *Inventory) Defs() *Registry {
+	return inv.defs
+}
+
+// Size returns the number of slots
+func (inv *Inventory) Size() int {
+	return len(inv.slots)
+}
+
+// Slot returns the stack in slot i, or an empty stack for an invalid index
+func (inv *Inventory) Slot(i int) Stack {
+	if i < 0 || i >= len(inv.slots) {
+		return Stack{}
+	}
+	return inv.slots[i]
+}
+
+// Slots returns a copy of every slot
+func (inv *Inventory) Slots() []Stack {
+	slots := make([]Stack, len(inv.slots))
+	copy(slots, inv.slots)
+	return slots
+}
+
+// Count returns how many of an item the inventory holds across all slots
+func (inv *Inventory) Count(id string) int {
+	total := 0
+	for _, s := range inv.slots {
+		if s.ItemID == id {
+			total += s.Count
+		}
+	}
+	return total
+}
+
+// FreeSlots returns the number of empty slots
+func (inv *Inventory) FreeSlots() int {
+	free := 0
+	for _, s := range inv.slots {
+		if s.Empty() {
+			free++
+		}
+	}
+	return free
+}
+
+// Weight returns the total weight of everything in the inventory
+func (inv *Inventory) Weight() float64 {
+	total := 0.0
+	for _, s := range inv.slots {
+		if d := inv.defs.Get(s.ItemID); d != nil && !s.Empty() {
+			total += d.Weight * float64(s.Count)
+		}
+	}
+	return total
+}
+
+// Clone returns an independent copy of the inventory
+func (inv *Inventory) Clone() *Inventory {
+	return &Inventory{
+		defs:      inv.defs,
+		slots:     inv.Slots(),
+		MaxWeight: inv.MaxWeight,
+	}
+}
+
+// Transact runs several operations as one: fn works on a copy of the
+// inventory, and the changes are only kept if it returns nil
+func (inv *Inventory) Transact(fn func(tx *Inventory) error) error {
+	tx := inv.Clone()
+	if err := fn(tx); err != nil {
+		return err
+	}
+	inv.slots = tx.slots
+	inv.MaxWeight = tx.MaxWeight
+	return nil
+}
+
+// CanAdd reports whether count of an item would fit, without changing anything
+func (inv *Inventory) CanAdd(id string, count int) error {
+	return inv.Clone().Add(id, count)
+}
+
+// Add puts count of an item into the inventory, topping up existing stacks
+// before starting new ones
+func (inv *Inventory) Add(id string, count int) error {
+	def, err := inv.checkAdd("add", id, count)
+	if err != nil {
+		return err
+	}
+
+	// Work out where everything goes before touching any slot
+	remaining := count
+	plan := make(map[int]int)
+	for i, s := range inv.slots {
+		if remaining == 0 {
+			break
+		}
+		if s.ItemID == id && !s.Empty() && s.Count < def.MaxStack {
+			n := min(def.MaxStack-s.Count, remaining)
+			plan[i] = n
+			remaining -= n
+		}
+	}
+	for i, s := range inv.slots {
+		if remaining == 0 {
+			break
+		}
+		if s.Empty() {
+			n := min(def.MaxStack, remaining)
+			plan[i] = n
+			remaining -= n
+		}
+	}
+
+	if remaining > 0 {
+		return &OpError{Op: "add", ItemID: id, Requested: count, Missing: remaining, Err: ErrNoSpace}
+	}
+
+	for i, n := range plan {
+		inv.slots[i] = Stack{ItemID: id, Count: inv.slots[i].Count + n}
+	}
+	return nil
+}
+
+// AddToSlot puts count of an item into a specific slot, which must be empty
+// or already hold the same item
+func (inv *Inventory) AddToSlot(slot int, id string, count int) error {
+	def, err := inv.checkAdd("add", id, count)
+	if err != nil {
+		return err
+	}
+	if err := inv.checkSlot("add", slot); err != nil {
+		return err
+	}
+
+	s := inv.slots[slot]
+	if !s.Empty() && s.ItemID != id {
+		return &OpError{Op: "add", ItemID: id, Requested: count, Err: ErrItemMismatch}
+	}
+	if s.Count+count > def.MaxStack {
+		return &OpError{Op: "add", ItemID: id, Requested: count, Missing: s.Count + count - def.MaxStack, Err: ErrNoSpace}
+	}
+
+	inv.slots[slot] = Stack{ItemID: id, Count: s.Count + count}
+	return nil
+}
+
+// Remove takes count of an item out of the inventory, emptying the last slots first
+func (inv *Inventory) Remove(id string, count int) error {
+	if count <= 0 {
+		return &OpError{Op: "remove", ItemID: id, Requested: count, Err: ErrInvalidCount}
+	}
+	if have := inv.Count(id); have < count {
+		return &OpError{Op: "remove", ItemID: id, Requested: count, Missing: count - have, Err: ErrNotEnough}
+	}
+
+	remaining := count
+	for i := len(inv.slots) - 1; i >= 0 && remaining > 0; i-- {
+		s := inv.slots[i]
+		if s.ItemID != id || s.Empty() {
+			continue
+		}
+		n := min(s.Count, remaining)
+		inv.setCount(i, s.Count-n)
+		remaining -= n
+	}
+	return nil
+}
+
+// RemoveAt takes count items out of a slot and returns them
+func (inv *Inventory) RemoveAt(slot, count int) (Stack, error) {
+	if err := inv.checkSlot("remove", slot); err != nil {
+		return Stack{}, err
+	}
+	s := inv.slots[slot]
+	if s.Empty() {
+		return Stack{}, &OpError{Op: "remove", Err: ErrEmptySlot}
+	}
+	if count <= 0 {
+		return Stack{}, &OpError{Op: "remove", ItemID: s.ItemID, Requested: count, Err: ErrInvalidCount}
+	}
+	if count > s.Count {
+		return Stack{}, &OpError{Op: "remove", ItemID: s.ItemID, Requested: count, Missing: count - s.Count, Err: ErrNotEnough}
+	}
+
+	inv.setCount(slot, s.Count-count)
+	return Stack{ItemID: s.ItemID, Count: count}, nil
+}
+
+// Move moves count items from one slot to another; a count of 0 moves the whole stack.
+// Moving onto the same item merges the stacks (all of count must fit), and moving a
+// whole stack onto a different item swaps the two slots.
+func (inv *Inventory) Move(from, to, count int) error {
+	if err := inv.checkSlot("move", from); err != nil {
+		return err
+	}
+	if err := inv.checkSlot("move", to); err != nil {
+		return err
+	}
+
+	src := inv.slots[from]
+	if src.Empty() {
+		return &OpError{Op: "move", Err: ErrEmptySlot}
+	}
+	if count == 0 {
+		count = src.Count
+	}
+	if count < 0 {
+		return &OpError{Op: "move", ItemID: src.ItemID, Requested: count, Err: ErrInvalidCount}
+	}
+	if count > src.Count {
+		return &OpError{Op: "move", ItemID: src.ItemID, Requested: count, Missing: count - src.Count, Err: ErrNotEnough}
+	}
+	if from == to {
+		return nil
+	}
+
+	dst := inv.slots[to]
+	switch {
+	case dst.Empty():
+		inv.slots[to] = Stack{ItemID: src.ItemID, Count: count}
+		inv.setCount(from, src.Count-count)
+
+	case dst.ItemID == src.ItemID:
+		maxStack := inv.maxStack(src.ItemID)
+		if dst.Count+count > maxStack {
+			return &OpError{Op: "move", ItemID: src.ItemID, Requested: count, Missing: dst.Count + count - maxStack, Err: ErrNoSpace}
+		}
+		inv.slots[to].Count += count
+		inv.setCount(from, src.Count-count)
+
+	case count == src.Count:
+		inv.slots[from], inv.slots[to] = dst, src
+
+	default:
+		return &OpError{Op: "move", ItemID: src.ItemID, Requested: count, Err: ErrItemMismatch}
+	}
+	return nil
+}
+
+// Merge moves as many items as will fit from one stack onto another stack of the
+// same item. Whatever doesn't fit stays in the source slot.
+func (inv *Inventory) Merge(from, to int) error {
+	if err := inv.checkSlot("merge", from); err != nil {
+		return err
+	}
+	if err := inv.checkSlot("merge", to); err != nil {
+		return err
+	}
+	src, dst := inv.slots[from], inv.slots[to]
+	if src.Empty() {
+		return &OpError{Op: "merge", Err: ErrEmptySlot}
+	}
+	if from == to {
+		return nil
+	}
+	if !dst.Empty() && dst.ItemID != src.ItemID {
+		return &OpError{Op: "merge", ItemID: src.ItemID, Err: ErrItemMismatch}
+	}
+
+	n := min(inv.maxStack(src.ItemID)-dst.Count, src.Count)
+	if n <= 0 {
+		return &OpError{Op: "merge", ItemID: src.ItemID, Requested: src.Count, Missing: src.Count, Err: ErrNoSpace}
+	}
+	return inv.Move(from, to, n)
+}
+
+// Split moves count items from a slot into the first empty slot and returns its index
+func (inv *Inventory) Split(slot, count int) (int, error) {
+	if err := inv.checkSlot("split", slot); err != nil {
+		return -1, err
+	}
+	s := inv.slots[slot]
+	if s.Empty() {
+		return -1, &OpError{Op: "split", Err: ErrEmptySlot}
+	}
+	if count <= 0 || count >= s.Count {
+		return -1, &OpError{Op: "split", ItemID: s.ItemID, Requested: count, Err: ErrInvalidCount}
+	}
+
+	for i, other := range inv.slots {
+		if other.Empty() {
+			inv.slots[i] = Stack{ItemID: s.ItemID, Count: count}
+			inv.slots[slot].Count -= count
+			return i, nil
+		}
+	}
+	return -1, &OpError{Op: "split", ItemID: s.ItemID, Requested: count, Missing: count, Err: ErrNoSpace}
+}
+
+// checkAdd validates an item and count and makes sure the weight limit allows it
+func (inv *Inventory) checkAdd(op, id string, count int) (*Def, error) {
+	def := inv.defs.Get(id)
+	if def == nil {
+		return nil, &OpError{Op: op, ItemID: id, Requested: count, Err: ErrUnknownItem}
+	}
+	if count <= 0 {
+		return nil, &OpError{Op: op, ItemID: id, Requested: count, Err: ErrInvalidCount}
+	}
+
+	if inv.MaxWeight > 0 && def.Weight > 0 {
+		spare := inv.MaxWeight - inv.Weight()
+		if def.Weight*float64(count) > spare+weightEpsilon {
+			fits := int(math.Floor((spare + weightEpsilon) / def.Weight))
+			return nil, &OpError{Op: op, ItemID: id, Requested: count, Missing: count - max(fits, 0), Err: ErrOverweight}
+		}
+	}
+	return def, nil
+}
+
+func (inv *Inventory) checkSlot(op string, slot int) error {
+	if slot < 0 || slot >= len(inv.slots) {
+		return &OpError{Op: op, Err: ErrInvalidSlot}
+	}
+	return nil
+}
+
+func (inv *Inventory) maxStack(id string) int {
+	if d := inv.defs.Get(id); d != nil {
+		return d.MaxStack
+	}
+	return 1
+}
+
+// setCount updates a slot's count, clearing it entirely when it reaches zero
+func (inv *Inventory) setCount(slot, count int) {
+	if count <= 0 {
+		inv.slots[slot] = Stack{}
+		return
+	}
+	inv.slots[slot].Count = count
+}
+
+// Sort gathers every item together, fills stacks to their maximum and orders
+// them by category, then rarity (rarest first), then name
+func (inv *Inventory) Sort() {
+	order := []string{}
+	totals := make(map[string]int)
+	for _, s := range inv.slots {
+		if s.Empty() {
+			continue
+		}
+		if _, seen := totals[s.ItemID]; !seen {
+			order = append(order, s.ItemID)
+		}
+		totals[s.ItemID] += s.Count
+	}
+
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := inv.defs.Get(order[i]), inv.defs.Get(order[j])
+		if a == nil || b == nil {
+			return a != nil
+		}
+		if a.Category != b.Category {
+			return categoryRank(a.Category) < categoryRank(b.Category)
+		}
+		if a.Rarity != b.Rarity {
+			return a.Rarity > b.Rarity
+		}
+		return a.Name < b.Name
+	})
+
+	// Merging stacks can only free slots, so everything is guaranteed to fit
+	slots := make([]Stack, len(inv.slots))
+	next := 0
+	for _, id := range order {
+		maxStack := inv.maxStack(id)
+		if inv.defs.Get(id) == nil {
+			maxStack = totals[id] // Keep unknown items together rather than guessing
+		}
+		for remaining := totals[id]; remaining > 0; next++ {
+			n := min(maxStack, remaining)
+			slots[next] = Stack{ItemID: id, Count: n}
+			remaining -= n
+		}
+	}
+	inv.slots = slots
+}
+
+// categoryOrder is the order categories appear in after sorting
+var categoryOrder = []Category{
+	CategoryWeapon,
+	CategoryArmor,
+	CategoryAccessory,
+	CategoryConsumable,
+	CategoryMaterial,
+	CategoryQuest,
+	CategoryCurrency,
+	CategoryMisc,
+}
+
+func categoryRank(c Category) int {
+	for i, other := range categoryOrder {
+		if c == other {
+			return i
+		}
+	}
+	return len(categoryOrder)
+}
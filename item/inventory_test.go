@@ -0,0 +1,313 @@
+package item
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+// testDefs returns a small registry covering every kind of stacking and weight
+func testDefs(t *testing.T) *Registry {
+	t.Helper()
+	r := NewRegistry()
+	for _, d := range []*Def{
+		{ID: "coin", MaxStack: 99, Weight: 0.01, Category: CategoryCurrency},
+		{ID: "potion", MaxStack: 5, Weight: 0.5, Category: CategoryConsumable},
+		{ID: "elixir", MaxStack: 5, Weight: 0.5, Category: CategoryConsumable, Rarity: RarityRare},
+		{ID: "stone", MaxStack: 10, Weight: 2, Category: CategoryMaterial},
+		{ID: "sword", Weight: 3, Category: CategoryWeapon},
+		{ID: "axe", Weight: 4, Category: CategoryWeapon},
+		{ID: "feather", MaxStack: 50, Category: CategoryMaterial},
+	} {
+		if err := r.Add(d); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return r
+}
+
+// fill returns an inventory holding the given slots
+func fill(t *testing.T, size int, maxWeight float64, slots ...Stack) *Inventory {
+	t.Helper()
+	inv := NewInventory(testDefs(t), size, maxWeight)
+	copy(inv.slots, slots)
+	return inv
+}
+
+// wantSlots fails the test if the inventory doesn't hold exactly want, padded with empty slots
+func wantSlots(t *testing.T, inv *Inventory, want ...Stack) {
+	t.Helper()
+	padded := make([]Stack, inv.Size())
+	copy(padded, want)
+	if got := inv.Slots(); !slices.Equal(got, padded) {
+		t.Errorf("slots = %v, want %v", got, padded)
+	}
+}
+
+// wantOpError fails the test unless err is an *OpError wrapping target
+func wantOpError(t *testing.T, err, target error) *OpError {
+	t.Helper()
+	var opErr *OpError
+	if !errors.As(err, &opErr) {
+		t.Fatalf("got error %v, want an *OpError", err)
+	}
+	if !errors.Is(err, target) {
+		t.Errorf("got error %v, want %v", err, target)
+	}
+	return opErr
+}
+
+func TestAdd(t *testing.T) {
+	inv := fill(t, 4, 0, Stack{"potion", 3}, Stack{}, Stack{"coin", 10})
+
+	if err := inv.Add("potion", 4); err != nil {
+		t.Fatal(err)
+	}
+	// Tops up the existing stack, then takes the first empty slot
+	wantSlots(t, inv, Stack{"potion", 5}, Stack{"potion", 2}, Stack{"coin", 10})
+
+	if err := inv.Add("coin", 89); err != nil {
+		t.Fatal(err)
+	}
+	wantSlots(t, inv, Stack{"potion", 5}, Stack{"potion", 2}, Stack{"coin", 99})
+
+	if err := inv.Add("sword", 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := inv.AddToSlot(1, "potion", 3); err != nil {
+		t.Fatal(err)
+	}
+	wantSlots(t, inv, Stack{"potion", 5}, Stack{"potion", 5}, Stack{"coin", 99}, Stack{"sword", 1})
+}
+
+func TestAddErrors(t *testing.T) {
+	inv := fill(t, 2, 0, Stack{"potion", 4})
+
+	wantOpError(t, inv.Add("nothing", 1), ErrUnknownItem)
+	wantOpError(t, inv.Add("potion", 0), ErrInvalidCount)
+	wantOpError(t, inv.Add("potion", -2), ErrInvalidCount)
+
+	// One more fits the first stack and five the empty slot, so seven is one short
+	if e := wantOpError(t, inv.Add("potion", 7), ErrNoSpace); e.Missing != 1 || e.Requested != 7 {
+		t.Errorf("got %+v, want 1 of 7 missing", e)
+	}
+	wantOpError(t, inv.AddToSlot(0, "coin", 1), ErrItemMismatch)
+	wantOpError(t, inv.AddToSlot(0, "potion", 2), ErrNoSpace)
+	wantOpError(t, inv.AddToSlot(5, "potion", 1), ErrInvalidSlot)
+	wantSlots(t, inv, Stack{"potion", 4})
+
+	if err := inv.CanAdd("potion", 6); err != nil {
+		t.Errorf("CanAdd(potion, 6) = %v, want it to fit", err)
+	}
+	wantSlots(t, inv, Stack{"potion", 4})
+}
+
+func TestSlotLimit(t *testing.T) {
+	inv := fill(t, 3, 0)
+	if err := inv.Add("sword", 3); err != nil {
+		t.Fatal(err)
+	}
+	if inv.FreeSlots() != 0 {
+		t.Errorf("FreeSlots() = %d with a sword in every slot", inv.FreeSlots())
+	}
+	wantOpError(t, inv.Add("axe", 1), ErrNoSpace)
+	wantOpError(t, inv.Add("feather", 1), ErrNoSpace)
+	wantSlots(t, inv, Stack{"sword", 1}, Stack{"sword", 1}, Stack{"sword", 1})
+}
+
+func TestWeightLimit(t *testing.T) {
+	inv := fill(t, 10, 10, Stack{"stone", 3})
+	if got := inv.Weight(); got != 6 {
+		t.Fatalf("Weight() = %v, want 6", got)
+	}
+
+	e := wantOpError(t, inv.Add("stone", 3), ErrOverweight)
+	if e.Missing != 1 {
+		t.Errorf("got %d stones missing, want 1 of 3", e.Missing)
+	}
+	wantSlots(t, inv, Stack{"stone", 3})
+
+	// Exactly filling the limit is fine, even with float error from many small items
+	if err := inv.Add("stone", 2); err != nil {
+		t.Fatal(err)
+	}
+	wantOpError(t, inv.Add("coin", 1), ErrOverweight)
+	if err := inv.Add("feather", 50); err != nil {
+		t.Errorf("weightless items should always fit: %v", err)
+	}
+
+	coins := fill(t, 10, 1)
+	if err := coins.Add("coin", 99); err != nil {
+		t.Fatal(err)
+	}
+	if err := coins.Add("coin", 1); err != nil {
+		t.Errorf("100 coins of 0.01 should weigh exactly 1: %v", err)
+	}
+}
+
+func TestRemove(t *testing.T) {
+	inv := fill(t, 4, 0, Stack{"potion", 5}, Stack{"coin", 3}, Stack{"potion", 2})
+
+	// Empties the last slots first
+	if err := inv.Remove("potion", 3); err != nil {
+		t.Fatal(err)
+	}
+	wantSlots(t, inv, Stack{"potion", 4}, Stack{"coin", 3})
+
+	e := wantOpError(t, inv.Remove("potion", 6), ErrNotEnough)
+	if e.Missing != 2 {
+		t.Errorf("got %d missing, want 2", e.Missing)
+	}
+	wantOpError(t, inv.Remove("potion", 0), ErrInvalidCount)
+	wantSlots(t, inv, Stack{"potion", 4}, Stack{"coin", 3})
+
+	got, err := inv.RemoveAt(1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != (Stack{"coin", 2}) {
+		t.Errorf("RemoveAt(1, 2) = %v, want 2 coins", got)
+	}
+	if _, err := inv.RemoveAt(1, 1); err != nil {
+		t.Fatal(err)
+	}
+	wantSlots(t, inv, Stack{"potion", 4})
+
+	_, err = inv.RemoveAt(1, 1)
+	wantOpError(t, err, ErrEmptySlot)
+	_, err = inv.RemoveAt(0, 5)
+	wantOpError(t, err, ErrNotEnough)
+	_, err = inv.RemoveAt(9, 1)
+	wantOpError(t, err, ErrInvalidSlot)
+}
+
+func TestMove(t *testing.T) {
+	tests := []struct {
+		name      string
+		slots     []Stack
+		from, to  int
+		count     int
+		want      []Stack
+		wantError error
+	}{
+		{"whole stack to empty", []Stack{{"potion", 3}}, 0, 2, 0, []Stack{{}, {}, {"potion", 3}}, nil},
+		{"part to empty", []Stack{{"potion", 3}}, 0, 1, 2, []Stack{{"potion", 1}, {"potion", 2}}, nil},
+		{"onto the same item", []Stack{{"potion", 3}, {"potion", 2}}, 0, 1, 2, []Stack{{"potion", 1}, {"potion", 4}}, nil},
+		{"swap", []Stack{{"potion", 3}, {"coin", 7}}, 0, 1, 0, []Stack{{"coin", 7}, {"potion", 3}}, nil},
+		{"same slot", []Stack{{"potion", 3}}, 0, 0, 0, []Stack{{"potion", 3}}, nil},
+		{"overfills", []Stack{{"potion", 3}, {"potion", 4}}, 0, 1, 2, []Stack{{"potion", 3}, {"potion", 4}}, ErrNoSpace},
+		{"part onto another item", []Stack{{"potion", 3}, {"coin", 7}}, 0, 1, 1, []Stack{{"potion", 3}, {"coin", 7}}, ErrItemMismatch},
+		{"more than there is", []Stack{{"potion", 3}}, 0, 1, 4, []Stack{{"potion", 3}}, ErrNotEnough},
+		{"negative", []Stack{{"potion", 3}}, 0, 1, -1, []Stack{{"potion", 3}}, ErrInvalidCount},
+		{"from empty", []Stack{{}, {"potion", 3}}, 0, 1, 0, []Stack{{}, {"potion", 3}}, ErrEmptySlot},
+		{"bad slot", []Stack{{"potion", 3}}, 0, 3, 0, []Stack{{"potion", 3}}, ErrInvalidSlot},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inv := fill(t, 3, 0, tt.slots...)
+			err := inv.Move(tt.from, tt.to, tt.count)
+			if tt.wantError == nil && err != nil {
+				t.Fatal(err)
+			}
+			if tt.wantError != nil {
+				wantOpError(t, err, tt.wantError)
+			}
+			wantSlots(t, inv, tt.want...)
+		})
+	}
+}
+
+func TestSplit(t *testing.T) {
+	inv := fill(t, 3, 0, Stack{"potion", 5}, Stack{"coin", 1})
+
+	slot, err := inv.Split(0, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if slot != 2 {
+		t.Errorf("Split put the stack in slot %d, want 2", slot)
+	}
+	wantSlots(t, inv, Stack{"potion", 3}, Stack{"coin", 1}, Stack{"potion", 2})
+
+	_, err = inv.Split(0, 1)
+	wantOpError(t, err, ErrNoSpace)
+	_, err = inv.Split(0, 3)
+	wantOpError(t, err, ErrInvalidCount)
+	_, err = inv.Split(1, 0)
+	wantOpError(t, err, ErrInvalidCount)
+	wantSlots(t, inv, Stack{"potion", 3}, Stack{"coin", 1}, Stack{"potion", 2})
+}
+
+func TestMerge(t *testing.T) {
+	inv := fill(t, 4, 0, Stack{"potion", 4}, Stack{"potion", 3}, Stack{"coin", 1})
+
+	// Only one fits; the rest stay behind
+	if err := inv.Merge(1, 0); err != nil {
+		t.Fatal(err)
+	}
+	wantSlots(t, inv, Stack{"potion", 5}, Stack{"potion", 2}, Stack{"coin", 1})
+
+	wantOpError(t, inv.Merge(1, 0), ErrNoSpace)
+	wantOpError(t, inv.Merge(1, 2), ErrItemMismatch)
+	wantOpError(t, inv.Merge(3, 0), ErrEmptySlot)
+	wantSlots(t, inv, Stack{"potion", 5}, Stack{"potion", 2}, Stack{"coin", 1})
+
+	if err := inv.Merge(1, 3); err != nil {
+		t.Fatal(err)
+	}
+	wantSlots(t, inv, Stack{"potion", 5}, Stack{}, Stack{"coin", 1}, Stack{"potion", 2})
+}
+
+func TestTransact(t *testing.T) {
+	inv := fill(t, 3, 0, Stack{"potion", 2}, Stack{"coin", 50})
+
+	// A trade that can't be paid for changes nothing
+	err := inv.Transact(func(tx *Inventory) error {
+		if err := tx.Add("sword", 1); err != nil {
+			return err
+		}
+		return tx.Remove("coin", 60)
+	})
+	wantOpError(t, err, ErrNotEnough)
+	wantSlots(t, inv, Stack{"potion", 2}, Stack{"coin", 50})
+
+	err = inv.Transact(func(tx *Inventory) error {
+		if err := tx.Add("sword", 1); err != nil {
+			return err
+		}
+		return tx.Remove("coin", 40)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantSlots(t, inv, Stack{"potion", 2}, Stack{"coin", 10}, Stack{"sword", 1})
+}
+
+func TestSort(t *testing.T) {
+	inv := fill(t, 8, 0,
+		Stack{"coin", 40},
+		Stack{},
+		Stack{"potion", 2},
+		Stack{"stone", 7},
+		Stack{"elixir", 1},
+		Stack{"coin", 70},
+		Stack{"potion", 4},
+		Stack{"sword", 1},
+	)
+	inv.Sort()
+
+	// Weapons, then consumables with the rare elixir first, then materials and
+	// currency; split stacks are gathered and refilled to the maximum
+	wantSlots(t, inv,
+		Stack{"sword", 1},
+		Stack{"elixir", 1},
+		Stack{"potion", 5},
+		Stack{"potion", 1},
+		Stack{"stone", 7},
+		Stack{"coin", 99},
+		Stack{"coin", 11},
+	)
+	if inv.Count("coin") != 110 || inv.Count("potion") != 6 {
+		t.Errorf("Sort changed the counts: %d coins, %d potions", inv.Count("coin"), inv.Count("potion"))
+	}
+}
@@ -0,0 +1,187 @@
+package pathfind
+
+import "math"
+
+// Finder runs A* searches over a grid. It keeps its working memory between
+// searches, so reuse one Finder rather than making one per path. A Finder is
+// not safe for concurrent use.
+type Finder struct {
+	Grid Grid
+
+	width, height int
+	gScore        []float64
+	parent        []int32
+	seen          []uint32 // Search generation that last touched each tile
+	closed        []uint32
+	gen           uint32
+	open          openList
+}
+
+// NewFinder creates a finder for a grid
+func NewFinder(grid Grid) *Finder {
+	return &Finder{Grid: grid}
+}
+
+// FindPath returns the cheapest path from one tile to another, including both ends
+func (f *Finder) FindPath(from, to Point, opts Options) ([]Point, error) {
+	f.begin()
+	if !f.inBounds(from) || !f.inBounds(to) {
+		return nil, ErrOutOfBounds
+	}
+	if math.IsInf(f.cost(to.X, to.Y), 1) {
+		return nil, ErrBlocked
+	}
+	if from == to {
+		return []Point{from}, nil
+	}
+
+	steps := opts.steps()
+	start, goal := f.index(from), f.index(to)
+	f.visit(start, 0, -1)
+	f.open.push(start, f.heuristic(from, to, opts))
+
+	expanded := 0
+	for f.open.len() > 0 {
+		current := f.open.pop()
+		if f.closed[current] == f.gen {
+			continue // A stale entry left behind by a cheaper route
+		}
+		if current == goal {
+			return f.walkBack(goal), nil
+		}
+		f.closed[current] = f.gen
+		if expanded++; opts.MaxNodes > 0 && expanded > opts.MaxNodes {
+			return nil, ErrTooFar
+		}
+
+		x, y := int(current)%f.width, int(current)/f.width
+		for _, s := range steps {
+			nx, ny := x+s.dx, y+s.dy
+			c := f.cost(nx, ny)
+			if math.IsInf(c, 1) || !canStep(f.Grid, f.width, f.height, x, y, s) {
+				continue
+			}
+			next := int32(ny*f.width + nx)
+			if f.closed[next] == f.gen {
+				continue
+			}
+			g := f.gScore[current] + c*s.length
+			if f.seen[next] == f.gen && g >= f.gScore[next] {
+				continue
+			}
+			f.visit(next, g, current)
+			f.open.push(next, g+f.heuristic(Point{nx, ny}, to, opts))
+		}
+	}
+	return nil, ErrNoPath
+}
+
+// begin starts a new search, resizing the working memory if the grid changed size
+func (f *Finder) begin() {
+	width, height := f.Grid.Size()
+	if width != f.width || height != f.height || f.gScore == nil {
+		f.width, f.height = width, height
+		n := width * height
+		f.gScore = make([]float64, n)
+		f.parent = make([]int32, n)
+		f.seen = make([]uint32, n)
+		f.closed = make([]uint32, n)
+		f.gen = 0
+	}
+	f.gen++
+	if f.gen == 0 {
+		// The generation counter wrapped, so old marks could look current
+		clear(f.seen)
+		clear(f.closed)
+		f.gen = 1
+	}
+	f.open.reset()
+}
+
+func (f *Finder) visit(i int32, g float64, parent int32) {
+	f.gScore[i] = g
+	f.parent[i] = parent
+	f.seen[i] = f.gen
+}
+
+func (f *Finder) walkBack(goal int32) []Point {
+	n := 0
+	for i := goal; i >= 0; i = f.parent[i] {
+		n++
+	}
+	path := make([]Point, n)
+	for i := goal; i >= 0; i = f.parent[i] {
+		n--
+		path[n] = Point{int(i) % f.width, int(i) / f.width}
+	}
+	return path
+}
+
+func (f *Finder) heuristic(a, b Point, opts Options) float64 {
+	dx, dy := math.Abs(float64(a.X-b.X)), math.Abs(float64(a.Y-b.Y))
+	if !opts.Diagonal {
+		return dx + dy
+	}
+	// Octile distance: diagonal moves for the shorter axis, straight for the rest
+	return dx + dy + (math.Sqrt2-2)*math.Min(dx, dy)
+}
+
+func (f *Finder) cost(x, y int) float64 {
+	return cost(f.Grid, f.width, f.height, x, y)
+}
+
+func (f *Finder) inBounds(p Point) bool {
+	return p.X >= 0 && p.Y >= 0 && p.X < f.width && p.Y < f.height
+}
+
+func (f *Finder) index(p Point) int32 {
+	return int32(p.Y*f.width + p.X)
+}
+
+// openList is a binary min-heap of tiles ordered by estimated total cost
+type openList struct {
+	items []openItem
+}
+
+type openItem struct {
+	index int32
+	score float64
+}
+
+func (h *openList) len() int { return len(h.items) }
+func (h *openList) reset()   { h.items = h.items[:0] }
+
+func (h *openList) push(index int32, score float64) {
+	h.items = append(h.items, openItem{index, score})
+	i := len(h.items) - 1
+	for i > 0 {
+		parent := (i - 1) / 2
+		if h.items[parent].score <= h.items[i].score {
+			break
+		}
+		h.items[parent], h.items[i] = h.items[i], h.items[parent]
+		i = parent
+	}
+}
+
+func (h *openList) pop() int32 {
+	top := h.items[0].index
+	last := len(h.items) - 1
+	h.items[0] = h.items[last]
+	h.items = h.items[:last]
+
+	i := 0
+	for {
+		smallest := i
+		for _, child := range [2]int{2*i + 1, 2*i + 2} {
+			if child < len(h.items) && h.items[child].score < h.items[smallest].score {
+				smallest = child
+			}
+		}
+		if smallest == i {
+			return top
+		}
+		h.items[i], h.items[smallest] = h.items[smallest], h.items[i]
+		i = smallest
+	}
+}
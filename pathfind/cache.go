@@ -0,0 +1,115 @@
+package pathfind
+
+const (
+	defaultFlowThreshold = 3
+	defaultMaxFields     = 8
+	maxTrackedTargets    = 1024
+)
+
+// Cache answers path requests for a whole map. Targets asked for by only a
+// few agents get plain A* searches; once FlowThreshold requests have gone to
+// the same target it switches to a flow field, which every later request for
+// that target shares. Call Invalidate whenever the map's costs change.
+type Cache struct {
+	Finder        *Finder
+	Options       Options
+	Smooth        bool // Smooth paths before returning them
+	FlowThreshold int  // Requests for one target before it gets a flow field; default 3
+	MaxFields     int  // Flow fields kept at once, least recently used dropped first; default 8
+
+	fields   map[Point]*FlowField
+	order    []Point // Flow field targets, least recently used first
+	requests map[Point]int
+}
+
+// NewCache creates a path cache over a grid
+func NewCache(grid Grid, opts Options) *Cache {
+	return &Cache{
+		Finder:   NewFinder(grid),
+		Options:  opts,
+		Smooth:   true,
+		fields:   make(map[Point]*FlowField),
+		requests: make(map[Point]int),
+	}
+}
+
+// Path returns a path from one tile to another, including both ends
+func (c *Cache) Path(from, to Point) ([]Point, error) {
+	var path []Point
+	var err error
+	if ff := c.flowField(to); ff != nil {
+		path, err = ff.Path(from)
+	} else {
+		path, err = c.Finder.FindPath(from, to, c.Options)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if c.Smooth {
+		path = c.Finder.Smooth(path)
+	}
+	return path, nil
+}
+
+// FlowField returns the cached flow field towards target, building it if needed
+func (c *Cache) FlowField(target Point) (*FlowField, error) {
+	if ff := c.fields[target]; ff != nil {
+		c.touch(target)
+		return ff, nil
+	}
+	ff, err := c.Finder.FlowField(target, c.Options)
+	if err != nil {
+		return nil, err
+	}
+
+	maxFields := c.MaxFields
+	if maxFields <= 0 {
+		maxFields = defaultMaxFields
+	}
+	if len(c.order) >= maxFields {
+		delete(c.fields, c.order[0])
+		c.order = c.order[1:]
+	}
+	c.fields[target] = ff
+	c.order = append(c.order, target)
+	return ff, nil
+}
+
+// Invalidate forgets every cached field, for when walls or costs change
+func (c *Cache) Invalidate() {
+	clear(c.fields)
+	clear(c.requests)
+	c.order = c.order[:0]
+}
+
+// flowField counts a request for target and returns its flow field if it is
+// popular enough to have one
+func (c *Cache) flowField(target Point) *FlowField {
+	if len(c.requests) >= maxTrackedTargets {
+		clear(c.requests)
+	}
+	c.requests[target]++
+
+	threshold := c.FlowThreshold
+	if threshold <= 0 {
+		threshold = defaultFlowThreshold
+	}
+	if c.requests[target] < threshold {
+		return nil
+	}
+	ff, err := c.FlowField(target)
+	if err != nil {
+		return nil // Let A* report the error
+	}
+	return ff
+}
+
+func (c *Cache) touch(target Point) {
+	for i, p := range c.order {
+		if p == target {
+			copy(c.order[i:], c.order[i+1:])
+			c.order[len(c.order)-1] = target
+			return
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package pathfind
+
+import "math"
+
+// FlowField holds the cost of reaching one target from every tile of a grid.
+// Building it costs about as much as one long A* search, after which any number
+// of agents can find their way to the target by walking downhill.
+type FlowField struct {
+	Target  Point
+	Options Options
+
+	grid          Grid
+	width, height int
+	dist          []float64
+}
+
+// FlowField builds a flow field towards target. Tiles that can't reach the
+// target are left at an infinite distance.
+func (f *Finder) FlowField(target Point, opts Options) (*FlowField, error) {
+	f.begin()
+	if !f.inBounds(target) {
+		return nil, ErrOutOfBounds
+	}
+	if math.IsInf(f.cost(target.X, target.Y), 1) {
+		return nil, ErrBlocked
+	}
+
+	ff := &FlowField{
+		Target:  target,
+		Options: opts,
+		grid:    f.Grid,
+		width:   f.width,
+		height:  f.height,
+		dist:    make([]float64, f.width*f.height),
+	}
+	for i := range ff.dist {
+		ff.dist[i] = math.Inf(1)
+	}
+
+	// Dijkstra outwards from the target. Walking from a neighbour into the
+	// current tile costs the current tile's cost, so search the moves backwards.
+	steps := opts.steps()
+	start := f.index(target)
+	ff.dist[start] = 0
+	f.open.push(start, 0)
+	for f.open.len() > 0 {
+		current := f.open.pop()
+		if f.closed[current] == f.gen {
+			continue
+		}
+		f.closed[current] = f.gen
+
+		x, y := int(current)%f.width, int(current)/f.width
+		enter := f.cost(x, y)
+		for _, s := range steps {
+			nx, ny := x+s.dx, y+s.dy
+			if math.IsInf(f.cost(nx, ny), 1) || !canStep(f.Grid, f.width, f.height, x, y, s) {
+				continue
+			}
+			next := int32(ny*f.width + nx)
+			d := ff.dist[current] + enter*s.length
+			if d < ff.dist[next] {
+				ff.dist[next] = d
+				f.open.push(next, d)
+			}
+		}
+	}
+	return ff, nil
+}
+
+// Distance returns the cost of reaching the target from p, or +Inf if it can't
+func (ff *FlowField) Distance(p Point) float64 {
+	if p.X < 0 || p.Y < 0 || p.X >= ff.width || p.Y >= ff.height {
+		return math.Inf(1)
+	}
+	return ff.dist[p.Y*ff.width+p.X]
+}
+
+// Next returns the neighbouring tile to move to from p, or false if p is the
+// target or can't reach it
+func (ff *FlowField) Next(p Point) (Point, bool) {
+	here := ff.Distance(p)
+	if here == 0 || math.IsInf(here, 1) {
+		return p, false
+	}
+
+	// Pick the move that gets to the target cheapest, counting the cost of the move itself
+	best, bestDist := p, math.Inf(1)
+	for _, s := range ff.Options.steps() {
+		n := Point{p.X + s.dx, p.Y + s.dy}
+		c := cost(ff.grid, ff.width, ff.height, n.X, n.Y)
+		if math.IsInf(c, 1) || !canStep(ff.grid, ff.width, ff.height, p.X, p.Y, s) {
+			continue
+		}
+		if d := c*s.length + ff.Distance(n); d < bestDist {
+			best, bestDist = n, d
+		}
+	}
+	return best, best != p
+}
+
+// Path follows the field from p to the target, including both ends
+func (ff *FlowField) Path(from Point) ([]Point, error) {
+	if from.X < 0 || from.Y < 0 || from.X >= ff.width || from.Y >= ff.height {
+		return nil, ErrOutOfBounds
+	}
+	if math.IsInf(ff.Distance(from), 1) {
+		return nil, ErrNoPath
+	}
+	path := []Point{from}
+	for p := from; ; {
+		next, ok := ff.Next(p)
+		if !ok {
+			return path, nil
+		}
+		path = append(path, next)
+		p = next
+	}
+}
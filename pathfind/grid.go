@@ -0,0 +1,93 @@
+// Package pathfind finds routes across tile grids: A* for single paths, path
+// smoothing, and flow fields for many agents heading to the same place
+package pathfind
+
+import (
+	"errors"
+	"math"
+)
+
+// Blocked is the cost of a tile nothing can enter
+var Blocked = math.Inf(1)
+
+var (
+	ErrOutOfBounds = errors.New("pathfind: point outside the grid")
+	ErrBlocked     = errors.New("pathfind: destination is blocked")
+	ErrNoPath      = errors.New("pathfind: no path")
+	ErrTooFar      = errors.New("pathfind: search limit reached")
+)
+
+// Point is a tile coordinate
+type Point struct {
+	X, Y int
+}
+
+// Grid is a map that can be searched
+type Grid interface {
+	// Size returns the grid's width and height in tiles
+	Size() (int, int)
+	// Cost returns the cost of entering a tile: 1 for normal ground, more for
+	// slow ground such as mud, and Blocked for walls. Costs below 1 count as 1
+	// so the search heuristic stays admissible.
+	Cost(x, y int) float64
+}
+
+// Func adapts a cost function to a Grid of the given size
+func Func(width, height int, cost func(x, y int) float64) Grid {
+	return funcGrid{width, height, cost}
+}
+
+type funcGrid struct {
+	width, height int
+	cost          func(x, y int) float64
+}
+
+func (g funcGrid) Size() (int, int)      { return g.width, g.height }
+func (g funcGrid) Cost(x, y int) float64 { return g.cost(x, y) }
+
+// Options control how a search moves
+type Options struct {
+	Diagonal bool // Allow 8-directional moves; diagonals never cut past a blocked corner
+	MaxNodes int  // Give up after expanding this many tiles; 0 means no limit
+}
+
+// step is one move to a neighbouring tile
+type step struct {
+	dx, dy int
+	length float64
+}
+
+var (
+	straightSteps = []step{{1, 0, 1}, {-1, 0, 1}, {0, 1, 1}, {0, -1, 1}}
+	allSteps      = append(append([]step(nil), straightSteps...),
+		step{1, 1, math.Sqrt2}, step{1, -1, math.Sqrt2}, step{-1, 1, math.Sqrt2}, step{-1, -1, math.Sqrt2})
+)
+
+func (o Options) steps() []step {
+	if o.Diagonal {
+		return allSteps
+	}
+	return straightSteps
+}
+
+// cost returns the cost of entering a tile, treating anything outside the grid as blocked
+func cost(g Grid, width, height, x, y int) float64 {
+	if x < 0 || y < 0 || x >= width || y >= height {
+		return Blocked
+	}
+	c := g.Cost(x, y)
+	if c < 1 {
+		return 1
+	}
+	return c
+}
+
+// canStep reports whether a move from (x, y) along s is allowed, without
+// checking the destination itself. Diagonals need both tiles they squeeze
+// between to be open, so agents never clip a wall corner.
+func canStep(g Grid, width, height, x, y int, s step) bool {
+	if s.dx == 0 || s.dy == 0 {
+		return true
+	}
+	return !math.IsInf(cost(g, width, height, x+s.dx, y), 1) && !math.IsInf(cost(g, width, height, x, y+s.dy), 1)
+}
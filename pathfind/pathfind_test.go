@@ -0,0 +1,323 @@
+package pathfind
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// testGrid is a grid drawn in ASCII: '#' is a wall, '~' is mud costing 3, anything else costs 1
+type testGrid struct {
+	rows []string
+}
+
+func parseGrid(s string) *testGrid {
+	return &testGrid{rows: strings.Fields(s)}
+}
+
+func (g *testGrid) Size() (int, int) { return len(g.rows[0]), len(g.rows) }
+
+func (g *testGrid) Cost(x, y int) float64 {
+	switch g.rows[y][x] {
+	case '#':
+		return Blocked
+	case '~':
+		return 3
+	}
+	return 1
+}
+
+// randomGrid fills a grid with scattered walls and mud
+func randomGrid(seed int64, w, h int) *testGrid {
+	r := rand.New(rand.NewSource(seed))
+	g := &testGrid{}
+	for y := 0; y < h; y++ {
+		row := make([]byte, w)
+		for x := range row {
+			switch v := r.Float64(); {
+			case v < 0.25:
+				row[x] = '#'
+			case v < 0.4:
+				row[x] = '~'
+			default:
+				row[x] = '.'
+			}
+		}
+		g.rows = append(g.rows, string(row))
+	}
+	return g
+}
+
+// pathCost adds up a path's moves, failing the test if any move is illegal:
+// not to a neighbour, into a wall, or diagonally past a wall corner
+func pathCost(t *testing.T, g Grid, path []Point, diagonal bool) float64 {
+	t.Helper()
+	w, h := g.Size()
+	open := func(x, y int) bool { return x >= 0 && y >= 0 && x < w && y < h && !math.IsInf(g.Cost(x, y), 1) }
+	total := 0.0
+	for i := 1; i < len(path); i++ {
+		a, b := path[i-1], path[i]
+		dx, dy := abs(b.X-a.X), abs(b.Y-a.Y)
+		switch {
+		case !open(b.X, b.Y):
+			t.Fatalf("path %v enters a wall at %v", path, b)
+		case dx+dy == 1:
+			total += g.Cost(b.X, b.Y)
+		case diagonal && dx == 1 && dy == 1:
+			if !open(b.X, a.Y) || !open(a.X, b.Y) {
+				t.Fatalf("path %v cuts a corner from %v to %v", path, a, b)
+			}
+			total += g.Cost(b.X, b.Y) * math.Sqrt2
+		default:
+			t.Fatalf("path %v jumps from %v to %v", path, a, b)
+		}
+	}
+	return total
+}
+
+// bruteForce returns the cheapest cost of reaching every reachable tile from
+// start, relaxing every move until nothing changes
+func bruteForce(g Grid, start Point, diagonal bool) map[Point]float64 {
+	w, h := g.Size()
+	open := func(x, y int) bool { return x >= 0 && y >= 0 && x < w && y < h && !math.IsInf(g.Cost(x, y), 1) }
+	dist := map[Point]float64{start: 0}
+	for changed := true; changed; {
+		changed = false
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				d, ok := dist[Point{x, y}]
+				if !ok {
+					continue
+				}
+				for dy := -1; dy <= 1; dy++ {
+					for dx := -1; dx <= 1; dx++ {
+						n := Point{x + dx, y + dy}
+						if dx == 0 && dy == 0 || !open(n.X, n.Y) {
+							continue
+						}
+						step := 1.0
+						if dx != 0 && dy != 0 {
+							if !diagonal || !open(x+dx, y) || !open(x, y+dy) {
+								continue
+							}
+							step = math.Sqrt2
+						}
+						if old, seen := dist[n]; !seen || d+g.Cost(n.X, n.Y)*step < old-1e-9 {
+							dist[n] = d + g.Cost(n.X, n.Y)*step
+							changed = true
+						}
+					}
+				}
+			}
+		}
+	}
+	return dist
+}
+
+func TestFindPathShortest(t *testing.T) {
+	for seed := int64(1); seed <= 20; seed++ {
+		g := randomGrid(seed, 16, 12)
+		from := Point{0, 0}
+		g.rows[0] = "." + g.rows[0][1:]
+		f := NewFinder(g)
+		for _, diagonal := range []bool{false, true} {
+			want := bruteForce(g, from, diagonal)
+			for y := range g.rows {
+				for x := range g.rows[y] {
+					to := Point{x, y}
+					path, err := f.FindPath(from, to, Options{Diagonal: diagonal})
+					d, reachable := want[to]
+					switch {
+					case g.rows[y][x] == '#':
+						if !errors.Is(err, ErrBlocked) {
+							t.Errorf("seed %d: path to wall %v gave %v", seed, to, err)
+						}
+					case !reachable:
+						if !errors.Is(err, ErrNoPath) {
+							t.Errorf("seed %d: path to unreachable %v gave %v", seed, to, err)
+						}
+					case err != nil:
+						t.Errorf("seed %d diagonal %v: path to %v: %v", seed, diagonal, to, err)
+					default:
+						if path[0] != from || path[len(path)-1] != to {
+							t.Errorf("seed %d: path %v doesn't run from %v to %v", seed, path, from, to)
+						}
+						if c := pathCost(t, g, path, diagonal); math.Abs(c-d) > 1e-9 {
+							t.Errorf("seed %d diagonal %v: path to %v costs %v, cheapest is %v", seed, diagonal, to, c, d)
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestFindPathCorners(t *testing.T) {
+	tests := []struct {
+		name     string
+		grid     string
+		from, to Point
+		diagonal bool
+		want     []Point
+		err      error
+	}{
+		{name: "straight", grid: "... ... ...", from: Point{0, 0}, to: Point{2, 0}, want: []Point{{0, 0}, {1, 0}, {2, 0}}},
+		{name: "diagonal", grid: "... ... ...", from: Point{0, 0}, to: Point{2, 2}, diagonal: true, want: []Point{{0, 0}, {1, 1}, {2, 2}}},
+		{name: "one corner", grid: ".. #.", from: Point{0, 0}, to: Point{1, 1}, diagonal: true, want: []Point{{0, 0}, {1, 0}, {1, 1}}},
+		{name: "squeeze", grid: ".# #.", from: Point{0, 0}, to: Point{1, 1}, diagonal: true, err: ErrNoPath},
+		{name: "around mud", grid: "..# .~# .~# ..#", from: Point{1, 0}, to: Point{1, 3}, want: []Point{{1, 0}, {0, 0}, {0, 1}, {0, 2}, {0, 3}, {1, 3}}},
+		{name: "through cheap mud", grid: ".~. ### ...", from: Point{0, 0}, to: Point{2, 0}, want: []Point{{0, 0}, {1, 0}, {2, 0}}},
+		{name: "same tile", grid: "..", from: Point{1, 0}, to: Point{1, 0}, want: []Point{{1, 0}}},
+		{name: "blocked", grid: ".#", from: Point{0, 0}, to: Point{1, 0}, err: ErrBlocked},
+		{name: "walled in", grid: ".#. ##. ...", from: Point{0, 0}, to: Point{2, 2}, diagonal: true, err: ErrNoPath},
+		{name: "out of bounds", grid: "..", from: Point{0, 0}, to: Point{2, 0}, err: ErrOutOfBounds},
+		{name: "start out of bounds", grid: "..", from: Point{-1, 0}, to: Point{1, 0}, err: ErrOutOfBounds},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, err := NewFinder(parseGrid(tt.grid)).FindPath(tt.from, tt.to, Options{Diagonal: tt.diagonal})
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("got error %v, want %v", err, tt.err)
+			}
+			if len(path) != len(tt.want) {
+				t.Fatalf("got path %v, want %v", path, tt.want)
+			}
+			for i := range path {
+				if path[i] != tt.want[i] {
+					t.Fatalf("got path %v, want %v", path, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestFindPathMaxNodes(t *testing.T) {
+	f := NewFinder(parseGrid(strings.Repeat(strings.Repeat(".", 32)+" ", 32)))
+	if _, err := f.FindPath(Point{0, 0}, Point{31, 31}, Options{MaxNodes: 10}); !errors.Is(err, ErrTooFar) {
+		t.Errorf("got %v, want ErrTooFar", err)
+	}
+	if _, err := f.FindPath(Point{0, 0}, Point{31, 31}, Options{}); err != nil {
+		t.Errorf("unlimited search after a limited one: %v", err)
+	}
+}
+
+func TestFlowFieldMatchesAStar(t *testing.T) {
+	for seed := int64(1); seed <= 20; seed++ {
+		g := randomGrid(seed, 16, 12)
+		target := Point{8, 6}
+		g.rows[target.Y] = g.rows[target.Y][:target.X] + "." + g.rows[target.Y][target.X+1:]
+		f := NewFinder(g)
+		for _, diagonal := range []bool{false, true} {
+			opts := Options{Diagonal: diagonal}
+			ff, err := f.FlowField(target, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for y := range g.rows {
+				for x := range g.rows[y] {
+					from := Point{x, y}
+					path, err := f.FindPath(from, target, opts)
+					d := ff.Distance(from)
+					if g.rows[y][x] == '#' {
+						continue
+					}
+					if err != nil {
+						if !errors.Is(err, ErrNoPath) || !math.IsInf(d, 1) {
+							t.Errorf("seed %d: A* from %v gave %v, flow field distance %v", seed, from, err, d)
+						}
+						continue
+					}
+					want := pathCost(t, g, path, diagonal)
+					if math.Abs(d-want) > 1e-9 {
+						t.Errorf("seed %d diagonal %v: flow field distance from %v is %v, A* path costs %v", seed, diagonal, from, d, want)
+					}
+					flow, err := ff.Path(from)
+					if err != nil {
+						t.Fatalf("seed %d: flow path from %v: %v", seed, from, err)
+					}
+					if c := pathCost(t, g, flow, diagonal); flow[len(flow)-1] != target || math.Abs(c-want) > 1e-9 {
+						t.Errorf("seed %d diagonal %v: flow path from %v costs %v to %v, A* %v", seed, diagonal, from, c, flow[len(flow)-1], want)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestLineOfSight(t *testing.T) {
+	f := NewFinder(parseGrid(`
+		.....
+		..#..
+		.....
+		.~...
+	`))
+	tests := []struct {
+		a, b Point
+		cost float64
+		want bool
+	}{
+		{Point{0, 0}, Point{4, 0}, 1, true},
+		{Point{0, 1}, Point{4, 1}, 1, false},
+		{Point{1, 0}, Point{3, 2}, 1, false}, // Through the wall's corner
+		{Point{0, 2}, Point{4, 2}, 1, true},
+		{Point{0, 3}, Point{4, 3}, 1, false},
+		{Point{0, 3}, Point{4, 3}, 3, true},
+		{Point{3, 0}, Point{4, 3}, 1, true},
+	}
+	for _, tt := range tests {
+		if got := f.LineOfSight(tt.a, tt.b, tt.cost); got != tt.want {
+			t.Errorf("LineOfSight(%v, %v, %v) = %v, want %v", tt.a, tt.b, tt.cost, got, tt.want)
+		}
+	}
+}
+
+func TestSmooth(t *testing.T) {
+	g := parseGrid(`
+		........
+		.~~~~~~.
+		.~~~~~~.
+		........
+	`)
+	f := NewFinder(g)
+	path, err := f.FindPath(Point{0, 0}, Point{7, 3}, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The path skirts the mud along two edges, so only the corner can't be skipped
+	smoothed := f.Smooth(path)
+	if len(smoothed) != 3 || smoothed[0] != path[0] || smoothed[2] != path[len(path)-1] {
+		t.Fatalf("smoothed %v to %v", path, smoothed)
+	}
+	for i := 1; i < len(smoothed); i++ {
+		if !f.LineOfSight(smoothed[i-1], smoothed[i], 1) {
+			t.Errorf("smoothed path %v cuts through mud from %v to %v", smoothed, smoothed[i-1], smoothed[i])
+		}
+	}
+}
+
+// TestFinderGrows checks a finder copes with its grid changing size between
+// uses, whichever method sees the new size first
+func TestFinderGrows(t *testing.T) {
+	g := parseGrid("... ... ...")
+	f := NewFinder(g)
+	if _, err := f.FindPath(Point{0, 0}, Point{2, 2}, Options{}); err != nil {
+		t.Fatal(err)
+	}
+
+	*g = *parseGrid(strings.Repeat("........ ", 8))
+	if !f.LineOfSight(Point{0, 0}, Point{7, 7}, 1) {
+		t.Error("no line of sight across the grown grid")
+	}
+	if len(f.Smooth([]Point{{0, 0}, {1, 1}, {7, 7}})) != 2 {
+		t.Error("didn't smooth across the grown grid")
+	}
+	path, err := f.FindPath(Point{0, 0}, Point{7, 7}, Options{Diagonal: true})
+	if err != nil || len(path) != 8 {
+		t.Errorf("path across the grown grid: %v, %v", path, err)
+	}
+	if _, err := f.FlowField(Point{7, 7}, Options{}); err != nil {
+		t.Errorf("flow field across the grown grid: %v", err)
+	}
+}
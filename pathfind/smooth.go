@@ -0,0 +1,84 @@
+package pathfind
+
+// LineOfSight reports whether a straight line between two tile centres only
+// crosses open tiles costing no more than maxCost. A line passing exactly
+// through a corner needs both tiles beside the corner open.
+func (f *Finder) LineOfSight(a, b Point, maxCost float64) bool {
+	// Read the size here rather than through f.width: a search resizes its
+	// working memory when that changes, and this isn't a search
+	width, height := f.Grid.Size()
+	open := func(x, y int) bool {
+		return cost(f.Grid, width, height, x, y) <= maxCost
+	}
+
+	dx, dy := b.X-a.X, b.Y-a.Y
+	nx, ny := abs(dx), abs(dy)
+	sx, sy := sign(dx), sign(dy)
+	x, y := a.X, a.Y
+	for ix, iy := 0, 0; ix < nx || iy < ny; {
+		// Compare where the line next crosses a vertical and a horizontal tile edge
+		decision := (1+2*ix)*ny - (1+2*iy)*nx
+		switch {
+		case decision == 0:
+			if !open(x+sx, y) || !open(x, y+sy) {
+				return false
+			}
+			x, y = x+sx, y+sy
+			ix, iy = ix+1, iy+1
+		case decision < 0:
+			x += sx
+			ix++
+		default:
+			y += sy
+			iy++
+		}
+		if !open(x, y) {
+			return false
+		}
+	}
+	return true
+}
+
+// Smooth drops waypoints that can be skipped by walking straight to a later
+// one. A shortcut may only cross tiles as cheap as the stretch of path it
+// replaces, so smoothing never routes through mud the search avoided.
+func (f *Finder) Smooth(path []Point) []Point {
+	if len(path) < 3 {
+		return path
+	}
+	width, height := f.Grid.Size()
+
+	smoothed := []Point{path[0]}
+	for i := 0; i < len(path)-1; {
+		j := i + 1
+		stretchCost := cost(f.Grid, width, height, path[j].X, path[j].Y)
+		for j+1 < len(path) {
+			c := max(stretchCost, cost(f.Grid, width, height, path[j+1].X, path[j+1].Y))
+			if !f.LineOfSight(path[i], path[j+1], c) {
+				break
+			}
+			stretchCost = c
+			j++
+		}
+		smoothed = append(smoothed, path[j])
+		i = j
+	}
+	return smoothed
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+func sign(v int) int {
+	switch {
+	case v > 0:
+		return 1
+	case v < 0:
+		return -1
+	}
+	return 0
+}
@@ -0,0 +1,178 @@
+// Package physics handles collision shapes, collision response and contact events
+package physics
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Nathene/bitbase/common"
+	"github.com/Nathene/bitbase/entity"
+)
+
+// ShapeKind selects the geometry of a collider
+type ShapeKind string
+
+const (
+	ShapeAABB    ShapeKind = "aabb"    // Axis-aligned box of Width x Height
+	ShapeCircle  ShapeKind = "circle"  // Circle of Radius
+	ShapeCapsule ShapeKind = "capsule" // Upright capsule of Radius and total Height, good for characters
+)
+
+// Layers is a bitmask of collision layers
+type Layers uint32
+
+// Built-in collision layers
+const (
+	LayerDefault Layers = 1 << iota
+	LayerWorld
+	LayerPlayer
+	LayerNPC
+	LayerEnemy
+	LayerObject
+	LayerPickup
+	LayerProjectile
+
+	LayerAll Layers = 0xFFFFFFFF
+)
+
+var layerNames = map[string]Layers{
+	"default":    LayerDefault,
+	"world":      LayerWorld,
+	"player":     LayerPlayer,
+	"npc":        LayerNPC,
+	"enemy":      LayerEnemy,
+	"object":     LayerObject,
+	"pickup":     LayerPickup,
+	"projectile": LayerProjectile,
+	"all":        LayerAll,
+}
+
+// UnmarshalJSON accepts a layer name, a list of layer names or a raw bitmask
+func (l *Layers) UnmarshalJSON(data []byte) error {
+	var bits uint32
+	if err := json.Unmarshal(data, &bits); err == nil {
+		*l = Layers(bits)
+		return nil
+	}
+
+	var names []string
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		names = []string{name}
+	} else if err := json.Unmarshal(data, &names); err != nil {
+		return fmt.Errorf("layers must be a name, list of names or number: %s", data)
+	}
+
+	*l = 0
+	for _, n := range names {
+		bit, ok := layerNames[strings.ToLower(n)]
+		if !ok {
+			return fmt.Errorf("unknown collision layer %q", n)
+		}
+		*l |= bit
+	}
+	return nil
+}
+
+// Collider gives an object a collision shape
+type Collider struct {
+	Shape   ShapeKind `json:"shape"`
+	Width   float64   `json:"width"`  // AABB width
+	Height  float64   `json:"height"` // AABB and capsule height
+	Radius  float64   `json:"radius"` // Circle and capsule radius
+	OffsetX float64   `json:"offsetX"`
+	OffsetY float64   `json:"offsetY"` // Offset of the shape's top-left corner from the object position
+
+	// Trigger colliders report contacts but never block movement
+	Trigger bool `json:"trigger"`
+	// Movable colliders can be pushed by other solid colliders
+	Movable bool `json:"movable"`
+	// Disabled colliders touch nothing, e.g. while their owner is dead
+	Disabled bool `json:"disabled"`
+
+	Layer Layers `json:"layer"` // Layers this collider is on (default: LayerDefault)
+	Mask  Layers `json:"mask"`  // Layers this collider interacts with (default: all)
+}
+
+func init() {
+	entity.RegisterComponent("collider", func() entity.Component { return &Collider{} })
+}
+
+func (c *Collider) ComponentName() string { return "collider" }
+
+// Solid reports whether the collider blocks movement
+func (c *Collider) Solid() bool {
+	return !c.Trigger
+}
+
+// Bounds returns the collider's bounding box for an object at (x, y)
+func (c *Collider) Bounds(x, y float64) common.Rect {
+	w, h := c.size()
+	return common.Rect{X: x + c.OffsetX, Y: y + c.OffsetY, W: w, H: h}
+}
+
+// OverlapsCircle reports whether the collider, for an object at (x, y),
+// overlaps a circle of radius r around (cx, cy)
+func (c *Collider) OverlapsCircle(x, y, cx, cy, r float64) bool {
+	circle := shape{rounded: true, rect: common.NewRectAround(cx, cy, r*2, r*2), cx: cx, y0: cy, y1: cy, r: r}
+	_, _, _, ok := overlap(c.shapeAt(x, y), circle)
+	return ok
+}
+
+// Interacts reports whether two colliders' layers and masks let them touch
+func (c *Collider) Interacts(other *Collider) bool {
+	if c.Disabled || other.Disabled {
+		return false
+	}
+	return c.mask()&other.layer() != 0 && other.mask()&c.layer() != 0
+}
+
+func (c *Collider) layer() Layers {
+	if c.Layer == 0 {
+		return LayerDefault
+	}
+	return c.Layer
+}
+
+func (c *Collider) mask() Layers {
+	if c.Mask == 0 {
+		return LayerAll
+	}
+	return c.Mask
+}
+
+func (c *Collider) size() (float64, float64) {
+	switch c.Shape {
+	case ShapeCircle:
+		return c.Radius * 2, c.Radius * 2
+	case ShapeCapsule:
+		return c.Radius * 2, max(c.Height, c.Radius*2)
+	default:
+		return c.Width, c.Height
+	}
+}
+
+// shapeAt returns the collider's world-space geometry for an object at (x, y)
+func (c *Collider) shapeAt(x, y float64) shape {
+	bounds := c.Bounds(x, y)
+	switch c.Shape {
+	case ShapeCircle, ShapeCapsule:
+		return shape{
+			rounded: true,
+			rect:    bounds,
+			cx:      bounds.X + c.Radius,
+			y0:      bounds.Y + c.Radius,
+			y1:      bounds.MaxY() - c.Radius,
+			r:       c.Radius,
+		}
+	default:
+		return shape{rect: bounds}
+	}
+}
+
+// ColliderOf returns the object's collider component, or nil
+func ColliderOf(o *entity.Object) *Collider {
+	c, _ := o.Component("collider").(*Collider)
+	return c
+}
@@ -0,0 +1,113 @@
+package physics
+
+import (
+	"math"
+
+	"github.com/Nathene/bitbase/common"
+)
+
+// shape is collider geometry in world space. Circles and capsules are both
+// represented as a vertical segment (cx, y0)-(cx, y1) swept by radius r;
+// a circle is simply a segment of zero length.
+type shape struct {
+	rounded    bool
+	rect       common.Rect // Bounding box; the shape itself for AABBs
+	cx, y0, y1 float64
+	r          float64
+}
+
+// overlap tests two shapes and returns the normal to push a out of b along,
+// and how far it has to move
+func overlap(a, b shape) (nx, ny, depth float64, ok bool) {
+	switch {
+	case !a.rounded && !b.rounded:
+		return overlapRects(a.rect, b.rect)
+	case a.rounded && !b.rounded:
+		return overlapRoundedRect(a, b.rect)
+	case !a.rounded && b.rounded:
+		nx, ny, depth, ok = overlapRoundedRect(b, a.rect)
+		return -nx, -ny, depth, ok
+	default:
+		return overlapRounded(a, b)
+	}
+}
+
+func overlapRects(a, b common.Rect) (float64, float64, float64, bool) {
+	ox := math.Min(a.MaxX(), b.MaxX()) - math.Max(a.X, b.X)
+	oy := math.Min(a.MaxY(), b.MaxY()) - math.Max(a.Y, b.Y)
+	if ox <= 0 || oy <= 0 {
+		return 0, 0, 0, false
+	}
+
+	acx, acy := a.Center()
+	bcx, bcy := b.Center()
+	if ox < oy {
+		return direction(acx - bcx), 0, ox, true
+	}
+	return 0, direction(acy - bcy), oy, true
+}
+
+func overlapRoundedRect(a shape, b common.Rect) (float64, float64, float64, bool) {
+	// Pick the point on a's segment closest to the rect
+	var py float64
+	switch {
+	case a.y1 < b.Y:
+		py = a.y1
+	case a.y0 > b.MaxY():
+		py = a.y0
+	default:
+		py = (math.Max(a.y0, b.Y) + math.Min(a.y1, b.MaxY())) / 2
+	}
+
+	qx := clamp(a.cx, b.X, b.MaxX())
+	qy := clamp(py, b.Y, b.MaxY())
+	dx, dy := a.cx-qx, py-qy
+	dist := math.Hypot(dx, dy)
+
+	if dist >= a.r {
+		return 0, 0, 0, false
+	}
+	if dist > 0 {
+		return dx / dist, dy / dist, a.r - dist, true
+	}
+
+	// The segment itself is inside the rect: fall back to separating the boxes
+	return overlapRects(a.rect, b)
+}
+
+func overlapRounded(a, b shape) (float64, float64, float64, bool) {
+	var pa, pb float64
+	switch {
+	case a.y1 < b.y0:
+		pa, pb = a.y1, b.y0
+	case b.y1 < a.y0:
+		pa, pb = a.y0, b.y1
+	default:
+		mid := (math.Max(a.y0, b.y0) + math.Min(a.y1, b.y1)) / 2
+		pa, pb = mid, mid
+	}
+
+	dx, dy := a.cx-b.cx, pa-pb
+	dist := math.Hypot(dx, dy)
+	radii := a.r + b.r
+	if dist >= radii {
+		return 0, 0, 0, false
+	}
+	if dist == 0 {
+		return 0, -1, radii, true
+	}
+	return dx / dist, dy / dist, radii - dist, true
+}
+
+// direction returns the sign of v, treating zero as negative so
+// perfectly stacked shapes still separate
+func direction(v float64) float64 {
+	if v > 0 {
+		return 1
+	}
+	return -1
+}
+
+func clamp(v, lo, hi float64) float64 {
+	return math.Max(lo, math.Min(hi, v))
+}
@@ -0,0 +1,299 @@
+package physics
+
+import (
+	"math"
+	"sort"
+
+	"github.com/Nathene/bitbase/common"
+	"github.com/Nathene/bitbase/entity"
+)
+
+const (
+	maxResolveIterations = 4 // Overlaps resolved per movement step
+	maxPushChain         = 3 // How many movable objects a push can travel through
+	minStepSize          = 1.0
+)
+
+// ContactType describes the phase of a contact between two colliders
+type ContactType int
+
+const (
+	ContactBegin ContactType = iota // The colliders started touching this tick
+	ContactStay                     // The colliders are still touching
+	ContactEnd                      // The colliders stopped touching
+)
+
+// Contact is an overlap between two objects. The normal points from B towards A.
+type Contact struct {
+	Type    ContactType
+	A, B    *entity.Object
+	NormalX float64
+	NormalY float64
+	Depth   float64
+	Trigger bool // Set when either collider is a trigger
+}
+
+// Other returns the object in the contact that isn't o
+func (c Contact) Other(o *entity.Object) *entity.Object {
+	if c.A == o {
+		return c.B
+	}
+	return c.A
+}
+
+// Space moves colliders through the entity world and the tile grid
+type Space struct {
+	World *entity.World
+
+	// SolidTile reports whether the tile at (tx, ty) blocks movement
+	SolidTile func(tx, ty int) bool
+	TileSize  float64
+
+	active    map[pairKey]Contact
+	events    []Contact
+	listeners []func(Contact)
+}
+
+type pairKey struct {
+	a, b int
+}
+
+// hit is an overlap found while resolving movement; other is nil for tiles
+type hit struct {
+	other         *entity.Object
+	nx, ny, depth float64
+}
+
+// NewSpace creates a physics space over a world and tile grid
+func NewSpace(world *entity.World, tileSize float64, solidTile func(tx, ty int) bool) *Space {
+	return &Space{
+		World:     world,
+		SolidTile: solidTile,
+		TileSize:  tileSize,
+		active:    make(map[pairKey]Contact),
+	}
+}
+
+// OnContact registers a function called for every contact event
+func (s *Space) OnContact(fn func(Contact)) {
+	s.listeners = append(s.listeners, fn)
+}
+
+// Events returns the contact events produced by the last Update
+func (s *Space) Events() []Contact {
+	return s.events
+}
+
+// Move moves an object by (dx, dy), sliding along solid tiles and colliders
+// and pushing movable colliders out of the way. It returns the distance
+// actually travelled.
+func (s *Space) Move(o *entity.Object, dx, dy float64) (float64, float64) {
+	return s.move(o, dx, dy, 0, nil)
+}
+
+// move moves o, ignoring the object pushing it (if any) so the two can't fight over the overlap
+func (s *Space) move(o *entity.Object, dx, dy float64, chain int, pusher *entity.Object) (float64, float64) {
+	startX, startY := o.GetX(), o.GetY()
+
+	c := ColliderOf(o)
+	if c == nil || c.Trigger || c.Disabled {
+		o.SetPosition(startX+dx, startY+dy)
+		return dx, dy
+	}
+
+	// Split long moves into steps no larger than half the collider so we can't tunnel through walls
+	w, h := c.size()
+	maxStep := math.Max(math.Min(w, h)/2, minStepSize)
+	if s.TileSize > 0 {
+		maxStep = math.Min(maxStep, s.TileSize/2)
+	}
+	steps := int(math.Ceil(math.Max(math.Abs(dx), math.Abs(dy)) / maxStep))
+	if steps < 1 {
+		steps = 1
+	}
+	stepX, stepY := dx/float64(steps), dy/float64(steps)
+
+	for i := 0; i < steps; i++ {
+		o.SetPosition(o.GetX()+stepX, o.GetY()+stepY)
+		s.resolve(o, c, chain, pusher)
+	}
+
+	return o.GetX() - startX, o.GetY() - startY
+}
+
+// resolve pushes o out of anything solid it overlaps
+func (s *Space) resolve(o *entity.Object, c *Collider, chain int, pusher *entity.Object) {
+	for i := 0; i < maxResolveIterations; i++ {
+		deepest, ok := s.deepestHit(o, c, pusher)
+		if !ok {
+			return
+		}
+
+		remaining := deepest.depth
+		if deepest.other != nil && chain < maxPushChain {
+			if oc := ColliderOf(deepest.other); oc != nil && oc.Movable {
+				// Push the other object away; whatever it can't absorb pushes us back
+				px, py := s.move(deepest.other, -deepest.nx*remaining, -deepest.ny*remaining, chain+1, o)
+				remaining -= -(px*deepest.nx + py*deepest.ny)
+			}
+		}
+
+		if remaining > 0 {
+			o.SetPosition(o.GetX()+deepest.nx*remaining, o.GetY()+deepest.ny*remaining)
+		}
+	}
+}
+
+// deepestHit finds the solid overlap with the greatest penetration, skipping ignore
+func (s *Space) deepestHit(o *entity.Object, c *Collider, ignore *entity.Object) (hit, bool) {
+	var best hit
+	found := false
+	consider := func(h hit) {
+		if !found || h.depth > best.depth {
+			best = h
+			found = true
+		}
+	}
+
+	a := c.shapeAt(o.GetX(), o.GetY())
+
+	for _, tile := range s.solidTilesIn(a.rect) {
+		if nx, ny, depth, ok := overlap(a, shape{rect: tile}); ok {
+			consider(hit{nx: nx, ny: ny, depth: depth})
+		}
+	}
+
+	for _, other := range s.candidates(o, c, a.rect) {
+		oc := ColliderOf(other)
+		if oc.Trigger || other == ignore {
+			continue
+		}
+		if nx, ny, depth, ok := overlap(a, oc.shapeAt(other.GetX(), other.GetY())); ok {
+			consider(hit{other: other, nx: nx, ny: ny, depth: depth})
+		}
+	}
+
+	return best, found
+}
+
+// candidates returns nearby objects whose colliders can interact with c
+func (s *Space) candidates(o *entity.Object, c *Collider, area common.Rect) []*entity.Object {
+	if s.World == nil || o.World() != s.World {
+		return nil
+	}
+
+	var result []*entity.Object
+	for _, other := range s.World.QueryRect(area) {
+		if other == o {
+			continue
+		}
+		if oc := ColliderOf(other); oc != nil && c.Interacts(oc) {
+			result = append(result, other)
+		}
+	}
+	return result
+}
+
+// solidTilesIn returns the rects of every solid tile touching area
+func (s *Space) solidTilesIn(area common.Rect) []common.Rect {
+	if s.SolidTile == nil || s.TileSize <= 0 {
+		return nil
+	}
+
+	minTX := int(math.Floor(area.X / s.TileSize))
+	maxTX := int(math.Floor((area.MaxX() - 0.001) / s.TileSize))
+	minTY := int(math.Floor(area.Y / s.TileSize))
+	maxTY := int(math.Floor((area.MaxY() - 0.001) / s.TileSize))
+
+	var tiles []common.Rect
+	for ty := minTY; ty <= maxTY; ty++ {
+		for tx := minTX; tx <= maxTX; tx++ {
+			if s.SolidTile(tx, ty) {
+				tiles = append(tiles, common.Rect{
+					X: float64(tx) * s.TileSize,
+					Y: float64(ty) * s.TileSize,
+					W: s.TileSize,
+					H: s.TileSize,
+				})
+			}
+		}
+	}
+	return tiles
+}
+
+// Overlapping returns every collider currently overlapping o, triggers included
+func (s *Space) Overlapping(o *entity.Object) []Contact {
+	c := ColliderOf(o)
+	if c == nil {
+		return nil
+	}
+
+	a := c.shapeAt(o.GetX(), o.GetY())
+	var contacts []Contact
+	for _, other := range s.candidates(o, c, a.rect) {
+		oc := ColliderOf(other)
+		if nx, ny, depth, ok := overlap(a, oc.shapeAt(other.GetX(), other.GetY())); ok {
+			contacts = append(contacts, Contact{
+				Type:    ContactStay,
+				A:       o,
+				B:       other,
+				NormalX: nx,
+				NormalY: ny,
+				Depth:   depth,
+				Trigger: c.Trigger || oc.Trigger,
+			})
+		}
+	}
+	return contacts
+}
+
+// Update finds every overlapping pair of colliders and raises begin, stay
+// and end events compared to the previous update
+func (s *Space) Update() {
+	s.events = s.events[:0]
+	if s.World == nil {
+		return
+	}
+
+	current := make(map[pairKey]Contact)
+	for _, o := range s.World.All() {
+		for _, contact := range s.Overlapping(o) {
+			if contact.B.ID < o.ID {
+				continue // Each pair is reported once, from the lower ID
+			}
+			key := pairKey{o.ID, contact.B.ID}
+			if _, existed := s.active[key]; existed {
+				contact.Type = ContactStay
+			} else {
+				contact.Type = ContactBegin
+			}
+			current[key] = contact
+			s.events = append(s.events, contact)
+		}
+	}
+
+	var ended []pairKey
+	for key := range s.active {
+		if _, still := current[key]; !still {
+			ended = append(ended, key)
+		}
+	}
+	sort.Slice(ended, func(i, j int) bool {
+		if ended[i].a != ended[j].a {
+			return ended[i].a < ended[j].a
+		}
+		return ended[i].b < ended[j].b
+	})
+	for _, key := range ended {
+		contact := s.active[key]
+		contact.Type = ContactEnd
+		s.events = append(s.events, contact)
+	}
+	s.active = current
+
+	for _, event := range s.events {
+		for _, fn := range s.listeners {
+			fn(event)
+		}
+	}
+}
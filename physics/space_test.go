@@ -0,0 +1,226 @@
+package physics
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"testing"
+
+	"github.com/Nathene/bitbase/entity"
+)
+
+// newBox makes an object with a box collider with its top-left corner at (x, y)
+func newBox(name string, x, y, w, h float64, c Collider) *entity.Object {
+	o := entity.NewObject(name)
+	c.Shape, c.Width, c.Height = ShapeAABB, w, h
+	o.AddComponent(&c)
+	o.SetPosition(x, y)
+	return o
+}
+
+// newTestSpace puts objects in a space of 16px tiles, solid wherever solid says
+func newTestSpace(solid func(tx, ty int) bool, objects ...*entity.Object) *Space {
+	w := entity.NewWorld()
+	for _, o := range objects {
+		w.Add(o)
+	}
+	return NewSpace(w, 16, solid)
+}
+
+func near(a, b float64) bool {
+	return math.Abs(a-b) < 1e-6
+}
+
+func TestMoveSlides(t *testing.T) {
+	floor := func(tx, ty int) bool { return ty >= 2 }            // Solid from y = 32 down
+	corner := func(tx, ty int) bool { return tx < 0 || ty < 0 }  // Walls left and above the origin
+	block := func(tx, ty int) bool { return tx == 2 && ty == 2 } // One tile from 32,32 to 48,48
+
+	tests := []struct {
+		name         string
+		solid        func(tx, ty int) bool
+		x, y, dx, dy float64
+		wantX, wantY float64
+	}{
+		// Across many tiles' seams without catching on any of them
+		{name: "along a floor", solid: floor, x: 0, y: 10, dx: 100, dy: 20, wantX: 100, wantY: 20},
+		{name: "into a floor", solid: floor, x: 0, y: 10, dx: 0, dy: 40, wantX: 0, wantY: 20},
+		{name: "into an inside corner", solid: corner, x: 4, y: 4, dx: -20, dy: -30, wantX: 0, wantY: 0},
+		{name: "along one wall of a corner", solid: corner, x: 4, y: 4, dx: 20, dy: -30, wantX: 24, wantY: 0},
+		// Clipping the top of a lone block nudges up and over rather than stopping dead
+		{name: "over a block's corner", solid: block, x: 10, y: 21, dx: 40, dy: 0, wantX: 50, wantY: 20},
+		// Held against a block's side only while beside it, then free to carry on
+		{name: "down a block's side", solid: block, x: 21, y: 30, dx: 10, dy: 30, wantX: 26, wantY: 60},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := newBox("player", tt.x, tt.y, 12, 12, Collider{})
+			s := newTestSpace(tt.solid, o)
+			dx, dy := s.Move(o, tt.dx, tt.dy)
+			if !near(o.GetX(), tt.wantX) || !near(o.GetY(), tt.wantY) {
+				t.Errorf("ended at %v,%v, want %v,%v", o.GetX(), o.GetY(), tt.wantX, tt.wantY)
+			}
+			if !near(dx, o.GetX()-tt.x) || !near(dy, o.GetY()-tt.y) {
+				t.Errorf("reported moving %v,%v, actually moved %v,%v", dx, dy, o.GetX()-tt.x, o.GetY()-tt.y)
+			}
+		})
+	}
+}
+
+func TestMovePushes(t *testing.T) {
+	wall := func(tx, ty int) bool { return tx >= 6 } // Solid from x = 96 on
+
+	// A free push moves the crate with the pusher
+	player := newBox("player", 0, 2, 12, 12, Collider{})
+	crate := newBox("crate", 12, 0, 16, 16, Collider{Movable: true})
+	s := newTestSpace(wall, player, crate)
+	s.Move(player, 30, 0)
+	if !near(player.GetX(), 30) || !near(crate.GetX(), 42) {
+		t.Errorf("player at %v and crate at %v, want 30 and 42", player.GetX(), crate.GetX())
+	}
+	if !near(crate.GetY(), 0) {
+		t.Errorf("crate moved sideways to %v", crate.GetY())
+	}
+
+	// Into the wall, the crate stops against it and the pusher stops against the crate
+	dx, _ := s.Move(player, 100, 0)
+	if !near(crate.GetX(), 80) || !near(player.GetX(), 68) || !near(dx, 38) {
+		t.Errorf("player at %v after moving %v, crate at %v; want 68, 38 and 80", player.GetX(), dx, crate.GetX())
+	}
+
+	// Something that isn't movable is a wall of its own
+	rock := newBox("rock", 40, 0, 16, 16, Collider{})
+	player = newBox("player", 0, 2, 12, 12, Collider{})
+	s = newTestSpace(nil, player, rock)
+	s.Move(player, 50, 0)
+	if !near(player.GetX(), 28) || !near(rock.GetX(), 40) {
+		t.Errorf("player at %v and rock at %v, want 28 and 40", player.GetX(), rock.GetX())
+	}
+}
+
+func TestMovePushChain(t *testing.T) {
+	for crates := 1; crates <= maxPushChain+2; crates++ {
+		t.Run(fmt.Sprint(crates), func(t *testing.T) {
+			player := newBox("player", 0, 2, 12, 12, Collider{})
+			objects := []*entity.Object{player}
+			for i := range crates {
+				objects = append(objects, newBox(fmt.Sprint("crate", i), 12+16*float64(i), 0, 16, 16, Collider{Movable: true}))
+			}
+			s := newTestSpace(nil, objects...)
+			dx, _ := s.Move(player, 10, 0)
+
+			// Up to maxPushChain crates go along; past that the row is stuck
+			moves := crates <= maxPushChain
+			want := 0.0
+			if moves {
+				want = 10
+			}
+			if !near(dx, want) {
+				t.Errorf("player moved %v, want %v", dx, want)
+			}
+			for i, o := range objects[1:] {
+				if got := o.GetX() - (12 + 16*float64(i)); !near(got, want) {
+					t.Errorf("crate %d moved %v, want %v", i, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestMoveLayers(t *testing.T) {
+	tests := []struct {
+		name    string
+		mover   Collider
+		other   Collider
+		blocked bool
+	}{
+		{name: "defaults", blocked: true},
+		{name: "on a layer the mover masks", mover: Collider{Layer: LayerPlayer, Mask: LayerWorld | LayerEnemy}, other: Collider{Layer: LayerEnemy}, blocked: true},
+		{name: "on a layer the mover doesn't mask", mover: Collider{Layer: LayerPlayer, Mask: LayerWorld | LayerEnemy}, other: Collider{Layer: LayerNPC}},
+		{name: "masking out the mover", mover: Collider{Layer: LayerPlayer}, other: Collider{Layer: LayerEnemy, Mask: LayerWorld}},
+		{name: "trigger", other: Collider{Trigger: true}},
+		{name: "disabled", other: Collider{Disabled: true}},
+		{name: "mover is a trigger", mover: Collider{Trigger: true}},
+		{name: "mover is disabled", mover: Collider{Disabled: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mover := newBox("mover", 0, 0, 12, 12, tt.mover)
+			other := newBox("other", 20, 0, 12, 12, tt.other)
+			s := newTestSpace(nil, mover, other)
+			s.Move(mover, 30, 0)
+
+			want := 30.0
+			if tt.blocked {
+				want = 8
+			}
+			if !near(mover.GetX(), want) {
+				t.Errorf("mover at %v, want %v", mover.GetX(), want)
+			}
+
+			// Only pairs that can touch at all make contacts
+			mover.SetPosition(14, 0)
+			s.Update()
+			touching := tt.blocked || tt.other.Trigger || tt.mover.Trigger
+			if got := len(s.Events()) == 1; got != touching {
+				t.Errorf("contacts %v, want touching %v", s.Events(), touching)
+			}
+		})
+	}
+}
+
+func TestUpdateContactEvents(t *testing.T) {
+	zone := newBox("zone", 0, 0, 32, 32, Collider{Trigger: true})
+	a := newBox("a", 100, 0, 8, 8, Collider{})
+	b := newBox("b", 100, 100, 8, 8, Collider{})
+	s := newTestSpace(nil, zone, a, b)
+	name := map[ContactType]string{ContactBegin: "begin", ContactStay: "stay", ContactEnd: "end"}
+	var heard []string
+	s.OnContact(func(c Contact) {
+		heard = append(heard, fmt.Sprintf("%s %s-%s", name[c.Type], c.A.Name, c.B.Name))
+	})
+
+	steps := []struct {
+		move func()
+		want []string
+	}{
+		{want: nil},
+		{move: func() { a.SetPosition(4, 4) }, want: []string{"begin zone-a"}},
+		{move: func() { b.SetPosition(20, 20) }, want: []string{"stay zone-a", "begin zone-b"}},
+		// Both leave the zone and bump into each other below it; new contacts come before ends
+		{move: func() { a.SetPosition(24, 40); b.SetPosition(28, 44) }, want: []string{"begin a-b", "end zone-a", "end zone-b"}},
+		{want: []string{"stay a-b"}},
+		{move: func() { b.SetPosition(200, 200) }, want: []string{"end a-b"}},
+		{want: nil},
+	}
+	for i, step := range steps {
+		if step.move != nil {
+			step.move()
+		}
+		heard = heard[:0]
+		s.Update()
+		var got []string
+		for _, c := range s.Events() {
+			got = append(got, fmt.Sprintf("%s %s-%s", name[c.Type], c.A.Name, c.B.Name))
+			if c.Trigger != (c.A == zone) {
+				t.Errorf("step %d: %s-%s trigger %v", i, c.A.Name, c.B.Name, c.Trigger)
+			}
+		}
+		if !slices.Equal(got, step.want) {
+			t.Errorf("step %d: events %v, want %v", i, got, step.want)
+		}
+		if !slices.Equal(heard, got) {
+			t.Errorf("step %d: listener heard %v", i, heard)
+		}
+	}
+
+	// The normal points from B to A, and depth is how far they overlap
+	a.SetPosition(0, 0)
+	b.SetPosition(6, 0)
+	s.Update()
+	for _, c := range s.Events() {
+		if c.A == a && c.B == b && (c.NormalX != -1 || c.NormalY != 0 || !near(c.Depth, 2)) {
+			t.Errorf("a-b normal %v,%v depth %v, want -1,0 and 2", c.NormalX, c.NormalY, c.Depth)
+		}
+	}
+}
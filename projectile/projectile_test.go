@@ -0,0 +1,220 @@
+package projectile
+
+import (
+	"math"
+	"slices"
+	"testing"
+
+	"github.com/Nathene/bitbase/combat"
+	"github.com/Nathene/bitbase/entity"
+	"github.com/Nathene/bitbase/physics"
+)
+
+// newTarget makes a 16x16 damageable object with its top-left corner at (x, y)
+func newTarget(name, team string, x, y float64) *entity.Object {
+	o := entity.NewObject(name)
+	o.AddComponent(&entity.Sprite{Width: 16, Height: 16})
+	o.AddComponent(&combat.Health{Max: 100})
+	o.AddComponent(&combat.Damageable{Team: team})
+	o.SetPosition(x, y)
+	return o
+}
+
+// newTestSystem fires projectiles among objects, with walls on every tile
+// where wall returns true
+func newTestSystem(t *testing.T, wall func(tx, ty int) bool, specs []*Spec, objects ...*entity.Object) *System {
+	t.Helper()
+	library := NewLibrary()
+	for _, s := range specs {
+		if err := library.Add(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	w := entity.NewWorld()
+	c := combat.NewSystem(w, nil, nil)
+	for _, o := range objects {
+		w.Add(o)
+		c.Prepare(o)
+	}
+	return NewSystem(library, w, physics.NewSpace(w, 16, wall), c)
+}
+
+// hits returns the names of the objects damaged so far, in order
+func hits(s *System) []string {
+	var names []string
+	for _, e := range s.Combat.Log.Events() {
+		names = append(names, e.Target.Name)
+	}
+	return names
+}
+
+func TestHitsCollider(t *testing.T) {
+	// A tall sprite whose body is only the bottom 8 pixels
+	ogre := entity.NewObject("ogre")
+	ogre.AddComponent(&entity.Sprite{Width: 32, Height: 48})
+	ogre.AddComponent(&combat.Health{Max: 100})
+	ogre.AddComponent(&combat.Damageable{Team: "ogres"})
+	ogre.AddComponent(&physics.Collider{Shape: physics.ShapeAABB, Width: 16, Height: 8, OffsetX: 8, OffsetY: 40})
+	ogre.SetPosition(100, 0)
+	bat := newTarget("bat", "bats", 100, 100) // No collider, so the sprite is what gets hit
+
+	tests := []struct {
+		name string
+		y    float64
+		want []string
+	}{
+		{name: "over the head", y: 10, want: nil},
+		{name: "past the body's edge", y: 36, want: nil},
+		{name: "through the body", y: 44, want: []string{"ogre"}},
+		{name: "grazing the body", y: 50, want: []string{"ogre"}},
+		{name: "through a sprite without a collider", y: 101, want: []string{"bat"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestSystem(t, nil, []*Spec{{ID: "arrow", Speed: 400, Lifetime: 1, Radius: 3}}, ogre, bat)
+			s.Fire("arrow", nil, 0, tt.y, 1, 0)
+			for range 20 {
+				s.Update(0.05)
+			}
+			if got := hits(s); !slices.Equal(got, tt.want) {
+				t.Errorf("hit %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPooling(t *testing.T) {
+	goblin := newTarget("goblin", "goblins", 40, -8)
+	s := newTestSystem(t, nil, []*Spec{
+		{ID: "seeker", Speed: 100, Lifetime: 0.2, Radius: 2, Pierce: 3, Homing: 90, HomingRange: 500},
+		{ID: "dart", Speed: 100, Lifetime: 5, Radius: 2},
+	}, goblin)
+
+	first := s.Fire("seeker", nil, 0, 0, 1, 0)
+	if first == nil || first.Target != goblin {
+		t.Fatalf("fired %+v, want it homing on the goblin", first)
+	}
+	for range 5 {
+		s.Update(0.05)
+	}
+	if len(s.Active()) != 0 || s.Pooled() != 1 {
+		t.Fatalf("%d active and %d pooled after the lifetime ran out", len(s.Active()), s.Pooled())
+	}
+
+	// The spent projectile comes back with nothing left over from its last flight
+	second := s.Fire("dart", nil, 0, 100, 0, 1)
+	if second != first {
+		t.Error("firing again didn't reuse the pooled projectile")
+	}
+	if second.Target != nil || second.pierceLeft != 0 || second.age != 0 || len(second.hit) != 0 || second.Spec.ID != "dart" {
+		t.Errorf("reused projectile kept old state: %+v", *second)
+	}
+	if s.Pooled() != 0 {
+		t.Errorf("%d pooled after reusing the only one", s.Pooled())
+	}
+
+	third := s.Fire("dart", nil, 0, 100, 0, 1)
+	if third == second {
+		t.Error("two projectiles in flight share one")
+	}
+	s.Clear()
+	if len(s.Active()) != 0 || s.Pooled() != 2 {
+		t.Errorf("%d active and %d pooled after Clear", len(s.Active()), s.Pooled())
+	}
+	if s.Fire("missing", nil, 0, 0, 1, 0) != nil || s.Fire("dart", nil, 0, 0, 0, 0) != nil {
+		t.Error("fired an unknown spec or with no direction")
+	}
+}
+
+func TestPierce(t *testing.T) {
+	tests := []struct {
+		pierce int
+		want   []string
+	}{
+		{0, []string{"a"}},
+		{1, []string{"a", "b"}},
+		{3, []string{"a", "b", "c", "d"}},
+		{10, []string{"a", "b", "c", "d"}},
+	}
+	for _, tt := range tests {
+		// Slow enough to spend several steps inside each target, which it hits once
+		a, b := newTarget("a", "goblins", 20, -8), newTarget("b", "goblins", 50, -8)
+		c, d := newTarget("c", "goblins", 80, -8), newTarget("d", "goblins", 110, -8)
+		ally := newTarget("ally", "player", 35, -8)
+		owner := newTarget("player", "player", -100, -8)
+		s := newTestSystem(t, nil, []*Spec{{ID: "bolt", Speed: 60, Lifetime: 4, Radius: 2, Pierce: tt.pierce}}, a, b, c, d, ally, owner)
+		s.Fire("bolt", owner, 0, 0, 1, 0)
+		for range 60 {
+			s.Update(1.0 / 30)
+		}
+		if got := hits(s); !slices.Equal(got, tt.want) {
+			t.Errorf("pierce %d hit %v, want %v", tt.pierce, got, tt.want)
+		}
+		if stopped := len(s.Active()) == 0; stopped != (tt.pierce < 4) {
+			t.Errorf("pierce %d: stopped %v", tt.pierce, stopped)
+		}
+	}
+}
+
+func TestHomingTurnLimit(t *testing.T) {
+	// Straight above the launch point while the projectile flies right, and
+	// far enough away to be outside its tightest turn
+	target := newTarget("goblin", "goblins", -8, -408)
+	s := newTestSystem(t, nil, []*Spec{{ID: "seeker", Speed: 200, Lifetime: 5, Radius: 2, Homing: 90, HomingRange: 500}}, target)
+	p := s.Fire("seeker", nil, 0, 0, 1, 0)
+
+	const dt = 0.05
+	maxTurn := 90 * dt * math.Pi / 180
+	heading := math.Atan2(p.VY, p.VX)
+	for step := 0; len(s.Active()) > 0 && step < 100; step++ {
+		s.Update(dt)
+		if len(s.Active()) == 0 {
+			break
+		}
+		now := math.Atan2(p.VY, p.VX)
+		if turned := math.Abs(math.Remainder(now-heading, 2*math.Pi)); turned > maxTurn+1e-9 {
+			t.Fatalf("step %d: turned %.2f°, limit %.2f°", step, turned*180/math.Pi, maxTurn*180/math.Pi)
+		}
+		if speed := math.Hypot(p.VX, p.VY); math.Abs(speed-200) > 1e-9 {
+			t.Fatalf("step %d: speed %v, want 200", step, speed)
+		}
+		heading = now
+	}
+	if got := hits(s); !slices.Equal(got, []string{"goblin"}) {
+		t.Errorf("hit %v, want the goblin", got)
+	}
+
+	// Out of range, it flies straight
+	far := newTarget("goblin", "goblins", 1000, 1000)
+	s = newTestSystem(t, nil, []*Spec{{ID: "seeker", Speed: 200, Lifetime: 5, Radius: 2, Homing: 90, HomingRange: 500}}, far)
+	p = s.Fire("seeker", nil, 0, 0, 1, 0)
+	s.Update(0.5)
+	if p.Target != nil || p.VY != 0 || p.Y != 0 {
+		t.Errorf("turned towards a target out of range: %+v", *p)
+	}
+}
+
+func TestWallStops(t *testing.T) {
+	// Walls fill every tile from x = 64 on
+	wall := func(tx, ty int) bool { return tx >= 4 }
+	behind := newTarget("behind", "goblins", 70, -8)
+	crate := entity.NewObject("crate")
+	crate.AddComponent(&physics.Collider{Shape: physics.ShapeAABB, Width: 16, Height: 16})
+	crate.SetPosition(20, 32)
+	s := newTestSystem(t, wall, []*Spec{{ID: "bullet", Speed: 2000, Lifetime: 1, Radius: 2}}, behind, crate)
+
+	wallShot := s.Fire("bullet", nil, 0, 0, 1, 0)
+	s.Update(0.1) // 200 pixels in one step, far past the wall
+	if len(s.Active()) != 0 {
+		t.Fatalf("bullet still flying at %v,%v", wallShot.X, wallShot.Y)
+	}
+	if hits(s) != nil {
+		t.Errorf("hit %v through a wall", hits(s))
+	}
+
+	s.Fire("bullet", nil, 0, 40, 1, 0)
+	s.Update(0.1)
+	if len(s.Active()) != 0 || hits(s) != nil {
+		t.Errorf("crate didn't stop the bullet: %d flying, hit %v", len(s.Active()), hits(s))
+	}
+}
@@ -0,0 +1,25 @@
+package projectile
+
+import "github.com/Nathene/bitbase/entity"
+
+func init() {
+	entity.RegisterComponent("ranged", func() entity.Component { return &Ranged{} })
+}
+
+// Ranged gives an object a ranged attack, such as a bow or a spell
+type Ranged struct {
+	Projectile string  `json:"projectile"` // Spec ID
+	Cooldown   float64 `json:"cooldown"`   // Seconds between shots
+	Count      int     `json:"count"`      // Projectiles per shot, default 1
+	Spread     float64 `json:"spread"`     // Total angle a multi-shot volley fans across, in degrees
+
+	readyAt float64 // System time the next shot is allowed
+}
+
+func (r *Ranged) ComponentName() string { return "ranged" }
+
+// RangedOf returns the object's ranged attack component, or nil
+func RangedOf(o *entity.Object) *Ranged {
+	r, _ := o.Component("ranged").(*Ranged)
+	return r
+}
@@ -0,0 +1,119 @@
+// Package projectile fires pooled projectiles such as arrows and spells, and resolves
+// their hits against walls and damageable entities
+package projectile
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/Nathene/bitbase/combat"
+)
+
+// Spec is the static definition of a kind of projectile
+type Spec struct {
+	ID       string  `json:"id"`
+	Speed    float64 `json:"speed"`    // Pixels per second
+	Lifetime float64 `json:"lifetime"` // Seconds before it disappears
+	Radius   float64 `json:"radius"`   // Collision radius in pixels
+	Pierce   int     `json:"pierce"`   // How many targets it passes through before stopping
+
+	// Homing projectiles turn towards the nearest enemy in range
+	Homing      float64 `json:"homing"`      // Turn rate in degrees per second; 0 flies straight
+	HomingRange float64 `json:"homingRange"` // Pixels
+
+	Damage    float64           `json:"damage"` // Added to the shooter's attack stat
+	Type      combat.DamageType `json:"type"`
+	Knockback float64           `json:"knockback"`
+	Effects   []string          `json:"effects"` // Status effects applied to whatever it hits
+
+	Image string `json:"image,omitempty"` // Asset ID; empty draws a coloured dot
+	Color string `json:"color,omitempty"`
+}
+
+// Library holds every known projectile spec, keyed by ID
+type Library struct {
+	specs map[string]*Spec
+}
+
+// NewLibrary creates an empty projectile library
+func NewLibrary() *Library {
+	return &Library{specs: make(map[string]*Spec)}
+}
+
+// Add validates and registers a spec
+func (l *Library) Add(s *Spec) error {
+	if s.ID == "" {
+		return fmt.Errorf("projectile has no id")
+	}
+	if _, exists := l.specs[s.ID]; exists {
+		return fmt.Errorf("projectile %q defined twice", s.ID)
+	}
+	if s.Speed <= 0 {
+		return fmt.Errorf("projectile %q: speed must be positive", s.ID)
+	}
+	if s.Lifetime <= 0 {
+		return fmt.Errorf("projectile %q: lifetime must be positive", s.ID)
+	}
+	if s.Radius <= 0 {
+		s.Radius = 3
+	}
+	if s.Homing > 0 && s.HomingRange <= 0 {
+		s.HomingRange = 300
+	}
+	if s.Type == "" {
+		s.Type = combat.Physical
+	}
+	l.specs[s.ID] = s
+	return nil
+}
+
+// Get returns the spec with the given ID, or nil
+func (l *Library) Get(id string) *Spec {
+	return l.specs[id]
+}
+
+// IDs returns every spec ID in sorted order
+func (l *Library) IDs() []string {
+	ids := make([]string, 0, len(l.specs))
+	for id := range l.specs {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// LoadFile reads a JSON array of projectile specs
+func (l *Library) LoadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var specs []*Spec
+	if err := json.Unmarshal(data, &specs); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	for _, s := range specs {
+		if err := l.Add(s); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// LoadDir loads every .json file in a directory
+func (l *Library) LoadDir(dir string) error {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return err
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		if err := l.LoadFile(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
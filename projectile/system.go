@@ -0,0 +1,347 @@
+package projectile
+
+import (
+	"image/color"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/Nathene/bitbase/combat"
+	"github.com/Nathene/bitbase/common"
+	"github.com/Nathene/bitbase/entity"
+	"github.com/Nathene/bitbase/physics"
+	"github.com/Nathene/bitbase/stats"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// Projectile is one projectile in flight. Projectiles are pooled by the System,
+// so don't keep a pointer to one after it has stopped.
+type Projectile struct {
+	Spec   *Spec
+	Owner  *entity.Object
+	Target *entity.Object // What a homing projectile is chasing, if anything
+	Damage float64
+
+	X, Y         float64 // Centre
+	VX, VY       float64 // Pixels per second
+	prevX, prevY float64
+	age          float64
+	pierceLeft   int
+	hit          []*entity.Object // Already damaged, so piercing shots hit each target once
+}
+
+// System owns every projectile in flight and a pool of spent ones to reuse
+type System struct {
+	Library *Library
+	World   *entity.World
+	Space   *physics.Space // Walls come from its SolidTile and TileSize
+	Combat  *combat.System
+	Images  func(id string) *ebiten.Image // Optional; projectiles without images draw as dots
+
+	active []*Projectile
+	free   []*Projectile
+	nearby []*entity.Object
+	time   float64
+}
+
+// NewSystem creates a projectile system
+func NewSystem(library *Library, world *entity.World, space *physics.Space, combatSystem *combat.System) *System {
+	return &System{
+		Library: library,
+		World:   world,
+		Space:   space,
+		Combat:  combatSystem,
+	}
+}
+
+// Active returns the projectiles currently in flight
+func (s *System) Active() []*Projectile {
+	return s.active
+}
+
+// Pooled returns how many spent projectiles are waiting to be reused
+func (s *System) Pooled() int {
+	return len(s.free)
+}
+
+// Fire launches a projectile from (x, y) in direction (dirX, dirY) and returns it,
+// or nil if the spec is unknown or the direction is zero
+func (s *System) Fire(specID string, owner *entity.Object, x, y, dirX, dirY float64) *Projectile {
+	spec := s.Library.Get(specID)
+	length := math.Hypot(dirX, dirY)
+	if spec == nil || length == 0 {
+		return nil
+	}
+
+	p := s.acquire()
+	p.Spec = spec
+	p.Owner = owner
+	p.Damage = spec.Damage
+	if owner != nil {
+		if st := entity.StatsOf(owner); st != nil {
+			p.Damage += st.Get(stats.Attack)
+		}
+	}
+	p.X, p.Y = x, y
+	p.prevX, p.prevY = x, y
+	p.VX, p.VY = dirX/length*spec.Speed, dirY/length*spec.Speed
+	p.pierceLeft = spec.Pierce
+	if spec.Homing > 0 {
+		p.Target = s.findTarget(p)
+	}
+
+	s.active = append(s.active, p)
+	return p
+}
+
+// Shoot fires an object's ranged attack towards (aimX, aimY) if it is off cooldown,
+// spreading multi-shot volleys evenly around the aim direction
+func (s *System) Shoot(o *entity.Object, aimX, aimY float64) bool {
+	if !s.Ready(o) || combat.IsDead(o) || (aimX == 0 && aimY == 0) {
+		return false
+	}
+	r := RangedOf(o)
+	r.readyAt = s.time + r.Cooldown
+
+	count := max(r.Count, 1)
+	spread := r.Spread * math.Pi / 180
+	base := math.Atan2(aimY, aimX)
+	x, y := o.Bounds().Center()
+	for i := 0; i < count; i++ {
+		angle := base
+		if count > 1 {
+			angle += spread * (float64(i)/float64(count-1) - 0.5)
+		}
+		s.Fire(r.Projectile, o, x, y, math.Cos(angle), math.Sin(angle))
+	}
+	return true
+}
+
+// Ready reports whether an object has a ranged attack that is off cooldown
+func (s *System) Ready(o *entity.Object) bool {
+	r := RangedOf(o)
+	return r != nil && s.time >= r.readyAt
+}
+
+// Update moves every projectile by dt seconds and resolves what it hits
+func (s *System) Update(dt float64) {
+	s.time += dt
+
+	alive := s.active[:0]
+	for _, p := range s.active {
+		if s.step(p, dt) {
+			alive = append(alive, p)
+		} else {
+			s.release(p)
+		}
+	}
+	// Clear the tail so released projectiles aren't kept reachable twice
+	for i := len(alive); i < len(s.active); i++ {
+		s.active[i] = nil
+	}
+	s.active = alive
+}
+
+// Clear stops every projectile in flight
+func (s *System) Clear() {
+	for i, p := range s.active {
+		s.release(p)
+		s.active[i] = nil
+	}
+	s.active = s.active[:0]
+}
+
+// step advances one projectile and reports whether it is still flying
+func (s *System) step(p *Projectile, dt float64) bool {
+	p.prevX, p.prevY = p.X, p.Y
+	p.age += dt
+	if p.age >= p.Spec.Lifetime {
+		return false
+	}
+
+	if p.Spec.Homing > 0 {
+		s.steer(p, dt)
+	}
+
+	// Move in steps no longer than the projectile's radius so it can't skip through thin things
+	dx, dy := p.VX*dt, p.VY*dt
+	steps := max(int(math.Ceil(math.Hypot(dx, dy)/math.Max(p.Spec.Radius, 1))), 1)
+	for i := 0; i < steps; i++ {
+		p.X += dx / float64(steps)
+		p.Y += dy / float64(steps)
+		if s.hitsWall(p) || !s.hitEntities(p) {
+			return false
+		}
+	}
+	return true
+}
+
+// steer turns a homing projectile towards its target, keeping its speed
+func (s *System) steer(p *Projectile, dt float64) {
+	if p.Target == nil || p.Target.World() != s.World || combat.IsDead(p.Target) {
+		p.Target = s.findTarget(p)
+		if p.Target == nil {
+			return
+		}
+	}
+
+	tx, ty := hurtbox(p.Target).Center()
+	want := math.Atan2(ty-p.Y, tx-p.X)
+	have := math.Atan2(p.VY, p.VX)
+	turn := math.Remainder(want-have, 2*math.Pi)
+	maxTurn := p.Spec.Homing * math.Pi / 180 * dt
+	turn = math.Max(-maxTurn, math.Min(maxTurn, turn))
+
+	speed := math.Hypot(p.VX, p.VY)
+	p.VX, p.VY = math.Cos(have+turn)*speed, math.Sin(have+turn)*speed
+}
+
+// findTarget picks the nearest enemy of the projectile's owner within homing range
+func (s *System) findTarget(p *Projectile) *entity.Object {
+	var best *entity.Object
+	bestDist := p.Spec.HomingRange
+	for _, o := range s.World.QueryRadius(p.X, p.Y, p.Spec.HomingRange) {
+		if !s.canHit(p, o) {
+			continue
+		}
+		cx, cy := hurtbox(o).Center()
+		if d := math.Hypot(cx-p.X, cy-p.Y); d <= bestDist {
+			best, bestDist = o, d
+		}
+	}
+	return best
+}
+
+// canHit reports whether p may damage o: not its owner, not on the owner's team,
+// alive and not already hit by this projectile
+func (s *System) canHit(p *Projectile, o *entity.Object) bool {
+	if o == p.Owner || combat.DamageableOf(o) == nil || combat.IsDead(o) {
+		return false
+	}
+	if p.Owner != nil {
+		if od := combat.DamageableOf(p.Owner); od != nil && od.Team != "" && od.Team == combat.DamageableOf(o).Team {
+			return false
+		}
+	}
+	for _, already := range p.hit {
+		if already == o {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *System) hitsWall(p *Projectile) bool {
+	if s.Space == nil || s.Space.SolidTile == nil || s.Space.TileSize <= 0 {
+		return false
+	}
+	tx := int(math.Floor(p.X / s.Space.TileSize))
+	ty := int(math.Floor(p.Y / s.Space.TileSize))
+	return s.Space.SolidTile(tx, ty)
+}
+
+// hitEntities damages whatever the projectile is touching and reports whether it keeps flying
+func (s *System) hitEntities(p *Projectile) bool {
+	area := common.NewRectAround(p.X, p.Y, p.Spec.Radius*2, p.Spec.Radius*2)
+	s.nearby = s.World.AppendQueryRect(s.nearby[:0], area)
+
+	for _, o := range s.nearby {
+		if o == p.Owner || !touches(p, o, area) {
+			continue
+		}
+
+		if !s.canHit(p, o) {
+			// Solid scenery such as crates and chests stops projectiles; triggers, allies and corpses don't
+			if c := physics.ColliderOf(o); c != nil && c.Solid() && !c.Disabled && combat.DamageableOf(o) == nil {
+				return false
+			}
+			continue
+		}
+
+		p.hit = append(p.hit, o)
+		if s.Combat != nil {
+			s.Combat.Damage(o, combat.Damage{
+				Amount:    p.Damage,
+				Type:      p.Spec.Type,
+				Source:    p.Owner,
+				Knockback: p.Spec.Knockback,
+				Effects:   p.Spec.Effects,
+			})
+		}
+		if p.pierceLeft == 0 {
+			return false
+		}
+		p.pierceLeft--
+	}
+	return true
+}
+
+// hurtbox returns the part of an object projectiles hit: its collider if it
+// has one, since sprites usually reach well past the body, or else its bounds
+func hurtbox(o *entity.Object) common.Rect {
+	if c := physics.ColliderOf(o); c != nil {
+		return c.Bounds(o.GetX(), o.GetY())
+	}
+	return o.Bounds()
+}
+
+// touches reports whether a projectile, whose bounding box is area, overlaps
+// an object's collider shape, or its bounds if it has no collider
+func touches(p *Projectile, o *entity.Object, area common.Rect) bool {
+	if c := physics.ColliderOf(o); c != nil {
+		return c.OverlapsCircle(o.GetX(), o.GetY(), p.X, p.Y, p.Spec.Radius)
+	}
+	return o.Bounds().Intersects(area)
+}
+
+func (s *System) acquire() *Projectile {
+	if n := len(s.free); n > 0 {
+		p := s.free[n-1]
+		s.free = s.free[:n-1]
+		return p
+	}
+	return &Projectile{}
+}
+
+// release resets a projectile and returns it to the pool, keeping its hit list's storage
+func (s *System) release(p *Projectile) {
+	hit := p.hit[:0]
+	clear(p.hit)
+	*p = Projectile{hit: hit}
+	s.free = append(s.free, p)
+}
+
+// Draw renders every projectile, interpolated between simulation steps
+func (s *System) Draw(screen *ebiten.Image, camera common.Camera, alpha float64) {
+	for _, p := range s.active {
+		x := p.prevX + (p.X-p.prevX)*alpha - camera.X
+		y := p.prevY + (p.Y-p.prevY)*alpha - camera.Y
+
+		var img *ebiten.Image
+		if p.Spec.Image != "" && s.Images != nil {
+			img = s.Images(p.Spec.Image)
+		}
+		if img == nil {
+			vector.DrawFilledCircle(screen, float32(x), float32(y), float32(p.Spec.Radius), parseColor(p.Spec.Color), true)
+			continue
+		}
+
+		// Images point right; rotate them to face the way the projectile flies
+		w, h := float64(img.Bounds().Dx()), float64(img.Bounds().Dy())
+		opts := &ebiten.DrawImageOptions{}
+		opts.GeoM.Translate(-w/2, -h/2)
+		opts.GeoM.Rotate(math.Atan2(p.VY, p.VX))
+		opts.GeoM.Translate(x, y)
+		screen.DrawImage(img, opts)
+	}
+}
+
+// parseColor reads a "#rrggbb" colour, falling back to white
+func parseColor(hex string) color.RGBA {
+	v, err := strconv.ParseUint(strings.TrimPrefix(hex, "#"), 16, 32)
+	if err != nil || len(strings.TrimPrefix(hex, "#")) != 6 {
+		return color.RGBA{255, 255, 255, 255}
+	}
+	return color.RGBA{uint8(v >> 16), uint8(v >> 8), uint8(v), 255}
+}
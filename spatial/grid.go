@@ -0,0 +1,375 @@
+// Package spatial provides broadphase structures for finding entities by position
+package spatial
+
+import (
+	"math"
+	"sort"
+
+	"github.com/Nathene/bitbase/common"
+)
+
+// Grid is a uniform grid spatial hash. Each item is stored in every cell its
+// bounds overlap, so lookups only have to visit the cells covered by a query.
+type Grid struct {
+	cellSize float64
+	cells    map[cell][]*entry
+	entries  map[int]*entry
+
+	// stamp is bumped for every query so items spanning several cells are only reported once
+	stamp uint64
+
+	// Extent of every cell that has ever been occupied, used to bound searches
+	minCell, maxCell cell
+	hasExtent        bool
+}
+
+type cell struct {
+	x, y int
+}
+
+type entry struct {
+	id       int
+	bounds   common.Rect
+	min, max cell
+	stamp    uint64
+}
+
+// Hit describes where a ray first touches an item
+type Hit struct {
+	ID       int
+	Distance float64
+	X, Y     float64
+}
+
+// NewGrid creates a spatial hash with square cells of the given size.
+// A cell size of roughly twice the typical entity size works well.
+func NewGrid(cellSize float64) *Grid {
+	if cellSize <= 0 {
+		cellSize = 64
+	}
+	return &Grid{
+		cellSize: cellSize,
+		cells:    make(map[cell][]*entry),
+		entries:  make(map[int]*entry),
+	}
+}
+
+// Len returns the number of items in the grid
+func (g *Grid) Len() int {
+	return len(g.entries)
+}
+
+// CellSize returns the size of a grid cell
+func (g *Grid) CellSize() float64 {
+	return g.cellSize
+}
+
+// Bounds returns the bounds an item was last stored with
+func (g *Grid) Bounds(id int) (common.Rect, bool) {
+	if e, ok := g.entries[id]; ok {
+		return e.bounds, true
+	}
+	return common.Rect{}, false
+}
+
+// Insert adds an item, or updates it if the ID is already present
+func (g *Grid) Insert(id int, bounds common.Rect) {
+	if _, exists := g.entries[id]; exists {
+		g.Update(id, bounds)
+		return
+	}
+
+	e := &entry{id: id, bounds: bounds}
+	e.min, e.max = g.cellRange(bounds)
+	g.entries[id] = e
+	g.link(e)
+}
+
+// Update moves an item to new bounds. Items that stay within the same cells
+// are updated in place without touching the cell lists.
+func (g *Grid) Update(id int, bounds common.Rect) {
+	e, ok := g.entries[id]
+	if !ok {
+		g.Insert(id, bounds)
+		return
+	}
+
+	lo, hi := g.cellRange(bounds)
+	e.bounds = bounds
+	if lo == e.min && hi == e.max {
+		return
+	}
+
+	g.unlink(e)
+	e.min, e.max = lo, hi
+	g.link(e)
+}
+
+// Remove deletes an item from the grid
+func (g *Grid) Remove(id int) {
+	if e, ok := g.entries[id]; ok {
+		g.unlink(e)
+		delete(g.entries, id)
+	}
+}
+
+// Clear removes every item from the grid
+func (g *Grid) Clear() {
+	g.cells = make(map[cell][]*entry)
+	g.entries = make(map[int]*entry)
+	g.hasExtent = false
+}
+
+// QueryRect appends the IDs of all items overlapping r to out
+func (g *Grid) QueryRect(r common.Rect, out []int) []int {
+	g.visit(r, func(e *entry) {
+		if e.bounds.Intersects(r) {
+			out = append(out, e.id)
+		}
+	})
+	return out
+}
+
+// QueryRadius appends the IDs of all items within radius of (x, y) to out
+func (g *Grid) QueryRadius(x, y, radius float64, out []int) []int {
+	r := common.Rect{X: x - radius, Y: y - radius, W: radius * 2, H: radius * 2}
+	g.visit(r, func(e *entry) {
+		if e.bounds.DistanceTo(x, y) <= radius {
+			out = append(out, e.id)
+		}
+	})
+	return out
+}
+
+// Nearest returns up to n item IDs ordered by distance from (x, y).
+// filter may be nil; otherwise only IDs it accepts are considered.
+func (g *Grid) Nearest(x, y float64, n int, filter func(id int) bool) []int {
+	if n <= 0 || len(g.entries) == 0 {
+		return nil
+	}
+
+	maxRadius := g.farthestExtent(x, y)
+	radius := g.cellSize
+	var candidates []int
+
+	for {
+		candidates = g.QueryRadius(x, y, radius, candidates[:0])
+		if filter != nil {
+			kept := candidates[:0]
+			for _, id := range candidates {
+				if filter(id) {
+					kept = append(kept, id)
+				}
+			}
+			candidates = kept
+		}
+
+		// Anything outside the radius is further away than everything inside it,
+		// so once we have n candidates the closest n are among them
+		if len(candidates) >= n || radius >= maxRadius {
+			break
+		}
+		radius *= 2
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		di := g.entries[candidates[i]].bounds.DistanceTo(x, y)
+		dj := g.entries[candidates[j]].bounds.DistanceTo(x, y)
+		if di != dj {
+			return di < dj
+		}
+		return candidates[i] < candidates[j]
+	})
+	if len(candidates) > n {
+		candidates = candidates[:n]
+	}
+	return candidates
+}
+
+// Raycast finds the first item hit by a ray from (x, y) along (dirX, dirY)
+// within maxDist. filter may be nil; otherwise only IDs it accepts can be hit.
+func (g *Grid) Raycast(x, y, dirX, dirY, maxDist float64, filter func(id int) bool) (Hit, bool) {
+	length := math.Hypot(dirX, dirY)
+	if length == 0 || len(g.entries) == 0 {
+		return Hit{}, false
+	}
+	dirX /= length
+	dirY /= length
+
+	g.stamp++
+	best := Hit{Distance: math.Inf(1)}
+	found := false
+
+	// Walk the cells along the ray (Amanatides & Woo DDA)
+	cx, cy := g.cellCoord(x), g.cellCoord(y)
+	stepX, tMaxX, tDeltaX := g.dda(x, dirX, cx)
+	stepY, tMaxY, tDeltaY := g.dda(y, dirY, cy)
+
+	for t := 0.0; t <= maxDist; {
+		for _, e := range g.cells[cell{cx, cy}] {
+			if e.stamp == g.stamp {
+				continue
+			}
+			e.stamp = g.stamp
+			if filter != nil && !filter(e.id) {
+				continue
+			}
+			if d, ok := rayRect(x, y, dirX, dirY, e.bounds); ok && d <= maxDist && d < best.Distance {
+				best = Hit{ID: e.id, Distance: d, X: x + dirX*d, Y: y + dirY*d}
+				found = true
+			}
+		}
+
+		// A hit inside the current cell can't be beaten by anything further along
+		next := math.Min(tMaxX, tMaxY)
+		if found && best.Distance <= next {
+			break
+		}
+		if g.leftExtent(cx, cy, stepX, stepY) {
+			break
+		}
+
+		if tMaxX < tMaxY {
+			cx += stepX
+			t = tMaxX
+			tMaxX += tDeltaX
+		} else {
+			cy += stepY
+			t = tMaxY
+			tMaxY += tDeltaY
+		}
+	}
+
+	return best, found
+}
+
+// visit calls fn once for every item stored in a cell overlapped by r
+func (g *Grid) visit(r common.Rect, fn func(e *entry)) {
+	g.stamp++
+	lo, hi := g.cellRange(r)
+	if g.hasExtent {
+		lo.x, lo.y = max(lo.x, g.minCell.x), max(lo.y, g.minCell.y)
+		hi.x, hi.y = min(hi.x, g.maxCell.x), min(hi.y, g.maxCell.y)
+	}
+
+	for cy := lo.y; cy <= hi.y; cy++ {
+		for cx := lo.x; cx <= hi.x; cx++ {
+			for _, e := range g.cells[cell{cx, cy}] {
+				if e.stamp == g.stamp {
+					continue
+				}
+				e.stamp = g.stamp
+				fn(e)
+			}
+		}
+	}
+}
+
+func (g *Grid) link(e *entry) {
+	for cy := e.min.y; cy <= e.max.y; cy++ {
+		for cx := e.min.x; cx <= e.max.x; cx++ {
+			c := cell{cx, cy}
+			g.cells[c] = append(g.cells[c], e)
+		}
+	}
+
+	if !g.hasExtent {
+		g.minCell, g.maxCell = e.min, e.max
+		g.hasExtent = true
+		return
+	}
+	g.minCell.x, g.minCell.y = min(g.minCell.x, e.min.x), min(g.minCell.y, e.min.y)
+	g.maxCell.x, g.maxCell.y = max(g.maxCell.x, e.max.x), max(g.maxCell.y, e.max.y)
+}
+
+func (g *Grid) unlink(e *entry) {
+	for cy := e.min.y; cy <= e.max.y; cy++ {
+		for cx := e.min.x; cx <= e.max.x; cx++ {
+			c := cell{cx, cy}
+			list := g.cells[c]
+			for i, other := range list {
+				if other == e {
+					list[i] = list[len(list)-1]
+					list[len(list)-1] = nil
+					list = list[:len(list)-1]
+					break
+				}
+			}
+			if len(list) == 0 {
+				delete(g.cells, c)
+			} else {
+				g.cells[c] = list
+			}
+		}
+	}
+}
+
+func (g *Grid) cellCoord(v float64) int {
+	return int(math.Floor(v / g.cellSize))
+}
+
+func (g *Grid) cellRange(r common.Rect) (cell, cell) {
+	return cell{g.cellCoord(r.X), g.cellCoord(r.Y)}, cell{g.cellCoord(r.MaxX()), g.cellCoord(r.MaxY())}
+}
+
+// dda returns the step direction, distance to the first cell boundary and
+// distance between boundaries along one axis of a ray
+func (g *Grid) dda(origin, dir float64, c int) (int, float64, float64) {
+	switch {
+	case dir > 0:
+		boundary := float64(c+1) * g.cellSize
+		return 1, (boundary - origin) / dir, g.cellSize / dir
+	case dir < 0:
+		boundary := float64(c) * g.cellSize
+		return -1, (boundary - origin) / dir, -g.cellSize / dir
+	default:
+		return 0, math.Inf(1), math.Inf(1)
+	}
+}
+
+// leftExtent reports whether a ray in cell (cx, cy) can never reach an occupied
+// cell again: on some axis it is already past the occupied range and moving away
+// from it, or not moving at all. A ray in the last column or row of the range
+// may still run along it, so that doesn't count as leaving.
+func (g *Grid) leftExtent(cx, cy, stepX, stepY int) bool {
+	return (stepX >= 0 && cx > g.maxCell.x) || (stepX <= 0 && cx < g.minCell.x) ||
+		(stepY >= 0 && cy > g.maxCell.y) || (stepY <= 0 && cy < g.minCell.y)
+}
+
+// farthestExtent returns the distance from a point to the far corner of the occupied area
+func (g *Grid) farthestExtent(x, y float64) float64 {
+	minX, minY := float64(g.minCell.x)*g.cellSize, float64(g.minCell.y)*g.cellSize
+	maxX, maxY := float64(g.maxCell.x+1)*g.cellSize, float64(g.maxCell.y+1)*g.cellSize
+	dx := math.Max(math.Abs(x-minX), math.Abs(x-maxX))
+	dy := math.Max(math.Abs(y-minY), math.Abs(y-maxY))
+	return math.Hypot(dx, dy)
+}
+
+// rayRect returns the distance along a normalised ray to the first point inside r
+func rayRect(ox, oy, dx, dy float64, r common.Rect) (float64, bool) {
+	tMin, tMax := 0.0, math.Inf(1)
+
+	axes := [2][4]float64{
+		{ox, dx, r.X, r.MaxX()},
+		{oy, dy, r.Y, r.MaxY()},
+	}
+	for _, a := range axes {
+		origin, dir, lo, hi := a[0], a[1], a[2], a[3]
+		if dir == 0 {
+			if origin < lo || origin > hi {
+				return 0, false
+			}
+			continue
+		}
+		t1, t2 := (lo-origin)/dir, (hi-origin)/dir
+		if t1 > t2 {
+			t1, t2 = t2, t1
+		}
+		tMin = math.Max(tMin, t1)
+		tMax = math.Min(tMax, t2)
+		if tMin > tMax {
+			return 0, false
+		}
+	}
+	return tMin, true
+}
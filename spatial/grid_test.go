@@ -0,0 +1,275 @@
+package spatial
+
+import (
+	"math"
+	"math/rand"
+	"slices"
+	"sort"
+	"testing"
+
+	"github.com/Nathene/bitbase/common"
+)
+
+const (
+	benchItems = 10000
+	worldSize  = 4000.0 // Side of the square the random items are scattered over
+)
+
+// randomItems returns n rects of assorted sizes scattered over the world,
+// a few of them hanging off its edges into negative coordinates
+func randomItems(r *rand.Rand, n int) []common.Rect {
+	items := make([]common.Rect, n)
+	for i := range items {
+		items[i] = common.Rect{
+			X: r.Float64()*(worldSize+200) - 100,
+			Y: r.Float64()*(worldSize+200) - 100,
+			W: 4 + r.Float64()*60,
+			H: 4 + r.Float64()*60,
+		}
+	}
+	return items
+}
+
+func newTestGrid(items []common.Rect) *Grid {
+	g := NewGrid(64)
+	for id, b := range items {
+		g.Insert(id, b)
+	}
+	return g
+}
+
+func sorted(ids []int) []int {
+	ids = slices.Clone(ids)
+	sort.Ints(ids)
+	return ids
+}
+
+func bruteRect(items []common.Rect, r common.Rect) []int {
+	var out []int
+	for id, b := range items {
+		if b.Intersects(r) {
+			out = append(out, id)
+		}
+	}
+	return out
+}
+
+func bruteRadius(items []common.Rect, x, y, radius float64) []int {
+	var out []int
+	for id, b := range items {
+		if b.DistanceTo(x, y) <= radius {
+			out = append(out, id)
+		}
+	}
+	return out
+}
+
+func bruteNearest(items []common.Rect, x, y float64, n int, filter func(int) bool) []int {
+	var ids []int
+	for id := range items {
+		if filter == nil || filter(id) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		di, dj := items[ids[i]].DistanceTo(x, y), items[ids[j]].DistanceTo(x, y)
+		if di != dj {
+			return di < dj
+		}
+		return ids[i] < ids[j]
+	})
+	return ids[:min(n, len(ids))]
+}
+
+func bruteRaycast(items []common.Rect, x, y, dirX, dirY, maxDist float64) (Hit, bool) {
+	length := math.Hypot(dirX, dirY)
+	dirX, dirY = dirX/length, dirY/length
+	best := Hit{Distance: math.Inf(1)}
+	found := false
+	for id, b := range items {
+		if d, ok := rayRect(x, y, dirX, dirY, b); ok && d <= maxDist && d < best.Distance {
+			best = Hit{ID: id, Distance: d, X: x + dirX*d, Y: y + dirY*d}
+			found = true
+		}
+	}
+	return best, found
+}
+
+func TestGridQueriesMatchBruteForce(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	items := randomItems(r, 2000)
+	g := newTestGrid(items)
+
+	for i := 0; i < 200; i++ {
+		q := common.Rect{X: r.Float64()*worldSize - 200, Y: r.Float64()*worldSize - 200, W: r.Float64() * 400, H: r.Float64() * 400}
+		if got, want := sorted(g.QueryRect(q, nil)), bruteRect(items, q); !slices.Equal(got, want) {
+			t.Fatalf("QueryRect(%+v) = %v, want %v", q, got, want)
+		}
+
+		x, y, radius := r.Float64()*worldSize, r.Float64()*worldSize, r.Float64()*300
+		if got, want := sorted(g.QueryRadius(x, y, radius, nil)), bruteRadius(items, x, y, radius); !slices.Equal(got, want) {
+			t.Fatalf("QueryRadius(%v, %v, %v) = %v, want %v", x, y, radius, got, want)
+		}
+
+		n := 1 + r.Intn(20)
+		if got, want := g.Nearest(x, y, n, nil), bruteNearest(items, x, y, n, nil); !slices.Equal(got, want) {
+			t.Fatalf("Nearest(%v, %v, %d) = %v, want %v", x, y, n, got, want)
+		}
+		odd := func(id int) bool { return id%2 == 1 }
+		if got, want := g.Nearest(x, y, n, odd), bruteNearest(items, x, y, n, odd); !slices.Equal(got, want) {
+			t.Fatalf("Nearest(%v, %v, %d, odd) = %v, want %v", x, y, n, got, want)
+		}
+
+		angle := r.Float64() * 2 * math.Pi
+		dirX, dirY := math.Cos(angle), math.Sin(angle)
+		maxDist := r.Float64() * 2000
+		got, gotOK := g.Raycast(x, y, dirX, dirY, maxDist, nil)
+		want, wantOK := bruteRaycast(items, x, y, dirX, dirY, maxDist)
+		if gotOK != wantOK || (wantOK && got.Distance != want.Distance) {
+			t.Fatalf("Raycast(%v, %v, %v, %v, %v) = %+v %v, want %+v %v", x, y, dirX, dirY, maxDist, got, gotOK, want, wantOK)
+		}
+	}
+}
+
+func TestGridRaycastAxisAligned(t *testing.T) {
+	g := NewGrid(32)
+	g.Insert(1, common.Rect{X: 100, Y: 0, W: 10, H: 10})
+	g.Insert(2, common.Rect{X: 200, Y: 0, W: 10, H: 10})
+	g.Insert(3, common.Rect{X: 0, Y: -300, W: 10, H: 10})
+
+	tests := []struct {
+		x, y, dirX, dirY, maxDist float64
+		wantID                    int
+		wantDist                  float64
+		wantOK                    bool
+	}{
+		{0, 5, 1, 0, 1000, 1, 100, true},
+		{150, 5, 1, 0, 1000, 2, 50, true},
+		{150, 5, 1, 0, 40, 0, 0, false},
+		{5, 0, 0, -1, 1000, 3, 290, true},
+		{5, 20, 0, 1, 1000, 0, 0, false},
+		{105, 5, -1, 0, 1000, 1, 0, true}, // Starting inside
+	}
+	for _, tt := range tests {
+		hit, ok := g.Raycast(tt.x, tt.y, tt.dirX, tt.dirY, tt.maxDist, nil)
+		if ok != tt.wantOK || (ok && (hit.ID != tt.wantID || hit.Distance != tt.wantDist)) {
+			t.Errorf("Raycast from (%v, %v) along (%v, %v) = %+v %v, want id %d at %v %v",
+				tt.x, tt.y, tt.dirX, tt.dirY, hit, ok, tt.wantID, tt.wantDist, tt.wantOK)
+		}
+	}
+}
+
+// TestGridRaycastSparse casts nearly axis-aligned rays through a grid with few
+// items, where the ray spends a long way in the edge column or row of the
+// occupied area before reaching anything
+func TestGridRaycastSparse(t *testing.T) {
+	items := []common.Rect{{X: 10, Y: 200, W: 20, H: 20}, {X: 0, Y: 0, W: 1, H: 1}}
+	g := newTestGrid(items)
+	if hit, ok := g.Raycast(15, 10, 0.05, 1, 1000, nil); !ok || hit.ID != 0 {
+		t.Fatalf("Raycast down the edge column = %+v %v, want item 0", hit, ok)
+	}
+
+	r := rand.New(rand.NewSource(3))
+	for i := 0; i < 500; i++ {
+		items := randomItems(r, 3)
+		g := newTestGrid(items)
+		x, y := r.Float64()*worldSize, r.Float64()*worldSize
+		dirX, dirY := r.Float64()*0.2-0.1, 1.0
+		if r.Intn(2) == 0 {
+			dirX, dirY = dirY, dirX
+		}
+		if r.Intn(2) == 0 {
+			dirX, dirY = -dirX, -dirY
+		}
+		got, gotOK := g.Raycast(x, y, dirX, dirY, 5000, nil)
+		want, wantOK := bruteRaycast(items, x, y, dirX, dirY, 5000)
+		if gotOK != wantOK || (wantOK && got.Distance != want.Distance) {
+			t.Fatalf("Raycast(%v, %v, %v, %v) over %v = %+v %v, want %+v %v", x, y, dirX, dirY, items, got, gotOK, want, wantOK)
+		}
+	}
+}
+
+func TestGridUpdateAndRemove(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	items := randomItems(r, 500)
+	g := newTestGrid(items)
+
+	// Move everything, some a little and some right across the world
+	for id := range items {
+		if id%3 == 0 {
+			items[id].X += r.Float64()*10 - 5
+		} else {
+			items[id].X, items[id].Y = r.Float64()*worldSize, r.Float64()*worldSize
+		}
+		g.Update(id, items[id])
+	}
+	for id := 0; id < len(items); id += 2 {
+		g.Remove(id)
+		items[id] = common.Rect{X: math.Inf(1), Y: math.Inf(1)} // Never matches
+	}
+	if want := len(items) / 2; g.Len() != want {
+		t.Fatalf("Len() = %d after removing half, want %d", g.Len(), want)
+	}
+
+	for i := 0; i < 100; i++ {
+		q := common.Rect{X: r.Float64() * worldSize, Y: r.Float64() * worldSize, W: 300, H: 300}
+		if got, want := sorted(g.QueryRect(q, nil)), bruteRect(items, q); !slices.Equal(got, want) {
+			t.Fatalf("QueryRect(%+v) after moves = %v, want %v", q, got, want)
+		}
+	}
+}
+
+func benchGrid(b *testing.B) (*Grid, []common.Rect) {
+	items := randomItems(rand.New(rand.NewSource(1)), benchItems)
+	g := newTestGrid(items)
+	b.ReportAllocs()
+	b.ResetTimer()
+	return g, items
+}
+
+func BenchmarkQueryRect(b *testing.B) {
+	g, _ := benchGrid(b)
+	var out []int
+	for i := 0; i < b.N; i++ {
+		x := float64(i%64) * worldSize / 64
+		out = g.QueryRect(common.Rect{X: x, Y: x, W: 320, H: 240}, out[:0])
+	}
+}
+
+func BenchmarkQueryRadius(b *testing.B) {
+	g, _ := benchGrid(b)
+	var out []int
+	for i := 0; i < b.N; i++ {
+		x := float64(i%64) * worldSize / 64
+		out = g.QueryRadius(x, x, 200, out[:0])
+	}
+}
+
+func BenchmarkNearest(b *testing.B) {
+	g, _ := benchGrid(b)
+	for i := 0; i < b.N; i++ {
+		x := float64(i%64) * worldSize / 64
+		g.Nearest(x, x, 8, nil)
+	}
+}
+
+func BenchmarkRaycast(b *testing.B) {
+	g, _ := benchGrid(b)
+	for i := 0; i < b.N; i++ {
+		angle := float64(i%360) * math.Pi / 180
+		g.Raycast(worldSize/2, worldSize/2, math.Cos(angle), math.Sin(angle), 1000, nil)
+	}
+}
+
+// BenchmarkUpdate moves every one of the items a little each iteration, like
+// a frame of entities walking about
+func BenchmarkUpdate(b *testing.B) {
+	g, items := benchGrid(b)
+	for i := 0; i < b.N; i++ {
+		step := float64(i%2*2-1) * 3
+		for id := range items {
+			items[id].X += step
+			g.Update(id, items[id])
+		}
+	}
+}
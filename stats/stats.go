@@ -0,0 +1,196 @@
+// Package stats combines base values with modifiers from equipment and buffs
+// into derived character stats. Like item, it has no dependency on ebiten.
+package stats
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Stat names a numeric attribute of a character
+type Stat string
+
+const (
+	MoveSpeed   Stat = "moveSpeed"   // Pixels per second
+	MaxHealth   Stat = "maxHealth"   // Hit points
+	Attack      Stat = "attack"      // Damage added to every hit
+	Defense     Stat = "defense"     // Damage removed from every hit taken
+	AttackSpeed Stat = "attackSpeed" // Attacks per second
+)
+
+// Op is how a modifier combines with a stat's base value
+type Op int
+
+const (
+	Add      Op = iota // Added to the base value
+	Percent            // Summed with other percentages, then scales the total: 0.1 is +10%
+	Multiply           // Multiplies the final value; applied last
+)
+
+var opNames = [...]string{"add", "percent", "multiply"}
+
+func (op Op) String() string {
+	if op < 0 || int(op) >= len(opNames) {
+		return fmt.Sprintf("Op(%d)", int(op))
+	}
+	return opNames[op]
+}
+
+// UnmarshalText lets modifier ops be written by name in JSON
+func (op *Op) UnmarshalText(text []byte) error {
+	for i, name := range opNames {
+		if string(text) == name {
+			*op = Op(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown modifier op %q", text)
+}
+
+// MarshalText writes the op's name
+func (op Op) MarshalText() ([]byte, error) {
+	return []byte(op.String()), nil
+}
+
+// Modifier changes one stat, such as +5 attack from a sword
+type Modifier struct {
+	Stat  Stat    `json:"stat"`
+	Op    Op      `json:"op"`
+	Value float64 `json:"value"`
+}
+
+// source groups the modifiers that were added together, so they can be removed together
+type source struct {
+	name      string
+	modifiers []Modifier
+}
+
+// Stats combines base values with modifiers from equipment, buffs and so on into derived stats.
+// Derived values are cached until something changes.
+type Stats struct {
+	Base map[Stat]float64 `json:"base"`
+
+	sources []source
+	derived map[Stat]float64
+}
+
+// New creates stats with the given base values
+func New(base map[Stat]float64) *Stats {
+	s := &Stats{Base: make(map[Stat]float64, len(base))}
+	for stat, v := range base {
+		s.Base[stat] = v
+	}
+	return s
+}
+
+func (s *Stats) ComponentName() string { return "stats" }
+
+// Get returns the derived value of a stat:
+// (base + adds) * (1 + percents) * multipliers
+func (s *Stats) Get(stat Stat) float64 {
+	if v, ok := s.derived[stat]; ok {
+		return v
+	}
+
+	add, percent, multiply := 0.0, 0.0, 1.0
+	for _, src := range s.sources {
+		for _, m := range src.modifiers {
+			if m.Stat != stat {
+				continue
+			}
+			switch m.Op {
+			case Add:
+				add += m.Value
+			case Percent:
+				percent += m.Value
+			case Multiply:
+				multiply *= m.Value
+			}
+		}
+	}
+	v := (s.Base[stat] + add) * (1 + percent) * multiply
+
+	if s.derived == nil {
+		s.derived = make(map[Stat]float64)
+	}
+	s.derived[stat] = v
+	return v
+}
+
+// BaseValue returns a stat's value before any modifiers
+func (s *Stats) BaseValue(stat Stat) float64 {
+	return s.Base[stat]
+}
+
+// SetBase changes a stat's base value
+func (s *Stats) SetBase(stat Stat, v float64) {
+	if s.Base == nil {
+		s.Base = make(map[Stat]float64)
+	}
+	s.Base[stat] = v
+	s.invalidate()
+}
+
+// SetModifiers replaces every modifier from a source, such as "equipment:weapon" or "buff:haste".
+// Passing no modifiers removes the source.
+func (s *Stats) SetModifiers(name string, modifiers ...Modifier) {
+	s.RemoveSource(name)
+	if len(modifiers) > 0 {
+		s.sources = append(s.sources, source{name: name, modifiers: append([]Modifier(nil), modifiers...)})
+	}
+	s.invalidate()
+}
+
+// RemoveSource removes every modifier added under a source name
+func (s *Stats) RemoveSource(name string) {
+	for i, src := range s.sources {
+		if src.name == name {
+			s.sources = append(s.sources[:i], s.sources[i+1:]...)
+			s.invalidate()
+			return
+		}
+	}
+}
+
+// Modifiers returns the modifiers added under a source name
+func (s *Stats) Modifiers(name string) []Modifier {
+	for _, src := range s.sources {
+		if src.name == name {
+			return src.modifiers
+		}
+	}
+	return nil
+}
+
+// Sources returns the names of every modifier source in sorted order
+func (s *Stats) Sources() []string {
+	names := make([]string, len(s.sources))
+	for i, src := range s.sources {
+		names[i] = src.name
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Stats returns every stat that has a base value or a modifier, in sorted order
+func (s *Stats) Stats() []Stat {
+	seen := make(map[Stat]bool)
+	for stat := range s.Base {
+		seen[stat] = true
+	}
+	for _, src := range s.sources {
+		for _, m := range src.modifiers {
+			seen[m.Stat] = true
+		}
+	}
+	list := make([]Stat, 0, len(seen))
+	for stat := range seen {
+		list = append(list, stat)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i] < list[j] })
+	return list
+}
+
+func (s *Stats) invalidate() {
+	clear(s.derived)
+}
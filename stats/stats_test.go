@@ -0,0 +1,126 @@
+package stats
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+)
+
+func TestGet(t *testing.T) {
+	tests := []struct {
+		name string
+		mods []Modifier
+		want float64
+	}{
+		{name: "base only", want: 10},
+		{name: "adds", mods: []Modifier{{Attack, Add, 5}, {Attack, Add, 3}}, want: 18},
+		{name: "percents sum", mods: []Modifier{{Attack, Percent, 0.25}, {Attack, Percent, 0.25}}, want: 15},
+		{name: "negative percent", mods: []Modifier{{Attack, Percent, -0.5}}, want: 5},
+		{name: "adds before percents", mods: []Modifier{{Attack, Percent, 0.5}, {Attack, Add, 10}}, want: 30},
+		{name: "multipliers compound", mods: []Modifier{{Attack, Multiply, 2}, {Attack, Multiply, 1.5}}, want: 30},
+		{name: "multipliers last", mods: []Modifier{{Attack, Multiply, 2}, {Attack, Percent, 0.5}, {Attack, Add, 10}}, want: 60},
+		{name: "other stats ignored", mods: []Modifier{{Defense, Add, 5}, {MoveSpeed, Multiply, 2}}, want: 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The same modifiers give the same value whichever order their
+			// sources were added in, or when they all share one source
+			reversed := slices.Clone(tt.mods)
+			slices.Reverse(reversed)
+			for _, order := range [][]Modifier{tt.mods, reversed} {
+				s := New(map[Stat]float64{Attack: 10})
+				for i, m := range order {
+					s.SetModifiers(string(rune('a'+i)), m)
+				}
+				if got := s.Get(Attack); got != tt.want {
+					t.Errorf("separate sources %v: got %v, want %v", order, got, tt.want)
+				}
+
+				s = New(map[Stat]float64{Attack: 10})
+				s.SetModifiers("all", order...)
+				if got := s.Get(Attack); got != tt.want {
+					t.Errorf("one source %v: got %v, want %v", order, got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestSources(t *testing.T) {
+	s := New(map[Stat]float64{Attack: 10, Defense: 2})
+	if got := s.Get(Attack); got != 10 {
+		t.Fatalf("attack %v, want 10", got)
+	}
+
+	// Changes show up straight away even though Get caches
+	s.SetModifiers("equipment:weapon", Modifier{Attack, Add, 5})
+	s.SetModifiers("buff:rage", Modifier{Attack, Multiply, 2}, Modifier{Defense, Add, -2})
+	if got := s.Get(Attack); got != 30 {
+		t.Errorf("attack %v with a sword and rage, want 30", got)
+	}
+	s.SetModifiers("equipment:weapon", Modifier{Attack, Add, 8})
+	if got := s.Get(Attack); got != 36 {
+		t.Errorf("attack %v after swapping swords, want 36", got)
+	}
+	s.SetBase(Attack, 12)
+	if got := s.Get(Attack); got != 40 {
+		t.Errorf("attack %v after raising the base, want 40", got)
+	}
+	if got := s.BaseValue(Attack); got != 12 {
+		t.Errorf("base attack %v, want 12", got)
+	}
+
+	if got, want := s.Sources(), []string{"buff:rage", "equipment:weapon"}; !slices.Equal(got, want) {
+		t.Errorf("sources %v, want %v", got, want)
+	}
+	if got, want := s.Stats(), []Stat{Attack, Defense}; !slices.Equal(got, want) {
+		t.Errorf("stats %v, want %v", got, want)
+	}
+
+	// A source keeps its own copy of the modifiers it was given
+	mods := []Modifier{{MoveSpeed, Add, 10}}
+	s.SetModifiers("buff:haste", mods...)
+	mods[0].Value = 100
+	if got := s.Modifiers("buff:haste"); !slices.Equal(got, []Modifier{{MoveSpeed, Add, 10}}) {
+		t.Errorf("haste modifiers %v after changing the caller's slice", got)
+	}
+
+	s.RemoveSource("buff:rage")
+	if got := s.Get(Attack); got != 20 {
+		t.Errorf("attack %v after rage wore off, want 20", got)
+	}
+	if got := s.Get(Defense); got != 2 {
+		t.Errorf("defense %v after rage wore off, want 2", got)
+	}
+	s.SetModifiers("equipment:weapon")
+	s.RemoveSource("missing")
+	if got := s.Get(Attack); got != 12 {
+		t.Errorf("attack %v after setting no modifiers, want 12", got)
+	}
+	if got, want := s.Sources(), []string{"buff:haste"}; !slices.Equal(got, want) {
+		t.Errorf("sources %v, want %v", got, want)
+	}
+}
+
+func TestModifierJSON(t *testing.T) {
+	var mods []Modifier
+	data := `[{"stat":"attack","op":"add","value":5},{"stat":"moveSpeed","op":"percent","value":0.1},{"stat":"defense","op":"multiply","value":2}]`
+	if err := json.Unmarshal([]byte(data), &mods); err != nil {
+		t.Fatal(err)
+	}
+	want := []Modifier{{Attack, Add, 5}, {MoveSpeed, Percent, 0.1}, {Defense, Multiply, 2}}
+	if !slices.Equal(mods, want) {
+		t.Errorf("read %v, want %v", mods, want)
+	}
+	out, err := json.Marshal(mods)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != data {
+		t.Errorf("wrote %s, want %s", out, data)
+	}
+
+	if err := json.Unmarshal([]byte(`{"stat":"attack","op":"divide","value":2}`), &Modifier{}); err == nil {
+		t.Error("read an unknown op without an error")
+	}
+}
@@ -0,0 +1,335 @@
+package tilemap
+
+import "sort"
+
+// AutotileKind is how an autotile set picks each tile from its surroundings
+type AutotileKind int
+
+const (
+	Edge4      AutotileKind = iota // 16 tiles, from which of the four edge neighbours are the same terrain
+	Blob47                         // 47 tiles, from all eight neighbours; a corner only counts between two matching edges
+	WangCorner                     // 16 tiles, from which of the tile's four corners are inside the terrain
+)
+
+// Bits of an autotile mask, clockwise from the top like Tiled's wang IDs
+const (
+	MaskN uint8 = 1 << iota
+	MaskNE
+	MaskE
+	MaskSE
+	MaskS
+	MaskSW
+	MaskW
+	MaskNW
+
+	edgeBits   = MaskN | MaskE | MaskS | MaskW
+	cornerBits = MaskNE | MaskSE | MaskSW | MaskNW
+)
+
+// maskDirs is the neighbour each mask bit looks at, in bit order
+var maskDirs = [8][2]int{{0, -1}, {1, -1}, {1, 0}, {1, 1}, {0, 1}, {-1, 1}, {-1, 0}, {-1, -1}}
+
+// cornerVertex is where each corner bit's vertex is, relative to the tile's top-left
+var cornerVertex = map[uint8][2]int{MaskNE: {1, 0}, MaskSE: {1, 1}, MaskSW: {0, 1}, MaskNW: {0, 0}}
+
+// Grid is a tile layer that can be autotiled, such as a map Layer or World.Grid
+type Grid interface {
+	InBounds(x, y int) bool
+	At(x, y int) GID
+	Set(x, y int, gid GID)
+}
+
+// Autotile picks terrain tiles so their edges and corners join up with the
+// cells around them. Cells outside the grid count as the same terrain, so
+// terrain running off the map doesn't grow a border.
+type Autotile struct {
+	Name string
+	Kind AutotileKind
+	Fill GID // Put down by Erase where the terrain is removed; 0 clears the cell
+
+	tiles map[uint8][]GID // Candidates for each mask
+	masks map[GID]uint8   // The mask of every tile in the set
+	busy  bool            // Set while the autotile writes, so Watch ignores its own changes
+}
+
+// NewAutotile returns an empty autotile set; Add its tiles
+func NewAutotile(name string, kind AutotileKind) *Autotile {
+	return &Autotile{
+		Name:  name,
+		Kind:  kind,
+		tiles: make(map[uint8][]GID),
+		masks: make(map[GID]uint8),
+	}
+}
+
+// Add makes gid the tile for a mask. Adding several tiles for one mask makes
+// variants, picked between by position.
+func (a *Autotile) Add(mask uint8, gid GID) {
+	mask = a.normalize(mask)
+	a.tiles[mask] = append(a.tiles[mask], gid.ID())
+	a.masks[gid.ID()] = mask
+}
+
+// Has reports whether a tile belongs to the set
+func (a *Autotile) Has(gid GID) bool {
+	_, ok := a.masks[gid.ID()]
+	return ok
+}
+
+// Count returns how many distinct masks the set has tiles for
+func (a *Autotile) Count() int {
+	return len(a.tiles)
+}
+
+// normalize drops the bits the set's kind ignores
+func (a *Autotile) normalize(mask uint8) uint8 {
+	switch a.Kind {
+	case Edge4:
+		return mask & edgeBits
+	case WangCorner:
+		return mask & cornerBits
+	}
+	for i := 1; i < 8; i += 2 {
+		before, after := uint8(1)<<(i-1), uint8(1)<<((i+1)%8)
+		if mask&before == 0 || mask&after == 0 {
+			mask &^= 1 << i
+		}
+	}
+	return mask
+}
+
+// Tile returns the tile for a mask at (x, y). If the set has no tile for the
+// mask it falls back to the fully surrounded one, and returns 0 if there's
+// none of those either.
+func (a *Autotile) Tile(mask uint8, x, y int) GID {
+	c := a.tiles[a.normalize(mask)]
+	if len(c) == 0 {
+		c = a.tiles[a.normalize(0xff)]
+	}
+	if len(c) == 0 {
+		return 0
+	}
+	return c[cellHash(x, y)%uint32(len(c))]
+}
+
+// Mask works out the mask of the cell at (x, y) from its neighbours. Corner
+// sets are painted corner by corner rather than worked out, so for them it is
+// the mask of the tile already there.
+func (a *Autotile) Mask(g Grid, x, y int) uint8 {
+	if a.Kind == WangCorner {
+		return a.masks[g.At(x, y).ID()]
+	}
+	var mask uint8
+	for i, d := range maskDirs {
+		nx, ny := x+d[0], y+d[1]
+		if !g.InBounds(nx, ny) || a.Has(g.At(nx, ny)) {
+			mask |= 1 << i
+		}
+	}
+	return a.normalize(mask)
+}
+
+// Refresh re-picks the tile at (x, y) from its neighbours, if it is one of the set's
+func (a *Autotile) Refresh(g Grid, x, y int) {
+	if a.Kind == WangCorner || !g.InBounds(x, y) || !a.Has(g.At(x, y)) {
+		return
+	}
+	a.set(g, x, y, a.Tile(a.Mask(g, x, y), x, y))
+}
+
+// Paint puts the terrain down at (x, y) and fixes up the cells around it
+func (a *Autotile) Paint(g Grid, x, y int) {
+	if !g.InBounds(x, y) {
+		return
+	}
+	if a.Kind == WangCorner {
+		a.paintCorners(g, x, y, true, false)
+		return
+	}
+	a.set(g, x, y, a.Tile(0xff, x, y))
+	a.refreshAround(g, x, y)
+}
+
+// Erase takes the terrain away from (x, y), putting Fill down, and fixes up
+// the cells around it
+func (a *Autotile) Erase(g Grid, x, y int) {
+	if !g.InBounds(x, y) {
+		return
+	}
+	if a.Kind == WangCorner {
+		a.paintCorners(g, x, y, false, false)
+		return
+	}
+	if a.Has(g.At(x, y)) {
+		a.set(g, x, y, a.Fill)
+	}
+	a.refreshAround(g, x, y)
+}
+
+// Apply re-picks every tile of the set in a rectangle of cells, for maps
+// laid out with any tile of the set standing for the terrain, such as
+// generated ones. Corner sets paint the cells whose tiles have all four
+// corners in.
+func (a *Autotile) Apply(g Grid, x, y, w, h int) {
+	if a.Kind != WangCorner {
+		for cy := y; cy < y+h; cy++ {
+			for cx := x; cx < x+w; cx++ {
+				a.Refresh(g, cx, cy)
+			}
+		}
+		return
+	}
+	var full [][2]int
+	for cy := y; cy < y+h; cy++ {
+		for cx := x; cx < x+w; cx++ {
+			if g.InBounds(cx, cy) && a.Has(g.At(cx, cy)) && a.Mask(g, cx, cy) == cornerBits {
+				full = append(full, [2]int{cx, cy})
+			}
+		}
+	}
+	for _, c := range full {
+		a.paintCorners(g, c[0], c[1], true, false)
+	}
+}
+
+// Watch keeps one layer of a world autotiled as it changes at runtime.
+// Setting a cell to any tile of the set paints the terrain there, and
+// replacing one of the set's tiles fixes up the neighbours it leaves behind.
+func (a *Autotile) Watch(w *World, layer int) {
+	g := w.Grid(layer)
+	w.OnSet(func(l, x, y int, old, gid GID) {
+		if a.busy || l != layer {
+			return
+		}
+		switch {
+		case a.Has(gid) && (a.Kind != WangCorner || a.masks[gid.ID()] == cornerBits):
+			a.Paint(g, x, y)
+		case a.Has(old) && !a.Has(gid):
+			if a.Kind == WangCorner {
+				a.paintCorners(g, x, y, false, true)
+			} else {
+				a.refreshAround(g, x, y)
+			}
+		}
+	})
+}
+
+// refreshAround re-picks the tiles in the 3x3 block around (x, y)
+func (a *Autotile) refreshAround(g Grid, x, y int) {
+	for dy := -1; dy <= 1; dy++ {
+		for dx := -1; dx <= 1; dx++ {
+			a.Refresh(g, x+dx, y+dy)
+		}
+	}
+}
+
+// paintCorners turns the four vertices around (x, y) on or off in every tile
+// that shares them. A tile left with no corners in becomes Fill. keepCentre
+// leaves the tile at (x, y) itself alone.
+func (a *Autotile) paintCorners(g Grid, x, y int, on, keepCentre bool) {
+	for dy := -1; dy <= 1; dy++ {
+		for dx := -1; dx <= 1; dx++ {
+			cx, cy := x+dx, y+dy
+			if !g.InBounds(cx, cy) || (keepCentre && dx == 0 && dy == 0) {
+				continue
+			}
+			var bits uint8
+			for bit, v := range cornerVertex {
+				vx, vy := cx+v[0], cy+v[1]
+				if vx >= x && vx <= x+1 && vy >= y && vy <= y+1 {
+					bits |= bit
+				}
+			}
+			cur := g.At(cx, cy)
+			mask, member := a.masks[cur.ID()]
+			if on {
+				mask |= bits
+			} else {
+				mask &^= bits
+			}
+			switch {
+			case mask != 0:
+				a.set(g, cx, cy, a.Tile(mask, cx, cy))
+			case member:
+				a.set(g, cx, cy, a.Fill)
+			}
+		}
+	}
+}
+
+// set writes a cell if it would change, without setting off Watch
+func (a *Autotile) set(g Grid, x, y int, gid GID) {
+	if g.At(x, y) == gid {
+		return
+	}
+	a.busy = true
+	g.Set(x, y, gid)
+	a.busy = false
+}
+
+// Autotile builds an autotile set from the wangset terrain called name in any
+// of the map's tilesets, or returns nil if there isn't one. Corner wangsets
+// make WangCorner sets, edge ones Edge4 and mixed ones Blob47. A tile is in the
+// set if any of its wang IDs is the terrain; in a wangset with only one terrain
+// the tile with none is in too, as the isolated piece. In a wangset of two
+// terrains, Fill is a tile that is all the other one.
+func (m *Map) Autotile(name string) *Autotile {
+	for _, ts := range m.Tilesets {
+		for _, ws := range ts.Wangsets {
+			for i, c := range ws.Colors {
+				if c == name {
+					return ws.autotile(ts, i+1)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// autotile builds the set for one of a wangset's colours, counting from 1
+func (ws *Wangset) autotile(ts *Tileset, color int) *Autotile {
+	kind := Blob47
+	switch ws.Type {
+	case "edge":
+		kind = Edge4
+	case "corner":
+		kind = WangCorner
+	}
+	a := NewAutotile(ws.Colors[color-1], kind)
+	ids := make([]int, 0, len(ws.Tiles))
+	for id := range ws.Tiles {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	for _, id := range ids {
+		wang := ws.Tiles[id]
+		var mask uint8
+		other := 0
+		for i, v := range wang {
+			if v == color {
+				mask |= 1 << i
+			} else if v != 0 {
+				other = v
+			}
+		}
+		mask = a.normalize(mask)
+		gid := ts.FirstGID + GID(id)
+		switch {
+		case mask != 0 || len(ws.Colors) == 1:
+			a.Add(mask, gid)
+		case len(ws.Colors) == 2 && other != 0 && a.Fill == 0:
+			a.Fill = gid
+		}
+	}
+	return a
+}
+
+// cellHash scrambles a cell position into a number that looks random but is
+// always the same for the same cell
+func cellHash(x, y int) uint32 {
+	h := uint32(x)*0x9e3779b1 ^ uint32(y)*0x85ebca77
+	h ^= h >> 15
+	h *= 0x2c1b3c6d
+	h ^= h >> 12
+	return h
+}
@@ -0,0 +1,188 @@
+package tilemap
+
+import "testing"
+
+// fullSet returns an autotile set of a kind with a tile for every mask it
+// tells apart: 100 plus the mask, so tests can read masks back off the grid.
+// Fill is 1.
+func fullSet(kind AutotileKind) *Autotile {
+	a := NewAutotile("path", kind)
+	a.Fill = 1
+	for m := range 256 {
+		mask := a.normalize(uint8(m))
+		if !a.Has(100 + GID(mask)) {
+			a.Add(mask, 100+GID(mask))
+		}
+	}
+	return a
+}
+
+// autotileLayer is a 5x5 layer filled with tile 1
+func autotileLayer() *Layer {
+	m := New(5, 5, 16, 16)
+	l := m.AddTileLayer("ground")
+	for i := range l.Tiles {
+		l.Tiles[i] = 1
+	}
+	return l
+}
+
+// wantTiles fails the test unless every cell of l holds what want says, or 1
+// for cells want leaves out
+func wantTiles(t *testing.T, l *Layer, want map[[2]int]GID) {
+	t.Helper()
+	for y := range l.Height {
+		for x := range l.Width {
+			w, ok := want[[2]int{x, y}]
+			if !ok {
+				w = 1
+			}
+			if got := l.At(x, y); got != w {
+				t.Errorf("%d,%d holds %d, want %d", x, y, got, w)
+			}
+		}
+	}
+}
+
+func TestAutotileMasks(t *testing.T) {
+	for _, tt := range []struct {
+		kind AutotileKind
+		want int
+	}{{Edge4, 16}, {Blob47, 47}, {WangCorner, 16}} {
+		if got := fullSet(tt.kind).Count(); got != tt.want {
+			t.Errorf("kind %d tells %d masks apart, want %d", tt.kind, got, tt.want)
+		}
+	}
+
+	// Blob corners only count when both edges beside them match
+	a := NewAutotile("wall", Blob47)
+	for _, tt := range []struct {
+		mask, want uint8
+	}{
+		{0, 0},
+		{MaskNE | MaskSE | MaskSW | MaskNW, 0},
+		{MaskN | MaskNE, MaskN},
+		{MaskN | MaskNE | MaskE, MaskN | MaskNE | MaskE},
+		{MaskW | MaskNW | MaskN, MaskW | MaskNW | MaskN},
+		{MaskW | MaskNW | MaskS, MaskW | MaskS},
+		{0xff, 0xff},
+		{0xff &^ MaskE, MaskS | MaskSW | MaskW | MaskNW | MaskN},
+	} {
+		if got := a.normalize(tt.mask); got != tt.want {
+			t.Errorf("normalize(%08b) = %08b, want %08b", tt.mask, got, tt.want)
+		}
+	}
+
+	// Tiles added under masks the kind can't tell apart become variants of one
+	e := NewAutotile("path", Edge4)
+	e.Add(MaskN|MaskNE, 10)
+	e.Add(MaskN, 11)
+	if e.Count() != 1 {
+		t.Errorf("%d masks after adding two that differ only in a corner", e.Count())
+	}
+	for x := range 20 {
+		if got := e.Tile(MaskN|MaskSE, x, 0); got != 10 && got != 11 {
+			t.Fatalf("picked %d for a mask with variants 10 and 11", got)
+		}
+		if e.Tile(MaskN, x, 3) != e.Tile(MaskN, x, 3) {
+			t.Fatalf("cell %d,3 picked different variants", x)
+		}
+	}
+	if got := e.Tile(MaskS, 0, 0); got != 0 {
+		t.Errorf("picked %d for a missing mask with no fully surrounded tile", got)
+	}
+	e.Add(edgeBits, 12)
+	if got := e.Tile(MaskS, 0, 0); got != 12 {
+		t.Errorf("picked %d for a missing mask, want the fully surrounded 12", got)
+	}
+}
+
+func TestAutotileEdgePaint(t *testing.T) {
+	a := fullSet(Edge4)
+	l := autotileLayer()
+	const path = 100
+
+	a.Paint(l, 2, 2)
+	wantTiles(t, l, map[[2]int]GID{{2, 2}: path})
+
+	a.Paint(l, 3, 2)
+	a.Paint(l, 2, 3)
+	wantTiles(t, l, map[[2]int]GID{
+		{2, 2}: path + GID(MaskE|MaskS),
+		{3, 2}: path + GID(MaskW),
+		{2, 3}: path + GID(MaskN),
+	})
+
+	// Diagonal neighbours don't count
+	a.Paint(l, 3, 3)
+	wantTiles(t, l, map[[2]int]GID{
+		{2, 2}: path + GID(MaskE|MaskS),
+		{3, 2}: path + GID(MaskW|MaskS),
+		{2, 3}: path + GID(MaskN|MaskE),
+		{3, 3}: path + GID(MaskN|MaskW),
+	})
+
+	// Past the edge of the map counts as more of the same
+	a.Paint(l, 0, 4)
+	wantTiles(t, l, map[[2]int]GID{
+		{2, 2}: path + GID(MaskE|MaskS),
+		{3, 2}: path + GID(MaskW|MaskS),
+		{2, 3}: path + GID(MaskN|MaskE),
+		{3, 3}: path + GID(MaskN|MaskW),
+		{0, 4}: path + GID(MaskS|MaskW),
+	})
+
+	a.Erase(l, 2, 2)
+	a.Erase(l, 0, 4)
+	wantTiles(t, l, map[[2]int]GID{
+		{3, 2}: path + GID(MaskS),
+		{2, 3}: path + GID(MaskE),
+		{3, 3}: path + GID(MaskN|MaskW),
+	})
+
+	// Erasing leaves tiles from outside the set alone but still fixes up around them
+	l.Set(3, 2, 7)
+	a.Erase(l, 3, 2)
+	wantTiles(t, l, map[[2]int]GID{
+		{3, 2}: 7,
+		{2, 3}: path + GID(MaskE),
+		{3, 3}: path + GID(MaskW),
+	})
+	a.Paint(l, 3, 2)
+	a.Erase(l, 2, 3)
+	a.Erase(l, 3, 3)
+	wantTiles(t, l, map[[2]int]GID{{3, 2}: path})
+
+	// Painting and erasing off the map does nothing
+	a.Paint(l, -1, 0)
+	a.Erase(l, 5, 0)
+	wantTiles(t, l, map[[2]int]GID{{3, 2}: path})
+}
+
+func TestAutotileBlobPaint(t *testing.T) {
+	a := fullSet(Blob47)
+	l := autotileLayer()
+	const wall = 100
+
+	// Touching only at the corners, neither sees the other
+	a.Paint(l, 1, 1)
+	a.Paint(l, 2, 2)
+	wantTiles(t, l, map[[2]int]GID{{1, 1}: wall, {2, 2}: wall})
+
+	// Filling in the square joins the corners up
+	a.Paint(l, 2, 1)
+	a.Paint(l, 1, 2)
+	wantTiles(t, l, map[[2]int]GID{
+		{1, 1}: wall + GID(MaskE|MaskSE|MaskS),
+		{2, 1}: wall + GID(MaskS|MaskSW|MaskW),
+		{1, 2}: wall + GID(MaskN|MaskNE|MaskE),
+		{2, 2}: wall + GID(MaskN|MaskW|MaskNW),
+	})
+
+	a.Erase(l, 2, 2)
+	wantTiles(t, l, map[[2]int]GID{
+		{1, 1}: wall + GID(MaskE|MaskS),
+		{2, 1}: wall + GID(MaskW),
+		{1, 2}: wall + GID(MaskN),
+	})
+}
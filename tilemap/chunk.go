@@ -0,0 +1,121 @@
+package tilemap
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+)
+
+// ChunkCoord is a chunk's position, counted in chunks from the top left of the world
+type ChunkCoord struct {
+	X, Y int
+}
+
+// Chunk is a square block of cells from every tile layer of a World
+type Chunk struct {
+	Coord   ChunkCoord
+	Size    int
+	Layers  [][]GID // One per World layer, Size*Size cells row by row
+	Dirty   bool    // Differs from what the world's source has for it
+	Version int     // Goes up on every change, so caches built from the chunk know to rebuild
+}
+
+func newChunk(coord ChunkCoord, size, layers int) *Chunk {
+	c := &Chunk{Coord: coord, Size: size, Layers: make([][]GID, layers)}
+	for i := range c.Layers {
+		c.Layers[i] = make([]GID, size*size)
+	}
+	return c
+}
+
+// Origin returns the world tile coordinate of the chunk's top-left cell
+func (c *Chunk) Origin() (int, int) {
+	return c.Coord.X * c.Size, c.Coord.Y * c.Size
+}
+
+// At returns a cell by its position within the chunk, or 0 outside it
+func (c *Chunk) At(layer, x, y int) GID {
+	if layer < 0 || layer >= len(c.Layers) || x < 0 || y < 0 || x >= c.Size || y >= c.Size {
+		return 0
+	}
+	return c.Layers[layer][y*c.Size+x]
+}
+
+// Set changes a cell by its position within the chunk
+func (c *Chunk) Set(layer, x, y int, gid GID) {
+	if layer < 0 || layer >= len(c.Layers) || x < 0 || y < 0 || x >= c.Size || y >= c.Size {
+		return
+	}
+	c.Layers[layer][y*c.Size+x] = gid
+	c.Version++
+}
+
+// ChunkSource fills in chunks for a World. layers are the world's tile layers,
+// matching the chunk's Layers by index. LoadChunk runs on background
+// goroutines, so it must be safe to call concurrently.
+type ChunkSource interface {
+	LoadChunk(c *Chunk, layers []*Layer) error
+}
+
+// SourceFunc adapts a function to a ChunkSource
+type SourceFunc func(c *Chunk, layers []*Layer) error
+
+func (f SourceFunc) LoadChunk(c *Chunk, layers []*Layer) error {
+	return f(c, layers)
+}
+
+// MapSource cuts chunks out of a map that is already in memory, matching layers by name
+type MapSource struct {
+	Map *Map
+}
+
+func (s MapSource) LoadChunk(c *Chunk, layers []*Layer) error {
+	copyLayers(c, layers, s.Map, 0, 0)
+	return nil
+}
+
+// DirSource loads each chunk from its own map file named "<x>_<y><Ext>" in Dir,
+// such as "3_-1.tmx". Chunk files must be the chunk's size and use the same
+// tilesets in the same order as the world's map. A missing file is an empty chunk.
+type DirSource struct {
+	Dir string
+	Ext string // ".tmx" if empty
+}
+
+func (s DirSource) LoadChunk(c *Chunk, layers []*Layer) error {
+	ext := s.Ext
+	if ext == "" {
+		ext = ".tmx"
+	}
+	m, err := Load(filepath.Join(s.Dir, fmt.Sprintf("%d_%d%s", c.Coord.X, c.Coord.Y, ext)))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if m.Width != c.Size || m.Height != c.Size {
+		return fmt.Errorf("%s is %dx%d, chunks are %dx%d", m.Path, m.Width, m.Height, c.Size, c.Size)
+	}
+	x, y := c.Origin()
+	copyLayers(c, layers, m, x, y)
+	return nil
+}
+
+// copyLayers fills a chunk from the same-named tile layers of m, whose top-left
+// cell is at world tile (originX, originY)
+func copyLayers(c *Chunk, layers []*Layer, m *Map, originX, originY int) {
+	x0, y0 := c.Origin()
+	for i, wl := range layers {
+		l := m.Layer(wl.Name)
+		if l == nil || l.Kind != TileLayer {
+			continue
+		}
+		for y := 0; y < c.Size; y++ {
+			for x := 0; x < c.Size; x++ {
+				c.Layers[i][y*c.Size+x] = l.At(x0+x-originX, y0+y-originY)
+			}
+		}
+	}
+}
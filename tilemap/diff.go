@@ -0,0 +1,123 @@
+package tilemap
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// TileChange is a cell of a World that differs from what its source loaded,
+// such as a cut bush or a placed block. A save only needs these to rebuild
+// the world, rather than whole layers.
+type TileChange struct {
+	Layer string `json:"layer"`
+	X     int    `json:"x"`
+	Y     int    `json:"y"`
+	GID   GID    `json:"gid"`
+}
+
+// cellKey is a cell of one layer
+type cellKey struct {
+	layer, x, y int
+}
+
+// cellDiff is a changed cell: what the source had there and what it is now
+type cellDiff struct {
+	base, gid GID
+}
+
+// record notes a change to a cell, forgetting it again if the cell is back
+// to what the source had
+func (w *World) record(coord ChunkCoord, layer, x, y int, old, gid GID) {
+	diffs := w.diffs[coord]
+	if diffs == nil {
+		diffs = make(map[cellKey]cellDiff)
+		w.diffs[coord] = diffs
+	}
+	key := cellKey{layer, x, y}
+	d, ok := diffs[key]
+	if !ok {
+		d.base = old
+	}
+	if gid == d.base {
+		delete(diffs, key)
+		if len(diffs) == 0 {
+			delete(w.diffs, coord)
+		}
+		return
+	}
+	d.gid = gid
+	diffs[key] = d
+}
+
+// reapply puts a chunk's changes back after it was loaded again from the source
+func (w *World) reapply(c *Chunk) {
+	diffs := w.diffs[c.Coord]
+	if len(diffs) == 0 {
+		return
+	}
+	ox, oy := c.Origin()
+	for key, d := range diffs {
+		c.Layers[key.layer][(key.y-oy)*c.Size+key.x-ox] = d.gid
+	}
+	c.Version++
+	c.Dirty = true
+}
+
+// Changes returns every cell that differs from the world's source, by layer
+// and then row by row
+func (w *World) Changes() []TileChange {
+	var keys []cellKey
+	for _, diffs := range w.diffs {
+		for key := range diffs {
+			keys = append(keys, key)
+		}
+	}
+	slices.SortFunc(keys, func(a, b cellKey) int {
+		if a.layer != b.layer {
+			return a.layer - b.layer
+		}
+		if a.y != b.y {
+			return a.y - b.y
+		}
+		return a.x - b.x
+	})
+	changes := make([]TileChange, len(keys))
+	for i, key := range keys {
+		changes[i] = TileChange{
+			Layer: w.Layers[key.layer].Name,
+			X:     key.x,
+			Y:     key.y,
+			GID:   w.diffs[w.ChunkAt(key.x, key.y)][key].gid,
+		}
+	}
+	return changes
+}
+
+// ChangeCount returns how many cells differ from the world's source
+func (w *World) ChangeCount() int {
+	n := 0
+	for _, diffs := range w.diffs {
+		n += len(diffs)
+	}
+	return n
+}
+
+// ApplyChanges sets every cell in changes, such as when loading a save. Like
+// Set, each change is reported to the OnSet functions. Changes to unknown
+// layers or cells outside the world are skipped and reported in the error.
+func (w *World) ApplyChanges(changes []TileChange) error {
+	var errs []error
+	for _, ch := range changes {
+		layer := w.Layer(ch.Layer)
+		switch {
+		case layer < 0:
+			errs = append(errs, fmt.Errorf("no tile layer %q", ch.Layer))
+		case !w.InBounds(ch.X, ch.Y):
+			errs = append(errs, fmt.Errorf("cell %d,%d is outside the world", ch.X, ch.Y))
+		default:
+			w.Set(layer, ch.X, ch.Y, ch.GID)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,188 @@
+package tilemap
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"testing"
+)
+
+// diffWorld is a 64x64 world of grass, in 8x8 chunks keeping one cached, with
+// an empty walls layer and an object layer that isn't part of it
+func diffWorld() *World {
+	m := New(64, 64, 16, 16)
+	ground := m.AddTileLayer("ground")
+	for i := range ground.Tiles {
+		ground.Tiles[i] = 1
+	}
+	m.Layers = append(m.Layers, &Layer{Name: "objects", Kind: ObjectLayer})
+	m.AddTileLayer("walls")
+	w := NewWorld(m, nil)
+	w.ChunkSize = 8
+	w.CacheSize = 1
+	return w
+}
+
+// evictAll loads chunks across the world until none of the given ones are cached
+func evictAll(t *testing.T, w *World, coords ...ChunkCoord) {
+	t.Helper()
+	for x := 0; x < w.Width; x += w.ChunkSize {
+		w.At(0, x, w.Height-1)
+	}
+	for _, c := range coords {
+		if w.Loaded(c) {
+			t.Fatalf("chunk %v still loaded", c)
+		}
+	}
+}
+
+func TestWorldSetRevert(t *testing.T) {
+	w := diffWorld()
+	var sets []string
+	w.OnSet(func(layer, x, y int, old, gid GID) {
+		sets = append(sets, fmt.Sprintf("%d %d,%d %d->%d", layer, x, y, old, gid))
+	})
+	coord := w.ChunkAt(3, 3)
+
+	w.Set(0, 3, 3, 7)
+	w.Set(0, 3, 3, 8)
+	if n := w.ChangeCount(); n != 1 {
+		t.Errorf("%d changes after changing one cell twice, want 1", n)
+	}
+	if !w.Chunk(coord).Dirty {
+		t.Error("changed chunk isn't dirty")
+	}
+
+	// Back to what the map had, not just the value before
+	w.Set(0, 3, 3, 1)
+	if n := w.ChangeCount(); n != 0 || len(w.Changes()) != 0 {
+		t.Errorf("%d changes after putting the cell back: %v", n, w.Changes())
+	}
+	if len(w.diffs) != 0 {
+		t.Errorf("reverted chunk kept its diffs: %v", w.diffs)
+	}
+	if w.Chunk(coord).Dirty {
+		t.Error("reverted chunk is still dirty")
+	}
+
+	// Setting what is already there, or anywhere outside the world, does nothing
+	w.Set(0, 3, 3, 1)
+	w.Set(0, -1, 3, 7)
+	w.Set(0, 64, 3, 7)
+	w.Set(2, 3, 3, 7)
+	want := []string{"0 3,3 1->7", "0 3,3 7->8", "0 3,3 8->1"}
+	if !slices.Equal(sets, want) {
+		t.Errorf("OnSet saw %v, want %v", sets, want)
+	}
+	if w.ChangeCount() != 0 {
+		t.Errorf("no-op sets left changes: %v", w.Changes())
+	}
+}
+
+func TestWorldDiffSurvivesEviction(t *testing.T) {
+	w := diffWorld()
+	w.Set(0, 3, 3, 7)
+	w.Set(1, 20, 9, 5)
+	evictAll(t, w, w.ChunkAt(3, 3), w.ChunkAt(20, 9))
+
+	if got := w.At(0, 3, 3); got != 7 {
+		t.Errorf("ground 3,3 is %d after reloading, want 7", got)
+	}
+	if got := w.TileAt(20, 9); got != 5 {
+		t.Errorf("top tile at 20,9 is %d after reloading, want 5", got)
+	}
+	if got := w.At(0, 4, 3); got != 1 {
+		t.Errorf("unchanged neighbour is %d after reloading, want 1", got)
+	}
+	if c := w.Chunk(w.ChunkAt(3, 3)); !c.Dirty || c.Version == 0 {
+		t.Errorf("reloaded chunk with changes: dirty %v, version %d", c.Dirty, c.Version)
+	}
+
+	// A change reverted while its chunk is out of the cache is still forgotten
+	w.Set(1, 20, 9, 0)
+	evictAll(t, w, w.ChunkAt(20, 9))
+	if got := w.At(1, 20, 9); got != 0 {
+		t.Errorf("reverted wall is %d after reloading, want 0", got)
+	}
+	want := []TileChange{{Layer: "ground", X: 3, Y: 3, GID: 7}}
+	if got := w.Changes(); !slices.Equal(got, want) {
+		t.Errorf("changes %v, want %v", got, want)
+	}
+}
+
+func TestWorldChangesOrder(t *testing.T) {
+	w := diffWorld()
+	for _, ch := range []struct {
+		layer, x, y int
+		gid         GID
+	}{
+		{1, 40, 2, 4}, {0, 63, 63, 2}, {0, 9, 1, 3}, {1, 1, 50, 4}, {0, 2, 1, 5}, {0, 50, 0, 6},
+	} {
+		w.Set(ch.layer, ch.x, ch.y, ch.gid)
+	}
+	want := []TileChange{
+		{"ground", 50, 0, 6},
+		{"ground", 2, 1, 5},
+		{"ground", 9, 1, 3},
+		{"ground", 63, 63, 2},
+		{"walls", 40, 2, 4},
+		{"walls", 1, 50, 4},
+	}
+	if got := w.Changes(); !slices.Equal(got, want) {
+		t.Errorf("changes %v, want %v", got, want)
+	}
+	if w.ChangeCount() != len(want) {
+		t.Errorf("%d changes counted, want %d", w.ChangeCount(), len(want))
+	}
+}
+
+func TestWorldApplyChanges(t *testing.T) {
+	saved := diffWorld()
+	saved.Set(0, 3, 3, 7)
+	saved.Set(0, 30, 60, 0)
+	saved.Set(1, 12, 40, 9)
+	data, err := json.Marshal(saved.Changes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var changes []TileChange
+	if err := json.Unmarshal(data, &changes); err != nil {
+		t.Fatal(err)
+	}
+
+	w := diffWorld()
+	sets := 0
+	w.OnSet(func(layer, x, y int, old, gid GID) { sets++ })
+	if err := w.ApplyChanges(changes); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := w.Changes(), saved.Changes(); !slices.Equal(got, want) {
+		t.Errorf("loaded %v from %s, want %v", got, data, want)
+	}
+	if sets != 3 {
+		t.Errorf("OnSet called %d times for 3 changes", sets)
+	}
+	for _, ch := range changes {
+		if got := w.At(w.Layer(ch.Layer), ch.X, ch.Y); got != ch.GID {
+			t.Errorf("%s %d,%d is %d, want %d", ch.Layer, ch.X, ch.Y, got, ch.GID)
+		}
+	}
+
+	// Bad changes are skipped without holding up the good ones
+	err = w.ApplyChanges([]TileChange{
+		{Layer: "lava", X: 1, Y: 1, GID: 3},
+		{Layer: "objects", X: 1, Y: 1, GID: 3},
+		{Layer: "walls", X: 64, Y: 0, GID: 3},
+		{Layer: "walls", X: 5, Y: 5, GID: 3},
+	})
+	want := "no tile layer \"lava\"\nno tile layer \"objects\"\ncell 64,0 is outside the world"
+	if err == nil || err.Error() != want {
+		t.Errorf("got error %v, want %s", err, want)
+	}
+	if got := w.At(1, 5, 5); got != 3 {
+		t.Errorf("good change after bad ones left %d, want 3", got)
+	}
+	if w.ChangeCount() != 4 {
+		t.Errorf("%d changes after a partly bad apply, want 4", w.ChangeCount())
+	}
+}
@@ -0,0 +1,130 @@
+// Package gen generates tile maps from a seed: layered noise for height and
+// moisture, biomes picked from both, objects scattered by Poisson-disk
+// sampling, and paths that always join the points of interest. The same seed
+// and config always give the same map.
+package gen
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Config describes how to generate a map. Tiles are local IDs in Tileset.
+type Config struct {
+	Tileset     string  `json:"tileset"` // Relative to the config file
+	Width       int     `json:"width"`
+	Height      int     `json:"height"`
+	HeightNoise Noise   `json:"height_noise"`
+	Moisture    Noise   `json:"moisture_noise"`
+	EdgeFalloff float64 `json:"edge_falloff"` // Tiles over which height drops towards 0 at the map's edge
+	BorderTile  *int    `json:"border_tile"`  // Wall put round the edge of the map, if set
+
+	Biomes  []Biome   `json:"biomes"` // Checked in order; the first that fits a cell wins
+	Points  Points    `json:"points_of_interest"`
+	Path    Path      `json:"path"`
+	Scatter []Scatter `json:"scatter"`
+
+	dir string
+}
+
+// Biome is a kind of ground, used where height and moisture fall in its ranges
+type Biome struct {
+	Name        string  `json:"name"`
+	MaxHeight   float64 `json:"max_height"`
+	MinMoisture float64 `json:"min_moisture"`
+	MaxMoisture float64 `json:"max_moisture"` // 0 means 1
+	Tiles       []int   `json:"tiles"`        // Ground tiles, varied by position
+	PathCost    float64 `json:"path_cost"`    // How much paths avoid it; 0 means 1
+}
+
+// Points says how many points of interest to place and what to put at them.
+// The first point holds the player start.
+type Points struct {
+	Count   int      `json:"count"`
+	Spacing float64  `json:"spacing"` // Minimum tiles between points
+	Biomes  []string `json:"biomes"`  // Where points may go; empty means anywhere
+	Objects []string `json:"objects"` // Object types for the points, in order, after the player start
+}
+
+// Path is the terrain paths between points of interest are painted with.
+// Terrain names an autotile set from the tileset's wangsets.
+type Path struct {
+	Terrain string `json:"terrain"`
+}
+
+// Scatter places a stamp of tiles or an object at Poisson-disk spaced spots
+// across some biomes
+type Scatter struct {
+	Biomes []string `json:"biomes"`
+	Radius float64  `json:"radius"` // Minimum tiles between two of them
+	Chance float64  `json:"chance"` // Chance each spot is used; 0 means 1
+	Stamp  []Stamp  `json:"stamp"`
+	Object string   `json:"object"` // Object type, such as a prefab name
+}
+
+// Stamp is one tile of a scattered stamp, relative to the spot
+type Stamp struct {
+	Layer string `json:"layer"`
+	X     int    `json:"x"`
+	Y     int    `json:"y"`
+	Tile  int    `json:"tile"`
+}
+
+// LoadConfig reads a generator config from a JSON file
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var c Config
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	c.dir = filepath.Dir(path)
+	if err := c.validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &c, nil
+}
+
+// validate checks the config makes sense before any generating starts
+func (c *Config) validate() error {
+	if c.Width <= 0 || c.Height <= 0 {
+		return fmt.Errorf("map size %dx%d must be positive", c.Width, c.Height)
+	}
+	if len(c.Biomes) == 0 {
+		return fmt.Errorf("no biomes")
+	}
+	names := make(map[string]bool)
+	for _, b := range c.Biomes {
+		if len(b.Tiles) == 0 {
+			return fmt.Errorf("biome %q has no tiles", b.Name)
+		}
+		names[b.Name] = true
+	}
+	check := func(what string, biomes []string) error {
+		for _, b := range biomes {
+			if !names[b] {
+				return fmt.Errorf("%s uses unknown biome %q", what, b)
+			}
+		}
+		return nil
+	}
+	if err := check("points of interest", c.Points.Biomes); err != nil {
+		return err
+	}
+	for i, s := range c.Scatter {
+		if err := check(fmt.Sprintf("scatter %d", i), s.Biomes); err != nil {
+			return err
+		}
+		if s.Radius <= 0 {
+			return fmt.Errorf("scatter %d: radius must be positive", i)
+		}
+		if len(s.Stamp) == 0 && s.Object == "" {
+			return fmt.Errorf("scatter %d places nothing", i)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,310 @@
+package gen
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"path/filepath"
+	"slices"
+
+	"github.com/Nathene/bitbase/pathfind"
+	"github.com/Nathene/bitbase/tilemap"
+)
+
+// PlayerStart is the object type put at the first point of interest
+const PlayerStart = "player_start"
+
+// Layers of a generated map, bottom first
+var layerRoles = []struct{ name, role string }{
+	{"ground", tilemap.RoleGround},
+	{"walls", tilemap.RoleGround},
+	{"decoration", tilemap.RoleDecoration},
+	{"above", tilemap.RoleAbove},
+	{"collision", tilemap.RoleCollision},
+}
+
+// ErrNoPoints is returned when no spot fits a point of interest
+var ErrNoPoints = errors.New("gen: nowhere to put points of interest")
+
+// generator holds one run's state
+type generator struct {
+	c        *Config
+	seed     int64
+	rand     *Rand
+	m        *tilemap.Map
+	ts       *tilemap.Tileset
+	layers   map[string]*tilemap.Layer
+	spawns   *tilemap.Layer
+	biome    []int  // Index into c.Biomes of each cell
+	reserved []bool // Cells kept clear of scattered things
+	path     []bool
+}
+
+// Generate builds a map from a config and a seed
+func Generate(c *Config, seed int64) (*tilemap.Map, error) {
+	ts, err := tilemap.LoadTileset(filepath.Join(c.dir, c.Tileset), 1)
+	if err != nil {
+		return nil, err
+	}
+	g := &generator{
+		c:        c,
+		seed:     seed,
+		rand:     NewRand(seed),
+		m:        tilemap.New(c.Width, c.Height, ts.TileWidth, ts.TileHeight),
+		ts:       ts,
+		layers:   make(map[string]*tilemap.Layer),
+		biome:    make([]int, c.Width*c.Height),
+		reserved: make([]bool, c.Width*c.Height),
+		path:     make([]bool, c.Width*c.Height),
+	}
+	g.m.Tilesets = []*tilemap.Tileset{ts}
+	for _, lr := range layerRoles {
+		l := g.m.AddTileLayer(lr.name)
+		l.Properties["role"] = lr.role
+		g.layers[lr.name] = l
+	}
+	g.layers["walls"].Properties["collision"] = true
+	if c.Path.Terrain != "" {
+		g.layers["ground"].Properties["autotile"] = c.Path.Terrain
+	}
+	g.spawns = &tilemap.Layer{
+		ID:         len(g.m.Layers) + 1,
+		Name:       "spawns",
+		Kind:       tilemap.ObjectLayer,
+		Visible:    true,
+		Opacity:    1,
+		Properties: tilemap.Properties{},
+	}
+	g.m.Layers = append(g.m.Layers, g.spawns)
+
+	g.terrain()
+	points, err := g.pointsOfInterest()
+	if err != nil {
+		return nil, err
+	}
+	if err := g.paths(points); err != nil {
+		return nil, err
+	}
+	for _, p := range points {
+		g.reserve(p[0], p[1], 2)
+	}
+	for i, s := range c.Scatter {
+		if err := g.scatter(i, s); err != nil {
+			return nil, err
+		}
+	}
+	for i, p := range points {
+		typ := PlayerStart
+		if i > 0 {
+			if i-1 >= len(c.Points.Objects) {
+				break
+			}
+			typ = c.Points.Objects[i-1]
+		}
+		g.addObject(typ, p[0], p[1])
+	}
+	return g.m, nil
+}
+
+// gid turns a local tile ID from the config into a GID
+func (g *generator) gid(tile int) tilemap.GID {
+	return g.ts.FirstGID + tilemap.GID(tile)
+}
+
+// terrain lays down the ground from the height and moisture noise
+func (g *generator) terrain() {
+	c := g.c
+	ground := g.layers["ground"]
+	for y := 0; y < c.Height; y++ {
+		for x := 0; x < c.Width; x++ {
+			h := c.HeightNoise.At(g.seed, float64(x), float64(y))
+			if c.EdgeFalloff > 0 {
+				edge := float64(min(x, y, c.Width-1-x, c.Height-1-y))
+				h *= math.Min(1, edge/c.EdgeFalloff)
+			}
+			wet := c.Moisture.At(g.seed^0x5bd1e995, float64(x), float64(y))
+
+			b := len(c.Biomes) - 1
+			for i, bi := range c.Biomes {
+				maxWet := bi.MaxMoisture
+				if maxWet == 0 {
+					maxWet = 1
+				}
+				if h <= bi.MaxHeight && wet >= bi.MinMoisture && wet <= maxWet {
+					b = i
+					break
+				}
+			}
+			g.biome[y*c.Width+x] = b
+			tiles := c.Biomes[b].Tiles
+			ground.Set(x, y, g.gid(tiles[Hash(g.seed, x, y)%uint64(len(tiles))]))
+
+			if c.BorderTile != nil && (x == 0 || y == 0 || x == c.Width-1 || y == c.Height-1) {
+				g.layers["walls"].Set(x, y, g.gid(*c.BorderTile))
+				g.reserved[y*c.Width+x] = true
+			}
+		}
+	}
+}
+
+// inBiomes reports whether a cell's biome is one of names, or if names is empty
+func (g *generator) inBiomes(x, y int, names []string) bool {
+	return len(names) == 0 || slices.Contains(names, g.c.Biomes[g.biome[y*g.c.Width+x]].Name)
+}
+
+// pointsOfInterest picks well-spread cells for the player start and the
+// config's objects
+func (g *generator) pointsOfInterest() ([][2]int, error) {
+	c := g.c
+	spacing := c.Points.Spacing
+	if spacing <= 0 {
+		spacing = float64(min(c.Width, c.Height)) / 4
+	}
+	count := max(c.Points.Count, 1+len(c.Points.Objects))
+	found := Poisson(g.rand, float64(c.Width), float64(c.Height), spacing, func(fx, fy float64) bool {
+		x, y := int(fx), int(fy)
+		return x >= 2 && y >= 2 && x < c.Width-2 && y < c.Height-2 && g.inBiomes(x, y, c.Points.Biomes)
+	})
+	if len(found) == 0 {
+		return nil, ErrNoPoints
+	}
+	points := make([][2]int, 0, count)
+	for _, p := range found[:min(count, len(found))] {
+		points = append(points, [2]int{int(p[0]), int(p[1])})
+	}
+	return points, nil
+}
+
+// paths joins every point of interest to the nearest one already joined,
+// painting the path terrain along the cheapest route. Routes prefer existing
+// paths and cut across water and rough ground only when they must, so every
+// point can always be reached.
+func (g *generator) paths(points [][2]int) error {
+	c := g.c
+	if c.Path.Terrain == "" || len(points) < 2 {
+		return nil
+	}
+	at := g.m.Autotile(c.Path.Terrain)
+	if at == nil {
+		return fmt.Errorf("gen: tileset has no terrain %q for paths", c.Path.Terrain)
+	}
+	ground := g.layers["ground"]
+	finder := pathfind.NewFinder(pathfind.Func(c.Width, c.Height, func(x, y int) float64 {
+		i := y*c.Width + x
+		switch {
+		case g.path[i]:
+			return 1
+		case c.BorderTile != nil && (x == 0 || y == 0 || x == c.Width-1 || y == c.Height-1):
+			return pathfind.Blocked
+		}
+		cost := c.Biomes[g.biome[i]].PathCost
+		if cost <= 0 {
+			cost = 1
+		}
+		return 2 * cost
+	}))
+
+	joined := []int{0}
+	left := make([]int, 0, len(points)-1)
+	for i := 1; i < len(points); i++ {
+		left = append(left, i)
+	}
+	for len(left) > 0 {
+		// Prim's algorithm: the closest pair between joined and not
+		bestFrom, bestTo, bestDist := 0, 0, math.Inf(1)
+		for _, j := range joined {
+			for k, l := range left {
+				dx, dy := float64(points[j][0]-points[l][0]), float64(points[j][1]-points[l][1])
+				if d := float64(dx*dx) + float64(dy*dy); d < bestDist {
+					bestFrom, bestTo, bestDist = j, k, d
+				}
+			}
+		}
+		to := left[bestTo]
+		route, err := finder.FindPath(
+			pathfind.Point{X: points[bestFrom][0], Y: points[bestFrom][1]},
+			pathfind.Point{X: points[to][0], Y: points[to][1]},
+			pathfind.Options{})
+		if err != nil {
+			return fmt.Errorf("gen: joining points of interest: %w", err)
+		}
+		for _, p := range route {
+			g.path[p.Y*c.Width+p.X] = true
+			at.Paint(ground, p.X, p.Y)
+			g.reserve(p.X, p.Y, 1)
+		}
+		joined = append(joined, to)
+		left = append(left[:bestTo], left[bestTo+1:]...)
+	}
+	return nil
+}
+
+// reserve keeps scattered things out of the square of cells within r of (x, y)
+func (g *generator) reserve(x, y, r int) {
+	for cy := max(y-r, 0); cy <= min(y+r, g.c.Height-1); cy++ {
+		for cx := max(x-r, 0); cx <= min(x+r, g.c.Width-1); cx++ {
+			g.reserved[cy*g.c.Width+cx] = true
+		}
+	}
+}
+
+// scatter places one scatter rule's stamps or objects
+func (g *generator) scatter(index int, s Scatter) error {
+	c := g.c
+	for _, st := range s.Stamp {
+		if g.layers[st.Layer] == nil {
+			return fmt.Errorf("gen: scatter %d stamps unknown layer %q", index, st.Layer)
+		}
+	}
+	chance := s.Chance
+	if chance <= 0 {
+		chance = 1
+	}
+	salt := g.seed + int64(index+1)*0x632be5ab
+	fits := func(x, y int) bool {
+		if !g.inBiomes(x, y, s.Biomes) || g.reserved[y*c.Width+x] {
+			return false
+		}
+		for _, st := range s.Stamp {
+			sx, sy := x+st.X, y+st.Y
+			if sx < 0 || sy < 0 || sx >= c.Width || sy >= c.Height || g.reserved[sy*c.Width+sx] {
+				return false
+			}
+		}
+		return true
+	}
+	spots := Poisson(g.rand, float64(c.Width), float64(c.Height), s.Radius, func(fx, fy float64) bool {
+		x, y := int(fx), int(fy)
+		return float64(Hash(salt, x, y)>>11)/(1<<53) < chance && fits(x, y)
+	})
+	for _, p := range spots {
+		x, y := int(p[0]), int(p[1])
+		if !fits(x, y) {
+			continue // An earlier stamp of this rule got here first
+		}
+		for _, st := range s.Stamp {
+			g.layers[st.Layer].Set(x+st.X, y+st.Y, g.gid(st.Tile))
+		}
+		for _, st := range s.Stamp {
+			g.reserved[(y+st.Y)*c.Width+x+st.X] = true
+		}
+		g.reserved[y*c.Width+x] = true
+		if s.Object != "" {
+			g.addObject(s.Object, x, y)
+		}
+	}
+	return nil
+}
+
+// addObject puts an object of the given type in the middle of a cell
+func (g *generator) addObject(typ string, x, y int) {
+	g.spawns.Objects = append(g.spawns.Objects, &tilemap.Object{
+		ID:         len(g.spawns.Objects) + 1,
+		Type:       typ,
+		X:          (float64(x) + 0.5) * float64(g.m.TileWidth),
+		Y:          (float64(y) + 0.5) * float64(g.m.TileHeight),
+		Visible:    true,
+		Shape:      tilemap.PointShape,
+		Properties: tilemap.Properties{},
+	})
+}
@@ -0,0 +1,100 @@
+package gen
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"testing"
+
+	"github.com/Nathene/bitbase/tilemap"
+)
+
+const configPath = "../../assets/maps/generated.json"
+
+// layerHashes returns an FNV-1a hash of every layer of a map, by name: the
+// cells of tile layers, and the type and position of each object otherwise
+func layerHashes(m *tilemap.Map) map[string]uint64 {
+	out := make(map[string]uint64)
+	for _, l := range m.Layers {
+		h := fnv.New64a()
+		for _, gid := range l.Tiles {
+			binary.Write(h, binary.LittleEndian, uint32(gid))
+		}
+		for _, o := range l.Objects {
+			fmt.Fprintf(h, "%s %v %v\n", o.Type, o.X, o.Y)
+		}
+		out[l.Name] = h.Sum64()
+	}
+	return out
+}
+
+func generate(t *testing.T, seed int64) *tilemap.Map {
+	t.Helper()
+	c, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := Generate(c, seed)
+	if err != nil {
+		t.Fatalf("seed %d: %v", seed, err)
+	}
+	return m
+}
+
+// TestGenerateGolden locks in the maps a few seeds make. The hashes are the
+// same on every architecture, because generation keeps fused multiply-add out
+// of its arithmetic. If a change to the generator, its config or the tileset is
+// meant to change them, update the hashes from the failure messages.
+func TestGenerateGolden(t *testing.T) {
+	golden := map[int64]map[string]uint64{
+		1: {
+			"ground":     0x4c98e1980e1f8232,
+			"walls":      0x3e938d6847c050e5,
+			"decoration": 0x4350c00ddeee3cd5,
+			"above":      0xe725ae20a04ca0e5,
+			"collision":  0xf430e7336f22de45,
+			"spawns":     0x6d46de58b7b6fc5e,
+		},
+		42: {
+			"ground":     0x3abf5f6af3e64109,
+			"walls":      0x3e938d6847c050e5,
+			"decoration": 0xb4474c2868b01308,
+			"above":      0x2de2c4e286e610a5,
+			"collision":  0xcd87ad17cb156615,
+			"spawns":     0x55c260fd99b1fddc,
+		},
+		1337: {
+			"ground":     0x4a7574b4d734e824,
+			"walls":      0x3e938d6847c050e5,
+			"decoration": 0x069f65af659ac0d1,
+			"above":      0x4b75e7eb9e079965,
+			"collision":  0xeb5fd7ec478e3994,
+			"spawns":     0xf8a98e630dc0e035,
+		},
+	}
+	for seed, want := range golden {
+		got := layerHashes(generate(t, seed))
+		for name, h := range got {
+			if want[name] != h {
+				t.Errorf("seed %d layer %q: got hash %#016x, want %#016x", seed, name, h, want[name])
+			}
+		}
+		if len(got) != len(want) {
+			t.Errorf("seed %d: got %d layers, want %d", seed, len(got), len(want))
+		}
+	}
+}
+
+func TestGenerateDeterministic(t *testing.T) {
+	for _, seed := range []int64{7, 99} {
+		a, b := layerHashes(generate(t, seed)), layerHashes(generate(t, seed))
+		for name, h := range a {
+			if b[name] != h {
+				t.Errorf("seed %d layer %q: %#016x then %#016x from the same seed", seed, name, h, b[name])
+			}
+		}
+	}
+	if a, b := layerHashes(generate(t, 1)), layerHashes(generate(t, 2)); a["ground"] == b["ground"] {
+		t.Error("seeds 1 and 2 made the same ground")
+	}
+}
@@ -0,0 +1,128 @@
+package gen
+
+import "math"
+
+// Hash mixes a seed and a cell position into 64 well-scrambled bits. The same
+// inputs always give the same result, on every platform.
+func Hash(seed int64, x, y int) uint64 {
+	h := uint64(seed) ^ uint64(int64(x))*0x9e3779b97f4a7c15 ^ uint64(int64(y))*0xc2b2ae3d27d4eb4f
+	return mix(h)
+}
+
+// mix is the splitmix64 finaliser
+func mix(h uint64) uint64 {
+	h ^= h >> 30
+	h *= 0xbf58476d1ce4e5b9
+	h ^= h >> 27
+	h *= 0x94d049bb133111eb
+	h ^= h >> 31
+	return h
+}
+
+// Rand is a small seeded random number generator. Generation uses it rather
+// than math/rand so maps don't change if the standard library's does.
+type Rand struct {
+	state uint64
+}
+
+// NewRand returns a generator seeded with seed
+func NewRand(seed int64) *Rand {
+	return &Rand{state: uint64(seed)}
+}
+
+// Uint64 returns the next 64 random bits
+func (r *Rand) Uint64() uint64 {
+	r.state += 0x9e3779b97f4a7c15
+	return mix(r.state)
+}
+
+// Float64 returns a number in [0, 1)
+func (r *Rand) Float64() float64 {
+	return float64(float64(r.Uint64()>>11) / (1 << 53)) // Rounded so callers' arithmetic can't fuse with it
+}
+
+// Intn returns a number in [0, n)
+func (r *Rand) Intn(n int) int {
+	return int(r.Uint64() % uint64(n))
+}
+
+// Noise describes layered gradient noise: Octaves layers, each at Lacunarity
+// times the frequency and Persistence times the strength of the one before
+type Noise struct {
+	Scale       float64 `json:"scale"` // Tiles across one feature of the first octave
+	Octaves     int     `json:"octaves"`
+	Persistence float64 `json:"persistence"`
+	Lacunarity  float64 `json:"lacunarity"`
+}
+
+// withDefaults fills in the fields left at zero
+func (n Noise) withDefaults() Noise {
+	if n.Scale <= 0 {
+		n.Scale = 32
+	}
+	if n.Octaves <= 0 {
+		n.Octaves = 4
+	}
+	if n.Persistence <= 0 {
+		n.Persistence = 0.5
+	}
+	if n.Lacunarity <= 0 {
+		n.Lacunarity = 2
+	}
+	return n
+}
+
+// At samples the noise at a tile position, giving a value in [0, 1]
+func (n Noise) At(seed int64, x, y float64) float64 {
+	n = n.withDefaults()
+	freq, amp := 1/n.Scale, 1.0
+	sum, total := 0.0, 0.0
+	for i := 0; i < n.Octaves; i++ {
+		sum += float64(gradient(seed+int64(i)*1013, x*freq, y*freq) * amp)
+		total += amp
+		freq *= n.Lacunarity
+		amp *= n.Persistence
+	}
+	// Gradient noise rarely strays past ±0.7, so stretch it to fill [0, 1]
+	return math.Max(0, math.Min(1, 0.5+sum/total/1.4))
+}
+
+// gradient is 2D Perlin noise in about [-0.7, 0.7], with a pseudo-random
+// gradient at each lattice point picked by Hash
+func gradient(seed int64, x, y float64) float64 {
+	x0, y0 := math.Floor(x), math.Floor(y)
+	fx, fy := x-x0, y-y0
+	ix, iy := int(x0), int(y0)
+	dot := func(cx, cy int, dx, dy float64) float64 {
+		g := gradients[Hash(seed, cx, cy)>>60]
+		return float64(g[0]*dx) + float64(g[1]*dy)
+	}
+	u, v := fade(fx), fade(fy)
+	top := lerp(dot(ix, iy, fx, fy), dot(ix+1, iy, fx-1, fy), u)
+	bottom := lerp(dot(ix, iy+1, fx, fy-1), dot(ix+1, iy+1, fx-1, fy-1), u)
+	return lerp(top, bottom, v)
+}
+
+// The products in fade, lerp and the noise sums are wrapped in float64
+// conversions, which the Go spec says must round, so compilers for CPUs with
+// fused multiply-add (arm64, ppc64, amd64 v3) can't fuse them and change the
+// result in the last bit. math.Cos and math.Sin are fused inside the standard
+// library, so gradients come from this table of constants instead.
+const (
+	g0 = 1.0
+	g1 = 0.9238795325112867 // cos(π/8)
+	g2 = 0.7071067811865476 // cos(π/4)
+	g3 = 0.3826834323650898 // cos(3π/8)
+)
+
+// gradients are 16 unit vectors evenly spaced around the circle
+var gradients = [16][2]float64{
+	{g0, 0}, {g1, g3}, {g2, g2}, {g3, g1}, {0, g0}, {-g3, g1}, {-g2, g2}, {-g1, g3},
+	{-g0, 0}, {-g1, -g3}, {-g2, -g2}, {-g3, -g1}, {0, -g0}, {g3, -g1}, {g2, -g2}, {g1, -g3},
+}
+
+func fade(t float64) float64 {
+	return float64(float64(t*t)*t) * float64(float64(t*float64(float64(t*6)-15))+10)
+}
+
+func lerp(a, b, t float64) float64 { return a + float64((b-a)*t) }
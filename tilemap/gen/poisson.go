@@ -0,0 +1,83 @@
+package gen
+
+import "math"
+
+// Poisson scatters points over a w by h area so that no two are closer than
+// radius, using Bridson's algorithm. accept, if not nil, rejects positions
+// that can't hold a point. The points come out in the order they were found.
+func Poisson(r *Rand, w, h, radius float64, accept func(x, y float64) bool) [][2]float64 {
+	const tries = 30
+	if w <= 0 || h <= 0 || radius <= 0 {
+		return nil
+	}
+	cell := radius / math.Sqrt2
+	cols, rows := int(math.Ceil(w/cell)), int(math.Ceil(h/cell))
+	grid := make([]int, cols*rows) // Index+1 of the point in each cell, or 0
+	var points [][2]float64
+	var active []int
+
+	ok := func(x, y float64) bool {
+		if x < 0 || y < 0 || x >= w || y >= h {
+			return false
+		}
+		cx, cy := int(x/cell), int(y/cell)
+		for gy := max(cy-2, 0); gy <= min(cy+2, rows-1); gy++ {
+			for gx := max(cx-2, 0); gx <= min(cx+2, cols-1); gx++ {
+				if i := grid[gy*cols+gx]; i != 0 {
+					p := points[i-1]
+					// Rounded products keep fused multiply-add from moving points between platforms
+					if float64((p[0]-x)*(p[0]-x))+float64((p[1]-y)*(p[1]-y)) < float64(radius*radius) {
+						return false
+					}
+				}
+			}
+		}
+		return accept == nil || accept(x, y)
+	}
+	add := func(x, y float64) {
+		points = append(points, [2]float64{x, y})
+		grid[int(y/cell)*cols+int(x/cell)] = len(points)
+		active = append(active, len(points)-1)
+	}
+
+	// Seed from a handful of random spots, so areas accept rejects don't cut
+	// the search off from the rest of the map
+	for i := 0; i < tries; i++ {
+		if x, y := float64(r.Float64()*w), float64(r.Float64()*h); ok(x, y) {
+			add(x, y)
+		}
+	}
+	for len(active) > 0 {
+		i := r.Intn(len(active))
+		p := points[active[i]]
+		found := false
+		for t := 0; t < tries; t++ {
+			dx, dy := direction(r)
+			d := float64(radius * (1 + r.Float64()))
+			x, y := p[0]+float64(dx*d), p[1]+float64(dy*d)
+			if ok(x, y) {
+				add(x, y)
+				found = true
+				break
+			}
+		}
+		if !found {
+			active[i] = active[len(active)-1]
+			active = active[:len(active)-1]
+		}
+	}
+	return points
+}
+
+// direction returns a random unit vector. It picks points in a square until
+// one lands inside the unit circle rather than calling math.Cos and math.Sin,
+// whose results vary in the last bit between CPUs; math.Sqrt is exact everywhere.
+func direction(r *Rand) (float64, float64) {
+	for {
+		x, y := r.Float64()*2-1, r.Float64()*2-1
+		if l := float64(x*x) + float64(y*y); l > 1e-9 && l <= 1 {
+			l = math.Sqrt(l)
+			return x / l, y / l
+		}
+	}
+}
@@ -0,0 +1,249 @@
+package tilemap
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Error is a problem found while reading a map or tileset file. Line is 0
+// when the problem can't be pinned to a line.
+type Error struct {
+	Path string
+	Line int
+	Err  error
+}
+
+func (e *Error) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("%s:%d: %v", e.Path, e.Line, e.Err)
+	}
+	return fmt.Sprintf("%s: %v", e.Path, e.Err)
+}
+
+func (e *Error) Unwrap() error { return e.Err }
+
+// ErrUnsupported is returned for valid Tiled files that use features this package doesn't read
+var ErrUnsupported = errors.New("unsupported")
+
+// Load reads a map file, choosing the format by extension: .tmx for XML, and
+// .tmj or .json for JSON. External tilesets are loaded relative to the map.
+func Load(path string) (*Map, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".tmx":
+		return ParseTMX(data, path)
+	case ".tmj", ".json":
+		return ParseTMJ(data, path)
+	}
+	return nil, &Error{Path: path, Err: fmt.Errorf("unknown map format %q", filepath.Ext(path))}
+}
+
+// LoadTileset reads a tileset file (.tsx, or .tsj or .json), giving its tiles
+// GIDs from firstGID
+func LoadTileset(path string, firstGID GID) (*Tileset, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var ts *Tileset
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".tsx":
+		ts, err = parseTSX(data, path)
+	case ".tsj", ".json":
+		ts, err = parseTSJ(data, path)
+	default:
+		return nil, &Error{Path: path, Err: fmt.Errorf("unknown tileset format %q", filepath.Ext(path))}
+	}
+	if err != nil {
+		return nil, err
+	}
+	ts.FirstGID = firstGID
+	ts.Source = path
+	return ts, nil
+}
+
+// lineAt returns the 1-based line number of a byte offset
+func lineAt(data []byte, offset int64) int {
+	offset = min(max(offset, 0), int64(len(data)))
+	return bytes.Count(data[:offset], []byte("\n")) + 1
+}
+
+// resolve makes a path found in a file relative to the working directory
+func resolve(base, path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(filepath.Dir(base), path)
+}
+
+// decodeTiles turns a tile layer's encoded data into cells
+func decodeTiles(encoding, compression, text string, count int) ([]GID, error) {
+	switch encoding {
+	case "csv":
+		return decodeCSV(text, count)
+	case "base64":
+		return decodeBase64(compression, text, count)
+	}
+	return nil, fmt.Errorf("%w tile encoding %q", ErrUnsupported, encoding)
+}
+
+func decodeCSV(text string, count int) ([]GID, error) {
+	fields := strings.FieldsFunc(text, func(r rune) bool {
+		return r == ',' || r == '\n' || r == '\r' || r == ' ' || r == '\t'
+	})
+	if len(fields) != count {
+		return nil, fmt.Errorf("tile data has %d cells, want %d", len(fields), count)
+	}
+	tiles := make([]GID, count)
+	for i, f := range fields {
+		v, err := strconv.ParseUint(f, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("cell %d: bad tile %q", i, f)
+		}
+		tiles[i] = GID(v)
+	}
+	return tiles, nil
+}
+
+func decodeBase64(compression, text string, count int) ([]GID, error) {
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(text))
+	if err != nil {
+		return nil, fmt.Errorf("tile data: %w", err)
+	}
+
+	var r io.Reader = bytes.NewReader(raw)
+	switch compression {
+	case "":
+	case "gzip":
+		if r, err = gzip.NewReader(r); err != nil {
+			return nil, fmt.Errorf("tile data: %w", err)
+		}
+	case "zlib":
+		if r, err = zlib.NewReader(r); err != nil {
+			return nil, fmt.Errorf("tile data: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("%w tile compression %q", ErrUnsupported, compression)
+	}
+	if raw, err = io.ReadAll(r); err != nil {
+		return nil, fmt.Errorf("tile data: %w", err)
+	}
+
+	if len(raw) != count*4 {
+		return nil, fmt.Errorf("tile data has %d cells, want %d", len(raw)/4, count)
+	}
+	tiles := make([]GID, count)
+	for i := range tiles {
+		tiles[i] = GID(binary.LittleEndian.Uint32(raw[i*4:]))
+	}
+	return tiles, nil
+}
+
+// parsePoints reads a polygon's "x,y x,y ..." point list
+func parsePoints(text string) ([][2]float64, error) {
+	var points [][2]float64
+	for _, pair := range strings.Fields(text) {
+		xs, ys, ok := strings.Cut(pair, ",")
+		if !ok {
+			return nil, fmt.Errorf("bad point %q", pair)
+		}
+		x, errX := strconv.ParseFloat(xs, 64)
+		y, errY := strconv.ParseFloat(ys, 64)
+		if errX != nil || errY != nil {
+			return nil, fmt.Errorf("bad point %q", pair)
+		}
+		points = append(points, [2]float64{x, y})
+	}
+	return points, nil
+}
+
+// parseWangID reads the eight colours of a wang tile
+func parseWangID(fields []string) ([8]int, error) {
+	var id [8]int
+	if len(fields) != len(id) {
+		return id, fmt.Errorf("wang ID has %d colours, want %d", len(fields), len(id))
+	}
+	for i, f := range fields {
+		v, err := strconv.Atoi(strings.TrimSpace(f))
+		if err != nil || v < 0 {
+			return id, fmt.Errorf("bad wang ID colour %q", f)
+		}
+		id[i] = v
+	}
+	return id, nil
+}
+
+// validate checks what every map needs, whichever format it came from
+func (m *Map) validate() error {
+	if m.Width <= 0 || m.Height <= 0 {
+		return fmt.Errorf("map size %dx%d must be positive", m.Width, m.Height)
+	}
+	if m.TileWidth <= 0 || m.TileHeight <= 0 {
+		return fmt.Errorf("tile size %dx%d must be positive", m.TileWidth, m.TileHeight)
+	}
+	if m.Orientation != "" && m.Orientation != "orthogonal" {
+		return fmt.Errorf("%w orientation %q", ErrUnsupported, m.Orientation)
+	}
+	return nil
+}
+
+// finishTileset fills in what Tiled lets a tileset leave out
+func finishTileset(ts *Tileset) error {
+	if ts.TileWidth <= 0 || ts.TileHeight <= 0 {
+		return fmt.Errorf("tileset %q: tile size must be positive", ts.Name)
+	}
+	if ts.Columns <= 0 && ts.ImageWidth > 0 {
+		ts.Columns = (ts.ImageWidth - 2*ts.Margin + ts.Spacing) / (ts.TileWidth + ts.Spacing)
+	}
+	if ts.TileCount <= 0 && ts.Columns > 0 && ts.ImageHeight > 0 {
+		rows := (ts.ImageHeight - 2*ts.Margin + ts.Spacing) / (ts.TileHeight + ts.Spacing)
+		ts.TileCount = rows * ts.Columns
+	}
+	if ts.Properties == nil {
+		ts.Properties = Properties{}
+	}
+	if ts.Tiles == nil {
+		ts.Tiles = make(map[int]*Tile)
+	}
+	for id, t := range ts.Tiles {
+		if ts.TileCount > 0 && (id < 0 || id >= ts.TileCount) {
+			return fmt.Errorf("tileset %q: tile %d out of range", ts.Name, id)
+		}
+		for _, f := range t.Animation {
+			if ts.TileCount > 0 && (f.TileID < 0 || f.TileID >= ts.TileCount) {
+				return fmt.Errorf("tileset %q: tile %d animates to missing tile %d", ts.Name, id, f.TileID)
+			}
+		}
+	}
+	for _, ws := range ts.Wangsets {
+		switch ws.Type {
+		case "corner", "edge", "mixed":
+		default:
+			return fmt.Errorf("tileset %q: wangset %q has unknown type %q", ts.Name, ws.Name, ws.Type)
+		}
+		for id, wang := range ws.Tiles {
+			if ts.TileCount > 0 && (id < 0 || id >= ts.TileCount) {
+				return fmt.Errorf("tileset %q: wangset %q tile %d out of range", ts.Name, ws.Name, id)
+			}
+			for _, c := range wang {
+				if c > len(ws.Colors) {
+					return fmt.Errorf("tileset %q: wangset %q tile %d uses missing colour %d", ts.Name, ws.Name, id, c)
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,316 @@
+package tilemap
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeFiles writes files into a fresh directory and returns it
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// loadError loads a map and returns its error with the directory trimmed off paths
+func loadError(t *testing.T, dir, name string) string {
+	t.Helper()
+	_, err := Load(filepath.Join(dir, name))
+	if err == nil {
+		return ""
+	}
+	return strings.ReplaceAll(err.Error(), dir+string(filepath.Separator), "")
+}
+
+// tmx makes a 2x2 map whose body starts on line 3
+func tmx(body string) string {
+	return `<?xml version="1.0" encoding="UTF-8"?>
+<map version="1.10" orientation="orthogonal" width="2" height="2" tilewidth="16" tileheight="16">
+` + body + `
+</map>
+`
+}
+
+// tmxLayer makes a tile layer called g on line 3
+func tmxLayer(data string) string {
+	return tmx(` <layer id="1" name="g" width="2" height="2">
+  ` + data + `
+ </layer>`)
+}
+
+// tmj makes a 2x2 map with its tilesets on line 3 and its layers on line 5
+func tmj(tilesets, layers string) string {
+	return `{
+ "width": 2, "height": 2, "tilewidth": 16, "tileheight": 16, "orientation": "orthogonal",
+ "tilesets": [` + tilesets + `],
+ "layers": [
+  ` + layers + `
+ ]
+}
+`
+}
+
+// tmjLayer makes a tile layer called g on line 5
+func tmjLayer(fields string) string {
+	return tmj("", `{"id": 1, "name": "g", "type": "tilelayer", "width": 2, "height": 2, `+fields+`}`)
+}
+
+// encodeTiles packs cells the way Tiled does for base64 data
+func encodeTiles(t *testing.T, compression string, tiles ...GID) string {
+	t.Helper()
+	raw := make([]byte, 4*len(tiles))
+	for i, g := range tiles {
+		binary.LittleEndian.PutUint32(raw[i*4:], uint32(g))
+	}
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	switch compression {
+	case "":
+		return base64.StdEncoding.EncodeToString(raw)
+	case "gzip":
+		w = gzip.NewWriter(&buf)
+	case "zlib":
+		w = zlib.NewWriter(&buf)
+	}
+	if _, err := w.Write(raw); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return base64.StdEncoding.EncodeToString(buf.Bytes())
+}
+
+func TestLoadErrors(t *testing.T) {
+	notCompressed := base64.StdEncoding.EncodeToString([]byte("not compressed at all"))
+	gzipped := encodeTiles(t, "gzip", 1, 2, 3, 4)
+	raw, _ := base64.StdEncoding.DecodeString(gzipped)
+	truncated := base64.StdEncoding.EncodeToString(raw[:len(raw)-8])
+	tsx := `<?xml version="1.0" encoding="UTF-8"?>
+<tileset version="1.10" name="t" tilewidth="16" tileheight="16" tilecount="4" columns="2">
+ <tile id="1"><animation><frame tileid="9" duration="100"/></animation></tile>
+</tileset>
+`
+
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  string
+	}{
+		// TMX
+		{name: "tmx bad tile", files: map[string]string{"a.tmx": tmxLayer(`<data encoding="csv">1,2,3,x</data>`)},
+			want: `a.tmx:3: layer "g": cell 3: bad tile "x"`},
+		{name: "tmx mismatched tag", files: map[string]string{"a.tmx": tmx(" <layer id=\"1\" name=\"g\" width=\"2\" height=\"2\">\n </layr>")},
+			want: `a.tmx:4: element <layer> closed by </layr>`},
+		{name: "tmx unclosed map", files: map[string]string{"a.tmx": "<map width=\"2\" height=\"2\" tilewidth=\"16\" tileheight=\"16\">\n <layer name=\"g\">\n"},
+			want: `a.tmx:3: unexpected EOF`},
+		{name: "tmx wrong root", files: map[string]string{"a.tmx": "<?xml version=\"1.0\"?>\n<tileset name=\"t\">\n</tileset>\n"},
+			want: `a.tmx:2: root element is <tileset>, want <map>`},
+		{name: "tmx bad map attribute", files: map[string]string{"a.tmx": "<?xml version=\"1.0\"?>\n<map width=\"two\" height=\"2\" tilewidth=\"16\" tileheight=\"16\">\n</map>\n"},
+			want: `a.tmx:2: map attribute width: bad number "two"`},
+		{name: "tmx layer size", files: map[string]string{"a.tmx": tmx(` <layer id="1" name="g" width="3" height="2"><data encoding="csv">1,2,3,4,5,6</data></layer>`)},
+			want: `a.tmx:3: layer "g" is 3x2, map is 2x2`},
+		{name: "tmx csv too short", files: map[string]string{"a.tmx": tmxLayer(`<data encoding="csv">1,2,3</data>`)},
+			want: `a.tmx:3: layer "g": tile data has 3 cells, want 4`},
+		{name: "tmx xml cells too long", files: map[string]string{"a.tmx": tmxLayer(`<data><tile gid="1"/><tile/><tile/><tile/><tile/></data>`)},
+			want: `a.tmx:3: layer "g" has 5 cells, want 4`},
+		{name: "tmx base64 too short", files: map[string]string{"a.tmx": tmxLayer(`<data encoding="base64">` + encodeTiles(t, "", 1, 2, 3) + `</data>`)},
+			want: `a.tmx:3: layer "g": tile data has 3 cells, want 4`},
+		{name: "tmx bad base64", files: map[string]string{"a.tmx": tmxLayer(`<data encoding="base64">AAAA*AAA</data>`)},
+			want: `a.tmx:3: layer "g": tile data: illegal base64 data at input byte 4`},
+		{name: "tmx bad gzip", files: map[string]string{"a.tmx": tmxLayer(`<data encoding="base64" compression="gzip">` + notCompressed + `</data>`)},
+			want: `a.tmx:3: layer "g": tile data: gzip: invalid header`},
+		{name: "tmx truncated gzip", files: map[string]string{"a.tmx": tmxLayer(`<data encoding="base64" compression="gzip">` + truncated + `</data>`)},
+			want: `a.tmx:3: layer "g": tile data: unexpected EOF`},
+		{name: "tmx bad zlib", files: map[string]string{"a.tmx": tmxLayer(`<data encoding="base64" compression="zlib">` + notCompressed + `</data>`)},
+			want: `a.tmx:3: layer "g": tile data: zlib: invalid header`},
+		{name: "tmx zstd", files: map[string]string{"a.tmx": tmxLayer(`<data encoding="base64" compression="zstd">` + notCompressed + `</data>`)},
+			want: `a.tmx:3: layer "g": unsupported tile compression "zstd"`},
+		{name: "tmx missing tileset", files: map[string]string{"a.tmx": tmx(` <tileset firstgid="1" source="missing.tsx"/>`)},
+			want: `a.tmx:3: open missing.tsx: no such file or directory`},
+		{name: "tmx tileset syntax", files: map[string]string{
+			"a.tmx":     tmx(` <tileset firstgid="1" source="tiles.tsx"/>`),
+			"tiles.tsx": "<?xml version=\"1.0\"?>\n<tileset name=\"t\" tilewidth=\"16\" tileheight=\"16\">\n <tile id=\"1\">\n</tileset>\n",
+		}, want: `tiles.tsx:4: element <tile> closed by </tileset>`},
+		{name: "tmx tileset contents", files: map[string]string{
+			"a.tmx":     tmx(` <tileset firstgid="1" source="tiles.tsx"/>`),
+			"tiles.tsx": tsx,
+		}, want: `tiles.tsx:2: tileset "t": tile 1 animates to missing tile 9`},
+
+		// TMJ
+		{name: "tmj bad tile", files: map[string]string{"a.tmj": tmjLayer(`"data": [1, 2, 3, "x"]`)},
+			want: `a.tmj:5: layer "g": cell 3: bad tile x`},
+		{name: "tmj syntax", files: map[string]string{"a.tmj": tmjLayer(`"data": [1, 2, 3, 4],`)},
+			want: `a.tmj:5: invalid character '}' looking for beginning of object key string`},
+		{name: "tmj truncated", files: map[string]string{"a.tmj": "{\n \"width\": 2,\n \"layers\": [\n"},
+			want: `a.tmj:4: unexpected end of JSON input`},
+		{name: "tmj not an object", files: map[string]string{"a.tmj": "\n[]\n"},
+			want: `a.tmj:1: map is not a JSON object`},
+		{name: "tmj layer size", files: map[string]string{"a.tmj": tmj("", `{"name": "g", "type": "tilelayer", "width": 2, "height": 1, "data": [1, 2]}`)},
+			want: `a.tmj:5: layer "g" is 2x1, map is 2x2`},
+		{name: "tmj cells too short", files: map[string]string{"a.tmj": tmjLayer(`"data": [1, 2, 3]`)},
+			want: `a.tmj:5: layer "g" has 3 cells, want 4`},
+		{name: "tmj negative tile", files: map[string]string{"a.tmj": tmjLayer(`"data": [1, 2, 3, -1]`)},
+			want: `a.tmj:5: layer "g": cell 3: bad tile -1`},
+		{name: "tmj no data", files: map[string]string{"a.tmj": tmjLayer(`"data": null`)},
+			want: `a.tmj:5: layer "g" has no data`},
+		{name: "tmj base64 too long", files: map[string]string{"a.tmj": tmjLayer(`"encoding": "base64", "data": "` + encodeTiles(t, "", 1, 2, 3, 4, 5) + `"`)},
+			want: `a.tmj:5: layer "g": tile data has 5 cells, want 4`},
+		{name: "tmj bad base64", files: map[string]string{"a.tmj": tmjLayer(`"encoding": "base64", "data": "AAAA*AAA"`)},
+			want: `a.tmj:5: layer "g": tile data: illegal base64 data at input byte 4`},
+		{name: "tmj bad gzip", files: map[string]string{"a.tmj": tmjLayer(`"encoding": "base64", "compression": "gzip", "data": "` + notCompressed + `"`)},
+			want: `a.tmj:5: layer "g": tile data: gzip: invalid header`},
+		{name: "tmj truncated gzip", files: map[string]string{"a.tmj": tmjLayer(`"encoding": "base64", "compression": "gzip", "data": "` + truncated + `"`)},
+			want: `a.tmj:5: layer "g": tile data: unexpected EOF`},
+		{name: "tmj bad zlib", files: map[string]string{"a.tmj": tmjLayer(`"encoding": "base64", "compression": "zlib", "data": "` + notCompressed + `"`)},
+			want: `a.tmj:5: layer "g": tile data: zlib: invalid header`},
+		{name: "tmj missing tileset", files: map[string]string{"a.tmj": tmj(`{"firstgid": 1, "source": "missing.tsj"}`, "")},
+			want: `a.tmj:3: open missing.tsj: no such file or directory`},
+		{name: "tmj tileset syntax", files: map[string]string{
+			"a.tmj":     tmj(`{"firstgid": 1, "source": "tiles.tsj"}`, ""),
+			"tiles.tsj": "{\n \"name\": \"t\",\n \"tilewidth\": 16\n \"tileheight\": 16\n}\n",
+		}, want: `tiles.tsj:4: invalid character '"' after object key:value pair`},
+		{name: "tmj tileset contents", files: map[string]string{
+			"a.tmj":     tmj(`{"firstgid": 1, "source": "tiles.tsj"}`, ""),
+			"tiles.tsj": `{"name": "t", "tilewidth": 0, "tileheight": 16}`,
+		}, want: `tiles.tsj:1: tileset "t": tile size must be positive`},
+
+		{name: "unknown format", files: map[string]string{"a.txt": ""}, want: `a.txt: unknown map format ".txt"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeFiles(t, tt.files)
+			name := "a.tmx"
+			for n := range tt.files {
+				if strings.HasPrefix(n, "a.") {
+					name = n
+				}
+			}
+			if got := loadError(t, dir, name); got != tt.want {
+				t.Errorf("got error %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestLoadTypeErrors checks JSON values of the wrong type are reported on
+// their line, leaving the wording to encoding/json
+func TestLoadTypeErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		line int
+	}{
+		{name: "map field", data: "{\n \"width\": \"2\",\n \"height\": 2\n}\n", line: 2},
+		{name: "layers not an array", data: "{\n \"width\": 2,\n \"layers\": {}\n}\n", line: 3},
+		{name: "layer field", data: tmjLayer(`"visible": "yes", "data": [1, 2, 3, 4]`), line: 5},
+		{name: "tileset field", data: tmj(`{"firstgid": "1", "name": "t", "tilewidth": 16, "tileheight": 16}`, ""), line: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseTMJ([]byte(tt.data), "a.tmj")
+			var loadErr *Error
+			var typ *json.UnmarshalTypeError
+			if !errors.As(err, &loadErr) || !errors.As(err, &typ) {
+				t.Fatalf("got error %v, want a type error", err)
+			}
+			if loadErr.Path != "a.tmj" || loadErr.Line != tt.line {
+				t.Errorf("error at %s:%d, want a.tmj:%d: %v", loadErr.Path, loadErr.Line, tt.line, err)
+			}
+		})
+	}
+}
+
+// TestLoadEncodings loads the same cells from every encoding in both formats
+func TestLoadEncodings(t *testing.T) {
+	want := []GID{1, 2 | FlipHorizontal, 0, 4}
+	layers := map[string]string{
+		"xml.tmx":   tmxLayer(`<data><tile gid="1"/><tile gid="2147483650"/><tile/><tile gid="4"/></data>`),
+		"csv.tmx":   tmxLayer("<data encoding=\"csv\">\n1,2147483650,\n0,4\n</data>"),
+		"array.tmj": tmjLayer(`"data": [1, 2147483650, 0, 4]`),
+	}
+	for _, compression := range []string{"", "gzip", "zlib"} {
+		data := encodeTiles(t, compression, want...)
+		layers["base64"+compression+".tmx"] = tmxLayer(`<data encoding="base64" compression="` + compression + `">` + "\n   " + data + "\n  </data>")
+		layers["base64"+compression+".tmj"] = tmjLayer(`"encoding": "base64", "compression": "` + compression + `", "data": "` + data + `"`)
+	}
+	dir := writeFiles(t, layers)
+	for name := range layers {
+		m, err := Load(filepath.Join(dir, name))
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if l := m.Layer("g"); l == nil || !reflect.DeepEqual(l.Tiles, want) {
+			t.Errorf("%s: loaded layers %+v, want cells %v", name, m.Layers, want)
+		}
+	}
+}
+
+// TestLoadExternalTileset checks tilesets in separate files, of either format,
+// get their first GID from the map and are found relative to it
+func TestLoadExternalTileset(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"tiles.tsx": `<?xml version="1.0" encoding="UTF-8"?>
+<tileset version="1.10" name="xml" tilewidth="16" tileheight="16" tilecount="4" columns="2">
+ <image source="tiles.png" width="32" height="32"/>
+</tileset>
+`,
+		"tiles.tsj": `{"name": "json", "tilewidth": 16, "tileheight": 16, "image": "tiles.png", "imagewidth": 32, "imageheight": 48}`,
+	})
+	if err := os.Mkdir(filepath.Join(dir, "maps"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	maps := map[string]string{
+		"a.tmx": tmx(` <tileset firstgid="1" source="../tiles.tsx"/>
+ <tileset firstgid="5" source="../tiles.tsj"/>`),
+		"a.tmj": tmj(`{"firstgid": 1, "source": "../tiles.tsx"}, {"firstgid": 5, "source": "../tiles.tsj"}`, ""),
+	}
+	for name, data := range maps {
+		path := filepath.Join(dir, "maps", name)
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		m, err := Load(path)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if len(m.Tilesets) != 2 {
+			t.Fatalf("%s: loaded %d tilesets, want 2", name, len(m.Tilesets))
+		}
+		for i, want := range []struct {
+			name     string
+			firstGID GID
+			source   string
+			count    int
+		}{
+			{"xml", 1, filepath.Join(dir, "tiles.tsx"), 4},
+			{"json", 5, filepath.Join(dir, "tiles.tsj"), 6},
+		} {
+			ts := m.Tilesets[i]
+			if ts.Name != want.name || ts.FirstGID != want.firstGID || filepath.Clean(ts.Source) != want.source ||
+				ts.TileCount != want.count || filepath.Clean(ts.Image) != filepath.Join(dir, "tiles.png") {
+				t.Errorf("%s: tileset %d is %+v", name, i, ts)
+			}
+		}
+		if ts, id := m.Tileset(6); ts != m.Tilesets[1] || id != 1 {
+			t.Errorf("%s: GID 6 is tile %d of %v, want tile 1 of json", name, id, ts)
+		}
+	}
+}
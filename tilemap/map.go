@@ -0,0 +1,326 @@
+// Package tilemap holds tile maps made in the Tiled editor: tile layers,
+// object layers, tilesets and their custom properties. It reads both of
+// Tiled's formats, TMX (XML) and TMJ (JSON), and has no dependency on ebiten.
+package tilemap
+
+// GID is a global tile ID: which tile of which tileset, plus flip flags in the
+// high bits. 0 is an empty cell.
+type GID uint32
+
+const (
+	FlipHorizontal GID = 0x80000000
+	FlipVertical   GID = 0x40000000
+	FlipDiagonal   GID = 0x20000000 // Swap x and y, which with the other flips makes 90° rotations
+	rotatedHex     GID = 0x10000000
+
+	flagMask = FlipHorizontal | FlipVertical | FlipDiagonal | rotatedHex
+)
+
+// ID returns the tile ID with the flip flags cleared
+func (g GID) ID() GID {
+	return g &^ flagMask
+}
+
+// Flags returns just the flip flags
+func (g GID) Flags() GID {
+	return g & flagMask
+}
+
+// Map is a whole tile map
+type Map struct {
+	Path                  string // File it was loaded from, if any
+	Width, Height         int    // Size in tiles
+	TileWidth, TileHeight int    // Size of one cell in pixels
+	Orientation           string
+	BackgroundColor       string
+	Properties            Properties
+	Tilesets              []*Tileset // In order of FirstGID
+	Layers                []*Layer   // Bottom first; group layers are flattened into this list
+}
+
+// New creates an empty orthogonal map
+func New(width, height, tileWidth, tileHeight int) *Map {
+	return &Map{
+		Width:       width,
+		Height:      height,
+		TileWidth:   tileWidth,
+		TileHeight:  tileHeight,
+		Orientation: "orthogonal",
+		Properties:  Properties{},
+	}
+}
+
+// LayerKind says what a layer holds
+type LayerKind int
+
+const (
+	TileLayer LayerKind = iota
+	ObjectLayer
+	ImageLayer
+)
+
+// Layer is one layer of a map
+type Layer struct {
+	ID               int
+	Name             string
+	Class            string
+	Kind             LayerKind
+	Visible          bool
+	Opacity          float64
+	OffsetX, OffsetY float64 // Pixels, including the offsets of any groups it was in
+	Properties       Properties
+
+	Width, Height int
+	Tiles         []GID     // Tile layers: Width*Height cells, row by row
+	Objects       []*Object // Object layers
+	Image         string    // Image layers: path relative to the working directory
+}
+
+// Layer roles say where a tile layer is drawn relative to entities. A layer's
+// role comes from its "role" string property, or its name if that is a role,
+// and is RoleGround otherwise.
+const (
+	RoleGround     = "ground"     // Drawn below entities
+	RoleDecoration = "decoration" // Drawn row by row, depth-sorted with entities by their feet
+	RoleAbove      = "above"      // Drawn over entities: tree canopies, roofs
+	RoleCollision  = "collision"  // Never drawn; any tile on it blocks movement
+)
+
+// Role returns the layer's role
+func (l *Layer) Role() string {
+	switch role := l.Properties.String("role", l.Name); role {
+	case RoleDecoration, RoleAbove, RoleCollision:
+		return role
+	}
+	return RoleGround
+}
+
+// AddTileLayer appends an empty tile layer the size of the map
+func (m *Map) AddTileLayer(name string) *Layer {
+	l := &Layer{
+		ID:         len(m.Layers) + 1,
+		Name:       name,
+		Kind:       TileLayer,
+		Visible:    true,
+		Opacity:    1,
+		Properties: Properties{},
+		Width:      m.Width,
+		Height:     m.Height,
+		Tiles:      make([]GID, m.Width*m.Height),
+	}
+	m.Layers = append(m.Layers, l)
+	return l
+}
+
+// InBounds reports whether a cell is inside the layer
+func (l *Layer) InBounds(x, y int) bool {
+	return x >= 0 && y >= 0 && x < l.Width && y < l.Height
+}
+
+// At returns the cell at (x, y), or 0 outside the layer
+func (l *Layer) At(x, y int) GID {
+	if x < 0 || y < 0 || x >= l.Width || y >= l.Height || l.Kind != TileLayer {
+		return 0
+	}
+	return l.Tiles[y*l.Width+x]
+}
+
+// Set changes the cell at (x, y), ignoring positions outside the layer
+func (l *Layer) Set(x, y int, gid GID) {
+	if x < 0 || y < 0 || x >= l.Width || y >= l.Height || l.Kind != TileLayer {
+		return
+	}
+	l.Tiles[y*l.Width+x] = gid
+}
+
+// Layer returns the first layer with the given name, or nil
+func (m *Map) Layer(name string) *Layer {
+	for _, l := range m.Layers {
+		if l.Name == name {
+			return l
+		}
+	}
+	return nil
+}
+
+// ShapeKind says what shape an object is
+type ShapeKind int
+
+const (
+	Rectangle ShapeKind = iota
+	Ellipse
+	PointShape
+	Polygon
+	Polyline
+)
+
+// Object is a placed object from an object layer, such as a spawn point or a
+// trigger area. Positions are in pixels.
+type Object struct {
+	ID                  int
+	Name                string
+	Type                string // Tiled's "class"
+	X, Y, Width, Height float64
+	Rotation            float64 // Degrees clockwise
+	GID                 GID     // Non-zero for tile objects
+	Visible             bool
+	Shape               ShapeKind
+	Points              [][2]float64 // Polygon and polyline vertices, relative to (X, Y)
+	Properties          Properties
+}
+
+// Objects returns every object of the given type across all object layers, or
+// every object if typ is empty
+func (m *Map) Objects(typ string) []*Object {
+	var out []*Object
+	for _, l := range m.Layers {
+		for _, o := range l.Objects {
+			if typ == "" || o.Type == typ {
+				out = append(out, o)
+			}
+		}
+	}
+	return out
+}
+
+// Tileset is a set of tiles cut from one image
+type Tileset struct {
+	FirstGID              GID
+	Source                string // External tileset file, if it came from one
+	Name                  string
+	Class                 string
+	TileWidth, TileHeight int
+	Spacing, Margin       int
+	TileCount, Columns    int
+	Image                 string // Path relative to the working directory
+	ImageWidth            int
+	ImageHeight           int
+	Properties            Properties
+	Tiles                 map[int]*Tile // Tiles with extra data, by local ID
+	Wangsets              []*Wangset
+}
+
+// Wangset is a set of terrain transition tiles made with Tiled's terrain tools
+type Wangset struct {
+	Name   string
+	Type   string         // "corner", "edge" or "mixed"
+	Colors []string       // Terrain names; wang IDs count from 1 into this
+	Tiles  map[int][8]int // Wang ID of each tile by local ID, clockwise from the top edge
+}
+
+// Tile is the extra data Tiled can hold for one tile of a tileset
+type Tile struct {
+	ID         int
+	Type       string // Tiled's "class"
+	Properties Properties
+	Collision  []*Object // Collision shapes drawn in the tile collision editor
+	Animation  []Frame
+}
+
+// Frame is one frame of a tile animation
+type Frame struct {
+	TileID   int     // Local ID in the same tileset
+	Duration float64 // Seconds
+}
+
+// Tileset returns the tileset a tile comes from and the tile's ID within it,
+// or nil for an empty cell or an unknown tile
+func (m *Map) Tileset(gid GID) (*Tileset, int) {
+	id := gid.ID()
+	if id == 0 {
+		return nil, 0
+	}
+	for i := len(m.Tilesets) - 1; i >= 0; i-- {
+		ts := m.Tilesets[i]
+		if id >= ts.FirstGID {
+			local := int(id - ts.FirstGID)
+			if ts.TileCount > 0 && local >= ts.TileCount {
+				return nil, 0
+			}
+			return ts, local
+		}
+	}
+	return nil, 0
+}
+
+// Tile returns the extra data for a tile, or nil if it has none
+func (m *Map) Tile(gid GID) *Tile {
+	ts, local := m.Tileset(gid)
+	if ts == nil {
+		return nil
+	}
+	return ts.Tiles[local]
+}
+
+// TileOfType returns the first tile whose type (Tiled's "class") is typ, or 0
+// if no tileset has one
+func (m *Map) TileOfType(typ string) GID {
+	for _, ts := range m.Tilesets {
+		best := -1
+		for id, t := range ts.Tiles {
+			if t.Type == typ && (best < 0 || id < best) {
+				best = id
+			}
+		}
+		if best >= 0 {
+			return ts.FirstGID + GID(best)
+		}
+	}
+	return 0
+}
+
+// InBounds reports whether a tile coordinate is inside the map
+func (m *Map) InBounds(x, y int) bool {
+	return x >= 0 && y >= 0 && x < m.Width && y < m.Height
+}
+
+// Solid reports whether a cell blocks movement. Everything outside the map is
+// solid. Inside, a cell is solid if any tile layer has a tile there and either
+// the layer is a collision layer or has a true "collision" property, or the
+// tile itself has a true "collision" or "deep_water" property, a false
+// "walkable" property, or collision shapes.
+func (m *Map) Solid(x, y int) bool {
+	if !m.InBounds(x, y) {
+		return true
+	}
+	for _, l := range m.Layers {
+		if m.Blocks(l, l.At(x, y)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Blocks reports whether a tile placed on a layer blocks movement
+func (m *Map) Blocks(l *Layer, gid GID) bool {
+	if gid == 0 {
+		return false
+	}
+	if l.Properties.Bool("collision") || l.Role() == RoleCollision {
+		return true
+	}
+	t := m.Tile(gid)
+	if t == nil {
+		return false
+	}
+	p := t.Properties
+	return p.Bool("collision") || p.Bool("deep_water") || (p.Has("walkable") && !p.Bool("walkable")) || len(t.Collision) > 0
+}
+
+// Cost returns the extra movement cost of a cell: the highest "cost" property of
+// any tile in it, or 0 for normal ground
+func (m *Map) Cost(x, y int) float64 {
+	cost := 0.0
+	for _, l := range m.Layers {
+		cost = max(cost, m.TileCost(l.At(x, y)))
+	}
+	return cost
+}
+
+// TileCost returns a tile's "cost" property, or 0
+func (m *Map) TileCost(gid GID) float64 {
+	if t := m.Tile(gid); t != nil {
+		return t.Properties.Float("cost", 0)
+	}
+	return 0
+}
@@ -0,0 +1,112 @@
+package tilemap
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Properties are the custom properties set on a map, layer, tileset, tile or
+// object. Values are bool, int, float64 or string (colours, files and plain
+// strings), or nested Properties for class-typed values.
+type Properties map[string]any
+
+// Has reports whether a property is set
+func (p Properties) Has(name string) bool {
+	_, ok := p[name]
+	return ok
+}
+
+// Bool returns a boolean property, or false
+func (p Properties) Bool(name string) bool {
+	b, _ := p[name].(bool)
+	return b
+}
+
+// Float returns a numeric property, or fallback
+func (p Properties) Float(name string, fallback float64) float64 {
+	switch v := p[name].(type) {
+	case float64:
+		return v
+	case int:
+		return float64(v)
+	}
+	return fallback
+}
+
+// Int returns a numeric property rounded down, or fallback
+func (p Properties) Int(name string, fallback int) int {
+	switch v := p[name].(type) {
+	case int:
+		return v
+	case float64:
+		return int(v)
+	}
+	return fallback
+}
+
+// String returns a string property, or fallback
+func (p Properties) String(name, fallback string) string {
+	if s, ok := p[name].(string); ok {
+		return s
+	}
+	return fallback
+}
+
+// parseProperty converts a property value written as text into its typed form
+func parseProperty(typ, value string) (any, error) {
+	switch typ {
+	case "", "string", "color", "file":
+		return value, nil
+	case "bool":
+		return strconv.ParseBool(value)
+	case "int", "object":
+		if value == "" {
+			return 0, nil
+		}
+		return strconv.Atoi(value)
+	case "float":
+		if value == "" {
+			return 0.0, nil
+		}
+		return strconv.ParseFloat(value, 64)
+	}
+	return nil, fmt.Errorf("unknown property type %q", typ)
+}
+
+// convertProperty checks a property value read from JSON against its declared type
+func convertProperty(typ string, value any) (any, error) {
+	switch v := value.(type) {
+	case string:
+		return parseProperty(typ, v)
+	case bool:
+		if typ != "bool" {
+			return nil, fmt.Errorf("%s property has a bool value", typ)
+		}
+		return v, nil
+	case float64:
+		switch typ {
+		case "int", "object":
+			return int(v), nil
+		case "float":
+			return v, nil
+		}
+		return nil, fmt.Errorf("%s property has a number value", typ)
+	case map[string]any:
+		if typ != "class" {
+			return nil, fmt.Errorf("%s property has an object value", typ)
+		}
+		nested := Properties{}
+		for key, raw := range v {
+			// Class members don't carry their own types in TMJ, so keep them as read
+			if f, ok := raw.(float64); ok && f == float64(int(f)) {
+				nested[key] = int(f)
+			} else {
+				nested[key] = raw
+			}
+		}
+		return nested, nil
+	case nil:
+		return parseProperty(typ, "")
+	}
+	return nil, fmt.Errorf("unsupported property value %v", value)
+}
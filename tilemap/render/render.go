@@ -0,0 +1,453 @@
+// Package render draws tilemap worlds with ebiten. Chunks are pre-rendered to
+// offscreen images the first time they come into view and redrawn only when
+// their tiles change, so a frame costs a few image draws however many tiles
+// are on screen.
+package render
+
+import (
+	"container/list"
+	"image"
+	"log"
+	"math"
+
+	"github.com/Nathene/bitbase/common"
+	"github.com/Nathene/bitbase/tilemap"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+)
+
+// DefaultCacheSize is how many chunk images are kept across both passes, enough
+// for a zoomed-out 1080p view
+const DefaultCacheSize = 48
+
+// Pass is a group of layers drawn together into cached chunk images
+type Pass int
+
+const (
+	Below Pass = iota // Ground layers, under entities
+	Above             // Layers drawn over entities
+)
+
+// Stats describe the frame drawn since the last DrawBelow
+type Stats struct {
+	Chunks   int // Chunk images drawn
+	Rebuilt  int // Chunk images rendered from tiles this frame
+	Tiles    int // Tiles drawn, including those drawn into rebuilt chunks
+	Sorted   int // Decoration tiles handed out to be depth-sorted
+	Cached   int // Chunk images held
+	Uncached bool
+}
+
+// Renderer draws the tile layers of a World. A frame is drawn in three steps:
+// DrawBelow, then the decoration tiles from AppendSorted mixed in with the
+// entities by depth, then DrawAbove.
+type Renderer struct {
+	World     *tilemap.World
+	Images    func(path string) *ebiten.Image // Optional; tileset images are loaded from file by default
+	CacheSize int
+	Time      float64 // Seconds, for tile animations
+	NoCache   bool    // Draw every visible tile every frame instead of using chunk images
+
+	Stats Stats
+
+	images map[string]*ebiten.Image
+	loaded []*ebiten.Image               // Tileset images read from file, which Release frees
+	tiles  map[tilemap.GID]*ebiten.Image // Sub-images by tile ID, without flip flags
+	cache  map[cacheKey]*list.Element
+	lru    *list.List // Of *chunkImage, most recently drawn first
+	spare  []*ebiten.Image
+	padX   int // How far the largest tile overhangs its cell to the right
+	padY   int // and upwards, which chunk images leave room for
+}
+
+type cacheKey struct {
+	coord tilemap.ChunkCoord
+	pass  Pass
+}
+
+// chunkImage is one pass of a chunk drawn to an image, and what it was drawn from
+type chunkImage struct {
+	key     cacheKey
+	chunk   *tilemap.Chunk
+	version int
+	image   *ebiten.Image  // nil if the pass has no static tiles in this chunk
+	moving  []animatedTile // Animated tiles, drawn over the image every frame
+}
+
+// animatedTile is an animated tile left out of a chunk image
+type animatedTile struct {
+	x, y  int // Cell within the chunk
+	layer int
+	gid   tilemap.GID
+}
+
+// SortedTile is a decoration tile to draw in depth order with entities
+type SortedTile struct {
+	X, Y  int // Cell
+	Layer int
+	GID   tilemap.GID
+	Depth float64 // World Y of the tile's base, plus its "sort_offset" property
+}
+
+// New creates a renderer for a world
+func New(world *tilemap.World) *Renderer {
+	r := &Renderer{
+		World:     world,
+		CacheSize: DefaultCacheSize,
+		images:    make(map[string]*ebiten.Image),
+		tiles:     make(map[tilemap.GID]*ebiten.Image),
+		cache:     make(map[cacheKey]*list.Element),
+		lru:       list.New(),
+	}
+	for _, ts := range world.Map.Tilesets {
+		r.padX = max(r.padX, ts.TileWidth-world.Map.TileWidth)
+		r.padY = max(r.padY, ts.TileHeight-world.Map.TileHeight)
+	}
+	return r
+}
+
+// Update advances tile animations by dt seconds
+func (r *Renderer) Update(dt float64) {
+	r.Time += dt
+}
+
+// Draw draws every layer with nothing in between, for views without entities
+func (r *Renderer) Draw(dst *ebiten.Image, camera common.Camera) {
+	r.DrawBelow(dst, camera)
+	for _, t := range r.AppendSorted(nil, dst, camera) {
+		r.DrawSorted(dst, t, camera)
+	}
+	r.DrawAbove(dst, camera)
+}
+
+// DrawBelow draws the ground layers that overlap the camera and starts a new
+// frame's Stats. dst is in view space, so its size is the size of the view.
+func (r *Renderer) DrawBelow(dst *ebiten.Image, camera common.Camera) {
+	r.Stats = Stats{Uncached: r.NoCache, Cached: r.lru.Len()}
+	r.drawPass(dst, camera, Below)
+}
+
+// DrawAbove draws the layers that go over entities
+func (r *Renderer) DrawAbove(dst *ebiten.Image, camera common.Camera) {
+	r.drawPass(dst, camera, Above)
+	r.evict()
+	r.Stats.Cached = r.lru.Len()
+}
+
+// AppendSorted appends the visible decoration tiles to out, row by row
+func (r *Renderer) AppendSorted(out []SortedTile, dst *ebiten.Image, camera common.Camera) []SortedTile {
+	w := r.World
+	x0, y0, x1, y1, ok := r.visible(dst, camera)
+	if !ok {
+		return out
+	}
+	th := float64(w.Map.TileHeight)
+	for i, l := range w.Layers {
+		if !l.Visible || l.Role() != tilemap.RoleDecoration {
+			continue
+		}
+		for ty := y0; ty <= y1; ty++ {
+			for tx := x0; tx <= x1; tx++ {
+				gid := w.At(i, tx, ty)
+				if gid == 0 {
+					continue
+				}
+				depth := float64(ty+1) * th
+				if t := w.Map.Tile(gid); t != nil {
+					depth += t.Properties.Float("sort_offset", 0)
+				}
+				out = append(out, SortedTile{X: tx, Y: ty, Layer: i, GID: gid, Depth: depth})
+				r.Stats.Sorted++
+			}
+		}
+	}
+	return out
+}
+
+// DrawSorted draws one tile from AppendSorted
+func (r *Renderer) DrawSorted(dst *ebiten.Image, t SortedTile, camera common.Camera) {
+	m := r.World.Map
+	r.drawTile(dst, t.GID, r.World.Layers[t.Layer], float64(t.X*m.TileWidth)-camera.X, float64(t.Y*m.TileHeight)-camera.Y)
+}
+
+// Invalidate throws away every chunk image, such as after the tilesets change
+func (r *Renderer) Invalidate() {
+	for e := r.lru.Front(); e != nil; e = e.Next() {
+		if img := e.Value.(*chunkImage).image; img != nil {
+			r.spare = append(r.spare, img)
+		}
+	}
+	r.lru.Init()
+	clear(r.cache)
+	clear(r.tiles)
+}
+
+// Release frees the renderer's chunk images and the tileset images it loaded
+// itself, such as when leaving a map. Images from the Images func belong to
+// whoever supplied them and are left alone. The renderer can still be used
+// afterwards, and will load what it needs again.
+func (r *Renderer) Release() {
+	r.Invalidate()
+	for _, img := range r.spare {
+		img.Deallocate()
+	}
+	for _, img := range r.loaded {
+		img.Deallocate()
+	}
+	r.spare, r.loaded = nil, nil
+	clear(r.images)
+}
+
+// visible returns the range of cells that can show in dst, including cells
+// whose oversized tiles reach into view, clamped to the world
+func (r *Renderer) visible(dst *ebiten.Image, camera common.Camera) (x0, y0, x1, y1 int, ok bool) {
+	w := r.World
+	tw, th := float64(w.Map.TileWidth), float64(w.Map.TileHeight)
+	viewW, viewH := float64(dst.Bounds().Dx()), float64(dst.Bounds().Dy())
+	x0 = max(int(math.Floor((camera.X-float64(r.padX))/tw)), 0)
+	y0 = max(int(math.Floor(camera.Y/th)), 0)
+	x1 = min(int(math.Floor((camera.X+viewW)/tw)), w.Width-1)
+	y1 = min(int(math.Floor((camera.Y+viewH+float64(r.padY))/th)), w.Height-1)
+	return x0, y0, x1, y1, x0 <= x1 && y0 <= y1
+}
+
+// inPass reports whether a layer is drawn in a pass
+func inPass(l *tilemap.Layer, pass Pass) bool {
+	if !l.Visible {
+		return false
+	}
+	switch l.Role() {
+	case tilemap.RoleGround:
+		return pass == Below
+	case tilemap.RoleAbove:
+		return pass == Above
+	}
+	return false
+}
+
+func (r *Renderer) drawPass(dst *ebiten.Image, camera common.Camera, pass Pass) {
+	w := r.World
+	tw, th := w.Map.TileWidth, w.Map.TileHeight
+	x0, y0, x1, y1, ok := r.visible(dst, camera)
+	if !ok {
+		return
+	}
+
+	if r.NoCache {
+		for ty := y0; ty <= y1; ty++ {
+			for tx := x0; tx <= x1; tx++ {
+				for i, l := range w.Layers {
+					if inPass(l, pass) {
+						r.drawTile(dst, w.At(i, tx, ty), l, float64(tx*tw)-camera.X, float64(ty*th)-camera.Y)
+					}
+				}
+			}
+		}
+		return
+	}
+
+	c0, c1 := w.ChunkAt(x0, y0), w.ChunkAt(x1, y1)
+	size := w.ChunkSize
+	for cy := c0.Y; cy <= c1.Y; cy++ {
+		for cx := c0.X; cx <= c1.X; cx++ {
+			ci := r.chunkImage(cacheKey{tilemap.ChunkCoord{X: cx, Y: cy}, pass})
+			if ci == nil {
+				continue
+			}
+			originX, originY := float64(cx*size*tw)-camera.X, float64(cy*size*th)-camera.Y
+			if ci.image != nil {
+				opts := &ebiten.DrawImageOptions{}
+				opts.GeoM.Translate(originX, originY-float64(r.padY))
+				dst.DrawImage(ci.image, opts)
+				r.Stats.Chunks++
+			}
+			// Animated tiles go over the chunk's image, so they look right on the top layer of a pass
+			for _, a := range ci.moving {
+				r.drawTile(dst, a.gid, w.Layers[a.layer], originX+float64(a.x*tw), originY+float64(a.y*th))
+			}
+		}
+	}
+}
+
+// chunkImage returns a chunk's image for a pass, drawing it if the chunk is
+// new or has changed
+func (r *Renderer) chunkImage(key cacheKey) *chunkImage {
+	chunk := r.World.Chunk(key.coord)
+	if chunk == nil {
+		return nil
+	}
+	e, ok := r.cache[key]
+	if !ok {
+		e = r.lru.PushFront(&chunkImage{key: key})
+		r.cache[key] = e
+	}
+	r.lru.MoveToFront(e)
+	ci := e.Value.(*chunkImage)
+	if !ok || ci.chunk != chunk || ci.version != chunk.Version {
+		ci.chunk, ci.version = chunk, chunk.Version
+		r.render(ci, chunk)
+	}
+	return ci
+}
+
+// render draws the static tiles of a pass of a chunk into its image, leaving it
+// without one if the pass has none there, and lists the animated tiles
+func (r *Renderer) render(ci *chunkImage, chunk *tilemap.Chunk) {
+	r.Stats.Rebuilt++
+	m := r.World.Map
+	ci.moving = ci.moving[:0]
+	empty := true
+	for i, l := range r.World.Layers {
+		if !inPass(l, ci.key.pass) {
+			continue
+		}
+		for j, gid := range chunk.Layers[i] {
+			switch {
+			case gid == 0:
+			case m.Animated(gid):
+				ci.moving = append(ci.moving, animatedTile{x: j % chunk.Size, y: j / chunk.Size, layer: i, gid: gid})
+			default:
+				empty = false
+			}
+		}
+	}
+	if empty {
+		if ci.image != nil {
+			r.spare = append(r.spare, ci.image)
+			ci.image = nil
+		}
+		return
+	}
+
+	if ci.image == nil {
+		ci.image = r.newChunkImage()
+	}
+	ci.image.Clear()
+	tw, th := float64(r.World.Map.TileWidth), float64(r.World.Map.TileHeight)
+	for i, l := range r.World.Layers {
+		if !inPass(l, ci.key.pass) {
+			continue
+		}
+		for y := 0; y < chunk.Size; y++ {
+			for x := 0; x < chunk.Size; x++ {
+				if gid := chunk.At(i, x, y); !m.Animated(gid) {
+					r.drawTile(ci.image, gid, l, float64(x)*tw, float64(y)*th+float64(r.padY))
+				}
+			}
+		}
+	}
+}
+
+func (r *Renderer) newChunkImage() *ebiten.Image {
+	if n := len(r.spare); n > 0 {
+		img := r.spare[n-1]
+		r.spare = r.spare[:n-1]
+		return img
+	}
+	w := r.World
+	return ebiten.NewImage(w.ChunkSize*w.Map.TileWidth+r.padX, w.ChunkSize*w.Map.TileHeight+r.padY)
+}
+
+// evict drops the least recently drawn chunk images over CacheSize, keeping
+// them for reuse since every chunk image is the same size
+func (r *Renderer) evict() {
+	for r.lru.Len() > r.CacheSize {
+		e := r.lru.Back()
+		ci := e.Value.(*chunkImage)
+		r.lru.Remove(e)
+		delete(r.cache, ci.key)
+		if ci.image != nil {
+			r.spare = append(r.spare, ci.image)
+		}
+	}
+	// Only keep a few spare images around
+	for len(r.spare) > 4 {
+		r.spare[len(r.spare)-1].Deallocate()
+		r.spare = r.spare[:len(r.spare)-1]
+	}
+}
+
+// drawTile draws one cell at its current animation frame with its top-left
+// corner at (x, y). Tiles taller than the grid line up with the bottom of their
+// cell, as in Tiled.
+func (r *Renderer) drawTile(dst *ebiten.Image, gid tilemap.GID, l *tilemap.Layer, x, y float64) {
+	gid = r.World.Map.Animate(gid, r.Time)
+	img := r.tile(gid)
+	if img == nil {
+		return
+	}
+	r.Stats.Tiles++
+	w, h := float64(img.Bounds().Dx()), float64(img.Bounds().Dy())
+
+	opts := &ebiten.DrawImageOptions{}
+	if flags := gid.Flags(); flags != 0 {
+		// Flip around the tile's centre: diagonal first, then horizontal and vertical
+		opts.GeoM.Translate(-w/2, -h/2)
+		if flags&tilemap.FlipDiagonal != 0 {
+			var transpose ebiten.GeoM
+			transpose.SetElement(0, 0, 0)
+			transpose.SetElement(0, 1, 1)
+			transpose.SetElement(1, 0, 1)
+			transpose.SetElement(1, 1, 0)
+			opts.GeoM.Concat(transpose)
+			w, h = h, w
+		}
+		sx, sy := 1.0, 1.0
+		if flags&tilemap.FlipHorizontal != 0 {
+			sx = -1
+		}
+		if flags&tilemap.FlipVertical != 0 {
+			sy = -1
+		}
+		opts.GeoM.Scale(sx, sy)
+		opts.GeoM.Translate(w/2, h/2)
+	}
+	opts.GeoM.Translate(x, y+float64(r.World.Map.TileHeight)-h)
+	if l.Opacity < 1 {
+		opts.ColorScale.ScaleAlpha(float32(l.Opacity))
+	}
+	dst.DrawImage(img, opts)
+}
+
+// tile returns the part of a tileset image showing a tile, or nil
+func (r *Renderer) tile(gid tilemap.GID) *ebiten.Image {
+	id := gid.ID()
+	if id == 0 {
+		return nil
+	}
+	if img, ok := r.tiles[id]; ok {
+		return img
+	}
+
+	var sub *ebiten.Image
+	if ts, local := r.World.Map.Tileset(id); ts != nil && ts.Columns > 0 {
+		if sheet := r.image(ts.Image); sheet != nil {
+			x := ts.Margin + local%ts.Columns*(ts.TileWidth+ts.Spacing)
+			y := ts.Margin + local/ts.Columns*(ts.TileHeight+ts.Spacing)
+			sub = sheet.SubImage(image.Rect(x, y, x+ts.TileWidth, y+ts.TileHeight)).(*ebiten.Image)
+		}
+	}
+	r.tiles[id] = sub // Remember misses too, so a bad tileset is only reported once
+	return sub
+}
+
+func (r *Renderer) image(path string) *ebiten.Image {
+	if img, ok := r.images[path]; ok {
+		return img
+	}
+	var img *ebiten.Image
+	if r.Images != nil {
+		img = r.Images(path)
+	}
+	if img == nil {
+		var err error
+		if img, _, err = ebitenutil.NewImageFromFile(path); err != nil {
+			log.Printf("Failed to load tileset image %s: %v", path, err)
+			img = nil
+		} else {
+			r.loaded = append(r.loaded, img)
+		}
+	}
+	r.images[path] = img
+	return img
+}
@@ -0,0 +1,106 @@
+package render
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/Nathene/bitbase/common"
+	"github.com/Nathene/bitbase/tilemap"
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+const viewW, viewH = 1280, 720
+
+// benchWorld returns a square map n tiles across: grass everywhere, with a
+// bush on every seventh cell of the decoration layer and canopies above
+func benchWorld(b *testing.B, n int) *tilemap.World {
+	ts, err := tilemap.LoadTileset("../../assets/maps/terrain.tsx", 1)
+	if err != nil {
+		b.Fatal(err)
+	}
+	m := tilemap.New(n, n, 32, 32)
+	m.Tilesets = []*tilemap.Tileset{ts}
+	ground := m.AddTileLayer("ground")
+	decoration := m.AddTileLayer("decoration")
+	decoration.Properties["role"] = tilemap.RoleDecoration
+	above := m.AddTileLayer("above")
+	above.Properties["role"] = tilemap.RoleAbove
+	for i := range ground.Tiles {
+		ground.Tiles[i] = tilemap.GID(1 + i%2)
+		if i%7 == 0 {
+			decoration.Tiles[i] = 13
+		}
+		if i%11 == 0 {
+			above.Tiles[i] = 10
+		}
+	}
+	return tilemap.NewWorld(m, nil)
+}
+
+// BenchmarkDraw draws a 720p view in the middle of maps of growing size, with
+// and without chunk caching. Draw time should depend on the view, not the map.
+func BenchmarkDraw(b *testing.B) {
+	for _, n := range []int{64, 256, 1024, 4096} {
+		for _, noCache := range []bool{false, true} {
+			name := fmt.Sprintf("%dx%d/cached", n, n)
+			if noCache {
+				name = fmt.Sprintf("%dx%d/nocache", n, n)
+			}
+			b.Run(name, func(b *testing.B) {
+				w := benchWorld(b, n)
+				sheet := ebiten.NewImage(256, 192)
+				r := New(w)
+				r.Images = func(string) *ebiten.Image { return sheet }
+				r.NoCache = noCache
+				dst := ebiten.NewImage(viewW, viewH)
+
+				var camera common.Camera
+				camera.CenterOn(float64(n*32)/2, float64(n*32)/2, viewW, viewH)
+				view := camera.View(viewW, viewH)
+				w.Update(view.X, view.Y, view.W, view.H)
+				r.Draw(dst, camera) // Fill the cache, so the cached runs measure steady frames
+
+				b.ReportAllocs()
+				b.ResetTimer()
+				var tiles, chunks int
+				for i := 0; i < b.N; i++ {
+					r.Draw(dst, camera)
+					tiles += r.Stats.Tiles
+					chunks += r.Stats.Chunks
+				}
+				b.ReportMetric(float64(tiles)/float64(b.N), "tiles/op")
+				b.ReportMetric(float64(chunks)/float64(b.N), "chunks/op")
+			})
+		}
+	}
+}
+
+// BenchmarkDrawPan scrolls across maps of growing size, so chunks keep coming
+// into view and have to be rendered
+func BenchmarkDrawPan(b *testing.B) {
+	for _, n := range []int{256, 1024, 4096} {
+		b.Run(fmt.Sprintf("%dx%d", n, n), func(b *testing.B) {
+			w := benchWorld(b, n)
+			sheet := ebiten.NewImage(256, 192)
+			r := New(w)
+			r.Images = func(string) *ebiten.Image { return sheet }
+			dst := ebiten.NewImage(viewW, viewH)
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			var tiles, chunks, rebuilt int
+			for i := 0; i < b.N; i++ {
+				camera := common.Camera{X: float64(i*8%(n*32-viewW)) + 1, Y: float64(n*32) / 2}
+				view := camera.View(viewW, viewH)
+				w.Update(view.X, view.Y, view.W, view.H)
+				r.Draw(dst, camera)
+				tiles += r.Stats.Tiles
+				chunks += r.Stats.Chunks
+				rebuilt += r.Stats.Rebuilt
+			}
+			b.ReportMetric(float64(tiles)/float64(b.N), "tiles/op")
+			b.ReportMetric(float64(chunks)/float64(b.N), "chunks/op")
+			b.ReportMetric(float64(rebuilt)/float64(b.N), "rebuilt/op")
+		})
+	}
+}
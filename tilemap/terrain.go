@@ -0,0 +1,120 @@
+package tilemap
+
+// Terrain is what a cell is like to move through, read from the "walkable",
+// "cost", "speed", "footstep", "damage_per_second", "damage_type", "slippery",
+// "water", "deep_water" and "ladder" properties of its tiles
+type Terrain struct {
+	Walkable        bool    // False if any tile is solid, unwalkable or deep water
+	Cost            float64 // Extra pathfinding cost; the highest of any tile
+	Speed           float64 // Movement speed multiplier; the lowest of any tile, 1 normally
+	Footstep        string  // Footstep sound; the top-most tile that has one wins
+	DamagePerSecond float64 // The highest of any tile
+	DamageType      string  // Goes with the tile that deals the most damage
+	Slippery        bool
+	Water           bool
+	DeepWater       bool
+	Ladder          bool
+}
+
+// TileTerrain returns the terrain of a single tile on a layer
+func (m *Map) TileTerrain(l *Layer, gid GID) Terrain {
+	t := Terrain{Walkable: !m.Blocks(l, gid), Speed: 1}
+	tile := m.Tile(gid)
+	if tile == nil {
+		return t
+	}
+	p := tile.Properties
+	t.Cost = p.Float("cost", 0)
+	t.Speed = p.Float("speed", 1)
+	t.Footstep = p.String("footstep", "")
+	t.DamagePerSecond = p.Float("damage_per_second", 0)
+	t.DamageType = p.String("damage_type", "")
+	t.Slippery = p.Bool("slippery")
+	t.Water = p.Bool("water") || p.Bool("deep_water")
+	t.DeepWater = p.Bool("deep_water")
+	t.Ladder = p.Bool("ladder")
+	return t
+}
+
+// add combines the terrain of a tile layered over t
+func (t Terrain) add(top Terrain) Terrain {
+	t.Walkable = t.Walkable && top.Walkable
+	t.Cost = max(t.Cost, top.Cost)
+	t.Speed = min(t.Speed, top.Speed)
+	if top.Footstep != "" {
+		t.Footstep = top.Footstep
+	}
+	if top.DamagePerSecond > t.DamagePerSecond {
+		t.DamagePerSecond, t.DamageType = top.DamagePerSecond, top.DamageType
+	}
+	t.Slippery = t.Slippery || top.Slippery
+	t.Water = t.Water || top.Water
+	t.DeepWater = t.DeepWater || top.DeepWater
+	t.Ladder = t.Ladder || top.Ladder
+	return t
+}
+
+// Terrain returns what the cell at (x, y) is like, combining every tile layer.
+// Cells outside the map aren't walkable.
+func (m *Map) Terrain(x, y int) Terrain {
+	if !m.InBounds(x, y) {
+		return Terrain{Speed: 1}
+	}
+	t := Terrain{Walkable: true, Speed: 1}
+	for _, l := range m.Layers {
+		if gid := l.At(x, y); gid != 0 {
+			t = t.add(m.TileTerrain(l, gid))
+		}
+	}
+	return t
+}
+
+// Terrain returns what the cell at (x, y) is like, by the same rules as Map.Terrain
+func (w *World) Terrain(x, y int) Terrain {
+	c, lx, ly := w.cell(x, y)
+	if c == nil {
+		return Terrain{Speed: 1}
+	}
+	t := Terrain{Walkable: true, Speed: 1}
+	for i, l := range w.Layers {
+		if gid := c.At(i, lx, ly); gid != 0 {
+			t = t.add(w.Map.TileTerrain(l, gid))
+		}
+	}
+	return t
+}
+
+// Animate returns the tile an animated tile shows at time t in seconds, keeping
+// its flip flags. Tiles without an animation are returned unchanged.
+func (m *Map) Animate(gid GID, t float64) GID {
+	ts, local := m.Tileset(gid)
+	if ts == nil {
+		return gid
+	}
+	tile := ts.Tiles[local]
+	if tile == nil || len(tile.Animation) == 0 {
+		return gid
+	}
+
+	total := 0.0
+	for _, f := range tile.Animation {
+		total += f.Duration
+	}
+	if total <= 0 {
+		return gid
+	}
+	t -= total * float64(int(t/total))
+	for _, f := range tile.Animation {
+		if t < f.Duration {
+			return ts.FirstGID + GID(f.TileID) | gid.Flags()
+		}
+		t -= f.Duration
+	}
+	return ts.FirstGID + GID(tile.Animation[len(tile.Animation)-1].TileID) | gid.Flags()
+}
+
+// Animated reports whether a tile has an animation
+func (m *Map) Animated(gid GID) bool {
+	t := m.Tile(gid)
+	return t != nil && len(t.Animation) > 0
+}
@@ -0,0 +1,127 @@
+package tilemap
+
+import "testing"
+
+// Tiles in terrainMap's first tileset
+const (
+	grass GID = iota + 1
+	mud
+	shallows
+	deepWater
+	lava
+	thorns
+	ice
+	ladder
+	rock
+	plain // No properties at all
+)
+
+// terrainMap is a 1x1 map with a ground, a decoration and a collision layer.
+// Its second tileset, from gid 17, holds a tile animated through 18, 19 and 20
+// and one whose frames take no time.
+func terrainMap() *Map {
+	m := New(1, 1, 16, 16)
+	m.Tilesets = []*Tileset{
+		{FirstGID: 1, Name: "terrain", TileCount: 16, Tiles: map[int]*Tile{
+			0: {Properties: Properties{"footstep": "grass"}},
+			1: {Properties: Properties{"speed": 0.5, "cost": 2.0, "footstep": "mud"}},
+			2: {Properties: Properties{"water": true, "speed": 0.75, "footstep": "splash"}},
+			3: {Properties: Properties{"deep_water": true}},
+			4: {Properties: Properties{"damage_per_second": 10.0, "damage_type": "fire"}},
+			5: {Properties: Properties{"damage_per_second": 2.0, "damage_type": "pierce", "cost": 1.0, "speed": 0.8}},
+			6: {Properties: Properties{"slippery": true}},
+			7: {Properties: Properties{"ladder": true, "footstep": "wood"}},
+			8: {Properties: Properties{"walkable": false}},
+		}},
+		{FirstGID: 17, Name: "animated", TileCount: 8, Tiles: map[int]*Tile{
+			0: {Animation: []Frame{{TileID: 1, Duration: 0.25}, {TileID: 2, Duration: 0.5}, {TileID: 3, Duration: 0.25}}},
+			4: {Animation: []Frame{{TileID: 5}, {TileID: 6}}},
+		}},
+	}
+	m.AddTileLayer("ground")
+	m.AddTileLayer("decoration")
+	m.AddTileLayer("collision")
+	return m
+}
+
+func TestTerrain(t *testing.T) {
+	tests := []struct {
+		name  string
+		tiles []GID // Bottom layer first
+		want  Terrain
+	}{
+		{name: "empty", want: Terrain{Walkable: true, Speed: 1}},
+		{name: "grass", tiles: []GID{grass}, want: Terrain{Walkable: true, Speed: 1, Footstep: "grass"}},
+		{name: "a tile with no properties", tiles: []GID{plain}, want: Terrain{Walkable: true, Speed: 1}},
+		// The top-most footstep wins, but speed and cost don't care about order
+		{name: "mud over grass", tiles: []GID{grass, mud}, want: Terrain{Walkable: true, Speed: 0.5, Cost: 2, Footstep: "mud"}},
+		{name: "grass over mud", tiles: []GID{mud, grass}, want: Terrain{Walkable: true, Speed: 0.5, Cost: 2, Footstep: "grass"}},
+		{name: "silent tile over mud", tiles: []GID{mud, plain}, want: Terrain{Walkable: true, Speed: 0.5, Cost: 2, Footstep: "mud"}},
+		{name: "thorns over mud", tiles: []GID{mud, thorns}, want: Terrain{Walkable: true, Speed: 0.5, Cost: 2, Footstep: "mud", DamagePerSecond: 2, DamageType: "pierce"}},
+		// The worst damage wins and brings its type along
+		{name: "thorns over lava", tiles: []GID{lava, thorns}, want: Terrain{Walkable: true, Speed: 0.8, Cost: 1, DamagePerSecond: 10, DamageType: "fire"}},
+		{name: "lava over thorns", tiles: []GID{thorns, lava}, want: Terrain{Walkable: true, Speed: 0.8, Cost: 1, DamagePerSecond: 10, DamageType: "fire"}},
+		{name: "shallows", tiles: []GID{shallows}, want: Terrain{Walkable: true, Speed: 0.75, Footstep: "splash", Water: true}},
+		{name: "deep water", tiles: []GID{deepWater}, want: Terrain{Speed: 1, Water: true, DeepWater: true}},
+		{name: "ladder over ice", tiles: []GID{ice, ladder}, want: Terrain{Walkable: true, Speed: 1, Footstep: "wood", Slippery: true, Ladder: true}},
+		{name: "rock on grass", tiles: []GID{grass, rock}, want: Terrain{Speed: 1, Footstep: "grass"}},
+		{name: "anything on the collision layer", tiles: []GID{grass, 0, plain}, want: Terrain{Speed: 1, Footstep: "grass"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := terrainMap()
+			for i, gid := range tt.tiles {
+				m.Layers[i].Set(0, 0, gid)
+			}
+			if got := m.Terrain(0, 0); got != tt.want {
+				t.Errorf("map terrain %+v, want %+v", got, tt.want)
+			}
+			if got := NewWorld(m, nil).Terrain(0, 0); got != tt.want {
+				t.Errorf("world terrain %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	// Nothing outside the map can be walked on
+	m := terrainMap()
+	for _, p := range [][2]int{{-1, 0}, {1, 0}, {0, -1}, {0, 1}} {
+		if got := m.Terrain(p[0], p[1]); got != (Terrain{Speed: 1}) {
+			t.Errorf("terrain at %v is %+v", p, got)
+		}
+	}
+}
+
+func TestAnimate(t *testing.T) {
+	m := terrainMap()
+	tests := []struct {
+		gid  GID
+		t    float64
+		want GID
+	}{
+		{17, 0, 18},
+		{17, 0.2, 18},
+		{17, 0.25, 19},
+		{17, 0.7, 19},
+		{17, 0.75, 20},
+		{17, 0.99, 20},
+		{17, 1, 18},   // Loops
+		{17, 2.3, 19}, // Many loops in
+		{17 | FlipHorizontal | FlipDiagonal, 0.8, 20 | FlipHorizontal | FlipDiagonal},
+		{21, 5, 21},         // Frames without durations never change
+		{18, 0.5, 18},       // Not animated
+		{grass, 0.5, grass}, // From a tileset without animations
+		{0, 0.5, 0},
+		{99, 0.5, 99}, // Past the last tileset
+	}
+	for _, tt := range tests {
+		if got := m.Animate(tt.gid, tt.t); got != tt.want {
+			t.Errorf("gid %#x at %vs shows %#x, want %#x", tt.gid, tt.t, got, tt.want)
+		}
+	}
+
+	for gid, want := range map[GID]bool{17: true, 17 | FlipVertical: true, 21: true, 18: false, grass: false, 0: false} {
+		if got := m.Animated(gid); got != want {
+			t.Errorf("Animated(%#x) = %v, want %v", gid, got, want)
+		}
+	}
+}
@@ -0,0 +1,462 @@
+package tilemap
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// The json* types mirror TMJ objects
+
+type jsonProperty struct {
+	Name  string `json:"name"`
+	Type  string `json:"type"`
+	Value any    `json:"value"`
+}
+
+type jsonObject struct {
+	ID         int            `json:"id"`
+	Name       string         `json:"name"`
+	Type       string         `json:"type"`
+	Class      string         `json:"class"`
+	X          float64        `json:"x"`
+	Y          float64        `json:"y"`
+	Width      float64        `json:"width"`
+	Height     float64        `json:"height"`
+	Rotation   float64        `json:"rotation"`
+	GID        GID            `json:"gid"`
+	Visible    *bool          `json:"visible"`
+	Ellipse    bool           `json:"ellipse"`
+	Point      bool           `json:"point"`
+	Polygon    []jsonPoint    `json:"polygon"`
+	Polyline   []jsonPoint    `json:"polyline"`
+	Properties []jsonProperty `json:"properties"`
+}
+
+type jsonPoint struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+type jsonLayer struct {
+	ID          int            `json:"id"`
+	Name        string         `json:"name"`
+	Class       string         `json:"class"`
+	Type        string         `json:"type"`
+	Width       int            `json:"width"`
+	Height      int            `json:"height"`
+	Visible     *bool          `json:"visible"`
+	Opacity     *float64       `json:"opacity"`
+	OffsetX     float64        `json:"offsetx"`
+	OffsetY     float64        `json:"offsety"`
+	Properties  []jsonProperty `json:"properties"`
+	Encoding    string         `json:"encoding"`
+	Compression string         `json:"compression"`
+	Data        any            `json:"data"` // Array of GIDs, or a base64 string
+	Chunks      []any          `json:"chunks"`
+	Objects     []jsonObject   `json:"objects"`
+	Image       string         `json:"image"`
+	Layers      []jsonLayer    `json:"layers"`
+}
+
+type jsonTileset struct {
+	FirstGID    GID            `json:"firstgid"`
+	Source      string         `json:"source"`
+	Name        string         `json:"name"`
+	Class       string         `json:"class"`
+	TileWidth   int            `json:"tilewidth"`
+	TileHeight  int            `json:"tileheight"`
+	Spacing     int            `json:"spacing"`
+	Margin      int            `json:"margin"`
+	TileCount   int            `json:"tilecount"`
+	Columns     int            `json:"columns"`
+	Image       string         `json:"image"`
+	ImageWidth  int            `json:"imagewidth"`
+	ImageHeight int            `json:"imageheight"`
+	Properties  []jsonProperty `json:"properties"`
+	Tiles       []struct {
+		ID          int            `json:"id"`
+		Type        string         `json:"type"`
+		Class       string         `json:"class"`
+		Properties  []jsonProperty `json:"properties"`
+		ObjectGroup *struct {
+			Objects []jsonObject `json:"objects"`
+		} `json:"objectgroup"`
+		Animation []struct {
+			TileID   int `json:"tileid"`
+			Duration int `json:"duration"` // Milliseconds
+		} `json:"animation"`
+	} `json:"tiles"`
+	Wangsets []struct {
+		Name   string `json:"name"`
+		Type   string `json:"type"`
+		Colors []struct {
+			Name string `json:"name"`
+		} `json:"colors"`
+		Tiles []struct {
+			TileID int   `json:"tileid"`
+			WangID []int `json:"wangid"`
+		} `json:"wangtiles"`
+	} `json:"wangsets"`
+}
+
+type jsonMap struct {
+	Width           int            `json:"width"`
+	Height          int            `json:"height"`
+	TileWidth       int            `json:"tilewidth"`
+	TileHeight      int            `json:"tileheight"`
+	Orientation     string         `json:"orientation"`
+	BackgroundColor string         `json:"backgroundcolor"`
+	Infinite        bool           `json:"infinite"`
+	Properties      []jsonProperty `json:"properties"`
+}
+
+// rawAt is a JSON value along with the byte offset it starts at
+type rawAt struct {
+	json.RawMessage
+	offset int64
+}
+
+// ParseTMJ reads a map in Tiled's JSON format. path is used to resolve external
+// tilesets and images and to label errors.
+func ParseTMJ(data []byte, path string) (*Map, error) {
+	top, err := splitObject(data, "layers", "tilesets")
+	if err != nil {
+		return nil, jsonError(path, data, 0, err)
+	}
+
+	var jm jsonMap
+	if err := json.Unmarshal(data, &jm); err != nil {
+		return nil, jsonError(path, data, 0, err)
+	}
+	if jm.Infinite {
+		return nil, &Error{Path: path, Line: 1, Err: fmt.Errorf("%w: infinite maps", ErrUnsupported)}
+	}
+	m := &Map{
+		Path:            path,
+		Width:           jm.Width,
+		Height:          jm.Height,
+		TileWidth:       jm.TileWidth,
+		TileHeight:      jm.TileHeight,
+		Orientation:     jm.Orientation,
+		BackgroundColor: jm.BackgroundColor,
+	}
+	if m.Orientation == "" {
+		m.Orientation = "orthogonal"
+	}
+	if err := m.validate(); err != nil {
+		return nil, &Error{Path: path, Line: 1, Err: err}
+	}
+	if m.Properties, err = jsonProperties(jm.Properties); err != nil {
+		return nil, &Error{Path: path, Line: 1, Err: err}
+	}
+
+	for _, raw := range top["tilesets"] {
+		var jt jsonTileset
+		if err := json.Unmarshal(raw.RawMessage, &jt); err != nil {
+			return nil, jsonError(path, data, raw.offset, err)
+		}
+		ts, err := tmjTileset(jt, path)
+		if err != nil {
+			var tilesetErr *Error
+			if errors.As(err, &tilesetErr) {
+				return nil, err
+			}
+			return nil, &Error{Path: path, Line: lineAt(data, raw.offset), Err: err}
+		}
+		m.Tilesets = append(m.Tilesets, ts)
+	}
+	for _, raw := range top["layers"] {
+		var jl jsonLayer
+		if err := json.Unmarshal(raw.RawMessage, &jl); err != nil {
+			return nil, jsonError(path, data, raw.offset, err)
+		}
+		if err := m.addTMJLayer(jl, path, 0, 0, 1, true); err != nil {
+			return nil, &Error{Path: path, Line: lineAt(data, raw.offset), Err: err}
+		}
+	}
+	return m, nil
+}
+
+// parseTSJ reads an external tileset in Tiled's JSON format
+func parseTSJ(data []byte, path string) (*Tileset, error) {
+	var jt jsonTileset
+	if err := json.Unmarshal(data, &jt); err != nil {
+		return nil, jsonError(path, data, 0, err)
+	}
+	jt.Source = ""
+	ts, err := tmjTileset(jt, path)
+	if err != nil {
+		return nil, &Error{Path: path, Line: 1, Err: err}
+	}
+	return ts, nil
+}
+
+// splitObject walks a top-level JSON object and returns the elements of the
+// named array fields with their offsets, so errors in them can name a line
+func splitObject(data []byte, arrays ...string) (map[string][]rawAt, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if tok, err := dec.Token(); err != nil {
+		return nil, err
+	} else if tok != json.Delim('{') {
+		return nil, errors.New("map is not a JSON object")
+	}
+
+	out := make(map[string][]rawAt)
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		key, _ := tok.(string)
+		wanted := false
+		for _, a := range arrays {
+			wanted = wanted || a == key
+		}
+		if !wanted {
+			var skip json.RawMessage
+			if err := dec.Decode(&skip); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if tok, err := dec.Token(); err != nil {
+			return nil, err
+		} else if tok != json.Delim('[') {
+			return nil, &json.UnmarshalTypeError{Value: fmt.Sprint(tok), Field: key, Offset: dec.InputOffset()}
+		}
+		for dec.More() {
+			var raw rawAt
+			raw.offset = dec.InputOffset()
+			if err := dec.Decode(&raw.RawMessage); err != nil {
+				return nil, err
+			}
+			// InputOffset sits on the separator before the element, so skip to the value itself
+			raw.offset += int64(len(data[raw.offset:]) - len(bytes.TrimLeft(data[raw.offset:], ", \t\r\n")))
+			out[key] = append(out[key], raw)
+		}
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// jsonError wraps a decoding error with the line it happened on. base is the
+// offset of the value that was being decoded.
+func jsonError(path string, data []byte, base int64, err error) error {
+	var syntax *json.SyntaxError
+	var typ *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntax):
+		return &Error{Path: path, Line: lineAt(data, base+syntax.Offset), Err: err}
+	case errors.As(err, &typ):
+		return &Error{Path: path, Line: lineAt(data, base+typ.Offset), Err: err}
+	case errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF):
+		return &Error{Path: path, Line: lineAt(data, int64(len(data))), Err: errors.New("unexpected end of file")}
+	}
+	return &Error{Path: path, Line: lineAt(data, base), Err: err}
+}
+
+// addTMJLayer converts a layer, flattening groups into the map's layer list
+func (m *Map) addTMJLayer(j jsonLayer, path string, offsetX, offsetY, opacity float64, visible bool) error {
+	props, err := jsonProperties(j.Properties)
+	if err != nil {
+		return fmt.Errorf("layer %q: %w", j.Name, err)
+	}
+	l := &Layer{
+		ID:         j.ID,
+		Name:       j.Name,
+		Class:      j.Class,
+		Visible:    visible && (j.Visible == nil || *j.Visible),
+		Opacity:    opacity,
+		OffsetX:    offsetX + j.OffsetX,
+		OffsetY:    offsetY + j.OffsetY,
+		Properties: props,
+	}
+	if j.Opacity != nil {
+		l.Opacity *= *j.Opacity
+	}
+
+	switch j.Type {
+	case "group":
+		for _, child := range j.Layers {
+			if err := m.addTMJLayer(child, path, l.OffsetX, l.OffsetY, l.Opacity, l.Visible); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "tilelayer":
+		l.Kind = TileLayer
+		l.Width, l.Height = j.Width, j.Height
+		if l.Width != m.Width || l.Height != m.Height {
+			return fmt.Errorf("layer %q is %dx%d, map is %dx%d", j.Name, l.Width, l.Height, m.Width, m.Height)
+		}
+		if len(j.Chunks) > 0 {
+			return fmt.Errorf("layer %q: %w: chunked data", j.Name, ErrUnsupported)
+		}
+		count := l.Width * l.Height
+		switch data := j.Data.(type) {
+		case []any:
+			if len(data) != count {
+				return fmt.Errorf("layer %q has %d cells, want %d", j.Name, len(data), count)
+			}
+			l.Tiles = make([]GID, count)
+			for i, v := range data {
+				f, ok := v.(float64)
+				if !ok || f < 0 || f != float64(uint32(f)) {
+					return fmt.Errorf("layer %q: cell %d: bad tile %v", j.Name, i, v)
+				}
+				l.Tiles[i] = GID(f)
+			}
+		case string:
+			if l.Tiles, err = decodeBase64(j.Compression, data, count); err != nil {
+				return fmt.Errorf("layer %q: %w", j.Name, err)
+			}
+		default:
+			return fmt.Errorf("layer %q has no data", j.Name)
+		}
+	case "objectgroup":
+		l.Kind = ObjectLayer
+		for _, jo := range j.Objects {
+			o, err := tmjObject(jo)
+			if err != nil {
+				return fmt.Errorf("layer %q: %w", j.Name, err)
+			}
+			l.Objects = append(l.Objects, o)
+		}
+	case "imagelayer":
+		l.Kind = ImageLayer
+		l.Image = resolve(path, j.Image)
+	default:
+		return fmt.Errorf("layer %q has unknown type %q", j.Name, j.Type)
+	}
+	m.Layers = append(m.Layers, l)
+	return nil
+}
+
+func tmjObject(j jsonObject) (*Object, error) {
+	props, err := jsonProperties(j.Properties)
+	if err != nil {
+		return nil, fmt.Errorf("object %d: %w", j.ID, err)
+	}
+	o := &Object{
+		ID:         j.ID,
+		Name:       j.Name,
+		Type:       j.Type,
+		X:          j.X,
+		Y:          j.Y,
+		Width:      j.Width,
+		Height:     j.Height,
+		Rotation:   j.Rotation,
+		GID:        j.GID,
+		Visible:    j.Visible == nil || *j.Visible,
+		Properties: props,
+	}
+	if o.Type == "" {
+		o.Type = j.Class
+	}
+	switch {
+	case j.Ellipse:
+		o.Shape = Ellipse
+	case j.Point:
+		o.Shape = PointShape
+	case j.Polygon != nil:
+		o.Shape = Polygon
+		o.Points = jsonPoints(j.Polygon)
+	case j.Polyline != nil:
+		o.Shape = Polyline
+		o.Points = jsonPoints(j.Polyline)
+	}
+	return o, nil
+}
+
+func jsonPoints(points []jsonPoint) [][2]float64 {
+	out := make([][2]float64, len(points))
+	for i, p := range points {
+		out[i] = [2]float64{p.X, p.Y}
+	}
+	return out
+}
+
+// tmjTileset converts an embedded tileset, or loads the external one it points at
+func tmjTileset(j jsonTileset, path string) (*Tileset, error) {
+	if j.Source != "" {
+		return LoadTileset(resolve(path, j.Source), j.FirstGID)
+	}
+	props, err := jsonProperties(j.Properties)
+	if err != nil {
+		return nil, fmt.Errorf("tileset %q: %w", j.Name, err)
+	}
+	ts := &Tileset{
+		FirstGID:    j.FirstGID,
+		Name:        j.Name,
+		Class:       j.Class,
+		TileWidth:   j.TileWidth,
+		TileHeight:  j.TileHeight,
+		Spacing:     j.Spacing,
+		Margin:      j.Margin,
+		TileCount:   j.TileCount,
+		Columns:     j.Columns,
+		Image:       resolve(path, j.Image),
+		ImageWidth:  j.ImageWidth,
+		ImageHeight: j.ImageHeight,
+		Properties:  props,
+		Tiles:       make(map[int]*Tile),
+	}
+	for _, jt := range j.Tiles {
+		t := &Tile{ID: jt.ID, Type: jt.Type}
+		if t.Type == "" {
+			t.Type = jt.Class
+		}
+		if t.Properties, err = jsonProperties(jt.Properties); err != nil {
+			return nil, fmt.Errorf("tileset %q tile %d: %w", j.Name, jt.ID, err)
+		}
+		if jt.ObjectGroup != nil {
+			for _, jo := range jt.ObjectGroup.Objects {
+				o, err := tmjObject(jo)
+				if err != nil {
+					return nil, fmt.Errorf("tileset %q tile %d: %w", j.Name, jt.ID, err)
+				}
+				t.Collision = append(t.Collision, o)
+			}
+		}
+		for _, f := range jt.Animation {
+			t.Animation = append(t.Animation, Frame{TileID: f.TileID, Duration: float64(f.Duration) / 1000})
+		}
+		ts.Tiles[t.ID] = t
+	}
+	for _, jw := range j.Wangsets {
+		ws := &Wangset{Name: jw.Name, Type: jw.Type, Tiles: make(map[int][8]int)}
+		for _, c := range jw.Colors {
+			ws.Colors = append(ws.Colors, c.Name)
+		}
+		for _, wt := range jw.Tiles {
+			fields := make([]string, len(wt.WangID))
+			for i, v := range wt.WangID {
+				fields[i] = strconv.Itoa(v)
+			}
+			if ws.Tiles[wt.TileID], err = parseWangID(fields); err != nil {
+				return nil, fmt.Errorf("tileset %q wangset %q tile %d: %w", j.Name, jw.Name, wt.TileID, err)
+			}
+		}
+		ts.Wangsets = append(ts.Wangsets, ws)
+	}
+	return ts, finishTileset(ts)
+}
+
+func jsonProperties(js []jsonProperty) (Properties, error) {
+	props := Properties{}
+	for _, j := range js {
+		v, err := convertProperty(j.Type, j.Value)
+		if err != nil {
+			return nil, fmt.Errorf("property %q: %w", j.Name, err)
+		}
+		props[j.Name] = v
+	}
+	return props, nil
+}
@@ -0,0 +1,480 @@
+package tilemap
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// The xml* types mirror TMX elements. They are decoded one child of <map> at a
+// time so errors can carry the line of the element that caused them.
+
+type xmlProperty struct {
+	Name       string        `xml:"name,attr"`
+	Type       string        `xml:"type,attr"`
+	Value      *string       `xml:"value,attr"`
+	Text       string        `xml:",chardata"` // Multi-line strings
+	Properties []xmlProperty `xml:"properties>property"`
+}
+
+type xmlImage struct {
+	Source string `xml:"source,attr"`
+	Width  int    `xml:"width,attr"`
+	Height int    `xml:"height,attr"`
+}
+
+type xmlData struct {
+	Encoding    string `xml:"encoding,attr"`
+	Compression string `xml:"compression,attr"`
+	Text        string `xml:",chardata"`
+	Tiles       []struct {
+		GID GID `xml:"gid,attr"`
+	} `xml:"tile"`
+	Chunks []struct{} `xml:"chunk"`
+}
+
+type xmlObject struct {
+	ID         int           `xml:"id,attr"`
+	Name       string        `xml:"name,attr"`
+	Type       string        `xml:"type,attr"`
+	Class      string        `xml:"class,attr"`
+	X          float64       `xml:"x,attr"`
+	Y          float64       `xml:"y,attr"`
+	Width      float64       `xml:"width,attr"`
+	Height     float64       `xml:"height,attr"`
+	Rotation   float64       `xml:"rotation,attr"`
+	GID        GID           `xml:"gid,attr"`
+	Visible    *int          `xml:"visible,attr"`
+	Ellipse    *struct{}     `xml:"ellipse"`
+	Point      *struct{}     `xml:"point"`
+	Polygon    *xmlPoints    `xml:"polygon"`
+	Polyline   *xmlPoints    `xml:"polyline"`
+	Properties []xmlProperty `xml:"properties>property"`
+}
+
+type xmlPoints struct {
+	Points string `xml:"points,attr"`
+}
+
+// xmlLayer holds any of <layer>, <objectgroup>, <imagelayer> and <group>
+type xmlLayer struct {
+	XMLName    xml.Name
+	ID         int           `xml:"id,attr"`
+	Name       string        `xml:"name,attr"`
+	Class      string        `xml:"class,attr"`
+	Width      int           `xml:"width,attr"`
+	Height     int           `xml:"height,attr"`
+	Visible    *int          `xml:"visible,attr"`
+	Opacity    *float64      `xml:"opacity,attr"`
+	OffsetX    float64       `xml:"offsetx,attr"`
+	OffsetY    float64       `xml:"offsety,attr"`
+	Properties []xmlProperty `xml:"properties>property"`
+	Data       *xmlData      `xml:"data"`
+	Objects    []xmlObject   `xml:"object"`
+	Image      *xmlImage     `xml:"image"`
+	Children   []xmlLayer    `xml:",any"`
+}
+
+type xmlTile struct {
+	ID          int           `xml:"id,attr"`
+	Type        string        `xml:"type,attr"`
+	Class       string        `xml:"class,attr"`
+	Properties  []xmlProperty `xml:"properties>property"`
+	ObjectGroup *struct {
+		Objects []xmlObject `xml:"object"`
+	} `xml:"objectgroup"`
+	Animation []struct {
+		TileID   int `xml:"tileid,attr"`
+		Duration int `xml:"duration,attr"` // Milliseconds
+	} `xml:"animation>frame"`
+}
+
+type xmlWangset struct {
+	Name   string `xml:"name,attr"`
+	Type   string `xml:"type,attr"`
+	Colors []struct {
+		Name string `xml:"name,attr"`
+	} `xml:"wangcolor"`
+	Tiles []struct {
+		TileID int    `xml:"tileid,attr"`
+		WangID string `xml:"wangid,attr"`
+	} `xml:"wangtile"`
+}
+
+type xmlTileset struct {
+	FirstGID   GID           `xml:"firstgid,attr"`
+	Source     string        `xml:"source,attr"`
+	Name       string        `xml:"name,attr"`
+	Class      string        `xml:"class,attr"`
+	TileWidth  int           `xml:"tilewidth,attr"`
+	TileHeight int           `xml:"tileheight,attr"`
+	Spacing    int           `xml:"spacing,attr"`
+	Margin     int           `xml:"margin,attr"`
+	TileCount  int           `xml:"tilecount,attr"`
+	Columns    int           `xml:"columns,attr"`
+	Image      *xmlImage     `xml:"image"`
+	Properties []xmlProperty `xml:"properties>property"`
+	Tiles      []xmlTile     `xml:"tile"`
+	Wangsets   []xmlWangset  `xml:"wangsets>wangset"`
+}
+
+// ParseTMX reads a map in Tiled's XML format. path is used to resolve external
+// tilesets and images and to label errors.
+func ParseTMX(data []byte, path string) (*Map, error) {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	fail := func(offset int64, err error) (*Map, error) {
+		return nil, &Error{Path: path, Line: lineAt(data, offset), Err: err}
+	}
+
+	root, err := findRoot(dec, "map")
+	if err != nil {
+		return nil, xmlError(path, data, dec, err)
+	}
+	m := &Map{Path: path, Orientation: "orthogonal", Properties: Properties{}}
+	infinite := false
+	for _, a := range root.Attr {
+		switch a.Name.Local {
+		case "width":
+			_, err = fmt.Sscan(a.Value, &m.Width)
+		case "height":
+			_, err = fmt.Sscan(a.Value, &m.Height)
+		case "tilewidth":
+			_, err = fmt.Sscan(a.Value, &m.TileWidth)
+		case "tileheight":
+			_, err = fmt.Sscan(a.Value, &m.TileHeight)
+		case "orientation":
+			m.Orientation = a.Value
+		case "backgroundcolor":
+			m.BackgroundColor = a.Value
+		case "infinite":
+			infinite = a.Value == "1"
+		}
+		if err != nil {
+			return fail(dec.InputOffset(), fmt.Errorf("map attribute %s: bad number %q", a.Name.Local, a.Value))
+		}
+	}
+	rootOffset := dec.InputOffset()
+	if infinite {
+		return fail(rootOffset, fmt.Errorf("%w: infinite maps", ErrUnsupported))
+	}
+	if err := m.validate(); err != nil {
+		return fail(rootOffset, err)
+	}
+
+	for {
+		offset := dec.InputOffset()
+		tok, err := dec.Token()
+		if err != nil {
+			if err == io.EOF {
+				return fail(offset, errors.New("unexpected end of file inside <map>"))
+			}
+			return nil, xmlError(path, data, dec, err)
+		}
+		if _, ok := tok.(xml.EndElement); ok {
+			return m, nil
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		// The element starts just before the decoder's position after its start tag
+		line := lineAt(data, offset+int64(len(leadingSpace(data[offset:]))))
+
+		switch start.Name.Local {
+		case "properties":
+			var props struct {
+				Properties []xmlProperty `xml:"property"`
+			}
+			if err := dec.DecodeElement(&props, &start); err != nil {
+				return nil, xmlError(path, data, dec, err)
+			}
+			if m.Properties, err = xmlProperties(props.Properties); err != nil {
+				return nil, &Error{Path: path, Line: line, Err: err}
+			}
+		case "tileset":
+			var x xmlTileset
+			if err := dec.DecodeElement(&x, &start); err != nil {
+				return nil, xmlError(path, data, dec, err)
+			}
+			ts, err := tmxTileset(x, path)
+			if err != nil {
+				var tilesetErr *Error
+				if errors.As(err, &tilesetErr) {
+					return nil, err
+				}
+				return nil, &Error{Path: path, Line: line, Err: err}
+			}
+			m.Tilesets = append(m.Tilesets, ts)
+		case "layer", "objectgroup", "imagelayer", "group":
+			var x xmlLayer
+			if err := dec.DecodeElement(&x, &start); err != nil {
+				return nil, xmlError(path, data, dec, err)
+			}
+			x.XMLName = start.Name
+			if err := m.addTMXLayer(x, path, 0, 0, 1, true); err != nil {
+				return nil, &Error{Path: path, Line: line, Err: err}
+			}
+		default:
+			if err := dec.Skip(); err != nil {
+				return nil, xmlError(path, data, dec, err)
+			}
+		}
+	}
+}
+
+// parseTSX reads an external tileset in Tiled's XML format
+func parseTSX(data []byte, path string) (*Tileset, error) {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	start, err := findRoot(dec, "tileset")
+	if err != nil {
+		return nil, xmlError(path, data, dec, err)
+	}
+	line := lineAt(data, dec.InputOffset())
+	var x xmlTileset
+	if err := dec.DecodeElement(&x, &start); err != nil {
+		return nil, xmlError(path, data, dec, err)
+	}
+	x.Source = ""
+	ts, err := tmxTileset(x, path)
+	if err != nil {
+		return nil, &Error{Path: path, Line: line, Err: err}
+	}
+	return ts, nil
+}
+
+// findRoot skips the prolog and returns the document's root element, which must be called name
+func findRoot(dec *xml.Decoder, name string) (xml.StartElement, error) {
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return xml.StartElement{}, fmt.Errorf("no <%s> element", name)
+		}
+		if err != nil {
+			return xml.StartElement{}, err
+		}
+		if start, ok := tok.(xml.StartElement); ok {
+			if start.Name.Local != name {
+				return start, fmt.Errorf("root element is <%s>, want <%s>", start.Name.Local, name)
+			}
+			return start, nil
+		}
+	}
+}
+
+// xmlError wraps a decoding error with the line it happened on
+func xmlError(path string, data []byte, dec *xml.Decoder, err error) error {
+	var syntax *xml.SyntaxError
+	if errors.As(err, &syntax) {
+		return &Error{Path: path, Line: syntax.Line, Err: errors.New(syntax.Msg)}
+	}
+	return &Error{Path: path, Line: lineAt(data, dec.InputOffset()), Err: err}
+}
+
+func leadingSpace(b []byte) []byte {
+	return b[:len(b)-len(bytes.TrimLeft(b, " \t\r\n"))]
+}
+
+// addTMXLayer converts a layer, flattening groups into the map's layer list
+func (m *Map) addTMXLayer(x xmlLayer, path string, offsetX, offsetY, opacity float64, visible bool) error {
+	props, err := xmlProperties(x.Properties)
+	if err != nil {
+		return fmt.Errorf("layer %q: %w", x.Name, err)
+	}
+	l := &Layer{
+		ID:         x.ID,
+		Name:       x.Name,
+		Class:      x.Class,
+		Visible:    visible && (x.Visible == nil || *x.Visible != 0),
+		Opacity:    opacity,
+		OffsetX:    offsetX + x.OffsetX,
+		OffsetY:    offsetY + x.OffsetY,
+		Properties: props,
+	}
+	if x.Opacity != nil {
+		l.Opacity *= *x.Opacity
+	}
+
+	switch x.XMLName.Local {
+	case "group":
+		for _, child := range x.Children {
+			switch child.XMLName.Local {
+			case "layer", "objectgroup", "imagelayer", "group":
+				if err := m.addTMXLayer(child, path, l.OffsetX, l.OffsetY, l.Opacity, l.Visible); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	case "layer":
+		l.Kind = TileLayer
+		l.Width, l.Height = x.Width, x.Height
+		if l.Width != m.Width || l.Height != m.Height {
+			return fmt.Errorf("layer %q is %dx%d, map is %dx%d", x.Name, l.Width, l.Height, m.Width, m.Height)
+		}
+		if x.Data == nil {
+			return fmt.Errorf("layer %q has no data", x.Name)
+		}
+		if len(x.Data.Chunks) > 0 {
+			return fmt.Errorf("layer %q: %w: chunked data", x.Name, ErrUnsupported)
+		}
+		count := l.Width * l.Height
+		if x.Data.Encoding == "" {
+			if len(x.Data.Tiles) != count {
+				return fmt.Errorf("layer %q has %d cells, want %d", x.Name, len(x.Data.Tiles), count)
+			}
+			l.Tiles = make([]GID, count)
+			for i, t := range x.Data.Tiles {
+				l.Tiles[i] = t.GID
+			}
+		} else if l.Tiles, err = decodeTiles(x.Data.Encoding, x.Data.Compression, x.Data.Text, count); err != nil {
+			return fmt.Errorf("layer %q: %w", x.Name, err)
+		}
+	case "objectgroup":
+		l.Kind = ObjectLayer
+		for _, xo := range x.Objects {
+			o, err := tmxObject(xo)
+			if err != nil {
+				return fmt.Errorf("layer %q: %w", x.Name, err)
+			}
+			l.Objects = append(l.Objects, o)
+		}
+	case "imagelayer":
+		l.Kind = ImageLayer
+		if x.Image != nil {
+			l.Image = resolve(path, x.Image.Source)
+		}
+	}
+	m.Layers = append(m.Layers, l)
+	return nil
+}
+
+func tmxObject(x xmlObject) (*Object, error) {
+	props, err := xmlProperties(x.Properties)
+	if err != nil {
+		return nil, fmt.Errorf("object %d: %w", x.ID, err)
+	}
+	o := &Object{
+		ID:         x.ID,
+		Name:       x.Name,
+		Type:       x.Type,
+		X:          x.X,
+		Y:          x.Y,
+		Width:      x.Width,
+		Height:     x.Height,
+		Rotation:   x.Rotation,
+		GID:        x.GID,
+		Visible:    x.Visible == nil || *x.Visible != 0,
+		Properties: props,
+	}
+	if o.Type == "" {
+		o.Type = x.Class
+	}
+	switch {
+	case x.Ellipse != nil:
+		o.Shape = Ellipse
+	case x.Point != nil:
+		o.Shape = PointShape
+	case x.Polygon != nil:
+		o.Shape = Polygon
+		o.Points, err = parsePoints(x.Polygon.Points)
+	case x.Polyline != nil:
+		o.Shape = Polyline
+		o.Points, err = parsePoints(x.Polyline.Points)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("object %d: %w", x.ID, err)
+	}
+	return o, nil
+}
+
+// tmxTileset converts an embedded tileset, or loads the external one it points at
+func tmxTileset(x xmlTileset, path string) (*Tileset, error) {
+	if x.Source != "" {
+		return LoadTileset(resolve(path, x.Source), x.FirstGID)
+	}
+	props, err := xmlProperties(x.Properties)
+	if err != nil {
+		return nil, fmt.Errorf("tileset %q: %w", x.Name, err)
+	}
+	ts := &Tileset{
+		FirstGID:   x.FirstGID,
+		Name:       x.Name,
+		Class:      x.Class,
+		TileWidth:  x.TileWidth,
+		TileHeight: x.TileHeight,
+		Spacing:    x.Spacing,
+		Margin:     x.Margin,
+		TileCount:  x.TileCount,
+		Columns:    x.Columns,
+		Properties: props,
+		Tiles:      make(map[int]*Tile),
+	}
+	if x.Image != nil {
+		ts.Image = resolve(path, x.Image.Source)
+		ts.ImageWidth, ts.ImageHeight = x.Image.Width, x.Image.Height
+	}
+	for _, xt := range x.Tiles {
+		t := &Tile{ID: xt.ID, Type: xt.Type}
+		if t.Type == "" {
+			t.Type = xt.Class
+		}
+		if t.Properties, err = xmlProperties(xt.Properties); err != nil {
+			return nil, fmt.Errorf("tileset %q tile %d: %w", x.Name, xt.ID, err)
+		}
+		if xt.ObjectGroup != nil {
+			for _, xo := range xt.ObjectGroup.Objects {
+				o, err := tmxObject(xo)
+				if err != nil {
+					return nil, fmt.Errorf("tileset %q tile %d: %w", x.Name, xt.ID, err)
+				}
+				t.Collision = append(t.Collision, o)
+			}
+		}
+		for _, f := range xt.Animation {
+			t.Animation = append(t.Animation, Frame{TileID: f.TileID, Duration: float64(f.Duration) / 1000})
+		}
+		ts.Tiles[t.ID] = t
+	}
+	for _, xw := range x.Wangsets {
+		ws := &Wangset{Name: xw.Name, Type: xw.Type, Tiles: make(map[int][8]int)}
+		for _, c := range xw.Colors {
+			ws.Colors = append(ws.Colors, c.Name)
+		}
+		for _, wt := range xw.Tiles {
+			if ws.Tiles[wt.TileID], err = parseWangID(strings.Split(wt.WangID, ",")); err != nil {
+				return nil, fmt.Errorf("tileset %q wangset %q tile %d: %w", x.Name, xw.Name, wt.TileID, err)
+			}
+		}
+		ts.Wangsets = append(ts.Wangsets, ws)
+	}
+	return ts, finishTileset(ts)
+}
+
+func xmlProperties(xs []xmlProperty) (Properties, error) {
+	props := Properties{}
+	for _, x := range xs {
+		if x.Type == "class" {
+			nested, err := xmlProperties(x.Properties)
+			if err != nil {
+				return nil, fmt.Errorf("property %q: %w", x.Name, err)
+			}
+			props[x.Name] = nested
+			continue
+		}
+		value := x.Text
+		if x.Value != nil {
+			value = *x.Value
+		} else {
+			value = strings.TrimSpace(value)
+		}
+		v, err := parseProperty(x.Type, value)
+		if err != nil {
+			return nil, fmt.Errorf("property %q: %w", x.Name, err)
+		}
+		props[x.Name] = v
+	}
+	return props, nil
+}
@@ -0,0 +1,397 @@
+package tilemap
+
+import (
+	"container/list"
+	"log"
+	"math"
+)
+
+// Defaults for a World's chunking
+const (
+	DefaultChunkSize = 32
+	DefaultCacheSize = 64 // About four screens of 32x32 chunks of 32px tiles
+	DefaultMargin    = 1  // Chunks beyond the view to load ahead of the camera
+	loadWorkers      = 2
+	requestQueue     = 64
+)
+
+// World is a tile map split into chunks that are loaded in the background as
+// the camera nears them and kept in an LRU cache. Cell queries work across
+// chunk boundaries and load a missing chunk on the spot, so callers never see
+// the chunking. Everything except loading must happen on one goroutine.
+type World struct {
+	Map           *Map     // Tilesets, tile size and layer properties
+	Layers        []*Layer // The tile layers, bottom first; chunks hold one slice per entry
+	Width, Height int      // Size in tiles; may be larger than Map for sourced worlds
+	ChunkSize     int
+	CacheSize     int // Chunks kept loaded, not counting ones in view
+	Margin        int
+	Source        ChunkSource
+
+	chunks  map[ChunkCoord]*list.Element
+	lru     *list.List // Of *Chunk, most recently used first
+	last    *Chunk     // Speeds up runs of queries in the same chunk
+	pending map[ChunkCoord]bool
+	keep    [4]int // Chunk range in view at the last Update: x0, y0, x1, y1 inclusive
+	onSet   []func(layer, x, y int, old, gid GID)
+	diffs   map[ChunkCoord]map[cellKey]cellDiff // Cells changed from the source, by chunk
+
+	// Background loaders, started by the first request after NewWorld or Unload.
+	// Each run gets fresh channels, so loaders stopped by Unload can't deliver
+	// into a later run.
+	running  bool
+	closed   bool
+	requests chan ChunkCoord
+	results  chan *Chunk
+	done     chan struct{}
+}
+
+// NewWorld streams a map's tile layers in chunks. source may be nil to cut the
+// chunks out of the map itself.
+func NewWorld(m *Map, source ChunkSource) *World {
+	if source == nil {
+		source = MapSource{Map: m}
+	}
+	w := &World{
+		Map:       m,
+		Width:     m.Width,
+		Height:    m.Height,
+		ChunkSize: DefaultChunkSize,
+		CacheSize: DefaultCacheSize,
+		Margin:    DefaultMargin,
+		Source:    source,
+		chunks:    make(map[ChunkCoord]*list.Element),
+		lru:       list.New(),
+		pending:   make(map[ChunkCoord]bool),
+		diffs:     make(map[ChunkCoord]map[cellKey]cellDiff),
+		keep:      [4]int{0, 0, -1, -1},
+	}
+	for _, l := range m.Layers {
+		if l.Kind == TileLayer {
+			w.Layers = append(w.Layers, l)
+		}
+	}
+	return w
+}
+
+// Layer returns the index of the tile layer with the given name, or -1
+func (w *World) Layer(name string) int {
+	for i, l := range w.Layers {
+		if l.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// InBounds reports whether a tile coordinate is inside the world
+func (w *World) InBounds(x, y int) bool {
+	return x >= 0 && y >= 0 && x < w.Width && y < w.Height
+}
+
+// At returns the cell at (x, y) on one layer, or 0 outside the world
+func (w *World) At(layer, x, y int) GID {
+	c, lx, ly := w.cell(x, y)
+	if c == nil {
+		return 0
+	}
+	return c.At(layer, lx, ly)
+}
+
+// TileAt returns the top-most tile at (x, y), or 0 if every layer is empty there
+func (w *World) TileAt(x, y int) GID {
+	c, lx, ly := w.cell(x, y)
+	if c == nil {
+		return 0
+	}
+	for i := len(c.Layers) - 1; i >= 0; i-- {
+		if gid := c.At(i, lx, ly); gid != 0 {
+			return gid
+		}
+	}
+	return 0
+}
+
+// Set changes the cell at (x, y) on one layer. The change is kept as a diff
+// against the source, so it survives the chunk being evicted and reloaded and
+// is all a save needs to store.
+func (w *World) Set(layer, x, y int, gid GID) {
+	c, lx, ly := w.cell(x, y)
+	if c == nil || layer < 0 || layer >= len(w.Layers) {
+		return
+	}
+	old := c.At(layer, lx, ly)
+	if old == gid {
+		return
+	}
+	c.Set(layer, lx, ly, gid)
+	w.record(c.Coord, layer, x, y, old, gid)
+	c.Dirty = len(w.diffs[c.Coord]) > 0
+	for _, fn := range w.onSet {
+		fn(layer, x, y, old, gid)
+	}
+}
+
+// OnSet registers a function to call after Set changes a cell. Collision,
+// terrain and tile drawing read the world directly, so only caches built from
+// it, such as paths, need to listen.
+func (w *World) OnSet(fn func(layer, x, y int, old, gid GID)) {
+	w.onSet = append(w.onSet, fn)
+}
+
+// Grid returns one layer of the world as a Grid for autotiling
+func (w *World) Grid(layer int) Grid {
+	return worldGrid{w, layer}
+}
+
+type worldGrid struct {
+	w     *World
+	layer int
+}
+
+func (g worldGrid) InBounds(x, y int) bool { return g.w.InBounds(x, y) }
+func (g worldGrid) At(x, y int) GID        { return g.w.At(g.layer, x, y) }
+func (g worldGrid) Set(x, y int, gid GID)  { g.w.Set(g.layer, x, y, gid) }
+
+// Solid reports whether a cell blocks movement, by the same rules as Map.Solid
+func (w *World) Solid(x, y int) bool {
+	c, lx, ly := w.cell(x, y)
+	if c == nil {
+		return true
+	}
+	for i, l := range w.Layers {
+		if w.Map.Blocks(l, c.At(i, lx, ly)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Cost returns a cell's extra movement cost, by the same rules as Map.Cost
+func (w *World) Cost(x, y int) float64 {
+	c, lx, ly := w.cell(x, y)
+	if c == nil {
+		return 0
+	}
+	cost := 0.0
+	for i := range w.Layers {
+		cost = max(cost, w.Map.TileCost(c.At(i, lx, ly)))
+	}
+	return cost
+}
+
+// Chunk returns a chunk, loading it now if it isn't already, or nil if it is
+// outside the world
+func (w *World) Chunk(coord ChunkCoord) *Chunk {
+	if coord.X < 0 || coord.Y < 0 || coord.X*w.ChunkSize >= w.Width || coord.Y*w.ChunkSize >= w.Height {
+		return nil
+	}
+	if e, ok := w.chunks[coord]; ok {
+		w.lru.MoveToFront(e)
+		return e.Value.(*Chunk)
+	}
+	// It may be sitting finished in the queue
+	w.collect()
+	if e, ok := w.chunks[coord]; ok {
+		w.lru.MoveToFront(e)
+		return e.Value.(*Chunk)
+	}
+	c := w.load(coord)
+	w.insert(c)
+	return c
+}
+
+// Loaded reports whether a chunk is in the cache
+func (w *World) Loaded(coord ChunkCoord) bool {
+	_, ok := w.chunks[coord]
+	return ok
+}
+
+// LoadedCount returns how many chunks are in the cache
+func (w *World) LoadedCount() int {
+	return w.lru.Len()
+}
+
+// PendingCount returns how many chunks are being loaded in the background
+func (w *World) PendingCount() int {
+	return len(w.pending)
+}
+
+// ChunkAt returns the coordinate of the chunk holding a tile
+func (w *World) ChunkAt(x, y int) ChunkCoord {
+	return ChunkCoord{floorDiv(x, w.ChunkSize), floorDiv(y, w.ChunkSize)}
+}
+
+// Update takes in chunks that finished loading and starts loading those within
+// Margin chunks of a view, given in pixels. Chunks in view are kept from eviction.
+func (w *World) Update(viewX, viewY, viewW, viewH float64) {
+	w.collect()
+
+	tw, th := float64(w.Map.TileWidth), float64(w.Map.TileHeight)
+	x0 := floorDiv(int(math.Floor(viewX/tw)), w.ChunkSize) - w.Margin
+	y0 := floorDiv(int(math.Floor(viewY/th)), w.ChunkSize) - w.Margin
+	x1 := floorDiv(int(math.Floor((viewX+viewW)/tw)), w.ChunkSize) + w.Margin
+	y1 := floorDiv(int(math.Floor((viewY+viewH)/th)), w.ChunkSize) + w.Margin
+	w.keep = [4]int{x0, y0, x1, y1}
+
+	for cy := y0; cy <= y1; cy++ {
+		for cx := x0; cx <= x1; cx++ {
+			coord := ChunkCoord{cx, cy}
+			if e, ok := w.chunks[coord]; ok {
+				w.lru.MoveToFront(e)
+				continue
+			}
+			if w.pending[coord] || coord.X < 0 || coord.Y < 0 || cx*w.ChunkSize >= w.Width || cy*w.ChunkSize >= w.Height {
+				continue
+			}
+			w.request(coord)
+		}
+	}
+	w.evict()
+}
+
+// Unload drops every chunk and stops the background loaders to free memory
+// while the world isn't in use. Changes are kept and put back as chunks load
+// again, and the loaders start again with the next Update.
+func (w *World) Unload() {
+	w.stopLoaders()
+	w.lru.Init()
+	clear(w.chunks)
+	w.last = nil
+	w.keep = [4]int{0, 0, -1, -1}
+}
+
+// Close stops the background loaders for good. The world still works
+// afterwards, loading chunks on demand.
+func (w *World) Close() {
+	w.closed = true
+	w.stopLoaders()
+}
+
+// request queues a chunk for background loading, leaving it for the next
+// Update if the queue is full
+func (w *World) request(coord ChunkCoord) {
+	if w.closed {
+		return
+	}
+	if !w.running {
+		w.startLoaders()
+	}
+	select {
+	case w.requests <- coord:
+		w.pending[coord] = true
+	default:
+	}
+}
+
+func (w *World) startLoaders() {
+	requests, results, done := make(chan ChunkCoord, requestQueue), make(chan *Chunk, requestQueue), make(chan struct{})
+	w.requests, w.results, w.done = requests, results, done
+	w.running = true
+	for i := 0; i < loadWorkers; i++ {
+		go func() {
+			for {
+				select {
+				case coord := <-requests:
+					c := w.load(coord)
+					select {
+					case results <- c:
+					case <-done:
+						return
+					}
+				case <-done:
+					return
+				}
+			}
+		}()
+	}
+}
+
+// stopLoaders ends the background loaders. Chunks they were loading are
+// forgotten, to be requested again if they are still wanted.
+func (w *World) stopLoaders() {
+	if !w.running {
+		return
+	}
+	close(w.done)
+	w.requests, w.results = nil, nil
+	w.running = false
+	clear(w.pending)
+}
+
+// collect moves finished background loads into the cache
+func (w *World) collect() {
+	for {
+		select {
+		case c := <-w.results:
+			delete(w.pending, c.Coord)
+			if _, ok := w.chunks[c.Coord]; !ok {
+				w.insert(c)
+			}
+		default:
+			return
+		}
+	}
+}
+
+// load reads one chunk from the source. It runs on any goroutine.
+func (w *World) load(coord ChunkCoord) *Chunk {
+	c := newChunk(coord, w.ChunkSize, len(w.Layers))
+	if err := w.Source.LoadChunk(c, w.Layers); err != nil {
+		log.Printf("Failed to load chunk %d,%d: %v", coord.X, coord.Y, err)
+	}
+	return c
+}
+
+func (w *World) insert(c *Chunk) {
+	w.reapply(c)
+	w.chunks[c.Coord] = w.lru.PushFront(c)
+	w.evict()
+}
+
+// evict drops the least recently used chunks until the cache fits, skipping
+// chunks in view and the chunk used last
+func (w *World) evict() {
+	e := w.lru.Back()
+	for w.lru.Len() > w.CacheSize && e != nil && e != w.lru.Front() {
+		prev := e.Prev()
+		c := e.Value.(*Chunk)
+		if !w.inView(c.Coord) {
+			w.lru.Remove(e)
+			delete(w.chunks, c.Coord)
+			if w.last == c {
+				w.last = nil
+			}
+		}
+		e = prev
+	}
+}
+
+func (w *World) inView(coord ChunkCoord) bool {
+	return coord.X >= w.keep[0] && coord.Y >= w.keep[1] && coord.X <= w.keep[2] && coord.Y <= w.keep[3]
+}
+
+// cell finds the chunk holding a tile and the tile's position within it, or
+// nil outside the world
+func (w *World) cell(x, y int) (*Chunk, int, int) {
+	if !w.InBounds(x, y) {
+		return nil, 0, 0
+	}
+	coord := w.ChunkAt(x, y)
+	c := w.last
+	if c == nil || c.Coord != coord {
+		c = w.Chunk(coord)
+		w.last = c
+	}
+	ox, oy := c.Origin()
+	return c, x - ox, y - oy
+}
+
+// floorDiv divides rounding towards negative infinity, so tile -1 is in chunk -1
+func floorDiv(a, b int) int {
+	q := a / b
+	if a%b != 0 && (a < 0) != (b < 0) {
+		q--
+	}
+	return q
+}
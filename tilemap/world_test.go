@@ -0,0 +1,127 @@
+package tilemap
+
+import (
+	"runtime"
+	"testing"
+	"time"
+)
+
+func testWorld() *World {
+	m := New(256, 256, 32, 32)
+	ground := m.AddTileLayer("ground")
+	for i := range ground.Tiles {
+		ground.Tiles[i] = 1
+	}
+	return NewWorld(m, nil)
+}
+
+// settle updates a world over a view until nothing is waiting to load
+func settle(t *testing.T, w *World) {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(time.Millisecond) {
+		w.Update(0, 0, 1280, 720)
+		if w.PendingCount() == 0 && w.LoadedCount() > 0 {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d chunks still pending", w.PendingCount())
+		}
+	}
+}
+
+// waitGoroutines waits for the goroutine count to drop to at most n
+func waitGoroutines(t *testing.T, n int) {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); runtime.NumGoroutine() > n; time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("%d goroutines running, want at most %d", runtime.NumGoroutine(), n)
+		}
+	}
+}
+
+func TestWorldUnloadStopsLoaders(t *testing.T) {
+	before := runtime.NumGoroutine()
+	w := testWorld()
+	settle(t, w)
+	if n := runtime.NumGoroutine(); n != before+loadWorkers {
+		t.Errorf("%d goroutines while loading, want %d", n, before+loadWorkers)
+	}
+	w.Set(0, 3, 3, 7)
+
+	w.Unload()
+	waitGoroutines(t, before)
+	if w.LoadedCount() != 0 || w.PendingCount() != 0 {
+		t.Errorf("%d chunks loaded and %d pending after unloading", w.LoadedCount(), w.PendingCount())
+	}
+
+	// Coming back into use starts the loaders again and keeps the change
+	settle(t, w)
+	if got := w.At(0, 3, 3); got != 7 {
+		t.Errorf("changed tile is %d after reloading, want 7", got)
+	}
+	w.Close()
+	waitGoroutines(t, before)
+}
+
+func TestWorldClose(t *testing.T) {
+	before := runtime.NumGoroutine()
+	w := testWorld()
+	settle(t, w)
+	w.Close()
+	waitGoroutines(t, before)
+
+	w.Unload()
+	w.Update(0, 0, 1280, 720)
+	if n := runtime.NumGoroutine(); n > before || w.PendingCount() != 0 {
+		t.Errorf("closed world restarted its loaders: %d goroutines, %d pending", n, w.PendingCount())
+	}
+	if got := w.At(0, 100, 100); got != 1 {
+		t.Errorf("closed world loaded tile %d on demand, want 1", got)
+	}
+}
+
+func TestWorldAtAcrossChunks(t *testing.T) {
+	// 8x8 chunks don't divide the map, so the last row and column are partial
+	m := New(37, 29, 16, 16)
+	ground := m.AddTileLayer("ground")
+	walls := m.AddTileLayer("walls")
+	for y := range m.Height {
+		for x := range m.Width {
+			ground.Set(x, y, GID(1+x+y*m.Width))
+			if (x+y)%5 == 0 {
+				walls.Set(x, y, GID(5000+x+y*m.Width))
+			}
+		}
+	}
+	w := NewWorld(m, nil)
+	w.ChunkSize = 8
+	w.CacheSize = 1
+
+	// Column by column, so nearly every step crosses into a chunk that was evicted
+	for x := -1; x <= m.Width; x++ {
+		for y := -1; y <= m.Height; y++ {
+			var wantGround, wantTop GID
+			if x >= 0 && y >= 0 && x < m.Width && y < m.Height {
+				wantGround, wantTop = ground.At(x, y), ground.At(x, y)
+				if wall := walls.At(x, y); wall != 0 {
+					wantTop = wall
+				}
+			}
+			if got := w.At(0, x, y); got != wantGround {
+				t.Fatalf("At(0, %d, %d) = %d, want %d", x, y, got, wantGround)
+			}
+			if got := w.TileAt(x, y); got != wantTop {
+				t.Fatalf("TileAt(%d, %d) = %d, want %d", x, y, got, wantTop)
+			}
+			if n := w.LoadedCount(); n > w.CacheSize {
+				t.Fatalf("%d chunks loaded at %d,%d, cache holds %d", n, x, y, w.CacheSize)
+			}
+		}
+	}
+	if got := w.ChunkAt(36, 28); got != (ChunkCoord{4, 3}) {
+		t.Errorf("corner tile is in chunk %v, want 4,3", got)
+	}
+	if got := w.ChunkAt(-1, -9); got != (ChunkCoord{-1, -2}) {
+		t.Errorf("tile -1,-9 is in chunk %v, want -1,-2", got)
+	}
+}
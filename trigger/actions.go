@@ -0,0 +1,214 @@
+package trigger
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/Nathene/bitbase/entity"
+	"github.com/Nathene/bitbase/tilemap"
+)
+
+func init() {
+	RegisterAction("sound", func(def *ActionDef) (Action, error) {
+		sound := def.String("sound", "")
+		if sound == "" {
+			return nil, fmt.Errorf("needs a sound")
+		}
+		return func(ctx *Context) error {
+			x, y := ctx.Zone.Center()
+			ctx.Host.PlaySound(sound, x, y)
+			return nil
+		}, nil
+	})
+	RegisterAction("dialog", func(def *ActionDef) (Action, error) {
+		lines := def.Strings("lines")
+		if text := def.String("text", ""); text != "" {
+			lines = append([]string{text}, lines...)
+		}
+		if len(lines) == 0 {
+			return nil, fmt.Errorf("needs text or lines")
+		}
+		speaker := def.String("speaker", "")
+		return func(ctx *Context) error {
+			ctx.Host.ShowDialog(speaker, lines)
+			return nil
+		}, nil
+	})
+	RegisterAction("spawn", func(def *ActionDef) (Action, error) {
+		prefab := def.String("prefab", "")
+		if prefab == "" {
+			return nil, fmt.Errorf("needs a prefab")
+		}
+		return func(ctx *Context) error {
+			x, y := ctx.Zone.Center()
+			return ctx.Host.SpawnEntity(prefab, def.Float("x", x), def.Float("y", y))
+		}, nil
+	})
+	RegisterAction("tile", func(def *ActionDef) (Action, error) {
+		layer := def.String("layer", "")
+		if layer == "" {
+			return nil, fmt.Errorf("needs a layer")
+		}
+		for _, key := range []string{"x", "y", "gid"} {
+			if _, ok := def.Params[key].(float64); !ok {
+				return nil, fmt.Errorf("needs %s", key)
+			}
+		}
+		x, y := int(def.Float("x", 0)), int(def.Float("y", 0))
+		gid := tilemap.GID(def.Float("gid", 0))
+		return func(ctx *Context) error {
+			return ctx.Host.SetTile(layer, x, y, gid)
+		}, nil
+	})
+	RegisterAction("cutscene", func(def *ActionDef) (Action, error) {
+		name := def.String("cutscene", "")
+		if name == "" {
+			return nil, fmt.Errorf("needs a cutscene")
+		}
+		return func(ctx *Context) error {
+			ctx.Host.StartCutscene(name)
+			return nil
+		}, nil
+	})
+	RegisterAction("quest", func(def *ActionDef) (Action, error) {
+		quest := def.String("quest", "")
+		if quest == "" {
+			return nil, fmt.Errorf("needs a quest")
+		}
+		stage := int(def.Float("stage", 0))
+		return func(ctx *Context) error {
+			ctx.Host.SetQuestStage(quest, stage)
+			return nil
+		}, nil
+	})
+}
+
+// Host is implemented by the game to let zones check and affect the world
+type Host interface {
+	// PlaySound plays a sound coming from a point in the world
+	PlaySound(sound string, x, y float64)
+	// ShowDialog shows lines of dialogue one after another
+	ShowDialog(speaker string, lines []string)
+	// SpawnEntity creates an entity from a prefab
+	SpawnEntity(prefab string, x, y float64) error
+	// SetTile changes one cell of a tile layer
+	SetTile(layer string, x, y int, gid tilemap.GID) error
+	// StartCutscene plays a cutscene by name
+	StartCutscene(name string)
+	// HasItem reports whether an entity carries an item
+	HasItem(o *entity.Object, item string) bool
+	// QuestStage returns how far a quest has got, 0 if it hasn't started
+	QuestStage(quest string) int
+	// SetQuestStage moves a quest to a stage
+	SetQuestStage(quest string, stage int)
+}
+
+// Context is passed to an action when it runs
+type Context struct {
+	Host  Host
+	Zone  *Zone
+	Actor *entity.Object // The entity that set the zone off
+	Event Event
+}
+
+// Action is one built step of a zone's script
+type Action func(ctx *Context) error
+
+// ActionFactory builds an action from its definition, checking its parameters
+type ActionFactory func(def *ActionDef) (Action, error)
+
+var (
+	actionFactories = make(map[string]ActionFactory)
+	actionMutex     sync.RWMutex
+)
+
+// RegisterAction makes an action available to zones in map data
+func RegisterAction(name string, factory ActionFactory) {
+	actionMutex.Lock()
+	defer actionMutex.Unlock()
+
+	if _, exists := actionFactories[name]; exists {
+		panic(fmt.Sprintf("trigger: action %q registered twice", name))
+	}
+	actionFactories[name] = factory
+}
+
+// Actions returns every registered action name in sorted order
+func Actions() []string {
+	actionMutex.RLock()
+	defer actionMutex.RUnlock()
+
+	names := make([]string, 0, len(actionFactories))
+	for name := range actionFactories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ActionDef is the data form of an action: its name and parameters
+type ActionDef struct {
+	Action string
+	Params map[string]any
+
+	run Action
+}
+
+// UnmarshalJSON reads {"action": ..., ...params}
+func (d *ActionDef) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &d.Params); err != nil {
+		return err
+	}
+	d.Action, _ = d.Params["action"].(string)
+	if d.Action == "" {
+		return fmt.Errorf("action has no name")
+	}
+	delete(d.Params, "action")
+	return nil
+}
+
+// build makes the runnable action from the definition
+func (d *ActionDef) build() error {
+	actionMutex.RLock()
+	factory, ok := actionFactories[d.Action]
+	actionMutex.RUnlock()
+	if !ok {
+		return fmt.Errorf("unknown action")
+	}
+	run, err := factory(d)
+	if err != nil {
+		return err
+	}
+	d.run = run
+	return nil
+}
+
+// Float returns a numeric parameter, or fallback if it is missing
+func (d *ActionDef) Float(key string, fallback float64) float64 {
+	if v, ok := d.Params[key].(float64); ok {
+		return v
+	}
+	return fallback
+}
+
+// String returns a string parameter, or fallback if it is missing
+func (d *ActionDef) String(key string, fallback string) string {
+	if v, ok := d.Params[key].(string); ok {
+		return v
+	}
+	return fallback
+}
+
+// Strings returns a list of strings parameter, skipping anything that isn't a string
+func (d *ActionDef) Strings(key string) []string {
+	list, _ := d.Params[key].([]any)
+	var out []string
+	for _, v := range list {
+		if s, ok := v.(string); ok {
+			out = append(out, s)
+		}
+	}
+	return out
+}
@@ -0,0 +1,138 @@
+package trigger
+
+import (
+	"log"
+	"slices"
+
+	"github.com/Nathene/bitbase/entity"
+)
+
+// Handler is called when an entity enters, leaves or stays in a zone
+type Handler func(z *Zone, o *entity.Object)
+
+// System works out which entities are in which zones each step, raising
+// events and running the zones' actions
+type System struct {
+	Host  Host
+	World *entity.World
+	Zones []*Zone
+
+	handlers [3][]Handler // By Event
+	found    []*entity.Object
+	left     []*entity.Object
+}
+
+// NewSystem creates a trigger system with no zones
+func NewSystem(world *entity.World, host Host) *System {
+	return &System{Host: host, World: world}
+}
+
+// OnEnter registers a function to call when an entity comes into a zone
+func (s *System) OnEnter(fn Handler) {
+	s.handlers[Enter] = append(s.handlers[Enter], fn)
+}
+
+// OnExit registers a function to call when an entity leaves a zone
+func (s *System) OnExit(fn Handler) {
+	s.handlers[Exit] = append(s.handlers[Exit], fn)
+}
+
+// OnStay registers a function to call every step an entity stays in a zone
+func (s *System) OnStay(fn Handler) {
+	s.handlers[Stay] = append(s.handlers[Stay], fn)
+}
+
+// SetZones replaces the zones, such as when the map changes. The old zones
+// forget who was in them without raising exits, so they start afresh if they
+// come back.
+func (s *System) SetZones(zones []*Zone) {
+	for _, z := range s.Zones {
+		clear(z.inside)
+	}
+	s.Zones = zones
+}
+
+// Update checks every zone against the entities whose feet are in it
+func (s *System) Update(dt float64) {
+	for _, z := range s.Zones {
+		if z.inside == nil {
+			z.inside = make(map[*entity.Object]float64)
+		}
+		s.found = s.found[:0]
+		for _, o := range s.World.QueryRect(z.Bounds) {
+			if z.sets(o) && z.Contains(o.Feet()) {
+				s.found = append(s.found, o)
+			}
+		}
+
+		// Leaving first, ordered by ID so events come out the same every run
+		s.left = s.left[:0]
+		for o := range z.inside {
+			if !slices.Contains(s.found, o) {
+				s.left = append(s.left, o)
+			}
+		}
+		slices.SortFunc(s.left, func(a, b *entity.Object) int { return a.ID - b.ID })
+		for _, o := range s.left {
+			delete(z.inside, o)
+			s.fire(z, o, Exit)
+		}
+
+		for _, o := range s.found {
+			next, in := z.inside[o]
+			if !in {
+				z.inside[o] = z.Every
+				s.fire(z, o, Enter)
+				continue
+			}
+			for _, fn := range s.handlers[Stay] {
+				fn(z, o)
+			}
+			if next -= dt; next <= 0 {
+				next = z.Every
+				if z.On == Stay {
+					s.run(z, o, Stay)
+				}
+			}
+			z.inside[o] = next
+		}
+	}
+}
+
+// fire raises an event and runs the zone's actions if they are for it
+func (s *System) fire(z *Zone, o *entity.Object, e Event) {
+	for _, fn := range s.handlers[e] {
+		fn(z, o)
+	}
+	if z.On == e {
+		s.run(z, o, e)
+	}
+}
+
+// run carries out a zone's actions in order, if its conditions hold
+func (s *System) run(z *Zone, o *entity.Object, e Event) {
+	if !s.ready(z, o) {
+		return
+	}
+	z.Fired = true
+	ctx := &Context{Host: s.Host, Zone: z, Actor: o, Event: e}
+	for _, def := range z.Actions {
+		if err := def.run(ctx); err != nil {
+			log.Printf("Trigger %s: %s failed: %v", z.Name, def.Action, err)
+		}
+	}
+}
+
+// ready reports whether a zone's conditions hold for an entity
+func (s *System) ready(z *Zone, o *entity.Object) bool {
+	c := z.Conditions
+	switch {
+	case c.Once && z.Fired:
+		return false
+	case c.RequiresItem != "" && !s.Host.HasItem(o, c.RequiresItem):
+		return false
+	case c.Quest != "" && s.Host.QuestStage(c.Quest) != c.QuestStage:
+		return false
+	}
+	return true
+}
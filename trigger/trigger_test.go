@@ -0,0 +1,358 @@
+package trigger
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"testing"
+
+	"github.com/Nathene/bitbase/common"
+	"github.com/Nathene/bitbase/entity"
+	"github.com/Nathene/bitbase/tilemap"
+)
+
+// testHost records what zones ask of the game
+type testHost struct {
+	calls  []string
+	items  map[string]bool
+	quests map[string]int
+}
+
+func (h *testHost) PlaySound(sound string, x, y float64) {
+	h.calls = append(h.calls, "sound "+sound)
+}
+
+func (h *testHost) ShowDialog(speaker string, lines []string) {
+	h.calls = append(h.calls, fmt.Sprintf("dialog %s %v", speaker, lines))
+}
+
+func (h *testHost) SpawnEntity(prefab string, x, y float64) error {
+	h.calls = append(h.calls, "spawn "+prefab)
+	return nil
+}
+
+func (h *testHost) SetTile(layer string, x, y int, gid tilemap.GID) error {
+	h.calls = append(h.calls, fmt.Sprintf("tile %s %d,%d %d", layer, x, y, gid))
+	return nil
+}
+
+func (h *testHost) StartCutscene(name string) {
+	h.calls = append(h.calls, "cutscene "+name)
+}
+
+func (h *testHost) HasItem(o *entity.Object, item string) bool {
+	return h.items[item]
+}
+
+func (h *testHost) QuestStage(quest string) int {
+	return h.quests[quest]
+}
+
+func (h *testHost) SetQuestStage(quest string, stage int) {
+	h.calls = append(h.calls, fmt.Sprintf("quest %s %d", quest, stage))
+}
+
+// testZone makes a zone from a 32x32 rectangle at the origin with the given properties
+func testZone(t *testing.T, props tilemap.Properties) *Zone {
+	t.Helper()
+	z, err := FromObject(&tilemap.Object{ID: 1, Name: "room", Type: ObjectType, Width: 32, Height: 32, Shape: tilemap.Rectangle, Properties: props})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return z
+}
+
+// newWalker makes a 16x16 entity whose feet are at (x, y)
+func newWalker(w *entity.World, name string, x, y float64) *entity.Object {
+	o := entity.NewObject(name)
+	o.AddComponent(&entity.Sprite{Width: 16, Height: 16})
+	w.Add(o)
+	o.SetPosition(x-8, y-15.5)
+	return o
+}
+
+// moveFeet puts an entity's feet at (x, y)
+func moveFeet(o *entity.Object, x, y float64) {
+	o.SetPosition(x-8, y-15.5)
+}
+
+// newTestSystem runs zones against a fresh world, logging every event the handlers see
+func newTestSystem(host *testHost, zones ...*Zone) (*System, *[]string) {
+	s := NewSystem(entity.NewWorld(), host)
+	s.SetZones(zones)
+	var events []string
+	for _, e := range []Event{Enter, Exit, Stay} {
+		handler := func(z *Zone, o *entity.Object) {
+			events = append(events, fmt.Sprintf("%s %s", e, o.Name))
+		}
+		switch e {
+		case Enter:
+			s.OnEnter(handler)
+		case Exit:
+			s.OnExit(handler)
+		case Stay:
+			s.OnStay(handler)
+		}
+	}
+	return s, &events
+}
+
+func TestZoneContains(t *testing.T) {
+	type point struct {
+		x, y float64
+		in   bool
+	}
+	tests := []struct {
+		name   string
+		object tilemap.Object
+		bounds common.Rect
+		points []point
+	}{
+		{
+			name:   "rectangle",
+			object: tilemap.Object{X: 10, Y: 20, Width: 30, Height: 10, Shape: tilemap.Rectangle},
+			bounds: common.Rect{X: 10, Y: 20, W: 30, H: 10},
+			points: []point{{10, 20, true}, {40, 30, true}, {25, 25, true}, {9.9, 25, false}, {25, 30.1, false}},
+		},
+		{
+			// An L: the notch at the top right is inside the bounds but not the zone
+			name: "concave polygon",
+			object: tilemap.Object{X: 100, Y: 100, Shape: tilemap.Polygon,
+				Points: [][2]float64{{0, 0}, {10, 0}, {10, 10}, {20, 10}, {20, 20}, {0, 20}}},
+			bounds: common.Rect{X: 100, Y: 100, W: 20, H: 20},
+			points: []point{{105, 105, true}, {115, 115, true}, {105, 115, true}, {115, 105, false}, {119, 101, false}, {125, 115, false}},
+		},
+		{
+			// A ray from a point level with a vertex mustn't count that vertex twice
+			name: "ray through a vertex",
+			object: tilemap.Object{Shape: tilemap.Polygon,
+				Points: [][2]float64{{0, 0}, {10, 5}, {20, 0}, {20, 10}, {0, 10}}},
+			bounds: common.Rect{W: 20, H: 10},
+			points: []point{{5, 5, true}, {15, 5, true}, {10, 3, false}, {2, 0.5, false}, {10, 6, true}},
+		},
+		{
+			// Turned a quarter clockwise about its top-left corner, so it hangs off to the left
+			name:   "rectangle turned 90°",
+			object: tilemap.Object{Width: 40, Height: 10, Rotation: 90, Shape: tilemap.Rectangle},
+			bounds: common.Rect{X: -10, Y: 0, W: 10, H: 40},
+			points: []point{{-5, 20, true}, {-9, 39, true}, {5, 5, false}, {-5, 41, false}},
+		},
+		{
+			// A 20x20 square turned 45° into a diamond whose corners leave the bounds empty
+			name:   "rectangle turned 45°",
+			object: tilemap.Object{X: 100, Y: 100, Width: 20, Height: 20, Rotation: 45, Shape: tilemap.Rectangle},
+			bounds: common.Rect{X: 100 - 10*math.Sqrt2, Y: 100, W: 20 * math.Sqrt2, H: 20 * math.Sqrt2},
+			points: []point{{100, 101, true}, {100, 114, true}, {110, 114, true}, {90, 102, false}, {110, 102, false}, {112, 127, false}},
+		},
+		{
+			name: "turned polygon",
+			object: tilemap.Object{X: 50, Y: 50, Rotation: 180, Shape: tilemap.Polygon,
+				Points: [][2]float64{{0, 0}, {10, 0}, {0, 10}}},
+			bounds: common.Rect{X: 40, Y: 40, W: 10, H: 10},
+			points: []point{{48, 48, true}, {43, 48, true}, {42, 42, false}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			z, err := FromObject(&tt.object)
+			if err != nil {
+				t.Fatal(err)
+			}
+			b := z.Bounds
+			if math.Abs(b.X-tt.bounds.X) > 1e-9 || math.Abs(b.Y-tt.bounds.Y) > 1e-9 || math.Abs(b.W-tt.bounds.W) > 1e-9 || math.Abs(b.H-tt.bounds.H) > 1e-9 {
+				t.Errorf("bounds %+v, want %+v", b, tt.bounds)
+			}
+			if plain := tt.object.Shape == tilemap.Rectangle && tt.object.Rotation == 0; plain != (z.Polygon == nil) {
+				t.Errorf("polygon %v for an unrotated rectangle: %v", z.Polygon, plain)
+			}
+			for _, p := range tt.points {
+				if got := z.Contains(p.x, p.y); got != p.in {
+					t.Errorf("Contains(%v, %v) = %v, want %v", p.x, p.y, got, p.in)
+				}
+			}
+		})
+	}
+}
+
+func TestFromObjectErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		object tilemap.Object
+		want   string
+	}{
+		{name: "flat rectangle", object: tilemap.Object{ID: 3, Width: 10, Shape: tilemap.Rectangle}, want: "trigger 3: rectangle has no area"},
+		{name: "two points", object: tilemap.Object{Name: "door", Shape: tilemap.Polygon, Points: [][2]float64{{0, 0}, {1, 1}}}, want: "door: polygon needs at least 3 points"},
+		{name: "ellipse", object: tilemap.Object{Name: "door", Width: 5, Height: 5, Shape: tilemap.Ellipse}, want: "door: zones must be rectangles or polygons"},
+		{name: "event", object: tilemap.Object{Name: "door", Width: 5, Height: 5, Properties: tilemap.Properties{"on": "leave"}}, want: `door: unknown event "leave"`},
+		{name: "action", object: tilemap.Object{Name: "door", Width: 5, Height: 5, Properties: tilemap.Properties{"actions": `[{"action": "sound"}]`}}, want: "door: action 0 (sound): needs a sound"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := FromObject(&tt.object); err == nil || err.Error() != tt.want {
+				t.Errorf("got error %v, want %s", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpdateEventOrder(t *testing.T) {
+	host := &testHost{}
+	z := testZone(t, tilemap.Properties{"who": "any"})
+	s, events := newTestSystem(host, z)
+	a := newWalker(s.World, "a", 100, 100)
+	b := newWalker(s.World, "b", 16, 16)
+	c := newWalker(s.World, "c", 100, 16)
+
+	steps := []struct {
+		move     func()
+		want     []string
+		anyOrder bool // Things that arrive together come in the order the world finds them
+	}{
+		{want: []string{"enter b"}},
+		{want: []string{"stay b"}},
+		{move: func() { moveFeet(a, 8, 8); moveFeet(c, 24, 24) }, want: []string{"enter a", "enter c", "stay b"}, anyOrder: true},
+		// Leaving comes before anything else, and in ID order
+		{move: func() { moveFeet(c, 100, 100); moveFeet(a, 100, 100) }, want: []string{"exit a", "exit c", "stay b"}},
+		// Feet on the edge still count; a step past it leaves
+		{move: func() { moveFeet(b, 32, 32) }, want: []string{"stay b"}},
+		{move: func() { moveFeet(b, 32, 32.5) }, want: []string{"exit b"}},
+		{want: nil},
+	}
+	for i, step := range steps {
+		if step.move != nil {
+			step.move()
+		}
+		*events = nil
+		s.Update(0.1)
+		got := slices.Clone(*events)
+		if step.anyOrder {
+			slices.Sort(got)
+		}
+		if !slices.Equal(got, step.want) {
+			t.Errorf("step %d: got %v, want %v", i, *events, step.want)
+		}
+	}
+	if z.Inside() != 0 {
+		t.Errorf("%d still inside after everyone left", z.Inside())
+	}
+}
+
+func TestUpdateWho(t *testing.T) {
+	host := &testHost{}
+	s, events := newTestSystem(host, testZone(t, nil))
+	newWalker(s.World, "goblin", 16, 16)
+	player := newWalker(s.World, "player", 100, 100)
+	s.Update(0.1)
+	moveFeet(player, 16, 16)
+	s.Update(0.1)
+	if !slices.Equal(*events, []string{"enter player"}) {
+		t.Errorf("got %v, want only the player to set off a default zone", *events)
+	}
+}
+
+func TestUpdateStayEvery(t *testing.T) {
+	host := &testHost{}
+	z := testZone(t, tilemap.Properties{"on": "stay", "every": 0.5, "actions": `[{"action": "sound", "sound": "hum"}]`})
+	s, _ := newTestSystem(host, z)
+	o := newWalker(s.World, "player", 16, 16)
+
+	var ran []int
+	for step := 1; step <= 13; step++ {
+		switch step {
+		case 9:
+			moveFeet(o, 100, 100)
+		case 10:
+			moveFeet(o, 16, 16)
+		}
+		host.calls = nil
+		s.Update(0.2)
+		if len(host.calls) > 0 {
+			ran = append(ran, step)
+		}
+	}
+	// Entering doesn't run a stay zone; it runs each time 0.5s of staying adds
+	// up, and leaving and coming back starts the count again
+	if want := []int{4, 7, 13}; !slices.Equal(ran, want) {
+		t.Errorf("ran on steps %v, want %v", ran, want)
+	}
+}
+
+func TestUpdateConditions(t *testing.T) {
+	chime := `[{"action": "sound", "sound": "chime"}, {"action": "quest", "quest": "key", "stage": 2}]`
+	tests := []struct {
+		name   string
+		props  tilemap.Properties
+		items  map[string]bool
+		quests map[string]int
+		runs   int // Out of three visits
+	}{
+		{name: "always", props: tilemap.Properties{}, runs: 3},
+		{name: "once", props: tilemap.Properties{"once": true}, runs: 1},
+		{name: "missing item", props: tilemap.Properties{"requires_item": "key"}, runs: 0},
+		{name: "carrying item", props: tilemap.Properties{"requires_item": "key"}, items: map[string]bool{"key": true}, runs: 3},
+		{name: "quest at stage", props: tilemap.Properties{"quest": "key", "quest_stage": 1}, quests: map[string]int{"key": 1}, runs: 3},
+		{name: "quest at another stage", props: tilemap.Properties{"quest": "key", "quest_stage": 1}, quests: map[string]int{"key": 2}, runs: 0},
+		{name: "quest not started", props: tilemap.Properties{"quest": "key"}, runs: 3},
+		{name: "once waits for its item", props: tilemap.Properties{"once": true, "requires_item": "key"}, runs: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.props["actions"] = chime
+			host := &testHost{items: tt.items, quests: tt.quests}
+			z := testZone(t, tt.props)
+			s, _ := newTestSystem(host, z)
+			o := newWalker(s.World, "player", 100, 100)
+			for range 3 {
+				moveFeet(o, 16, 16)
+				s.Update(0.1)
+				s.Update(0.1)
+				moveFeet(o, 100, 100)
+				s.Update(0.1)
+			}
+
+			var want []string
+			for range tt.runs {
+				want = append(want, "sound chime", "quest key 2")
+			}
+			if !slices.Equal(host.calls, want) {
+				t.Errorf("got %v, want %v", host.calls, want)
+			}
+			if z.Fired != (tt.runs > 0) {
+				t.Errorf("fired %v after %d runs", z.Fired, tt.runs)
+			}
+		})
+	}
+
+	// Once counts from the first run that got through, not the first visit
+	host := &testHost{items: map[string]bool{}}
+	z := testZone(t, tilemap.Properties{"once": true, "requires_item": "key", "actions": chime})
+	s, _ := newTestSystem(host, z)
+	o := newWalker(s.World, "player", 16, 16)
+	s.Update(0.1)
+	host.items["key"] = true
+	for range 2 {
+		moveFeet(o, 100, 100)
+		s.Update(0.1)
+		moveFeet(o, 16, 16)
+		s.Update(0.1)
+	}
+	if want := []string{"sound chime", "quest key 2"}; !slices.Equal(host.calls, want) {
+		t.Errorf("got %v, want %v", host.calls, want)
+	}
+}
+
+func TestSetZonesForgets(t *testing.T) {
+	host := &testHost{}
+	z := testZone(t, nil)
+	s, events := newTestSystem(host, z)
+	newWalker(s.World, "player", 16, 16)
+	s.Update(0.1)
+
+	s.SetZones(nil)
+	s.Update(0.1)
+	s.SetZones([]*Zone{z})
+	s.Update(0.1)
+	if want := []string{"enter player", "enter player"}; !slices.Equal(*events, want) {
+		t.Errorf("got %v, want %v", *events, want)
+	}
+}
@@ -0,0 +1,187 @@
+// Package trigger runs zones laid out in map data that react when entities
+// walk into, out of or stay in them. Each zone has conditions and a list of
+// actions, so rooms can be scripted without writing Go for each one.
+package trigger
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+
+	"github.com/Nathene/bitbase/common"
+	"github.com/Nathene/bitbase/entity"
+	"github.com/Nathene/bitbase/tilemap"
+)
+
+// ObjectType is the type of the map objects that become zones
+const ObjectType = "trigger"
+
+// Event is something that happens between an entity and a zone
+type Event int
+
+const (
+	Enter Event = iota // The entity has just come into the zone
+	Exit               // The entity has just left the zone
+	Stay               // The entity is still in the zone
+)
+
+var eventNames = []string{"enter", "exit", "stay"}
+
+func (e Event) String() string {
+	if e < 0 || int(e) >= len(eventNames) {
+		return fmt.Sprintf("Event(%d)", int(e))
+	}
+	return eventNames[e]
+}
+
+// parseEvent reads an event name from map data
+func parseEvent(name string) (Event, error) {
+	for i, n := range eventNames {
+		if n == name {
+			return Event(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown event %q", name)
+}
+
+// Conditions must all hold for a zone's actions to run
+type Conditions struct {
+	Once         bool   // Run the actions only the first time
+	RequiresItem string // The entity must be carrying this item
+	Quest        string // If set, the quest must be at QuestStage
+	QuestStage   int
+}
+
+// Zone is a rectangle or polygon of a map that runs its actions when an entity
+// sets it off
+type Zone struct {
+	ID      int
+	Name    string
+	Bounds  common.Rect
+	Polygon [][2]float64 // Corners in world pixels, or nil if the zone is just Bounds
+	On      Event        // Event that runs the actions
+	Who     string       // Name of the entities that set the zone off, or "any"
+	Every   float64      // Seconds between runs while an entity stays, for Stay zones
+	Conditions
+	Actions []*ActionDef
+
+	Fired bool // The actions have run at least once
+
+	inside map[*entity.Object]float64 // Entities in the zone, with seconds until their next Stay run
+}
+
+// FromObject builds a zone from a rectangle or polygon map object. Its
+// properties are:
+//
+//	on            enter (default), exit or stay
+//	who           entity name that sets it off, default "player", or "any"
+//	every         seconds between runs while staying, default 1
+//	once          run only the first time
+//	requires_item item the entity must carry
+//	quest         quest that must be at quest_stage
+//	quest_stage
+//	actions       JSON list of actions, e.g. [{"action": "dialog", "text": "Hello"}]
+func FromObject(o *tilemap.Object) (*Zone, error) {
+	z := &Zone{
+		ID:   o.ID,
+		Name: o.Name,
+		Who:  o.Properties.String("who", "player"),
+		Conditions: Conditions{
+			Once:         o.Properties.Bool("once"),
+			RequiresItem: o.Properties.String("requires_item", ""),
+			Quest:        o.Properties.String("quest", ""),
+			QuestStage:   o.Properties.Int("quest_stage", 0),
+		},
+		Every: o.Properties.Float("every", 1),
+	}
+	if z.Name == "" {
+		z.Name = fmt.Sprintf("trigger %d", o.ID)
+	}
+
+	var corners [][2]float64
+	switch o.Shape {
+	case tilemap.Rectangle:
+		if o.Width <= 0 || o.Height <= 0 {
+			return nil, fmt.Errorf("%s: rectangle has no area", z.Name)
+		}
+		corners = [][2]float64{{0, 0}, {o.Width, 0}, {o.Width, o.Height}, {0, o.Height}}
+	case tilemap.Polygon:
+		if len(o.Points) < 3 {
+			return nil, fmt.Errorf("%s: polygon needs at least 3 points", z.Name)
+		}
+		corners = o.Points
+	default:
+		return nil, fmt.Errorf("%s: zones must be rectangles or polygons", z.Name)
+	}
+	z.setShape(o.X, o.Y, o.Rotation, corners, o.Shape == tilemap.Rectangle)
+
+	var err error
+	if z.On, err = parseEvent(o.Properties.String("on", "enter")); err != nil {
+		return nil, fmt.Errorf("%s: %w", z.Name, err)
+	}
+	if raw := o.Properties.String("actions", ""); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &z.Actions); err != nil {
+			return nil, fmt.Errorf("%s: actions: %w", z.Name, err)
+		}
+	}
+	for i, def := range z.Actions {
+		if err := def.build(); err != nil {
+			return nil, fmt.Errorf("%s: action %d (%s): %w", z.Name, i, def.Action, err)
+		}
+	}
+	return z, nil
+}
+
+// setShape places corners, relative to (x, y) and turned clockwise about it by
+// rotation degrees, and works out the bounds. An unrotated rectangle needs no
+// polygon.
+func (z *Zone) setShape(x, y, rotation float64, corners [][2]float64, rect bool) {
+	sin, cos := math.Sincos(rotation * math.Pi / 180)
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	z.Polygon = make([][2]float64, len(corners))
+	for i, c := range corners {
+		px, py := x+c[0]*cos-c[1]*sin, y+c[0]*sin+c[1]*cos
+		z.Polygon[i] = [2]float64{px, py}
+		minX, minY = math.Min(minX, px), math.Min(minY, py)
+		maxX, maxY = math.Max(maxX, px), math.Max(maxY, py)
+	}
+	z.Bounds = common.Rect{X: minX, Y: minY, W: maxX - minX, H: maxY - minY}
+	if rect && rotation == 0 {
+		z.Polygon = nil
+	}
+}
+
+// Contains reports whether a point in world pixels is inside the zone
+func (z *Zone) Contains(x, y float64) bool {
+	if !z.Bounds.Contains(x, y) {
+		return false
+	}
+	if z.Polygon == nil {
+		return true
+	}
+	// Count crossings of a ray running right from the point
+	in := false
+	for i, j := 0, len(z.Polygon)-1; i < len(z.Polygon); j, i = i, i+1 {
+		a, b := z.Polygon[i], z.Polygon[j]
+		if (a[1] > y) != (b[1] > y) && x < a[0]+(y-a[1])*(b[0]-a[0])/(b[1]-a[1]) {
+			in = !in
+		}
+	}
+	return in
+}
+
+// Center returns the middle of the zone's bounds
+func (z *Zone) Center() (float64, float64) {
+	return z.Bounds.Center()
+}
+
+// Inside returns how many entities are in the zone
+func (z *Zone) Inside() int {
+	return len(z.inside)
+}
+
+// sets reports whether an entity is one that sets the zone off
+func (z *Zone) sets(o *entity.Object) bool {
+	return z.Who == "any" || o.Name == z.Who
+}